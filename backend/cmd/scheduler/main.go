@@ -2,7 +2,6 @@ package main
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -12,9 +11,14 @@ import (
 	"pulsegrid/backend/internal/checker"
 	"pulsegrid/backend/internal/config"
 	"pulsegrid/backend/internal/database"
+	"pulsegrid/backend/internal/dependency"
+	"pulsegrid/backend/internal/escalation"
 	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/monitor"
 	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/oncall"
 	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/internal/scheduler"
 
 	_ "github.com/lib/pq"
 )
@@ -36,19 +40,30 @@ func main() {
 	serviceRepo := repository.NewServiceRepository(db)
 	healthCheckRepo := repository.NewHealthCheckRepository(db)
 	alertRepo := repository.NewAlertRepository(db)
+	stateRepo := repository.NewServiceStateRepository(db)
+	suppressor := dependency.NewSuppressor(repository.NewServiceDependencyRepository(db), stateRepo, serviceRepo)
 
 	log.Println("Health Check Scheduler started")
 	log.Println("Checking services every 10 seconds...")
 
 	// Initialize notifier service
-	notifierService := notifier.NewNotifierService(alertRepo)
+	oncallResolver := oncall.NewResolver(repository.NewOnCallRepository(db), repository.NewUserRepository(db))
+	notifierService := notifier.NewNotifierService(alertRepo, serviceRepo, repository.NewNotificationRepository(db), repository.NewNotificationTemplateRepository(db), repository.NewNotificationHoldRepository(db), repository.NewPushSubscriptionRepository(db), repository.NewNotificationRuleRepository(db), oncallResolver)
+	escalator := escalation.NewEscalator(repository.NewEscalationRepository(db), alertRepo, notifierService)
+	alertProcessor := monitor.NewAlertProcessor(alertRepo, repository.NewAlertRuleRepository(db), healthCheckRepo, repository.NewIncidentRepository(db), suppressor, escalator, notifierService)
+	recorder := monitor.NewRecorder(healthCheckRepo, serviceRepo, stateRepo, repository.NewMaintenanceWindowRepository(db), alertProcessor)
 
 	// Create ticker for periodic checks
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
 	// Run initial check
-	runHealthChecks(serviceRepo, healthCheckRepo, alertRepo, db, notifierService)
+	runHealthChecks(serviceRepo, stateRepo, recorder, db)
+	// Escalation steps live in the database, so any that came due while the
+	// scheduler was down go out now
+	go escalator.RunDue()
+	// So are notifications whose retries came due
+	go notifierService.DeliverDue()
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -57,7 +72,9 @@ func main() {
 	for {
 		select {
 		case <-ticker.C:
-			runHealthChecks(serviceRepo, healthCheckRepo, alertRepo, db, notifierService)
+			runHealthChecks(serviceRepo, stateRepo, recorder, db)
+			go escalator.RunDue()
+			go notifierService.DeliverDue()
 		case <-sigChan:
 			log.Println("Shutting down scheduler...")
 			return
@@ -67,10 +84,9 @@ func main() {
 
 func runHealthChecks(
 	serviceRepo *repository.ServiceRepository,
-	healthCheckRepo *repository.HealthCheckRepository,
-	alertRepo *repository.AlertRepository,
+	stateRepo *repository.ServiceStateRepository,
+	recorder *monitor.Recorder,
 	db *sql.DB,
-	notifierService *notifier.NotifierService,
 ) {
 	// Get all active services
 	services, err := serviceRepo.ListActive()
@@ -85,14 +101,27 @@ func runHealthChecks(
 
 	log.Printf("Checking %d active services...", len(services))
 
+	states, err := stateRepo.ListAll()
+	if err != nil {
+		log.Printf("Error fetching service states: %v", err)
+	}
+
 	for _, service := range services {
+		state := states[service.ID]
+
 		// Check if it's time to check this service
-		lastCheck, err := getLastCheckTime(db, service.ID)
-		if err == nil && lastCheck != nil {
-			// Check if enough time has passed based on check_interval
+		var lastCheck *time.Time
+		if state != nil && state.LastCheckedAt != nil {
+			lastCheck = state.LastCheckedAt
+		} else if t, err := getLastCheckTime(db, service.ID); err == nil {
+			lastCheck = t
+		}
+		if lastCheck != nil {
+			// Check if enough time has passed based on the effective interval,
+			// which is tighter than check_interval while the service is down
 			timeSinceLastCheck := time.Since(*lastCheck)
-			interval := time.Duration(service.CheckInterval) * time.Second
-			
+			interval := time.Duration(scheduler.EffectiveCheckInterval(service, state)) * time.Second
+
 			if timeSinceLastCheck < interval {
 				// Not time yet, skip
 				continue
@@ -100,7 +129,7 @@ func runHealthChecks(
 		}
 
 		// Perform health check
-		go performHealthCheck(service, healthCheckRepo, alertRepo, db, notifierService)
+		go performHealthCheck(service, state, recorder)
 	}
 }
 
@@ -124,10 +153,8 @@ func getLastCheckTime(db *sql.DB, serviceID interface{}) (*time.Time, error) {
 
 func performHealthCheck(
 	service *models.Service,
-	healthCheckRepo *repository.HealthCheckRepository,
-	alertRepo *repository.AlertRepository,
-	db *sql.DB,
-	notifierService *notifier.NotifierService,
+	state *models.ServiceState,
+	recorder *monitor.Recorder,
 ) {
 	timeout := time.Duration(service.Timeout) * time.Second
 
@@ -154,12 +181,18 @@ func performHealthCheck(
 		ResponseTimeMs: result.ResponseTimeMs,
 		StatusCode:    result.StatusCode,
 		ErrorMessage:  result.ErrorMessage,
+		ResolvedIP:    result.ResolvedIP,
 	}
 
-	if err := healthCheckRepo.Create(healthCheck); err != nil {
+	// Flag maintenance, track the effective interval and open, resolve or
+	// escalate alerts
+	if _, err := recorder.Record(service, healthCheck, state); err != nil {
 		log.Printf("Error saving health check for service %s: %v", service.Name, err)
 		return
 	}
+	if healthCheck.IntervalReason != nil {
+		log.Printf("⏱ %s: %s", service.Name, *healthCheck.IntervalReason)
+	}
 
 	if result.ResponseTimeMs != nil {
 		log.Printf("✓ %s: %s (%dms)", service.Name, result.Status, *result.ResponseTimeMs)
@@ -167,91 +200,9 @@ func performHealthCheck(
 		log.Printf("✓ %s: %s", service.Name, result.Status)
 	}
 
-	// Check for alerts
-	if result.Status == "down" {
-		// Check if previous check was up (new downtime)
-		prevCheck, _ := getLastHealthCheckBefore(db, service.ID, healthCheck.ID)
-		if prevCheck == nil || prevCheck.Status == "up" {
-			// Create alert
-			alert := &models.Alert{
-				ServiceID:  service.ID,
-				Type:       "downtime",
-				Message:    "Service is down: " + service.Name,
-				Severity:   "high",
-				IsResolved: false,
-			}
-			if result.ErrorMessage != nil {
-				alert.Message += " - " + *result.ErrorMessage
-			}
-			alertRepo.Create(alert)
-			log.Printf("⚠ Alert created for %s", service.Name)
-			
-			// Send notifications
-			go func() {
-				if err := notifierService.SendAlertNotifications(alert); err != nil {
-					log.Printf("Error sending notifications: %v", err)
-				}
-			}()
-		}
-	}
-
-	// Check for latency threshold breach
-	if service.LatencyThresholdMs != nil && result.ResponseTimeMs != nil {
-		if *result.ResponseTimeMs > *service.LatencyThresholdMs {
-			// Check if we should create an alert (avoid spam - only if previous check was below threshold)
-			prevCheck, _ := getLastHealthCheckBefore(db, service.ID, healthCheck.ID)
-			shouldAlert := true
-			if prevCheck != nil && prevCheck.ResponseTimeMs != nil {
-				// Only alert if previous check was below threshold
-				if *prevCheck.ResponseTimeMs > *service.LatencyThresholdMs {
-					shouldAlert = false // Already above threshold, don't spam
-				}
-			}
-			
-			if shouldAlert {
-				alert := &models.Alert{
-					ServiceID:  service.ID,
-					Type:       "latency",
-					Message:    fmt.Sprintf("Service latency threshold breached: %s (Response time: %dms, Threshold: %dms)", service.Name, *result.ResponseTimeMs, *service.LatencyThresholdMs),
-					Severity:   "medium",
-					IsResolved: false,
-				}
-				alertRepo.Create(alert)
-				log.Printf("⚠ Latency alert created for %s: %dms > %dms", service.Name, *result.ResponseTimeMs, *service.LatencyThresholdMs)
-				
-				// Send notifications
-				go func() {
-					if err := notifierService.SendAlertNotifications(alert); err != nil {
-						log.Printf("Error sending notifications: %v", err)
-					}
-				}()
-			}
-		}
-	}
-}
-
-func getLastHealthCheckBefore(db *sql.DB, serviceID interface{}, currentID interface{}) (*models.HealthCheck, error) {
-	query := `
-		SELECT id, status, response_time_ms
-		FROM health_checks
-		WHERE service_id = $1 AND id != $2
-		ORDER BY checked_at DESC
-		LIMIT 1
-	`
-
-	var check models.HealthCheck
-	var responseTime sql.NullInt64
-	err := db.QueryRow(query, serviceID, currentID).Scan(&check.ID, &check.Status, &responseTime)
-	if err != nil {
-		return nil, err
+	if healthCheck.InMaintenance {
+		log.Printf("🔧 %s: in maintenance, alerts suppressed", service.Name)
 	}
-
-	if responseTime.Valid {
-		rt := int(responseTime.Int64)
-		check.ResponseTimeMs = &rt
-	}
-
-	return &check, nil
 }
 
 func stringPtr(s string) *string {
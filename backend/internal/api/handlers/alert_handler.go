@@ -1,41 +1,160 @@
 package handlers
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"net/mail"
 	"strconv"
+	"strings"
+	"time"
 
 	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/escalation"
 	"pulsegrid/backend/internal/models"
 	"pulsegrid/backend/internal/notifier"
 	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/chat"
+	"pulsegrid/backend/pkg/digest"
+	"pulsegrid/backend/pkg/paging"
+	"pulsegrid/backend/pkg/verification"
+	"pulsegrid/backend/pkg/webhook"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type AlertHandler struct {
-	alertRepo   *repository.AlertRepository
-	serviceRepo *repository.ServiceRepository
-	notifier    *notifier.NotifierService
-	cfg         *config.Config
+	alertRepo        *repository.AlertRepository
+	serviceRepo      *repository.ServiceRepository
+	escalationRepo   *repository.EscalationRepository
+	oncallRepo       *repository.OnCallRepository
+	userRepo         *repository.UserRepository
+	incidentRepo     *repository.IncidentRepository
+	notificationRepo *repository.NotificationRepository
+	holdRepo         *repository.NotificationHoldRepository
+	notifier         *notifier.NotifierService
+	cfg              *config.Config
 }
 
-func NewAlertHandler(alertRepo *repository.AlertRepository, serviceRepo *repository.ServiceRepository, notifierService *notifier.NotifierService, cfg *config.Config) *AlertHandler {
+func NewAlertHandler(alertRepo *repository.AlertRepository, serviceRepo *repository.ServiceRepository, escalationRepo *repository.EscalationRepository, oncallRepo *repository.OnCallRepository, userRepo *repository.UserRepository, incidentRepo *repository.IncidentRepository, notificationRepo *repository.NotificationRepository, holdRepo *repository.NotificationHoldRepository, notifierService *notifier.NotifierService, cfg *config.Config) *AlertHandler {
 	return &AlertHandler{
-		alertRepo:   alertRepo,
-		serviceRepo: serviceRepo,
-		notifier:    notifierService,
-		cfg:         cfg,
+		alertRepo:        alertRepo,
+		serviceRepo:      serviceRepo,
+		escalationRepo:   escalationRepo,
+		oncallRepo:       oncallRepo,
+		userRepo:         userRepo,
+		incidentRepo:     incidentRepo,
+		notificationRepo: notificationRepo,
+		holdRepo:         holdRepo,
+		notifier:         notifierService,
+		cfg:              cfg,
 	}
 }
 
+// CreateSubscriptionRequest takes either a fixed destination or an on-call
+// schedule, whose current on-call user is emailed. Email is the default
+// channel; webhook subscriptions POST a signed JSON payload to the
+// destination URL. Teams, Discord and Mattermost destinations are incoming
+// webhook URLs, Telegram destinations are chat IDs, and PagerDuty and
+// Opsgenie destinations are integration keys. Web Push destinations are
+// user IDs, the caller by default; alerts go to every browser the user has
+// registered for push notifications.
 type CreateSubscriptionRequest struct {
-	ServiceID   *string `json:"service_id"`
-	Destination string  `json:"destination" binding:"required,email"`
+	ServiceID        *string         `json:"service_id"`
+	Channel          string          `json:"channel" binding:"omitempty,oneof=email sms slack webhook teams discord telegram mattermost pagerduty opsgenie webpush"`
+	Destination      string          `json:"destination"`
+	OnCallScheduleID *string         `json:"oncall_schedule_id"`
+	Webhook          *WebhookRequest `json:"webhook"`
+	SubscriptionRoutingRequest
+	SubscriptionQuietHoursRequest
 }
 
+// SubscriptionRoutingRequest filters the alerts a subscription is notified
+// about. Each filter left empty matches everything.
+type SubscriptionRoutingRequest struct {
+	MinSeverity string   `json:"min_severity" binding:"omitempty,oneof=low medium high critical"`
+	AlertTypes  []string `json:"alert_types"`
+	ServiceTags []string `json:"service_tags"`
+	Events      []string `json:"events"`
+}
+
+// SubscriptionQuietHoursRequest holds an email subscription's notifications
+// back for a digest. Between QuietHoursStart and QuietHoursEnd ("HH:MM" in
+// TimeZone, UTC by default) everything but critical alerts is held until
+// the window ends. DigestMinutes batches low-severity alerts into one email
+// sent at most that often; 0 turns it off.
+type SubscriptionQuietHoursRequest struct {
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	TimeZone        string `json:"time_zone"`
+	DigestMinutes   int    `json:"digest_minutes"`
+}
+
+// WebhookRequest configures a webhook subscription. Template is an optional
+// Go text/template for the request body, executed against the default
+// payload. A signing secret is generated when Secret is empty.
+type WebhookRequest struct {
+	Template string            `json:"template"`
+	Headers  map[string]string `json:"headers"`
+	Secret   string            `json:"secret"`
+}
+
+// CreatedSubscription is returned when a subscription is created. It is the
+// only time a webhook subscription's signing secret, or the URL PagerDuty and
+// Opsgenie send acknowledgements to, is shown.
+type CreatedSubscription struct {
+	*models.AlertSubscription
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+	InboundURL    string `json:"inbound_url,omitempty"`
+	// Test is the test message queued to verify the destination
+	Test *SubscriptionTest `json:"test,omitempty"`
+}
+
+// SubscriptionTest is the outcome of a test message sent to a subscription
+type SubscriptionTest struct {
+	Subscription *models.AlertSubscription `json:"subscription,omitempty"`
+	Delivered    bool                      `json:"delivered"`
+	StatusCode   *int                      `json:"status_code,omitempty"`
+	Error        string                    `json:"error,omitempty"`
+	// Pending is set when the message was queued rather than sent while
+	// the request waited; the subscription's verification status shows
+	// whether it arrived
+	Pending bool `json:"pending"`
+	// CodeSent is set when the message carried a confirmation code, to be
+	// submitted to the verify endpoint
+	CodeSent bool `json:"code_sent"`
+}
+
+type VerifySubscriptionRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// AlertDetail is an alert together with its timeline
+type AlertDetail struct {
+	*models.Alert
+	Timeline []*models.AlertEvent `json:"timeline"`
+}
+
+// SnoozeAlertRequest takes either a number of minutes or an end time
+type SnoozeAlertRequest struct {
+	Minutes int        `json:"minutes" binding:"omitempty,min=1"`
+	Until   *time.Time `json:"until"`
+}
+
+// AssignAlertRequest names the new assignee; an empty user_id unassigns
+type AssignAlertRequest struct {
+	UserID *string `json:"user_id"`
+}
+
+type AlertNoteRequest struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
+
+// ListAlerts lists the organization's alerts, newest first, optionally
+// filtered by ?status=triggered|acknowledged|resolved
 func (h *AlertHandler) ListAlerts(c *gin.Context) {
 	orgID, exists := c.Get("organization_id")
 	if !exists {
@@ -56,7 +175,15 @@ func (h *AlertHandler) ListAlerts(c *gin.Context) {
 		}
 	}
 
-	alerts, err := h.alertRepo.ListByOrganization(orgUUID, limit)
+	status := c.Query("status")
+	switch status {
+	case "", alerting.StatusTriggered, alerting.StatusAcknowledged, alerting.StatusResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be triggered, acknowledged or resolved"})
+		return
+	}
+
+	alerts, err := h.alertRepo.ListByOrganization(orgUUID, status, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
 		return
@@ -69,35 +196,397 @@ func (h *AlertHandler) ListAlerts(c *gin.Context) {
 	c.JSON(http.StatusOK, alerts)
 }
 
+// GetAlert returns an alert with its timeline
 func (h *AlertHandler) GetAlert(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	h.respondWithDetail(c, alert)
+}
+
+func (h *AlertHandler) ResolveAlert(c *gin.Context) {
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	resolved, err := h.alertRepo.Resolve(alert.ID, userID.String())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve alert"})
+		return
+	}
+	if resolved {
+		if err := h.escalationRepo.FinishEscalationsForAlert(alert.ID, escalation.StatusResolved); err != nil {
+			log.Printf("Error stopping escalation for alert %s: %v", alert.ID, err)
+		}
+		// The remote incident belongs to the alert that paged: this one, or
+		// its incident's lead once the incident closes
+		paged := alert
+		if alert.IncidentID != nil {
+			paged = nil
+			incident, err := h.incidentRepo.AlertResolved(*alert.IncidentID, alert.ID, userID.String(), alert.Message)
+			if err != nil {
+				log.Printf("Error recording resolution on incident %s: %v", *alert.IncidentID, err)
+			} else if incident != nil && incident.LeadAlertID != nil {
+				if paged, err = h.alertRepo.GetByID(*incident.LeadAlertID); err != nil {
+					log.Printf("Error fetching lead alert of incident %s: %v", incident.ID, err)
+				}
+			}
+		}
+		if paged != nil && !paged.IsSuppressed && h.notifier != nil {
+			go h.notifier.ResolveRemoteIncidents(paged, "Resolved manually in PulseGrid")
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert resolved successfully"})
+}
+
+// AcknowledgeAlert records that the caller has taken the alert, which stops
+// its escalation policy and repeat notifications. Acknowledging an already
+// acknowledged alert changes nothing.
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	alert, ok := h.loadAlert(c)
+	if !ok {
 		return
 	}
 
-	alert, err := h.alertRepo.GetByID(id)
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	if alert.IsResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alert is already resolved"})
+		return
+	}
+
+	acknowledged, err := h.alertRepo.Acknowledge(alert.ID, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge alert"})
 		return
 	}
+	if acknowledged {
+		if err := h.escalationRepo.FinishEscalationsForAlert(alert.ID, escalation.StatusAcknowledged); err != nil {
+			log.Printf("Error stopping escalation for alert %s: %v", alert.ID, err)
+		}
+	}
 
-	c.JSON(http.StatusOK, alert)
+	h.respondWithFreshDetail(c, alert.ID)
 }
 
-func (h *AlertHandler) ResolveAlert(c *gin.Context) {
+// SnoozeAlert silences an open alert's notifications for a while. Pending
+// escalation steps wait for the snooze to end.
+func (h *AlertHandler) SnoozeAlert(c *gin.Context) {
+	var req SnoozeAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	if (req.Minutes == 0) == (req.Until == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either minutes or until"})
+		return
+	}
+
+	now := time.Now().UTC()
+	until := now.Add(time.Duration(req.Minutes) * time.Minute)
+	if req.Until != nil {
+		until = req.Until.UTC()
+	}
+	if !until.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "until must be in the future"})
+		return
+	}
+	if until.Sub(now) > alerting.MaxSnooze {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alerts can be snoozed for at most 7 days"})
+		return
+	}
+
+	snoozed, err := h.alertRepo.Snooze(alert.ID, userID, &until)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to snooze alert"})
+		return
+	}
+	if !snoozed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alert is already resolved"})
+		return
+	}
+
+	h.respondWithFreshDetail(c, alert.ID)
+}
+
+// UnsnoozeAlert lifts a snooze early
+func (h *AlertHandler) UnsnoozeAlert(c *gin.Context) {
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	if alert.SnoozedUntil != nil {
+		if _, err := h.alertRepo.Snooze(alert.ID, userID, nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsnooze alert"})
+			return
+		}
+	}
+
+	h.respondWithFreshDetail(c, alert.ID)
+}
+
+// AssignAlert sets or clears the member of the organization responsible for
+// an alert
+func (h *AlertHandler) AssignAlert(c *gin.Context) {
+	var req AssignAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var assignee *models.User
+	if req.UserID != nil && *req.UserID != "" {
+		assigneeID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		user, err := h.userRepo.GetByID(assigneeID)
+		if err != nil || user.OrganizationID == nil || *user.OrganizationID != orgID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Assignee must be a member of your organization"})
+			return
+		}
+		assignee = user
+	}
+
+	if _, err := h.alertRepo.Assign(alert.ID, userID, assignee); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign alert"})
+		return
+	}
+
+	h.respondWithFreshDetail(c, alert.ID)
+}
+
+// AddAlertNote adds the caller's note to an alert's timeline. Notes can be
+// left on resolved alerts too, for follow-up.
+func (h *AlertHandler) AddAlertNote(c *gin.Context) {
+	var req AlertNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body is required"})
+		return
+	}
+
+	event := &models.AlertEvent{
+		AlertID: alert.ID,
+		Kind:    alerting.EventNote,
+		UserID:  &userID,
+		Body:    body,
+	}
+	if err := h.alertRepo.AddEvent(event); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add note"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
+
+// GetAlertTimeline returns an alert's lifecycle events and notes, oldest first
+func (h *AlertHandler) GetAlertTimeline(c *gin.Context) {
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.alertRepo.ListEvents(alert.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert timeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// ListDeliveries returns every notification sent about an alert with its
+// delivery attempts, oldest first
+func (h *AlertHandler) ListDeliveries(c *gin.Context) {
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.notificationRepo.ListByAlert(alert.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ResendDelivery puts one of an alert's notifications back in the outbox
+// with a fresh set of attempts and tries it straight away
+func (h *AlertHandler) ResendDelivery(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can resend notifications"})
+		return
+	}
+
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.notificationRepo.Get(deliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch delivery"})
+		}
+		return
+	}
+	if delivery.AlertID == nil || *delivery.AlertID != alert.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	delivery, err = h.notifier.Resend(delivery.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend notification"})
+		return
+	}
+
+	event := &models.AlertEvent{
+		AlertID: alert.ID,
+		Kind:    alerting.EventNotified,
+		UserID:  &userID,
+		Body:    fmt.Sprintf("Resent notification to %s via %s (%s)", delivery.Destination, delivery.Channel, delivery.Status),
+	}
+	if err := h.alertRepo.AddEvent(event); err != nil {
+		log.Printf("Error recording resend for alert %s: %v", alert.ID, err)
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// loadAlert fetches the alert named in the path and checks its service
+// belongs to the caller's organization
+func (h *AlertHandler) loadAlert(c *gin.Context) (*models.Alert, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return nil, false
+	}
+
+	alert, err := h.alertRepo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert"})
+		}
+		return nil, false
+	}
+
+	service, err := h.serviceRepo.GetByID(alert.ServiceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert"})
+		return nil, false
+	}
+	if service.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return alert, true
+}
+
+// respondWithFreshDetail reloads an alert after a change and writes it with
+// its timeline
+func (h *AlertHandler) respondWithFreshDetail(c *gin.Context, id uuid.UUID) {
+	alert, err := h.alertRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert"})
 		return
 	}
 
-	if err := h.alertRepo.Resolve(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve alert"})
+	h.respondWithDetail(c, alert)
+}
+
+func (h *AlertHandler) respondWithDetail(c *gin.Context, alert *models.Alert) {
+	events, err := h.alertRepo.ListEvents(alert.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert timeline"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Alert resolved successfully"})
+	c.JSON(http.StatusOK, AlertDetail{Alert: alert, Timeline: events})
 }
 
 func (h *AlertHandler) CreateSubscription(c *gin.Context) {
@@ -129,12 +618,120 @@ func (h *AlertHandler) CreateSubscription(c *gin.Context) {
 		serviceUUID = &id
 	}
 
+	var scheduleUUID *uuid.UUID
+	if req.OnCallScheduleID != nil && *req.OnCallScheduleID != "" {
+		id, err := uuid.Parse(*req.OnCallScheduleID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+			return
+		}
+		schedule, err := h.oncallRepo.GetSchedule(id)
+		if err != nil || schedule.OrganizationID != orgUUID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "On-call schedule not found"})
+			return
+		}
+		scheduleUUID = &id
+	}
+
+	if req.Channel == "webpush" && req.Destination == "" && scheduleUUID == nil {
+		userID, ok := userIDFromContext(c)
+		if !ok {
+			return
+		}
+		req.Destination = userID.String()
+	}
+
+	if (scheduleUUID == nil) == (req.Destination == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either destination or oncall_schedule_id"})
+		return
+	}
+
 	sub := &models.AlertSubscription{
-		OrganizationID: orgUUID,
-		ServiceID:      serviceUUID,
-		Channel:        "email",
-		Destination:    req.Destination,
-		IsActive:       true,
+		OrganizationID:   orgUUID,
+		ServiceID:        serviceUUID,
+		Channel:          req.Channel,
+		Destination:      req.Destination,
+		OnCallScheduleID: scheduleUUID,
+		IsActive:         true,
+	}
+	if sub.Channel == "" {
+		sub.Channel = "email"
+	}
+	// Schedules send to their on-call users, who have no single
+	// destination to confirm
+	if scheduleUUID != nil {
+		now := time.Now().UTC()
+		sub.VerificationStatus = verification.StatusVerified
+		sub.VerifiedAt = &now
+	}
+
+	if !applyRouting(c, sub, &req.SubscriptionRoutingRequest) {
+		return
+	}
+	if !applyQuietHours(c, sub, &req.SubscriptionQuietHoursRequest) {
+		return
+	}
+	if sub.Channel != "webhook" && req.Webhook != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "webhook settings only apply to the webhook channel"})
+		return
+	}
+	if sub.Channel != "email" && scheduleUUID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "On-call schedules can only be used with the email channel"})
+		return
+	}
+
+	switch sub.Channel {
+	case "email":
+		if req.Destination != "" {
+			if addr, err := mail.ParseAddress(req.Destination); err != nil || addr.Address != req.Destination {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "destination must be an email address"})
+				return
+			}
+		}
+	case "sms":
+		if err := verification.ValidatePhoneNumber(req.Destination); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "slack":
+		if err := webhook.ValidateURL(req.Destination); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "webhook":
+		config, err := webhookConfig(req.Destination, req.Webhook)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		sub.Webhook = config
+	case "webpush":
+		userID, err := uuid.Parse(req.Destination)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "destination must be a user ID"})
+			return
+		}
+		user, err := h.userRepo.GetByID(userID)
+		if err != nil || user.OrganizationID == nil || *user.OrganizationID != orgUUID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+			return
+		}
+	case paging.PagerDuty, paging.Opsgenie:
+		if err := paging.ValidateKey(sub.Channel, req.Destination); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		token, err := webhook.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+			return
+		}
+		sub.InboundToken = token
+	default:
+		if err := chat.ValidateDestination(sub.Channel, req.Destination); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	if err := h.alertRepo.CreateSubscription(sub); err != nil {
@@ -142,9 +739,65 @@ func (h *AlertHandler) CreateSubscription(c *gin.Context) {
 		return
 	}
 
-	go h.sendSubscriptionConfirmation(sub)
+	created := CreatedSubscription{AlertSubscription: sub}
+	if sub.OnCallScheduleID == nil {
+		created.Test = h.queueSubscriptionTest(sub)
+	}
+	if sub.Webhook != nil {
+		created.WebhookSecret = sub.Webhook.Secret
+	}
+	if sub.InboundToken != "" {
+		created.InboundURL = fmt.Sprintf("%s/api/v1/integrations/%s/events?token=%s", requestOrigin(c), sub.ID, sub.InboundToken)
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// webhookConfig validates a webhook subscription's destination and settings,
+// rendering the template against a sample alert so a broken one is rejected
+// now rather than when an alert fires
+func webhookConfig(destination string, req *WebhookRequest) (*models.WebhookConfig, error) {
+	if err := webhook.ValidateURL(destination); err != nil {
+		return nil, err
+	}
+
+	config := &models.WebhookConfig{}
+	if req != nil {
+		config.Template = req.Template
+		config.Headers = req.Headers
+		config.Secret = req.Secret
+	}
+	if err := webhook.ValidateHeaders(config.Headers); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	sample := webhook.Payload{
+		Event:   webhook.EventAlertTriggered,
+		Subject: "PulseGrid Alert: Example service is down",
+		Message: "🟠 HIGH Alert: Example service is down",
+		Alert: &models.Alert{
+			ID:        uuid.New(),
+			ServiceID: uuid.New(),
+			Type:      alerting.TypeDowntime,
+			Message:   "Example service is down",
+			Severity:  "high",
+			Status:    alerting.StatusTriggered,
+			CreatedAt: now,
+		},
+		SentAt: now,
+	}
+	if err := webhook.ValidateTemplate(config.Template, sample); err != nil {
+		return nil, err
+	}
 
-	c.JSON(http.StatusCreated, sub)
+	if config.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return nil, err
+		}
+		config.Secret = secret
+	}
+	return config, nil
 }
 
 func (h *AlertHandler) ListSubscriptions(c *gin.Context) {
@@ -184,28 +837,342 @@ func (h *AlertHandler) DeleteSubscription(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Subscription deleted successfully"})
 }
 
-func (h *AlertHandler) sendSubscriptionConfirmation(sub *models.AlertSubscription) {
+// UpdateSubscriptionRouting replaces a subscription's routing filters
+func (h *AlertHandler) UpdateSubscriptionRouting(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionRoutingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !applyRouting(c, sub, &req) {
+		return
+	}
+
+	if err := h.alertRepo.UpdateSubscriptionRouting(sub); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// applyRouting validates a routing request and copies it onto sub
+func applyRouting(c *gin.Context, sub *models.AlertSubscription, req *SubscriptionRoutingRequest) bool {
+	filter := alerting.RouteFilter{
+		MinSeverity: req.MinSeverity,
+		AlertTypes:  req.AlertTypes,
+		ServiceTags: req.ServiceTags,
+		Events:      req.Events,
+	}
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	// The resolve closes the remote incident the trigger opened
+	if paging.IsChannel(sub.Channel) && len(req.Events) > 0 && !containsString(req.Events, alerting.EventResolved) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "PagerDuty and Opsgenie subscriptions must receive resolved events to close their incidents"})
+		return false
+	}
+
+	sub.MinSeverity = req.MinSeverity
+	sub.AlertTypes = req.AlertTypes
+	sub.ServiceTags = req.ServiceTags
+	sub.Events = req.Events
+	return true
+}
+
+// UpdateSubscriptionQuietHours replaces a subscription's quiet hours and
+// digest interval. Notifications already held go out when they were due.
+func (h *AlertHandler) UpdateSubscriptionQuietHours(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionQuietHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !applyQuietHours(c, sub, &req) {
+		return
+	}
+
+	if err := h.alertRepo.UpdateSubscriptionQuietHours(sub); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// ListHeldNotifications returns the notifications a subscription is holding
+// for its next digest
+func (h *AlertHandler) ListHeldNotifications(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	holds, err := h.holdRepo.ListBySubscription(sub.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch held notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, holds)
+}
+
+// applyQuietHours validates a quiet hours request and copies it onto sub
+func applyQuietHours(c *gin.Context, sub *models.AlertSubscription, req *SubscriptionQuietHoursRequest) bool {
+	if req.TimeZone == "" {
+		req.TimeZone = "UTC"
+	}
+	schedule := digest.Schedule{
+		Start:           req.QuietHoursStart,
+		End:             req.QuietHoursEnd,
+		TimeZone:        req.TimeZone,
+		IntervalMinutes: req.DigestMinutes,
+	}
+	if err := schedule.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	// Digests are emails
+	if sub.Channel != "email" && (req.QuietHoursStart != "" || req.DigestMinutes > 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quiet hours and digests are only available for email subscriptions"})
+		return false
+	}
+
+	sub.QuietHoursStart = req.QuietHoursStart
+	sub.QuietHoursEnd = req.QuietHoursEnd
+	sub.TimeZone = req.TimeZone
+	sub.DigestMinutes = req.DigestMinutes
+	return true
+}
+
+// TestSubscription sends a test message to a subscription. Email and SMS
+// destinations get a new confirmation code; webhook, chat and paging
+// destinations are verified, and re-enabled if failures had disabled them,
+// by accepting the message.
+func (h *AlertHandler) TestSubscription(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
 	if h.notifier == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Notifications are not configured"})
 		return
 	}
 
-	scope := "all services"
-	if sub.ServiceID != nil && h.serviceRepo != nil {
-		if service, err := h.serviceRepo.GetByID(*sub.ServiceID); err == nil {
-			scope = fmt.Sprintf("service \"%s\"", service.Name)
+	test := h.testSubscription(sub)
+	test.Subscription = sub
+	c.JSON(http.StatusOK, test)
+}
+
+// VerifySubscription confirms an email or SMS subscription with the code
+// sent to it, re-enabling it if failures had disabled it
+func (h *AlertHandler) VerifySubscription(c *gin.Context) {
+	sub, ok := h.loadSubscription(c)
+	if !ok {
+		return
+	}
+
+	var req VerifySubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !verification.ByCode(sub.Channel) || sub.OnCallScheduleID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This subscription is verified by sending it a test message"})
+		return
+	}
+
+	err := verification.Check(sub.VerificationCode, sub.VerificationExpiresAt, sub.VerificationAttempts, strings.TrimSpace(req.Code), time.Now().UTC())
+	if err != nil {
+		if err == verification.ErrMismatch {
+			if err := h.alertRepo.RecordVerificationAttempt(sub.ID); err != nil {
+				log.Printf("Error recording verification attempt for subscription %s: %v", sub.ID, err)
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.alertRepo.MarkSubscriptionVerified(sub.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify subscription"})
+		return
+	}
+
+	h.respondWithSubscription(c, sub.ID)
+}
+
+// loadSubscription fetches the subscription named in the path and checks it
+// belongs to the caller's organization
+func (h *AlertHandler) loadSubscription(c *gin.Context) (*models.AlertSubscription, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return nil, false
+	}
+
+	sub, err := h.alertRepo.GetSubscription(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
 		} else {
-			scope = "the selected service"
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscription"})
 		}
+		return nil, false
+	}
+	if sub.OrganizationID != orgID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return nil, false
+	}
+
+	return sub, true
+}
+
+func (h *AlertHandler) respondWithSubscription(c *gin.Context, id uuid.UUID) {
+	sub, err := h.alertRepo.GetSubscription(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscription"})
+		return
 	}
+	c.JSON(http.StatusOK, sub)
+}
 
-	subject := "PulseGrid Alerts: Subscription Confirmed"
-	body := fmt.Sprintf(`You’re all set! This email address (%s) will now receive PulseGrid alert notifications for %s.
+// testSubscription sends sub a test message and records what it proves.
+// Email and SMS destinations are sent a fresh confirmation code, since
+// accepting the message says nothing about who reads it; any other
+// destination is verified by accepting it. sub is updated to match.
+func (h *AlertHandler) testSubscription(sub *models.AlertSubscription) *SubscriptionTest {
+	test := &SubscriptionTest{}
+	if h.notifier == nil {
+		test.Error = "Notifications are not configured"
+		return test
+	}
 
-To trigger a test alert, you can run a manual health check on a service that’s intentionally down or has a low latency threshold.
+	subject, body, byCode, err := h.testMessage(sub)
+	if err != nil {
+		log.Printf("Error creating confirmation code for subscription %s: %v", sub.ID, err)
+		test.Error = "Failed to create confirmation code"
+		return test
+	}
 
-If this wasn’t you, please delete the subscription from the Alert Subscriptions page.`, sub.Destination, scope)
+	statusCode, err := h.notifier.TestSubscription(sub, subject, body)
+	if statusCode != 0 {
+		test.StatusCode = &statusCode
+	}
+	if err != nil {
+		test.Error = err.Error()
+		return test
+	}
+	test.Delivered = true
+	test.CodeSent = byCode
+
+	if !byCode && sub.OnCallScheduleID == nil {
+		if err := h.alertRepo.MarkSubscriptionVerified(sub.ID); err != nil {
+			log.Printf("Error marking subscription %s verified: %v", sub.ID, err)
+			return test
+		}
+		now := time.Now().UTC()
+		sub.VerificationStatus = verification.StatusVerified
+		sub.VerifiedAt = &now
+		sub.ConsecutiveFailures = 0
+		sub.DisabledReason = nil
+		sub.IsActive = true
+	}
+	return test
+}
 
-	if err := h.notifier.SendCustomEmail(sub.Destination, subject, body); err != nil {
-		log.Printf("Failed to send subscription confirmation: %v", err)
+// queueSubscriptionTest queues the test message for a new subscription
+// rather than waiting on its destination, which may take as long as the
+// notification timeout. The subscription's verification status shows the
+// outcome once the message has been sent.
+func (h *AlertHandler) queueSubscriptionTest(sub *models.AlertSubscription) *SubscriptionTest {
+	test := &SubscriptionTest{}
+	if h.notifier == nil {
+		test.Error = "Notifications are not configured"
+		return test
+	}
+
+	subject, body, byCode, err := h.testMessage(sub)
+	if err != nil {
+		log.Printf("Error creating confirmation code for subscription %s: %v", sub.ID, err)
+		test.Error = "Failed to create confirmation code"
+		return test
+	}
+	if err := h.notifier.QueueSubscriptionTest(sub, subject, body, byCode); err != nil {
+		test.Error = err.Error()
+		return test
+	}
+	test.Pending = true
+	test.CodeSent = byCode
+	return test
+}
+
+// testMessage builds the test message for sub. For destinations verified by
+// code it creates and stores a fresh confirmation code to send, the only
+// step that can fail.
+func (h *AlertHandler) testMessage(sub *models.AlertSubscription) (subject, body string, byCode bool, err error) {
+	subject = "PulseGrid Alerts: Test Notification"
+	body = fmt.Sprintf("This is a test notification from PulseGrid. Alerts for %s will be sent here.", h.subscriptionScope(sub))
+
+	byCode = verification.ByCode(sub.Channel) && sub.OnCallScheduleID == nil
+	if !byCode {
+		return subject, body, false, nil
+	}
+
+	code, err := verification.NewCode()
+	if err != nil {
+		return "", "", false, err
+	}
+	expiresAt := time.Now().UTC().Add(verification.CodeTTL)
+	if err := h.alertRepo.SetVerificationCode(sub.ID, code, expiresAt); err != nil {
+		return "", "", false, err
+	}
+	subject = "PulseGrid Alerts: Confirm Your Subscription"
+	body = fmt.Sprintf(`Your PulseGrid confirmation code is %s
+
+Enter it on the Alert Subscriptions page to start receiving alert notifications for %s at %s. The code expires in %d hours.
+
+If this wasn’t you, you can ignore this message.`, code, h.subscriptionScope(sub), sub.Destination, int(verification.CodeTTL.Hours()))
+	if sub.Channel == "sms" {
+		body = fmt.Sprintf("Your PulseGrid confirmation code is %s. It expires in %d hours.", code, int(verification.CodeTTL.Hours()))
+	}
+	return subject, body, true, nil
+}
+
+// subscriptionScope describes the services a subscription covers
+func (h *AlertHandler) subscriptionScope(sub *models.AlertSubscription) string {
+	if sub.ServiceID == nil {
+		return "all services"
+	}
+	if h.serviceRepo != nil {
+		if service, err := h.serviceRepo.GetByID(*sub.ServiceID); err == nil {
+			return fmt.Sprintf("service \"%s\"", service.Name)
+		}
+	}
+	return "the selected service"
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/monitor"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/alerting"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AlertRuleHandler struct {
+	ruleRepo    *repository.AlertRuleRepository
+	serviceRepo *repository.ServiceRepository
+	cfg         *config.Config
+}
+
+func NewAlertRuleHandler(ruleRepo *repository.AlertRuleRepository, serviceRepo *repository.ServiceRepository, cfg *config.Config) *AlertRuleHandler {
+	return &AlertRuleHandler{
+		ruleRepo:    ruleRepo,
+		serviceRepo: serviceRepo,
+		cfg:         cfg,
+	}
+}
+
+type AlertRuleRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	Kind            string  `json:"kind" binding:"required"`
+	Threshold       float64 `json:"threshold"`
+	StatusCodes     *string `json:"status_codes"`
+	WindowSeconds   int     `json:"window_seconds"`
+	CooldownSeconds int     `json:"cooldown_seconds"`
+	Severity        string  `json:"severity"` // defaults to medium
+	IsEnabled       *bool   `json:"is_enabled"`
+}
+
+func (h *AlertRuleHandler) ListRules(c *gin.Context) {
+	service, ok := h.loadService(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.ruleRepo.ListByService(service.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *AlertRuleHandler) GetRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *AlertRuleHandler) CreateRule(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage alert rules"})
+		return
+	}
+
+	service, ok := h.loadService(c)
+	if !ok {
+		return
+	}
+
+	var req AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &models.AlertRule{ServiceID: service.ID, IsEnabled: true}
+	if !applyAlertRuleRequest(c, rule, &req) {
+		return
+	}
+
+	if err := h.ruleRepo.Create(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create alert rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *AlertRuleHandler) UpdateRule(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage alert rules"})
+		return
+	}
+
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	var req AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyAlertRuleRequest(c, rule, &req) {
+		return
+	}
+
+	if err := h.ruleRepo.Update(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *AlertRuleHandler) DeleteRule(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage alert rules"})
+		return
+	}
+
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleRepo.Delete(rule.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alert rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted successfully"})
+}
+
+// loadService fetches the service named in the path and checks it belongs
+// to the caller's organization
+func (h *AlertRuleHandler) loadService(c *gin.Context) (*models.Service, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return nil, false
+	}
+
+	service, err := h.serviceRepo.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return nil, false
+	}
+
+	if service.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return service, true
+}
+
+// loadRule fetches the rule named in the path, checking it belongs to the
+// service in the path
+func (h *AlertRuleHandler) loadRule(c *gin.Context) (*models.AlertRule, bool) {
+	service, ok := h.loadService(c)
+	if !ok {
+		return nil, false
+	}
+
+	ruleID, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert rule ID"})
+		return nil, false
+	}
+
+	rule, err := h.ruleRepo.GetByID(ruleID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert rule"})
+		}
+		return nil, false
+	}
+
+	if rule.ServiceID != service.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		return nil, false
+	}
+
+	return rule, true
+}
+
+// applyAlertRuleRequest copies a request onto rule and validates the result
+func applyAlertRuleRequest(c *gin.Context, rule *models.AlertRule, req *AlertRuleRequest) bool {
+	rule.Name = req.Name
+	rule.Kind = req.Kind
+	rule.Threshold = req.Threshold
+	rule.StatusCodes = nil
+	if req.Kind == alerting.RuleStatusCodeCount && req.StatusCodes != nil {
+		rule.StatusCodes = req.StatusCodes
+	}
+	rule.WindowSeconds = req.WindowSeconds
+	if req.Kind == alerting.RuleConsecutiveFailures {
+		rule.WindowSeconds = 0
+	}
+	rule.CooldownSeconds = req.CooldownSeconds
+	rule.Severity = req.Severity
+	if rule.Severity == "" {
+		rule.Severity = alerting.SeverityMedium
+	}
+	if req.IsEnabled != nil {
+		rule.IsEnabled = *req.IsEnabled
+	}
+
+	if err := alerting.ValidateRule(monitor.ToRule(rule)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/badge"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// badgeCacheTTL is how long a rendered badge is served, both from memory
+// and by browsers and proxies, before it is drawn again
+const badgeCacheTTL = 5 * time.Minute
+
+// badgeCacheSize caps the rendered badges kept in memory, since custom
+// labels make the set of addresses open-ended
+const badgeCacheSize = 10000
+
+// Badge kinds, which are also the last part of a badge's address
+const (
+	badgeKindStatus       = "status"
+	badgeKindUptime       = "uptime"
+	badgeKindResponseTime = "response-time"
+)
+
+// cachedBadge is a rendered badge and the token it was drawn for, so
+// revoking the token can drop it
+type cachedBadge struct {
+	tokenID uuid.UUID
+	svg     string
+	etag    string
+	builtAt time.Time
+}
+
+type BadgeHandler struct {
+	badgeRepo       *repository.BadgeTokenRepository
+	serviceRepo     *repository.ServiceRepository
+	healthCheckRepo *repository.HealthCheckRepository
+	cfg             *config.Config
+
+	// In-memory cache of rendered badges by address (use Redis in
+	// production for distributed systems)
+	cacheMu sync.RWMutex
+	cache   map[string]*cachedBadge
+}
+
+func NewBadgeHandler(badgeRepo *repository.BadgeTokenRepository, serviceRepo *repository.ServiceRepository, healthCheckRepo *repository.HealthCheckRepository, cfg *config.Config) *BadgeHandler {
+	return &BadgeHandler{
+		badgeRepo:       badgeRepo,
+		serviceRepo:     serviceRepo,
+		healthCheckRepo: healthCheckRepo,
+		cfg:             cfg,
+		cache:           make(map[string]*cachedBadge),
+	}
+}
+
+type BadgeTokenRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// BadgeURLs are the addresses of a token's badges, ready to embed
+type BadgeURLs struct {
+	Status       string `json:"status"`
+	Uptime       string `json:"uptime"`
+	ResponseTime string `json:"response_time"`
+}
+
+// CreatedBadgeToken is returned once, when a token is created; the token
+// itself is never shown again
+type CreatedBadgeToken struct {
+	*models.BadgeToken
+	Token string    `json:"token"`
+	URLs  BadgeURLs `json:"urls"`
+}
+
+// ListBadgeTokens lists a service's badge tokens, without the secrets
+// GET /api/v1/services/:id/badges
+func (h *BadgeHandler) ListBadgeTokens(c *gin.Context) {
+	service, ok := h.loadService(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.badgeRepo.ListByService(service.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch badge tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateBadgeToken issues a token whose badges anyone holding it can view
+// POST /api/v1/services/:id/badges
+func (h *BadgeHandler) CreateBadgeToken(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage badges"})
+		return
+	}
+
+	service, ok := h.loadService(c)
+	if !ok {
+		return
+	}
+
+	var req BadgeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	secret, err := badge.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create badge token"})
+		return
+	}
+
+	token := &models.BadgeToken{
+		ServiceID: service.ID,
+		Name:      req.Name,
+		Token:     secret,
+	}
+	if userID, ok := userIDFromContext(c); ok {
+		token.CreatedBy = &userID
+	}
+
+	if err := h.badgeRepo.Create(token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create badge token"})
+		return
+	}
+
+	base := requestOrigin(c) + "/api/v1/badges/" + secret
+	c.JSON(http.StatusCreated, CreatedBadgeToken{
+		BadgeToken: token,
+		Token:      secret,
+		URLs: BadgeURLs{
+			Status:       base + "/" + badgeKindStatus + ".svg",
+			Uptime:       base + "/" + badgeKindUptime + ".svg",
+			ResponseTime: base + "/" + badgeKindResponseTime + ".svg",
+		},
+	})
+}
+
+// DeleteBadgeToken revokes a badge token; its badges stop rendering at once
+// DELETE /api/v1/services/:id/badges/:badgeId
+func (h *BadgeHandler) DeleteBadgeToken(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage badges"})
+		return
+	}
+
+	service, ok := h.loadService(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("badgeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid badge token ID"})
+		return
+	}
+
+	if err := h.badgeRepo.Delete(service.ID, id); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Badge token not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete badge token"})
+		}
+		return
+	}
+	h.invalidate(id)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Badge token deleted successfully"})
+}
+
+// StatusBadge shows whether a service is up, degraded or down
+// GET /api/v1/badges/:token/status.svg
+func (h *BadgeHandler) StatusBadge(c *gin.Context) {
+	h.serveBadge(c, badgeKindStatus)
+}
+
+// UptimeBadge shows a service's uptime over ?period= (24h, 7d or 30d)
+// GET /api/v1/badges/:token/uptime.svg
+func (h *BadgeHandler) UptimeBadge(c *gin.Context) {
+	h.serveBadge(c, badgeKindUptime)
+}
+
+// ResponseTimeBadge shows a service's average response time over ?period=
+// GET /api/v1/badges/:token/response-time.svg
+func (h *BadgeHandler) ResponseTimeBadge(c *gin.Context) {
+	h.serveBadge(c, badgeKindResponseTime)
+}
+
+// serveBadge renders one of a token's badges, or a "not found" badge for an
+// unknown or revoked token so embeds fail visibly rather than as a broken
+// image
+func (h *BadgeHandler) serveBadge(c *gin.Context, kind string) {
+	secret := c.Param("token")
+
+	period := ""
+	var window time.Duration
+	if kind != badgeKindStatus {
+		var err error
+		period, window, err = badge.ParsePeriod(c.Query("period"))
+		if err != nil {
+			h.writeError(c, http.StatusBadRequest, "invalid period")
+			return
+		}
+	}
+	label := c.Query("label")
+
+	key := strings.Join([]string{secret, kind, period, label}, "\x00")
+	if cached := h.cached(key); cached != nil {
+		h.write(c, cached)
+		return
+	}
+
+	token, err := h.badgeRepo.GetByToken(secret)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(c, http.StatusNotFound, "not found")
+		} else {
+			h.writeError(c, http.StatusInternalServerError, "error")
+		}
+		return
+	}
+
+	service, err := h.serviceRepo.GetByID(token.ServiceID)
+	if err != nil {
+		h.writeError(c, http.StatusInternalServerError, "error")
+		return
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-window)
+	if kind == badgeKindStatus {
+		// Only the last check matters; a day is enough to find it cheaply
+		since = now.Add(-24 * time.Hour)
+	}
+	stats, err := h.healthCheckRepo.GetStatsByServiceID(service.ID, since)
+	if err != nil {
+		h.writeError(c, http.StatusInternalServerError, "error")
+		return
+	}
+
+	var message, color, defaultLabel string
+	switch kind {
+	case badgeKindStatus:
+		message, color = badge.Status(stats.Status, service.IsActive)
+		defaultLabel = service.Name
+	case badgeKindUptime:
+		message, color = badge.Uptime(stats.UptimePercent, stats.TotalChecks-stats.MaintenanceChecks)
+		defaultLabel = "uptime " + period
+	case badgeKindResponseTime:
+		message, color = badge.ResponseTime(stats.AvgResponseTime, stats.TotalChecks)
+		defaultLabel = "response time " + period
+	}
+
+	svg := badge.Render(badge.Label(label, defaultLabel), message, color)
+	sum := sha256.Sum256([]byte(svg))
+	rendered := &cachedBadge{
+		tokenID: token.ID,
+		svg:     svg,
+		etag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+		builtAt: now,
+	}
+	h.store(key, rendered)
+
+	if err := h.badgeRepo.MarkUsed(token.ID, now); err != nil {
+		log.Printf("Failed to record use of badge token %s: %v", token.ID, err)
+	}
+
+	h.write(c, rendered)
+}
+
+// write sends a rendered badge with caching headers, or 304 if the client
+// already has it
+func (h *BadgeHandler) write(c *gin.Context, rendered *cachedBadge) {
+	maxAge := badgeCacheTTL - time.Since(rendered.builtAt)
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Header("ETag", rendered.etag)
+
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, rendered.etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(rendered.svg))
+}
+
+// writeError sends a grey badge saying what went wrong, never cached so a
+// fixed embed shows up straight away
+func (h *BadgeHandler) writeError(c *gin.Context, status int, message string) {
+	c.Header("Cache-Control", "no-cache, no-store")
+	c.Data(status, "image/svg+xml; charset=utf-8", []byte(badge.Render("badge", message, badge.ColorGrey)))
+}
+
+// cached returns the rendered badge for key if it is still fresh
+func (h *BadgeHandler) cached(key string) *cachedBadge {
+	h.cacheMu.RLock()
+	defer h.cacheMu.RUnlock()
+	rendered, ok := h.cache[key]
+	if !ok || time.Since(rendered.builtAt) > badgeCacheTTL {
+		return nil
+	}
+	return rendered
+}
+
+// store caches a rendered badge, first dropping stale entries if the cache
+// is full; if it is still full the badge just isn't cached
+func (h *BadgeHandler) store(key string, rendered *cachedBadge) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+	if len(h.cache) >= badgeCacheSize {
+		for k, entry := range h.cache {
+			if time.Since(entry.builtAt) > badgeCacheTTL {
+				delete(h.cache, k)
+			}
+		}
+		if len(h.cache) >= badgeCacheSize {
+			return
+		}
+	}
+	h.cache[key] = rendered
+}
+
+// invalidate drops every cached badge drawn for a token
+func (h *BadgeHandler) invalidate(tokenID uuid.UUID) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+	for k, entry := range h.cache {
+		if entry.tokenID == tokenID {
+			delete(h.cache, k)
+		}
+	}
+}
+
+// loadService fetches the service named in the path and checks it belongs
+// to the caller's organization
+func (h *BadgeHandler) loadService(c *gin.Context) (*models.Service, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return nil, false
+	}
+
+	service, err := h.serviceRepo.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return nil, false
+	}
+
+	if service.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return service, true
+}
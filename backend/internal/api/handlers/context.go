@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// organizationIDFromContext returns the caller's organization ID, writing an
+// error response and returning false if it is missing or malformed
+func organizationIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	orgID, exists := c.Get("organization_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Organization ID not found"})
+		return uuid.Nil, false
+	}
+
+	orgIDStr, _ := orgID.(string)
+	orgUUID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return uuid.Nil, false
+	}
+
+	return orgUUID, true
+}
+
+// userIDFromContext returns the caller's user ID, writing an error response
+// and returning false if it is missing or malformed
+func userIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return uuid.Nil, false
+	}
+
+	userIDStr, _ := userID.(string)
+	userUUID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID format"})
+		return uuid.Nil, false
+	}
+
+	return userUUID, true
+}
+
+// isOrgAdmin reports whether the caller is an Organization Admin or Super Admin
+func isOrgAdmin(c *gin.Context) bool {
+	role, exists := c.Get("role")
+	return exists && (role == "admin" || role == "super_admin")
+}
+
+// requestOrigin returns the scheme and host the API is reached at, for URLs
+// handed to external services. BACKEND_URL overrides it behind proxies that
+// rewrite the host.
+func requestOrigin(c *gin.Context) string {
+	if backendURL := os.Getenv("BACKEND_URL"); backendURL != "" {
+		return backendURL
+	}
+
+	scheme := "http"
+	if c.GetHeader("X-Forwarded-Proto") == "https" || c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
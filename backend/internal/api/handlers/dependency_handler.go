@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/dependency"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DependencyHandler struct {
+	dependencyRepo *repository.ServiceDependencyRepository
+	serviceRepo    *repository.ServiceRepository
+	stateRepo      *repository.ServiceStateRepository
+	cfg            *config.Config
+}
+
+func NewDependencyHandler(
+	dependencyRepo *repository.ServiceDependencyRepository,
+	serviceRepo *repository.ServiceRepository,
+	stateRepo *repository.ServiceStateRepository,
+	cfg *config.Config,
+) *DependencyHandler {
+	return &DependencyHandler{
+		dependencyRepo: dependencyRepo,
+		serviceRepo:    serviceRepo,
+		stateRepo:      stateRepo,
+		cfg:            cfg,
+	}
+}
+
+type SetDependenciesRequest struct {
+	DependsOn []string `json:"depends_on"`
+}
+
+// GraphNode is a service in the dependency graph with its last known status
+type GraphNode struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Status string    `json:"status"`
+}
+
+// GetGraph returns the organization's services and the dependency edges between them
+func (h *DependencyHandler) GetGraph(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	services, err := h.serviceRepo.ListByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch services"})
+		return
+	}
+
+	edges, err := h.dependencyRepo.ListByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dependencies"})
+		return
+	}
+
+	states, err := h.stateRepo.ListByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service states"})
+		return
+	}
+
+	nodes := make([]GraphNode, 0, len(services))
+	for _, service := range services {
+		status := "unknown"
+		if state, ok := states[service.ID]; ok {
+			status = state.LastStatus
+		}
+		nodes = append(nodes, GraphNode{ID: service.ID, Name: service.Name, Status: status})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nodes": nodes,
+		"edges": edges,
+	})
+}
+
+func (h *DependencyHandler) GetDependencies(c *gin.Context) {
+	service, ok := h.loadService(c)
+	if !ok {
+		return
+	}
+
+	deps, err := h.dependencyRepo.ListByService(service.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dependencies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deps)
+}
+
+// SetDependencies replaces the list of services a service depends on,
+// rejecting changes that would introduce a dependency cycle
+func (h *DependencyHandler) SetDependencies(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can update dependencies"})
+		return
+	}
+
+	service, ok := h.loadService(c)
+	if !ok {
+		return
+	}
+
+	var req SetDependenciesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dependsOn := make([]uuid.UUID, 0, len(req.DependsOn))
+	seen := make(map[uuid.UUID]bool)
+	for _, idStr := range req.DependsOn {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID: " + idStr})
+			return
+		}
+		// Each upstream is stored once, however often it is listed
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if id == service.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A service cannot depend on itself"})
+			return
+		}
+		upstream, err := h.serviceRepo.GetByID(id)
+		if err != nil || upstream.OrganizationID != service.OrganizationID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Service not found: " + idStr})
+			return
+		}
+		dependsOn = append(dependsOn, id)
+	}
+
+	edges, err := h.dependencyRepo.ListByOrganization(service.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dependencies"})
+		return
+	}
+
+	graph := dependency.NewGraph(edges).WithDependencies(service.ID, dependsOn)
+	if cycle := graph.FindCycle(service.ID); cycle != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Dependency cycle detected",
+			"cycle": cycle,
+		})
+		return
+	}
+
+	if err := h.dependencyRepo.SetDependencies(service.ID, dependsOn); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dependencies"})
+		return
+	}
+
+	deps, err := h.dependencyRepo.ListByService(service.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dependencies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deps)
+}
+
+func (h *DependencyHandler) loadService(c *gin.Context) (*models.Service, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return nil, false
+	}
+
+	service, err := h.serviceRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return nil, false
+	}
+
+	if service.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return service, true
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/escalation"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type EscalationHandler struct {
+	escalationRepo *repository.EscalationRepository
+	serviceRepo    *repository.ServiceRepository
+	userRepo       *repository.UserRepository
+	cfg            *config.Config
+}
+
+func NewEscalationHandler(escalationRepo *repository.EscalationRepository, serviceRepo *repository.ServiceRepository, userRepo *repository.UserRepository, cfg *config.Config) *EscalationHandler {
+	return &EscalationHandler{
+		escalationRepo: escalationRepo,
+		serviceRepo:    serviceRepo,
+		userRepo:       userRepo,
+		cfg:            cfg,
+	}
+}
+
+type EscalationPolicyRequest struct {
+	ServiceID   *string                 `json:"service_id"`
+	Tag         *string                 `json:"tag"`
+	Name        string                  `json:"name" binding:"required"`
+	Description *string                 `json:"description"`
+	Steps       []EscalationStepRequest `json:"steps" binding:"required"`
+}
+
+type EscalationStepRequest struct {
+	DelayMinutes int    `json:"delay_minutes"`
+	Channel      string `json:"channel" binding:"required"`
+	Destination  string `json:"destination" binding:"required"`
+}
+
+func (h *EscalationHandler) ListPolicies(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	policies, err := h.escalationRepo.ListPoliciesByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch escalation policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+func (h *EscalationHandler) GetPolicy(c *gin.Context) {
+	policy, ok := h.loadPolicy(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *EscalationHandler) CreatePolicy(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage escalation policies"})
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req EscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := &models.EscalationPolicy{OrganizationID: orgID}
+	if !h.applyRequest(c, policy, &req) {
+		return
+	}
+
+	if err := h.escalationRepo.CreatePolicy(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create escalation policy"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+func (h *EscalationHandler) UpdatePolicy(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage escalation policies"})
+		return
+	}
+
+	policy, ok := h.loadPolicy(c)
+	if !ok {
+		return
+	}
+
+	var req EscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.applyRequest(c, policy, &req) {
+		return
+	}
+
+	if err := h.escalationRepo.UpdatePolicy(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update escalation policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *EscalationHandler) DeletePolicy(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage escalation policies"})
+		return
+	}
+
+	policy, ok := h.loadPolicy(c)
+	if !ok {
+		return
+	}
+
+	if err := h.escalationRepo.DeletePolicy(policy.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete escalation policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Escalation policy deleted successfully"})
+}
+
+// loadPolicy fetches the policy named in the path and checks it belongs to
+// the caller's organization
+func (h *EscalationHandler) loadPolicy(c *gin.Context) (*models.EscalationPolicy, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid escalation policy ID"})
+		return nil, false
+	}
+
+	policy, err := h.escalationRepo.GetPolicy(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Escalation policy not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch escalation policy"})
+		}
+		return nil, false
+	}
+
+	if policy.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return policy, true
+}
+
+// applyRequest copies a validated request onto policy
+func (h *EscalationHandler) applyRequest(c *gin.Context, policy *models.EscalationPolicy, req *EscalationPolicyRequest) bool {
+	policy.ServiceID = nil
+	if req.ServiceID != nil && *req.ServiceID != "" {
+		serviceID, err := uuid.Parse(*req.ServiceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+			return false
+		}
+		service, err := h.serviceRepo.GetByID(serviceID)
+		if err != nil || service.OrganizationID != policy.OrganizationID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Service not found"})
+			return false
+		}
+		policy.ServiceID = &serviceID
+	}
+
+	policy.Tag = nil
+	if req.Tag != nil && *req.Tag != "" {
+		if policy.ServiceID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "An escalation policy can target a service or a tag, not both"})
+			return false
+		}
+		policy.Tag = req.Tag
+	}
+
+	policy.Name = req.Name
+	policy.Description = req.Description
+	policy.Steps = make([]models.EscalationStep, 0, len(req.Steps))
+	for i, step := range req.Steps {
+		policy.Steps = append(policy.Steps, models.EscalationStep{
+			Position:     i + 1,
+			DelayMinutes: step.DelayMinutes,
+			Channel:      step.Channel,
+			Destination:  step.Destination,
+		})
+	}
+
+	if err := escalation.Validate(policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	// Web push steps notify a user's browsers, so the user must be in the
+	// policy's organization
+	for _, step := range policy.Steps {
+		if step.Channel != "webpush" {
+			continue
+		}
+		userID, _ := uuid.Parse(step.Destination)
+		user, err := h.userRepo.GetByID(userID)
+		if err != nil || user.OrganizationID == nil || *user.OrganizationID != policy.OrganizationID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("step %d: User not found", step.Position)})
+			return false
+		}
+	}
+
+	return true
+}
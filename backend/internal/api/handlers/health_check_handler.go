@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -10,7 +9,7 @@ import (
 	"pulsegrid/backend/internal/checker"
 	"pulsegrid/backend/internal/config"
 	"pulsegrid/backend/internal/models"
-	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/monitor"
 	"pulsegrid/backend/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -20,23 +19,23 @@ import (
 type HealthCheckHandler struct {
 	healthCheckRepo *repository.HealthCheckRepository
 	serviceRepo     *repository.ServiceRepository
-	alertRepo       *repository.AlertRepository
-	notifier        *notifier.NotifierService
+	stateRepo       *repository.ServiceStateRepository
+	recorder        *monitor.Recorder
 	cfg             *config.Config
 }
 
 func NewHealthCheckHandler(
 	healthCheckRepo *repository.HealthCheckRepository,
 	serviceRepo *repository.ServiceRepository,
-	alertRepo *repository.AlertRepository,
-	notifierService *notifier.NotifierService,
+	stateRepo *repository.ServiceStateRepository,
+	recorder *monitor.Recorder,
 	cfg *config.Config,
 ) *HealthCheckHandler {
 	return &HealthCheckHandler{
 		healthCheckRepo: healthCheckRepo,
 		serviceRepo:     serviceRepo,
-		alertRepo:       alertRepo,
-		notifier:        notifierService,
+		stateRepo:       stateRepo,
+		recorder:        recorder,
 		cfg:             cfg,
 	}
 }
@@ -111,89 +110,20 @@ func (h *HealthCheckHandler) TriggerHealthCheck(c *gin.Context) {
 		ResponseTimeMs: result.ResponseTimeMs,
 		StatusCode:     result.StatusCode,
 		ErrorMessage:   result.ErrorMessage,
+		ResolvedIP:     result.ResolvedIP,
 	}
 
-	if err := h.healthCheckRepo.Create(healthCheck); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save health check"})
-		return
-	}
-
-	// Evaluate alert conditions similar to scheduler (manual checks should also notify)
-	prevCheck, err := h.healthCheckRepo.GetPreviousCheckBefore(service.ID, healthCheck.CheckedAt)
+	// Manual checks count towards the service's state and alerts like
+	// scheduled ones, so a manual check can tighten or relax the effective
+	// interval and open, resolve or escalate alerts
+	state, err := h.stateRepo.GetByServiceID(service.ID)
 	if err != nil {
-		log.Printf("Failed to fetch previous health check: %v", err)
-	}
-
-	h.evaluateAlerts(service, healthCheck, prevCheck)
-
-	c.JSON(http.StatusOK, healthCheck)
-}
-
-func (h *HealthCheckHandler) evaluateAlerts(service *models.Service, currentCheck *models.HealthCheck, prevCheck *models.HealthCheck) {
-	// Downtime alert
-	if currentCheck.Status == "down" {
-		shouldAlert := prevCheck == nil || prevCheck.Status == "up"
-		if shouldAlert {
-			alert := &models.Alert{
-				ServiceID:  service.ID,
-				Type:       "downtime",
-				Message:    buildDowntimeMessage(service.Name, currentCheck.ErrorMessage),
-				Severity:   "high",
-				IsResolved: false,
-			}
-
-			if err := h.alertRepo.Create(alert); err != nil {
-				log.Printf("Failed to create downtime alert: %v", err)
-			} else {
-				h.dispatchAlert(alert)
-			}
-		}
+		log.Printf("Failed to fetch service state: %v", err)
 	}
-
-	// Latency alert
-	if service.LatencyThresholdMs != nil && currentCheck.ResponseTimeMs != nil {
-		if *currentCheck.ResponseTimeMs > *service.LatencyThresholdMs {
-			shouldAlert := true
-			if prevCheck != nil && prevCheck.ResponseTimeMs != nil {
-				if *prevCheck.ResponseTimeMs > *service.LatencyThresholdMs {
-					shouldAlert = false
-				}
-			}
-
-			if shouldAlert {
-				alert := &models.Alert{
-					ServiceID:  service.ID,
-					Type:       "latency",
-					Message:    fmt.Sprintf("Service latency threshold breached: %s (Response time: %dms, Threshold: %dms)", service.Name, *currentCheck.ResponseTimeMs, *service.LatencyThresholdMs),
-					Severity:   "medium",
-					IsResolved: false,
-				}
-				if err := h.alertRepo.Create(alert); err != nil {
-					log.Printf("Failed to create latency alert: %v", err)
-				} else {
-					h.dispatchAlert(alert)
-				}
-			}
-		}
-	}
-}
-
-func (h *HealthCheckHandler) dispatchAlert(alert *models.Alert) {
-	if h.notifier == nil {
+	if _, err := h.recorder.Record(service, healthCheck, state); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save health check"})
 		return
 	}
 
-	go func() {
-		if err := h.notifier.SendAlertNotifications(alert); err != nil {
-			log.Printf("Error sending alert notifications: %v", err)
-		}
-	}()
-}
-
-func buildDowntimeMessage(serviceName string, errorMessage *string) string {
-	message := "Service is down: " + serviceName
-	if errorMessage != nil {
-		message += " - " + *errorMessage
-	}
-	return message
+	c.JSON(http.StatusOK, healthCheck)
 }
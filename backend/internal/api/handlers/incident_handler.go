@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/escalation"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/correlation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type IncidentHandler struct {
+	incidentRepo   *repository.IncidentRepository
+	alertRepo      *repository.AlertRepository
+	escalationRepo *repository.EscalationRepository
+	notifier       *notifier.NotifierService
+	cfg            *config.Config
+}
+
+func NewIncidentHandler(incidentRepo *repository.IncidentRepository, alertRepo *repository.AlertRepository, escalationRepo *repository.EscalationRepository, notifierService *notifier.NotifierService, cfg *config.Config) *IncidentHandler {
+	return &IncidentHandler{
+		incidentRepo:   incidentRepo,
+		alertRepo:      alertRepo,
+		escalationRepo: escalationRepo,
+		notifier:       notifierService,
+		cfg:            cfg,
+	}
+}
+
+// IncidentDetail is an incident with its alerts and timeline
+type IncidentDetail struct {
+	*models.Incident
+	Alerts   []*models.Alert         `json:"alerts"`
+	Timeline []*models.IncidentEvent `json:"timeline"`
+}
+
+type CorrelationRulesRequest struct {
+	Enabled       bool `json:"enabled"`
+	WindowSeconds int  `json:"window_seconds" binding:"required"`
+	MatchTags     bool `json:"match_tags"`
+	MatchIPs      bool `json:"match_ips"`
+}
+
+type IncidentNoteRequest struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
+
+// ListIncidents lists the organization's incidents, newest first, optionally
+// filtered by ?status=triggered|acknowledged|resolved
+func (h *IncidentHandler) ListIncidents(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", alerting.StatusTriggered, alerting.StatusAcknowledged, alerting.StatusResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be triggered, acknowledged or resolved"})
+		return
+	}
+
+	incidents, err := h.incidentRepo.ListByOrganization(orgID, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, incidents)
+}
+
+// GetIncident returns an incident with its alerts and timeline
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	incident, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	h.respondWithDetail(c, incident)
+}
+
+// AcknowledgeIncident acknowledges the incident and each of its open alerts,
+// stopping their escalation
+func (h *IncidentHandler) AcknowledgeIncident(c *gin.Context) {
+	incident, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	if incident.Status == alerting.StatusResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Incident is already resolved"})
+		return
+	}
+
+	alerts, err := h.alertRepo.ListByIncident(incident.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident alerts"})
+		return
+	}
+	for _, alert := range alerts {
+		if alert.IsResolved || alert.AcknowledgedAt != nil {
+			continue
+		}
+		if _, err := h.alertRepo.Acknowledge(alert.ID, userID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge incident"})
+			return
+		}
+		if err := h.escalationRepo.FinishEscalationsForAlert(alert.ID, escalation.StatusAcknowledged); err != nil {
+			log.Printf("Error stopping escalation for alert %s: %v", alert.ID, err)
+		}
+	}
+
+	if _, err := h.incidentRepo.Acknowledge(incident.ID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge incident"})
+		return
+	}
+
+	h.respondWithFreshDetail(c, incident.ID)
+}
+
+// ResolveIncident resolves each of the incident's open alerts and then the
+// incident itself
+func (h *IncidentHandler) ResolveIncident(c *gin.Context) {
+	incident, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	alerts, err := h.alertRepo.ListByIncident(incident.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident alerts"})
+		return
+	}
+	for _, alert := range alerts {
+		if alert.IsResolved {
+			continue
+		}
+		resolved, err := h.alertRepo.Resolve(alert.ID, userID.String())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve incident"})
+			return
+		}
+		if !resolved {
+			continue
+		}
+		if err := h.escalationRepo.FinishEscalationsForAlert(alert.ID, escalation.StatusResolved); err != nil {
+			log.Printf("Error stopping escalation for alert %s: %v", alert.ID, err)
+		}
+		if _, err := h.incidentRepo.AlertResolved(incident.ID, alert.ID, userID.String(), alert.Message); err != nil {
+			log.Printf("Error recording resolution on incident %s: %v", incident.ID, err)
+		}
+	}
+
+	// Closes incidents whose alerts were already resolved some other way
+	if _, err := h.incidentRepo.Resolve(incident.ID, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve incident"})
+		return
+	}
+
+	// The lead alert is the one that paged PagerDuty or Opsgenie
+	if incident.LeadAlertID != nil && h.notifier != nil {
+		for _, alert := range alerts {
+			if alert.ID == *incident.LeadAlertID && !alert.IsSuppressed {
+				go h.notifier.ResolveRemoteIncidents(alert, "Resolved manually in PulseGrid")
+			}
+		}
+	}
+
+	h.respondWithFreshDetail(c, incident.ID)
+}
+
+// GetIncidentTimeline returns an incident's events and notes, oldest first
+func (h *IncidentHandler) GetIncidentTimeline(c *gin.Context) {
+	incident, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.incidentRepo.ListEvents(incident.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident timeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// AddIncidentNote adds the caller's note to an incident's timeline
+func (h *IncidentHandler) AddIncidentNote(c *gin.Context) {
+	var req IncidentNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	incident, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body is required"})
+		return
+	}
+
+	event := &models.IncidentEvent{
+		IncidentID: incident.ID,
+		Kind:       correlation.EventNote,
+		UserID:     &userID,
+		Body:       body,
+	}
+	if err := h.incidentRepo.AddEvent(event); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add note"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
+
+// GetCorrelationRules returns how the organization's alerts are grouped
+// into incidents
+func (h *IncidentHandler) GetCorrelationRules(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.incidentRepo.GetRules(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch correlation rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// UpdateCorrelationRules replaces the organization's correlation rules. They
+// apply to alerts raised from now on.
+func (h *IncidentHandler) UpdateCorrelationRules(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage correlation rules"})
+		return
+	}
+
+	var req CorrelationRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	rules := &models.CorrelationRules{
+		OrganizationID: orgID,
+		Enabled:        req.Enabled,
+		WindowSeconds:  req.WindowSeconds,
+		MatchTags:      req.MatchTags,
+		MatchIPs:       req.MatchIPs,
+	}
+	if err := correlation.ValidateRules(repository.ToCorrelationRules(rules)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.incidentRepo.SaveRules(rules); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save correlation rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// loadIncident fetches the incident named in the path and checks it belongs
+// to the caller's organization
+func (h *IncidentHandler) loadIncident(c *gin.Context) (*models.Incident, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incident ID"})
+		return nil, false
+	}
+
+	incident, err := h.incidentRepo.Get(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident"})
+		}
+		return nil, false
+	}
+
+	if incident.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return incident, true
+}
+
+// respondWithFreshDetail reloads an incident after a change and writes it
+// with its alerts and timeline
+func (h *IncidentHandler) respondWithFreshDetail(c *gin.Context, id uuid.UUID) {
+	incident, err := h.incidentRepo.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident"})
+		return
+	}
+
+	h.respondWithDetail(c, incident)
+}
+
+func (h *IncidentHandler) respondWithDetail(c *gin.Context, incident *models.Incident) {
+	alerts, err := h.alertRepo.ListByIncident(incident.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident alerts"})
+		return
+	}
+
+	events, err := h.incidentRepo.ListEvents(incident.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident timeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, IncidentDetail{Incident: incident, Alerts: alerts, Timeline: events})
+}
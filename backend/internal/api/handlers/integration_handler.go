@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"io"
+	"log"
+	"net/http"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/escalation"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/paging"
+	"pulsegrid/backend/pkg/sms"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxInboundBody caps the webhook bodies read from paging tools
+const maxInboundBody = 1 << 20
+
+// IntegrationHandler receives the webhooks PagerDuty and Opsgenie send back
+// about the incidents PulseGrid opened there, and the delivery receipts of
+// SMS notifications
+type IntegrationHandler struct {
+	alertRepo        *repository.AlertRepository
+	serviceRepo      *repository.ServiceRepository
+	escalationRepo   *repository.EscalationRepository
+	notificationRepo *repository.NotificationRepository
+	cfg              *config.Config
+}
+
+func NewIntegrationHandler(alertRepo *repository.AlertRepository, serviceRepo *repository.ServiceRepository, escalationRepo *repository.EscalationRepository, notificationRepo *repository.NotificationRepository, cfg *config.Config) *IntegrationHandler {
+	return &IntegrationHandler{
+		alertRepo:        alertRepo,
+		serviceRepo:      serviceRepo,
+		escalationRepo:   escalationRepo,
+		notificationRepo: notificationRepo,
+		cfg:              cfg,
+	}
+}
+
+// ReceiveEvent takes a webhook for a PagerDuty or Opsgenie subscription,
+// authenticated by the token in the subscription's inbound URL. An
+// acknowledgement of a PulseGrid alert acknowledges it here too, stopping
+// its escalation; every other event is ignored.
+func (h *IntegrationHandler) ReceiveEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Integration not found"})
+		return
+	}
+
+	sub, err := h.alertRepo.GetSubscription(id)
+	if err == sql.ErrNoRows || (err == nil && (!paging.IsChannel(sub.Channel) || sub.InboundToken == "")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Integration not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch integration"})
+		return
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		token = c.GetHeader("X-PulseGrid-Token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(sub.InboundToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxInboundBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+	ack, err := paging.ParseAcknowledgement(sub.Channel, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+	if ack == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored"})
+		return
+	}
+
+	// Incidents opened outside PulseGrid, or by another organization, are
+	// none of this subscription's business
+	alertID, err := uuid.Parse(ack.DedupKey)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored"})
+		return
+	}
+	alert, err := h.alertRepo.GetByID(alertID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert"})
+		return
+	}
+	service, err := h.serviceRepo.GetByID(alert.ServiceID)
+	if err != nil || service.OrganizationID != sub.OrganizationID {
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored"})
+		return
+	}
+
+	note := "Acknowledged in PagerDuty"
+	if sub.Channel == paging.Opsgenie {
+		note = "Acknowledged in Opsgenie"
+	}
+	if ack.By != "" {
+		note += " by " + ack.By
+	}
+
+	acknowledged, err := h.alertRepo.AcknowledgeRemotely(alert.ID, note)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge alert"})
+		return
+	}
+	if !acknowledged {
+		c.JSON(http.StatusOK, gin.H{"message": "Alert already acknowledged or resolved"})
+		return
+	}
+	if err := h.escalationRepo.FinishEscalationsForAlert(alert.ID, escalation.StatusAcknowledged); err != nil {
+		log.Printf("Error stopping escalation for alert %s: %v", alert.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert acknowledged"})
+}
+
+// ReceiveSMSStatus takes the delivery receipts Twilio posts for SMS
+// notifications and records each message's status on its notification.
+// Receipts are authenticated by their X-Twilio-Signature.
+func (h *IntegrationHandler) ReceiveSMSStatus(c *gin.Context) {
+	if h.cfg.SMS.TwilioAuthToken == "" || h.cfg.SMS.StatusCallbackURL == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Integration not found"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInboundBody)
+	if err := c.Request.ParseForm(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+	params := c.Request.PostForm
+	if !sms.ValidateSignature(h.cfg.SMS.TwilioAuthToken, h.cfg.SMS.StatusCallbackURL, params, c.GetHeader("X-Twilio-Signature")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+
+	messageID, status := params.Get("MessageSid"), params.Get("MessageStatus")
+	if messageID == "" || status == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+	var errorMessage *string
+	if status == "failed" || status == "undelivered" {
+		message := "SMS " + status
+		if code := params.Get("ErrorCode"); code != "" {
+			message += " (provider error " + code + ")"
+		}
+		errorMessage = &message
+	}
+
+	found, err := h.notificationRepo.RecordProviderStatus("sms", messageID, status, errorMessage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record SMS status"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusOK, gin.H{"message": "Event ignored"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "SMS status recorded"})
+}
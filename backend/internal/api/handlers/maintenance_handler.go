@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/maintenance"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MaintenanceHandler struct {
+	maintenanceRepo *repository.MaintenanceWindowRepository
+	serviceRepo     *repository.ServiceRepository
+	cfg             *config.Config
+}
+
+func NewMaintenanceHandler(maintenanceRepo *repository.MaintenanceWindowRepository, serviceRepo *repository.ServiceRepository, cfg *config.Config) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceRepo: maintenanceRepo,
+		serviceRepo:     serviceRepo,
+		cfg:             cfg,
+	}
+}
+
+type MaintenanceWindowRequest struct {
+	ServiceID        *string    `json:"service_id"`
+	Tag              *string    `json:"tag"`
+	Title            string     `json:"title" binding:"required"`
+	Description      *string    `json:"description"`
+	StartsAt         time.Time  `json:"starts_at" binding:"required"`
+	EndsAt           time.Time  `json:"ends_at" binding:"required"`
+	Recurrence       string     `json:"recurrence"`
+	RecurrenceEndsAt *time.Time `json:"recurrence_ends_at"`
+	TimeZone         string     `json:"time_zone"` // defaults to UTC
+}
+
+// ActiveMaintenanceWindow is a window together with its current occurrence
+type ActiveMaintenanceWindow struct {
+	*models.MaintenanceWindow
+	OccurrenceStartsAt time.Time `json:"occurrence_starts_at"`
+	OccurrenceEndsAt   time.Time `json:"occurrence_ends_at"`
+}
+
+func (h *MaintenanceHandler) ListWindows(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	windows, err := h.maintenanceRepo.ListByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance windows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, windows)
+}
+
+// ListActiveWindows returns the windows in effect right now, optionally
+// narrowed to those covering a single service
+func (h *MaintenanceHandler) ListActiveWindows(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	var windows []*models.MaintenanceWindow
+	var err error
+	if serviceIDStr := c.Query("service_id"); serviceIDStr != "" {
+		serviceID, parseErr := uuid.Parse(serviceIDStr)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+			return
+		}
+		service, getErr := h.serviceRepo.GetByID(serviceID)
+		if getErr != nil || service.OrganizationID != orgID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			return
+		}
+		windows, err = h.maintenanceRepo.ListForService(service, now)
+	} else {
+		windows, err = h.maintenanceRepo.ListByOrganization(orgID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance windows"})
+		return
+	}
+
+	active := make([]ActiveMaintenanceWindow, 0)
+	for _, w := range windows {
+		if start, end, ok := maintenance.OccurrenceAt(w, now); ok {
+			active = append(active, ActiveMaintenanceWindow{
+				MaintenanceWindow:  w,
+				OccurrenceStartsAt: start,
+				OccurrenceEndsAt:   end,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, active)
+}
+
+func (h *MaintenanceHandler) GetWindow(c *gin.Context) {
+	window, ok := h.loadWindow(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+func (h *MaintenanceHandler) CreateWindow(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage maintenance windows"})
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	window := &models.MaintenanceWindow{OrganizationID: orgID, CreatedBy: &userID}
+	if !h.applyRequest(c, window, &req) {
+		return
+	}
+
+	if err := h.maintenanceRepo.Create(window); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create maintenance window"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, window)
+}
+
+func (h *MaintenanceHandler) UpdateWindow(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage maintenance windows"})
+		return
+	}
+
+	window, ok := h.loadWindow(c)
+	if !ok {
+		return
+	}
+
+	var req MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.applyRequest(c, window, &req) {
+		return
+	}
+
+	if err := h.maintenanceRepo.Update(window); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update maintenance window"})
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+func (h *MaintenanceHandler) DeleteWindow(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage maintenance windows"})
+		return
+	}
+
+	window, ok := h.loadWindow(c)
+	if !ok {
+		return
+	}
+
+	if err := h.maintenanceRepo.Delete(window.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete maintenance window"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window deleted successfully"})
+}
+
+// loadWindow fetches the window named in the path and checks it belongs to
+// the caller's organization
+func (h *MaintenanceHandler) loadWindow(c *gin.Context) (*models.MaintenanceWindow, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance window ID"})
+		return nil, false
+	}
+
+	window, err := h.maintenanceRepo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance window not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance window"})
+		}
+		return nil, false
+	}
+
+	if window.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return window, true
+}
+
+// applyRequest copies a validated request onto window
+func (h *MaintenanceHandler) applyRequest(c *gin.Context, window *models.MaintenanceWindow, req *MaintenanceWindowRequest) bool {
+	window.ServiceID = nil
+	if req.ServiceID != nil && *req.ServiceID != "" {
+		serviceID, err := uuid.Parse(*req.ServiceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+			return false
+		}
+		service, err := h.serviceRepo.GetByID(serviceID)
+		if err != nil || service.OrganizationID != window.OrganizationID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Service not found"})
+			return false
+		}
+		window.ServiceID = &serviceID
+	}
+
+	window.Tag = nil
+	if req.Tag != nil && *req.Tag != "" {
+		if window.ServiceID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A maintenance window can target a service or a tag, not both"})
+			return false
+		}
+		window.Tag = req.Tag
+	}
+
+	window.Title = req.Title
+	window.Description = req.Description
+	window.StartsAt = req.StartsAt.UTC()
+	window.EndsAt = req.EndsAt.UTC()
+	window.Recurrence = req.Recurrence
+	if window.Recurrence == "" {
+		window.Recurrence = maintenance.RecurrenceNone
+	}
+	window.TimeZone = req.TimeZone
+	if window.TimeZone == "" {
+		window.TimeZone = "UTC"
+	}
+	window.RecurrenceEndsAt = nil
+	if req.RecurrenceEndsAt != nil && window.Recurrence != maintenance.RecurrenceNone {
+		recurrenceEndsAt := req.RecurrenceEndsAt.UTC()
+		window.RecurrenceEndsAt = &recurrenceEndsAt
+	}
+
+	if err := maintenance.Validate(window); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
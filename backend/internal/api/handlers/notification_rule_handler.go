@@ -0,0 +1,525 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/verification"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NotificationRuleHandler manages the caller's own contact methods and the
+// personal notification rules that send alerts to them, alongside their
+// organization's subscriptions
+type NotificationRuleHandler struct {
+	contactRepo *repository.ContactMethodRepository
+	ruleRepo    *repository.NotificationRuleRepository
+	userRepo    *repository.UserRepository
+	serviceRepo *repository.ServiceRepository
+	notifier    *notifier.NotifierService
+	cfg         *config.Config
+}
+
+func NewNotificationRuleHandler(contactRepo *repository.ContactMethodRepository, ruleRepo *repository.NotificationRuleRepository, userRepo *repository.UserRepository, serviceRepo *repository.ServiceRepository, notifierService *notifier.NotifierService, cfg *config.Config) *NotificationRuleHandler {
+	return &NotificationRuleHandler{
+		contactRepo: contactRepo,
+		ruleRepo:    ruleRepo,
+		userRepo:    userRepo,
+		serviceRepo: serviceRepo,
+		notifier:    notifierService,
+		cfg:         cfg,
+	}
+}
+
+// CreateContactMethodRequest adds a way of reaching the caller. A webpush
+// method reaches every browser they registered, so it takes no
+// destination.
+type CreateContactMethodRequest struct {
+	Channel     string `json:"channel" binding:"required,oneof=email sms webpush"`
+	Destination string `json:"destination"`
+	Label       string `json:"label" binding:"max=100"`
+}
+
+// ContactMethodTest reports the outcome of sending a contact method a test
+// message
+type ContactMethodTest struct {
+	Delivered  bool   `json:"delivered"`
+	StatusCode *int   `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// CodeSent is set when the message carried a confirmation code, to be
+	// submitted to the verify endpoint
+	CodeSent bool `json:"code_sent"`
+}
+
+// CreatedContactMethod is a new contact method with the outcome of the test
+// message sent to confirm it
+type CreatedContactMethod struct {
+	*models.ContactMethod
+	Test *ContactMethodTest `json:"test,omitempty"`
+}
+
+// NotificationRuleRequest sets up a personal notification rule. Leaving
+// service_id empty covers every service, or only those the caller owns with
+// owned_services_only. delay_minutes waits that long after an alert
+// triggers and notifies only if it is still unacknowledged.
+type NotificationRuleRequest struct {
+	ContactMethodID   string  `json:"contact_method_id" binding:"required"`
+	ServiceID         *string `json:"service_id"`
+	OwnedServicesOnly bool    `json:"owned_services_only"`
+	SubscriptionRoutingRequest
+	DelayMinutes int   `json:"delay_minutes" binding:"min=0,max=1440"`
+	IsActive     *bool `json:"is_active"`
+}
+
+func (h *NotificationRuleHandler) ListContactMethods(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	methods, err := h.contactRepo.ListByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact methods"})
+		return
+	}
+
+	c.JSON(http.StatusOK, methods)
+}
+
+// CreateContactMethod adds a contact method and sends it a test message to
+// confirm it. Email and SMS are confirmed with the code the message
+// carries, webpush by a browser receiving it. The caller's own verified
+// account email needs no confirming.
+func (h *NotificationRuleHandler) CreateContactMethod(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req CreateContactMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	method := &models.ContactMethod{
+		UserID:      userID,
+		Channel:     req.Channel,
+		Destination: strings.TrimSpace(req.Destination),
+		Label:       req.Label,
+	}
+	switch method.Channel {
+	case "email":
+		if addr, err := mail.ParseAddress(method.Destination); err != nil || addr.Address != method.Destination {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "destination must be an email address"})
+			return
+		}
+		if user.EmailVerified && strings.EqualFold(method.Destination, user.Email) {
+			now := time.Now().UTC()
+			method.VerificationStatus = verification.StatusVerified
+			method.VerifiedAt = &now
+		}
+	case "sms":
+		if err := verification.ValidatePhoneNumber(method.Destination); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "webpush":
+		if method.Destination != "" && method.Destination != userID.String() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Web push contact methods reach your own browsers"})
+			return
+		}
+		method.Destination = userID.String()
+	}
+
+	if err := h.contactRepo.Create(method); err != nil {
+		if err == repository.ErrDuplicateEntry {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have this contact method"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact method"})
+		return
+	}
+
+	created := CreatedContactMethod{ContactMethod: method}
+	if method.VerificationStatus != verification.StatusVerified {
+		created.Test = h.testContactMethod(method)
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *NotificationRuleHandler) DeleteContactMethod(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact method ID"})
+		return
+	}
+
+	if err := h.contactRepo.Delete(id, userID); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contact method not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact method"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact method deleted successfully"})
+}
+
+// TestContactMethod sends a contact method a test message, with a fresh
+// confirmation code if it is confirmed by code
+func (h *NotificationRuleHandler) TestContactMethod(c *gin.Context) {
+	method, ok := h.loadContactMethod(c)
+	if !ok {
+		return
+	}
+	if h.notifier == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Notifications are not configured"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.testContactMethod(method))
+}
+
+// VerifyContactMethod confirms an email or SMS contact method with the code
+// sent to it
+func (h *NotificationRuleHandler) VerifyContactMethod(c *gin.Context) {
+	method, ok := h.loadContactMethod(c)
+	if !ok {
+		return
+	}
+
+	var req VerifySubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !verification.ByCode(method.Channel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This contact method is verified by sending it a test message"})
+		return
+	}
+
+	err := verification.Check(method.VerificationCode, method.VerificationExpiresAt, method.VerificationAttempts, strings.TrimSpace(req.Code), time.Now().UTC())
+	if err != nil {
+		if err == verification.ErrMismatch {
+			if err := h.contactRepo.RecordVerificationAttempt(method.ID); err != nil {
+				log.Printf("Error recording verification attempt for contact method %s: %v", method.ID, err)
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.contactRepo.MarkVerified(method.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify contact method"})
+		return
+	}
+
+	method, err = h.contactRepo.GetByID(method.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact method"})
+		return
+	}
+	c.JSON(http.StatusOK, method)
+}
+
+// loadContactMethod fetches the contact method named in the path and checks
+// it belongs to the caller
+func (h *NotificationRuleHandler) loadContactMethod(c *gin.Context) (*models.ContactMethod, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact method ID"})
+		return nil, false
+	}
+
+	method, err := h.contactRepo.GetByID(id)
+	if err != nil || method.UserID != userID {
+		if err == nil || err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contact method not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact method"})
+		}
+		return nil, false
+	}
+
+	return method, true
+}
+
+// testContactMethod sends method a test message and records what it
+// proves, as testSubscription does for subscriptions. method is updated to
+// match.
+func (h *NotificationRuleHandler) testContactMethod(method *models.ContactMethod) *ContactMethodTest {
+	test := &ContactMethodTest{}
+	if h.notifier == nil {
+		test.Error = "Notifications are not configured"
+		return test
+	}
+
+	subject := "PulseGrid Alerts: Test Notification"
+	body := "This is a test notification from PulseGrid. Alerts your notification rules send to this contact method will arrive here."
+
+	byCode := verification.ByCode(method.Channel)
+	if byCode {
+		code, err := verification.NewCode()
+		if err != nil {
+			test.Error = "Failed to create confirmation code"
+			return test
+		}
+		expiresAt := time.Now().UTC().Add(verification.CodeTTL)
+		if err := h.contactRepo.SetVerificationCode(method.ID, code, expiresAt); err != nil {
+			test.Error = "Failed to create confirmation code"
+			return test
+		}
+		subject = "PulseGrid Alerts: Confirm Your Contact Method"
+		body = fmt.Sprintf(`Your PulseGrid confirmation code is %s
+
+Enter it on your Notification Rules page to receive your personal alert notifications at %s. The code expires in %d hours.
+
+If this wasn’t you, you can ignore this message.`, code, method.Destination, int(verification.CodeTTL.Hours()))
+		if method.Channel == "sms" {
+			body = fmt.Sprintf("Your PulseGrid confirmation code is %s. It expires in %d hours.", code, int(verification.CodeTTL.Hours()))
+		}
+	}
+
+	statusCode, err := h.notifier.TestContactMethod(method, subject, body)
+	if statusCode != 0 {
+		test.StatusCode = &statusCode
+	}
+	if err != nil {
+		test.Error = err.Error()
+		return test
+	}
+	test.Delivered = true
+	test.CodeSent = byCode
+
+	if !byCode && method.VerificationStatus != verification.StatusVerified {
+		if err := h.contactRepo.MarkVerified(method.ID); err != nil {
+			log.Printf("Error marking contact method %s verified: %v", method.ID, err)
+			return test
+		}
+		now := time.Now().UTC()
+		method.VerificationStatus = verification.StatusVerified
+		method.VerifiedAt = &now
+	}
+	return test
+}
+
+func (h *NotificationRuleHandler) ListNotificationRules(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.ruleRepo.ListByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *NotificationRuleHandler) CreateNotificationRule(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &models.NotificationRule{UserID: userID, IsActive: true}
+	if !h.applyRule(c, rule, &req) {
+		return
+	}
+
+	if err := h.ruleRepo.Create(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification rule"})
+		return
+	}
+
+	h.respondWithRule(c, http.StatusCreated, rule.ID)
+}
+
+// UpdateNotificationRule replaces one of the caller's rules
+func (h *NotificationRuleHandler) UpdateNotificationRule(c *gin.Context) {
+	rule, ok := h.loadRule(c)
+	if !ok {
+		return
+	}
+
+	var req NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.applyRule(c, rule, &req) {
+		return
+	}
+
+	if err := h.ruleRepo.Update(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification rule"})
+		return
+	}
+
+	h.respondWithRule(c, http.StatusOK, rule.ID)
+}
+
+func (h *NotificationRuleHandler) DeleteNotificationRule(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification rule ID"})
+		return
+	}
+
+	if err := h.ruleRepo.Delete(id, userID); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification rule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification rule deleted successfully"})
+}
+
+// applyRule validates a rule request and copies it onto rule, writing an
+// error response and returning false if it is invalid. The contact method
+// must be one of the rule owner's, and verified; the service must be in
+// their organization.
+func (h *NotificationRuleHandler) applyRule(c *gin.Context, rule *models.NotificationRule, req *NotificationRuleRequest) bool {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return false
+	}
+
+	methodID, err := uuid.Parse(req.ContactMethodID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact method ID"})
+		return false
+	}
+	method, err := h.contactRepo.GetByID(methodID)
+	if err != nil || method.UserID != rule.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contact method not found"})
+		return false
+	}
+	if method.VerificationStatus != verification.StatusVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verify the contact method before sending alerts to it"})
+		return false
+	}
+
+	var serviceID *uuid.UUID
+	if req.ServiceID != nil && *req.ServiceID != "" {
+		id, err := uuid.Parse(*req.ServiceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+			return false
+		}
+		service, err := h.serviceRepo.GetByID(id)
+		if err != nil || service.OrganizationID != orgID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Service not found"})
+			return false
+		}
+		serviceID = &id
+	}
+
+	filter := alerting.RouteFilter{
+		MinSeverity: req.MinSeverity,
+		AlertTypes:  req.AlertTypes,
+		ServiceTags: req.ServiceTags,
+		Events:      req.Events,
+	}
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	rule.ContactMethodID = methodID
+	rule.ServiceID = serviceID
+	rule.OwnedServicesOnly = req.OwnedServicesOnly
+	rule.MinSeverity = req.MinSeverity
+	rule.AlertTypes = req.AlertTypes
+	rule.ServiceTags = req.ServiceTags
+	rule.Events = req.Events
+	rule.DelayMinutes = req.DelayMinutes
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return true
+}
+
+// loadRule fetches the rule named in the path and checks it belongs to the
+// caller
+func (h *NotificationRuleHandler) loadRule(c *gin.Context) (*models.NotificationRule, bool) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification rule ID"})
+		return nil, false
+	}
+
+	rule, err := h.ruleRepo.GetByID(id)
+	if err != nil || rule.UserID != userID {
+		if err == nil || err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification rule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification rule"})
+		}
+		return nil, false
+	}
+
+	return rule, true
+}
+
+func (h *NotificationRuleHandler) respondWithRule(c *gin.Context, status int, id uuid.UUID) {
+	rule, err := h.ruleRepo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification rule"})
+		return
+	}
+	c.JSON(status, rule)
+}
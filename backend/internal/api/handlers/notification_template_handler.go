@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/message"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NotificationTemplateHandler struct {
+	templateRepo *repository.NotificationTemplateRepository
+	cfg          *config.Config
+}
+
+func NewNotificationTemplateHandler(templateRepo *repository.NotificationTemplateRepository, cfg *config.Config) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{
+		templateRepo: templateRepo,
+		cfg:          cfg,
+	}
+}
+
+// NotificationTemplateRequest overrides the text a channel sends for an
+// event. AlertType limits it to one type of alert; empty covers them all.
+// Empty fields keep the default text.
+type NotificationTemplateRequest struct {
+	Channel   string `json:"channel" binding:"required"`
+	AlertType string `json:"alert_type" binding:"omitempty,oneof=downtime latency threshold flapping"`
+	Event     string `json:"event" binding:"required,oneof=triggered resolved"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+	HTMLBody  string `json:"html_body"`
+}
+
+// NotificationPreview is a template rendered against a sample alert
+type NotificationPreview struct {
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	HTMLBody string `json:"html_body,omitempty"`
+}
+
+func (h *NotificationTemplateHandler) ListTemplates(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.templateRepo.ListByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// SaveTemplate creates the organization's template for a channel, alert
+// type and event, replacing any already there
+func (h *NotificationTemplateHandler) SaveTemplate(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage notification templates"})
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req NotificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Subject+req.Body+req.HTMLBody) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A template needs a subject, body or html_body"})
+		return
+	}
+	if !validateTemplateRequest(c, &req) {
+		return
+	}
+
+	tmpl := &models.NotificationTemplate{
+		OrganizationID: orgID,
+		Channel:        req.Channel,
+		AlertType:      req.AlertType,
+		Event:          req.Event,
+		Subject:        req.Subject,
+		Body:           req.Body,
+		HTMLBody:       req.HTMLBody,
+	}
+	if err := h.templateRepo.Save(tmpl); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+func (h *NotificationTemplateHandler) DeleteTemplate(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage notification templates"})
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification template ID"})
+		return
+	}
+
+	tmpl, err := h.templateRepo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification template not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification template"})
+		}
+		return
+	}
+	if tmpl.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	if err := h.templateRepo.Delete(tmpl.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification template deleted successfully"})
+}
+
+// PreviewTemplate renders a template against a sample alert without saving
+// it. Empty fields preview the default text.
+func (h *NotificationTemplateHandler) PreviewTemplate(c *gin.Context) {
+	var req NotificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateTemplateRequest(c, &req) {
+		return
+	}
+
+	data := message.Sample(req.Event)
+	if req.AlertType != "" {
+		data.Alert.Type = req.AlertType
+	}
+	tmpl := message.Override(req.Channel, req.Event, message.Template{Subject: req.Subject, Body: req.Body, HTML: req.HTMLBody})
+	rendered, err := message.Render(tmpl, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Emails without an HTML template send their text as HTML
+	if req.Channel == "email" && rendered.HTML == "" {
+		rendered.HTML = message.TextToHTML(rendered.Body)
+	}
+
+	c.JSON(http.StatusOK, NotificationPreview{Subject: rendered.Subject, Body: rendered.Body, HTMLBody: rendered.HTML})
+}
+
+// validateTemplateRequest checks the channel takes templates and the
+// template renders, writing the error response when it does not
+func validateTemplateRequest(c *gin.Context, req *NotificationTemplateRequest) bool {
+	if !message.IsChannel(req.Channel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be one of " + strings.Join(message.Channels, ", ")})
+		return false
+	}
+	if req.HTMLBody != "" && req.Channel != "email" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "html_body is only used for email"})
+		return false
+	}
+	tmpl := message.Override(req.Channel, req.Event, message.Template{Subject: req.Subject, Body: req.Body, HTML: req.HTMLBody})
+	if err := message.Validate(tmpl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
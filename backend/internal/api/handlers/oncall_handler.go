@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/oncall"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/rotation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// onCallLookaround is how far either side of an instant the on-call endpoint
+// looks to find the edges of the current shift; it covers a weekly rotation
+const onCallLookaround = 35 * 24 * time.Hour
+
+// maxShiftRange caps the span of the shifts endpoint
+const maxShiftRange = 90 * 24 * time.Hour
+
+type OnCallHandler struct {
+	oncallRepo *repository.OnCallRepository
+	userRepo   *repository.UserRepository
+	resolver   *oncall.Resolver
+	cfg        *config.Config
+}
+
+func NewOnCallHandler(oncallRepo *repository.OnCallRepository, userRepo *repository.UserRepository, resolver *oncall.Resolver, cfg *config.Config) *OnCallHandler {
+	return &OnCallHandler{
+		oncallRepo: oncallRepo,
+		userRepo:   userRepo,
+		resolver:   resolver,
+		cfg:        cfg,
+	}
+}
+
+type OnCallScheduleRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	Description *string              `json:"description"`
+	TimeZone    string               `json:"time_zone" binding:"required"`
+	Layers      []OnCallLayerRequest `json:"layers" binding:"required"`
+}
+
+type OnCallLayerRequest struct {
+	Name           string     `json:"name"`
+	Rotation       string     `json:"rotation" binding:"required"`
+	HandoffTime    string     `json:"handoff_time" binding:"required"`
+	HandoffWeekday *int       `json:"handoff_weekday"` // defaults to Monday
+	StartsAt       time.Time  `json:"starts_at" binding:"required"`
+	EndsAt         *time.Time `json:"ends_at"`
+	ParticipantIDs []string   `json:"participant_ids" binding:"required"`
+}
+
+type OnCallOverrideRequest struct {
+	UserID   string    `json:"user_id" binding:"required"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+}
+
+// OnCallUser is the public view of a user on call
+type OnCallUser struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+// OnCallResponse says who is on call for a schedule at an instant. User is
+// null when nobody is. The shift edges are limited to five weeks either side.
+type OnCallResponse struct {
+	ScheduleID uuid.UUID   `json:"schedule_id"`
+	At         time.Time   `json:"at"`
+	User       *OnCallUser `json:"user"`
+	Source     string      `json:"source,omitempty"` // layer name or "override"
+	ShiftStart *time.Time  `json:"shift_start,omitempty"`
+	ShiftEnd   *time.Time  `json:"shift_end,omitempty"`
+}
+
+// OnCallShift is one stretch of a schedule's timeline
+type OnCallShift struct {
+	UserID uuid.UUID `json:"user_id"`
+	Source string    `json:"source"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+func (h *OnCallHandler) ListSchedules(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.oncallRepo.ListSchedulesByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch on-call schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *OnCallHandler) GetSchedule(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *OnCallHandler) CreateSchedule(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage on-call schedules"})
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req OnCallScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule := &models.OnCallSchedule{OrganizationID: orgID}
+	if !h.applyRequest(c, schedule, &req) {
+		return
+	}
+
+	if err := h.oncallRepo.CreateSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create on-call schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *OnCallHandler) UpdateSchedule(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage on-call schedules"})
+		return
+	}
+
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	var req OnCallScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.applyRequest(c, schedule, &req) {
+		return
+	}
+
+	if err := h.oncallRepo.UpdateSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update on-call schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *OnCallHandler) DeleteSchedule(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage on-call schedules"})
+		return
+	}
+
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.oncallRepo.DeleteSchedule(schedule.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete on-call schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "On-call schedule deleted successfully"})
+}
+
+// GetOnCall returns who is on call now, or at the time given by ?at=
+func (h *OnCallHandler) GetOnCall(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	at := time.Now().UTC()
+	if atStr := c.Query("at"); atStr != "" {
+		parsed, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 time"})
+			return
+		}
+		at = parsed.UTC()
+	}
+
+	s, err := h.resolver.Load(schedule, at.Add(-onCallLookaround), at.Add(onCallLookaround))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load on-call schedule"})
+		return
+	}
+
+	response := OnCallResponse{ScheduleID: schedule.ID, At: at}
+	for _, shift := range s.Shifts(at.Add(-onCallLookaround), at.Add(onCallLookaround)) {
+		if at.Before(shift.Start) || !at.Before(shift.End) {
+			continue
+		}
+		user, ok := h.onCallUser(shift.UserID)
+		if !ok {
+			break
+		}
+		start, end := shift.Start, shift.End
+		response.User = user
+		response.Source = shift.Source
+		response.ShiftStart = &start
+		response.ShiftEnd = &end
+		break
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListShifts returns the schedule's timeline between ?from= (default now)
+// and ?to= (default two weeks later)
+func (h *OnCallHandler) ListShifts(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseRange(c, time.Now().UTC(), 14*24*time.Hour)
+	if !ok {
+		return
+	}
+
+	s, err := h.resolver.Load(schedule, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load on-call schedule"})
+		return
+	}
+
+	shifts := make([]OnCallShift, 0)
+	for _, shift := range s.Shifts(from, to) {
+		userID, err := uuid.Parse(shift.UserID)
+		if err != nil {
+			continue
+		}
+		shifts = append(shifts, OnCallShift{UserID: userID, Source: shift.Source, Start: shift.Start, End: shift.End})
+	}
+
+	c.JSON(http.StatusOK, shifts)
+}
+
+func (h *OnCallHandler) ListOverrides(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseRange(c, time.Now().UTC(), maxShiftRange)
+	if !ok {
+		return
+	}
+
+	overrides, err := h.oncallRepo.ListOverrides(schedule.ID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overrides"})
+		return
+	}
+
+	c.JSON(http.StatusOK, overrides)
+}
+
+// CreateOverride puts a user on call for a stretch of time. Admins can
+// override anyone; other members can only put themselves on call.
+func (h *OnCallHandler) CreateOverride(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	callerID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req OnCallOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if userID != callerID && !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can create overrides for other users"})
+		return
+	}
+	if !h.isMember(userID, schedule.OrganizationID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+		return
+	}
+
+	override := &models.OnCallOverride{
+		ScheduleID: schedule.ID,
+		UserID:     userID,
+		StartsAt:   req.StartsAt.UTC(),
+		EndsAt:     req.EndsAt.UTC(),
+		CreatedBy:  &callerID,
+	}
+	if err := h.oncallRepo.CreateOverride(override); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create override"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// DeleteOverride removes an override. Admins can remove any; other members
+// only their own.
+func (h *OnCallHandler) DeleteOverride(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+
+	callerID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	overrideID, err := uuid.Parse(c.Param("overrideId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid override ID"})
+		return
+	}
+
+	override, err := h.oncallRepo.GetOverride(overrideID)
+	if err != nil || override.ScheduleID != schedule.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Override not found"})
+		return
+	}
+
+	ownOverride := override.UserID == callerID || (override.CreatedBy != nil && *override.CreatedBy == callerID)
+	if !ownOverride && !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can delete other users' overrides"})
+		return
+	}
+
+	if err := h.oncallRepo.DeleteOverride(override.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete override"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Override deleted successfully"})
+}
+
+// ExportCalendar returns a user's on-call shifts across every schedule in the
+// organization as an iCalendar feed, from a week ago to 90 days ahead
+func (h *OnCallHandler) ExportCalendar(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil || user.OrganizationID == nil || *user.OrganizationID != orgID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	schedules, err := h.oncallRepo.ListSchedulesByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch on-call schedules"})
+		return
+	}
+
+	now := time.Now().UTC()
+	from, to := now.Add(-7*24*time.Hour), now.Add(maxShiftRange)
+	var events []rotation.CalendarEvent
+	for _, schedule := range schedules {
+		s, err := h.resolver.Load(schedule, from, to)
+		if err != nil {
+			log.Printf("Error loading on-call schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		for _, shift := range s.Shifts(from, to) {
+			if shift.UserID != userID.String() {
+				continue
+			}
+			events = append(events, rotation.CalendarEvent{
+				UID:     rotation.ShiftUID(schedule.ID.String(), shift),
+				Summary: fmt.Sprintf("On call: %s (%s)", schedule.Name, shift.Source),
+				Start:   shift.Start,
+				End:     shift.End,
+			})
+		}
+	}
+
+	calendar := rotation.ICalendar("PulseGrid on-call: "+user.Name, events, now)
+	c.Header("Content-Disposition", "inline; filename=oncall.ics")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+// loadSchedule fetches the schedule named in the path and checks it belongs
+// to the caller's organization
+func (h *OnCallHandler) loadSchedule(c *gin.Context) (*models.OnCallSchedule, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return nil, false
+	}
+
+	schedule, err := h.oncallRepo.GetSchedule(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "On-call schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch on-call schedule"})
+		}
+		return nil, false
+	}
+
+	if schedule.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return schedule, true
+}
+
+// applyRequest copies a validated request onto schedule
+func (h *OnCallHandler) applyRequest(c *gin.Context, schedule *models.OnCallSchedule, req *OnCallScheduleRequest) bool {
+	schedule.Name = req.Name
+	schedule.Description = req.Description
+	schedule.TimeZone = req.TimeZone
+	schedule.Layers = make([]models.OnCallLayer, 0, len(req.Layers))
+
+	for i, layerReq := range req.Layers {
+		layer := models.OnCallLayer{
+			Position:       i + 1,
+			Name:           layerReq.Name,
+			Rotation:       layerReq.Rotation,
+			HandoffTime:    layerReq.HandoffTime,
+			HandoffWeekday: int(time.Monday),
+			StartsAt:       layerReq.StartsAt.UTC(),
+		}
+		if layer.Name == "" {
+			layer.Name = fmt.Sprintf("Layer %d", i+1)
+		}
+		if layerReq.HandoffWeekday != nil {
+			layer.HandoffWeekday = *layerReq.HandoffWeekday
+		}
+		if layerReq.EndsAt != nil {
+			endsAt := layerReq.EndsAt.UTC()
+			layer.EndsAt = &endsAt
+		}
+
+		for _, idStr := range layerReq.ParticipantIDs {
+			id, err := uuid.Parse(idStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant ID"})
+				return false
+			}
+			if !h.isMember(id, schedule.OrganizationID) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Participant %s is not in this organization", id)})
+				return false
+			}
+			layer.ParticipantIDs = append(layer.ParticipantIDs, id)
+		}
+
+		schedule.Layers = append(schedule.Layers, layer)
+	}
+
+	if err := oncall.Validate(schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// isMember reports whether a user belongs to an organization
+func (h *OnCallHandler) isMember(userID, orgID uuid.UUID) bool {
+	user, err := h.userRepo.GetByID(userID)
+	return err == nil && user.OrganizationID != nil && *user.OrganizationID == orgID
+}
+
+func (h *OnCallHandler) onCallUser(userID string) (*OnCallUser, bool) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, false
+	}
+	user, err := h.userRepo.GetByID(id)
+	if err != nil {
+		return nil, false
+	}
+	return &OnCallUser{ID: user.ID, Name: user.Name, Email: user.Email}, true
+}
+
+// parseRange reads ?from= and ?to= as RFC 3339 times, defaulting to
+// defaultFrom and a span of defaultSpan, and caps the range at maxShiftRange
+func parseRange(c *gin.Context, defaultFrom time.Time, defaultSpan time.Duration) (time.Time, time.Time, bool) {
+	from := defaultFrom
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 time"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed.UTC()
+	}
+
+	to := from.Add(defaultSpan)
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC 3339 time"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed.UTC()
+	}
+
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Sub(from) > maxShiftRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range cannot exceed 90 days"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
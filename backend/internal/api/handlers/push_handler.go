@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/webpush"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PushHandler registers the browsers users get desktop notifications in.
+// Alerts reach them through subscriptions on the webpush channel.
+type PushHandler struct {
+	pushRepo *repository.PushSubscriptionRepository
+	notifier *notifier.NotifierService
+	cfg      *config.Config
+}
+
+func NewPushHandler(pushRepo *repository.PushSubscriptionRepository, notifierService *notifier.NotifierService, cfg *config.Config) *PushHandler {
+	return &PushHandler{
+		pushRepo: pushRepo,
+		notifier: notifierService,
+		cfg:      cfg,
+	}
+}
+
+// GetVAPIDPublicKey returns the applicationServerKey browsers pass to
+// pushManager.subscribe
+func (h *PushHandler) GetVAPIDPublicKey(c *gin.Context) {
+	key, err := h.notifier.PushPublicKey()
+	if err != nil {
+		log.Printf("Error loading VAPID keys: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web push is not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"public_key": key})
+}
+
+// SavePushSubscription stores the caller's browser, taking the JSON of its
+// PushSubscription
+func (h *PushHandler) SavePushSubscription(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req webpush.Subscription
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := &models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: c.Request.UserAgent(),
+	}
+	if err := h.pushRepo.Save(sub); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *PushHandler) ListPushSubscriptions(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.pushRepo.ListByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch push subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *PushHandler) DeletePushSubscription(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid push subscription ID"})
+		return
+	}
+
+	if err := h.pushRepo.Delete(id, userID); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Push subscription not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete push subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription deleted successfully"})
+}
@@ -72,6 +72,7 @@ func (h *ReportHandler) ExportCSV(c *gin.Context) {
 		"Total Checks",
 		"Up Checks",
 		"Down Checks",
+		"Maintenance Checks",
 		"Status",
 	})
 
@@ -85,6 +86,7 @@ func (h *ReportHandler) ExportCSV(c *gin.Context) {
 		strconv.Itoa(stats.TotalChecks),
 		strconv.Itoa(stats.UpChecks),
 		strconv.Itoa(stats.DownChecks),
+		strconv.Itoa(stats.MaintenanceChecks),
 		stats.Status,
 	})
 }
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 
 	"pulsegrid/backend/internal/config"
 	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/monitor"
 	"pulsegrid/backend/internal/repository"
 	"pulsegrid/backend/internal/scheduler"
+	"pulsegrid/backend/pkg/alerting"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,11 +18,12 @@ import (
 
 type ServiceHandler struct {
 	serviceRepo *repository.ServiceRepository
+	userRepo    *repository.UserRepository
 	scheduler   *scheduler.Scheduler
 	cfg         *config.Config
 }
 
-func NewServiceHandler(serviceRepo *repository.ServiceRepository, cfg *config.Config) *ServiceHandler {
+func NewServiceHandler(serviceRepo *repository.ServiceRepository, userRepo *repository.UserRepository, cfg *config.Config) *ServiceHandler {
 	var sched *scheduler.Scheduler
 	// Initialize scheduler if AWS credentials are available
 	if cfg.AWS.AccessKeyID != "" && cfg.AWS.SecretAccessKey != "" {
@@ -34,6 +38,7 @@ func NewServiceHandler(serviceRepo *repository.ServiceRepository, cfg *config.Co
 
 	return &ServiceHandler{
 		serviceRepo: serviceRepo,
+		userRepo:    userRepo,
 		scheduler:   sched,
 		cfg:         cfg,
 	}
@@ -47,7 +52,14 @@ type CreateServiceRequest struct {
 	Timeout           int      `json:"timeout"`
 	ExpectedStatusCode *int     `json:"expected_status_code"`
 	LatencyThresholdMs *int     `json:"latency_threshold_ms"`
+	EscalatedCheckInterval *int `json:"escalated_check_interval"`
+	AutoResolve       *bool    `json:"auto_resolve"` // defaults to true
+	FlapDetection     *bool    `json:"flap_detection"` // defaults to false
+	FlapWindow        *int     `json:"flap_window"`
+	FlapHighThreshold *float64 `json:"flap_high_threshold"`
+	FlapLowThreshold  *float64 `json:"flap_low_threshold"`
 	Tags              []string `json:"tags"`
+	OwnerID           *string  `json:"owner_id"` // a user in the organization
 }
 
 type UpdateServiceRequest struct {
@@ -58,7 +70,14 @@ type UpdateServiceRequest struct {
 	Timeout           int      `json:"timeout"`
 	ExpectedStatusCode *int     `json:"expected_status_code"`
 	LatencyThresholdMs *int     `json:"latency_threshold_ms"`
+	EscalatedCheckInterval *int `json:"escalated_check_interval"` // 0 disables adaptive checking
+	AutoResolve       *bool    `json:"auto_resolve"`
+	FlapDetection     *bool    `json:"flap_detection"`
+	FlapWindow        *int     `json:"flap_window"`
+	FlapHighThreshold *float64 `json:"flap_high_threshold"`
+	FlapLowThreshold  *float64 `json:"flap_low_threshold"`
 	Tags              []string `json:"tags"`
+	OwnerID           *string  `json:"owner_id"` // "" removes the owner
 	IsActive          *bool    `json:"is_active"`
 }
 
@@ -75,6 +94,11 @@ func (h *ServiceHandler) CreateService(c *gin.Context) {
 		return
 	}
 
+	if req.EscalatedCheckInterval != nil && *req.EscalatedCheckInterval < scheduler.MinCheckInterval {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("escalated_check_interval must be at least %d seconds", scheduler.MinCheckInterval)})
+		return
+	}
+
 	orgID, exists := c.Get("organization_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Organization ID not found"})
@@ -96,10 +120,28 @@ func (h *ServiceHandler) CreateService(c *gin.Context) {
 		Timeout:           req.Timeout,
 		ExpectedStatusCode: req.ExpectedStatusCode,
 		LatencyThresholdMs: req.LatencyThresholdMs,
+		EscalatedCheckInterval: req.EscalatedCheckInterval,
+		AutoResolve:       true,
+		FlapDetection:     false,
+		FlapWindow:        alerting.DefaultFlapWindow,
+		FlapHighThreshold: alerting.DefaultFlapHighThreshold,
+		FlapLowThreshold:  alerting.DefaultFlapLowThreshold,
 		Tags:              req.Tags,
 		IsActive:          true,
 	}
 
+	if req.AutoResolve != nil {
+		service.AutoResolve = *req.AutoResolve
+	}
+	applyFlapSettings(service, req.FlapDetection, req.FlapWindow, req.FlapHighThreshold, req.FlapLowThreshold)
+	if err := alerting.ValidateFlapSettings(monitor.ToFlapSettings(service)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.OwnerID != nil && !h.applyOwner(c, service, *req.OwnerID) {
+		return
+	}
+
 	if service.CheckInterval == 0 {
 		service.CheckInterval = h.cfg.HealthCheck.Interval
 	}
@@ -224,9 +266,30 @@ func (h *ServiceHandler) UpdateService(c *gin.Context) {
 	if req.LatencyThresholdMs != nil {
 		service.LatencyThresholdMs = req.LatencyThresholdMs
 	}
+	if req.EscalatedCheckInterval != nil {
+		if *req.EscalatedCheckInterval == 0 {
+			service.EscalatedCheckInterval = nil
+		} else if *req.EscalatedCheckInterval < scheduler.MinCheckInterval {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("escalated_check_interval must be at least %d seconds", scheduler.MinCheckInterval)})
+			return
+		} else {
+			service.EscalatedCheckInterval = req.EscalatedCheckInterval
+		}
+	}
+	if req.AutoResolve != nil {
+		service.AutoResolve = *req.AutoResolve
+	}
+	applyFlapSettings(service, req.FlapDetection, req.FlapWindow, req.FlapHighThreshold, req.FlapLowThreshold)
+	if err := alerting.ValidateFlapSettings(monitor.ToFlapSettings(service)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if req.Tags != nil {
 		service.Tags = req.Tags
 	}
+	if req.OwnerID != nil && !h.applyOwner(c, service, *req.OwnerID) {
+		return
+	}
 	if req.IsActive != nil {
 		service.IsActive = *req.IsActive
 	}
@@ -253,6 +316,28 @@ func (h *ServiceHandler) UpdateService(c *gin.Context) {
 	c.JSON(http.StatusOK, service)
 }
 
+// applyOwner sets the service's owner to a user in its organization, or
+// clears it when ownerID is empty
+func (h *ServiceHandler) applyOwner(c *gin.Context, service *models.Service, ownerID string) bool {
+	if ownerID == "" {
+		service.OwnerID = nil
+		return true
+	}
+
+	id, err := uuid.Parse(ownerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid owner ID"})
+		return false
+	}
+	owner, err := h.userRepo.GetByID(id)
+	if err != nil || owner.OrganizationID == nil || *owner.OrganizationID != service.OrganizationID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Owner not found"})
+		return false
+	}
+	service.OwnerID = &id
+	return true
+}
+
 func (h *ServiceHandler) DeleteService(c *gin.Context) {
 	role, exists := c.Get("role")
 	if !exists || (role != "admin" && role != "super_admin") {
@@ -291,6 +376,23 @@ func (h *ServiceHandler) DeleteService(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Service deleted successfully"})
 }
 
+// applyFlapSettings copies the flap detection settings present in a request
+// onto the service
+func applyFlapSettings(service *models.Service, enabled *bool, window *int, high, low *float64) {
+	if enabled != nil {
+		service.FlapDetection = *enabled
+	}
+	if window != nil {
+		service.FlapWindow = *window
+	}
+	if high != nil {
+		service.FlapHighThreshold = *high
+	}
+	if low != nil {
+		service.FlapLowThreshold = *low
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,750 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/maintenance"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/statuspage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// statusPageCacheTTL is how long a rendered status page is served before it
+// is built again
+const statusPageCacheTTL = 60 * time.Second
+
+// statusPageAccessTTL is how long a password-protected page's access token
+// stays valid
+const statusPageAccessTTL = 24 * time.Hour
+
+// statusPageAudience marks access tokens as good only for status pages, so
+// they can't stand in for a session
+const statusPageAudience = "status-page"
+
+// cachedStatusPage is a rendered page together with what is needed to check
+// access to it without going back to the database
+type cachedStatusPage struct {
+	page    *models.StatusPage
+	body    []byte
+	builtAt time.Time
+}
+
+type StatusPageHandler struct {
+	pageRepo        *repository.StatusPageRepository
+	postRepo        *repository.StatusPagePostRepository
+	subscriberRepo  *repository.StatusPageSubscriberRepository
+	serviceRepo     *repository.ServiceRepository
+	stateRepo       *repository.ServiceStateRepository
+	healthCheckRepo *repository.HealthCheckRepository
+	incidentRepo    *repository.IncidentRepository
+	maintenanceRepo *repository.MaintenanceWindowRepository
+	notifierService *notifier.NotifierService
+	cfg             *config.Config
+
+	// In-memory cache of rendered pages by slug (use Redis in production
+	// for distributed systems)
+	cacheMu sync.RWMutex
+	cache   map[string]*cachedStatusPage
+}
+
+func NewStatusPageHandler(pageRepo *repository.StatusPageRepository, postRepo *repository.StatusPagePostRepository, subscriberRepo *repository.StatusPageSubscriberRepository, serviceRepo *repository.ServiceRepository, stateRepo *repository.ServiceStateRepository, healthCheckRepo *repository.HealthCheckRepository, incidentRepo *repository.IncidentRepository, maintenanceRepo *repository.MaintenanceWindowRepository, notifierService *notifier.NotifierService, cfg *config.Config) *StatusPageHandler {
+	return &StatusPageHandler{
+		pageRepo:        pageRepo,
+		postRepo:        postRepo,
+		subscriberRepo:  subscriberRepo,
+		serviceRepo:     serviceRepo,
+		stateRepo:       stateRepo,
+		healthCheckRepo: healthCheckRepo,
+		incidentRepo:    incidentRepo,
+		maintenanceRepo: maintenanceRepo,
+		notifierService: notifierService,
+		cfg:             cfg,
+		cache:           make(map[string]*cachedStatusPage),
+	}
+}
+
+type StatusPageRequest struct {
+	Slug        string `json:"slug" binding:"required"`
+	Title       string `json:"title" binding:"required,max=255"`
+	Description string `json:"description" binding:"max=2000"`
+	IsPublished *bool  `json:"is_published"`
+	// Password protects the page. An empty string removes the password, and
+	// leaving it out keeps the current one.
+	Password   *string                      `json:"password" binding:"omitempty,min=6,max=72"`
+	LogoURL    string                       `json:"logo_url"`
+	FaviconURL string                       `json:"favicon_url"`
+	BrandColor string                       `json:"brand_color"`
+	SupportURL string                       `json:"support_url"`
+	FooterText string                       `json:"footer_text" binding:"max=1000"`
+	Components []StatusPageComponentRequest `json:"components" binding:"dive"`
+}
+
+// StatusPageComponentRequest is one component of a page. Passing the ID of
+// an existing component keeps it across an update.
+type StatusPageComponentRequest struct {
+	ID          *string  `json:"id"`
+	Name        string   `json:"name" binding:"required,max=255"`
+	Description string   `json:"description" binding:"max=1000"`
+	ServiceIDs  []string `json:"service_ids"`
+}
+
+type StatusPageAccessRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// StatusPageView is the public rendering of a status page
+type StatusPageView struct {
+	Slug        string                    `json:"slug"`
+	Title       string                    `json:"title"`
+	Description string                    `json:"description"`
+	Branding    StatusPageBranding        `json:"branding"`
+	Status      string                    `json:"status"`
+	Components  []StatusPageComponentView `json:"components"`
+	Incidents   []StatusPageIncidentView  `json:"incidents"`
+	Posts       []StatusPagePostView      `json:"posts"`
+	UpdatedAt   time.Time                 `json:"updated_at"`
+}
+
+type StatusPageBranding struct {
+	LogoURL    string `json:"logo_url"`
+	FaviconURL string `json:"favicon_url"`
+	BrandColor string `json:"brand_color"`
+	SupportURL string `json:"support_url"`
+	FooterText string `json:"footer_text"`
+}
+
+type StatusPageComponentView struct {
+	ID            uuid.UUID               `json:"id"`
+	Name          string                  `json:"name"`
+	Description   string                  `json:"description"`
+	Status        string                  `json:"status"`
+	UptimePercent *float64                `json:"uptime_percent"`
+	UptimeBars    []statuspage.Bar        `json:"uptime_bars"`
+	Services      []StatusPageServiceView `json:"services"`
+}
+
+// StatusPageServiceView shows a service by name only; its URL stays private
+type StatusPageServiceView struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type StatusPageIncidentView struct {
+	ID         uuid.UUID `json:"id"`
+	Title      string    `json:"title"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	Components []string  `json:"components"`
+}
+
+// StatusPagePostView is an incident or maintenance post as the public sees
+// it, without who wrote it
+type StatusPagePostView struct {
+	ID             uuid.UUID                  `json:"id"`
+	Kind           string                     `json:"kind"`
+	Title          string                     `json:"title"`
+	Status         string                     `json:"status"`
+	Impact         string                     `json:"impact"`
+	Components     []string                   `json:"components"`
+	ScheduledFor   *time.Time                 `json:"scheduled_for,omitempty"`
+	ScheduledUntil *time.Time                 `json:"scheduled_until,omitempty"`
+	ResolvedAt     *time.Time                 `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	Updates        []StatusPagePostUpdateView `json:"updates"`
+}
+
+type StatusPagePostUpdateView struct {
+	Status    string    `json:"status"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (h *StatusPageHandler) ListPages(c *gin.Context) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	pages, err := h.pageRepo.ListByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status pages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pages)
+}
+
+func (h *StatusPageHandler) GetPage(c *gin.Context) {
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// PreviewPage renders a page as the public sees it, including unpublished
+// and password-protected ones
+func (h *StatusPageHandler) PreviewPage(c *gin.Context) {
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.buildView(page, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build status page"})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *StatusPageHandler) CreatePage(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req StatusPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page := &models.StatusPage{OrganizationID: orgID}
+	if !h.applyRequest(c, page, &req) {
+		return
+	}
+
+	if err := h.pageRepo.Create(page); err != nil {
+		if err == repository.ErrDuplicateEntry {
+			c.JSON(http.StatusConflict, gin.H{"error": "A status page with this slug already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create status page"})
+		return
+	}
+	h.invalidate(page.Slug)
+
+	c.JSON(http.StatusCreated, page)
+}
+
+func (h *StatusPageHandler) UpdatePage(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	var req StatusPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	oldSlug := page.Slug
+	if !h.applyRequest(c, page, &req) {
+		return
+	}
+
+	if err := h.pageRepo.Update(page); err != nil {
+		if err == repository.ErrDuplicateEntry {
+			c.JSON(http.StatusConflict, gin.H{"error": "A status page with this slug already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status page"})
+		return
+	}
+	h.invalidate(oldSlug, page.Slug)
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *StatusPageHandler) DeletePage(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	if err := h.pageRepo.Delete(page.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete status page"})
+		return
+	}
+	h.invalidate(page.Slug)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Status page deleted successfully"})
+}
+
+// GetPublicPage serves a published status page to anyone, or to holders of
+// an access token for a password-protected one
+// GET /api/v1/status/:slug
+func (h *StatusPageHandler) GetPublicPage(c *gin.Context) {
+	cached, ok := h.cachedPage(c)
+	if !ok {
+		return
+	}
+
+	if cached.page.PasswordProtected {
+		token := c.GetHeader("X-Status-Page-Token")
+		if token == "" {
+			token = c.Query("token")
+		}
+		if !h.validAccessToken(cached.page, token) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":             "This status page is password protected",
+				"password_required": true,
+			})
+			return
+		}
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(statusPageCacheTTL.Seconds())))
+	} else {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(statusPageCacheTTL.Seconds())))
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", cached.body)
+}
+
+// AccessPage exchanges a protected page's password for an access token
+// POST /api/v1/status/:slug/access
+func (h *StatusPageHandler) AccessPage(c *gin.Context) {
+	cached, ok := h.cachedPage(c)
+	if !ok {
+		return
+	}
+
+	var req StatusPageAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page := cached.page
+	if !page.PasswordProtected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This status page is not password protected"})
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(page.PasswordHash), []byte(req.Password)) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password"})
+		return
+	}
+
+	expiresAt := time.Now().Add(statusPageAccessTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": page.ID.String(),
+		"aud": statusPageAudience,
+		"exp": expiresAt.Unix(),
+	})
+	signed, err := token.SignedString(h.accessKey(page))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": signed, "expires_at": expiresAt.UTC()})
+}
+
+// accessKey signs a page's access tokens. It includes the password hash, so
+// changing or removing the password revokes every token handed out.
+func (h *StatusPageHandler) accessKey(page *models.StatusPage) []byte {
+	return []byte(h.cfg.JWT.Secret + page.PasswordHash)
+}
+
+func (h *StatusPageHandler) validAccessToken(page *models.StatusPage, tokenString string) bool {
+	if tokenString == "" {
+		return false
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return h.accessKey(page), nil
+	}, jwt.WithAudience(statusPageAudience), jwt.WithSubject(page.ID.String()))
+
+	return err == nil && token.Valid
+}
+
+// cachedPage returns the published page named in the path, building and
+// caching it when the cached copy is missing or stale
+func (h *StatusPageHandler) cachedPage(c *gin.Context) (*cachedStatusPage, bool) {
+	slug := c.Param("slug")
+
+	h.cacheMu.RLock()
+	cached, ok := h.cache[slug]
+	h.cacheMu.RUnlock()
+	if ok && time.Since(cached.builtAt) < statusPageCacheTTL {
+		return cached, true
+	}
+
+	page, err := h.pageRepo.GetBySlug(slug)
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status page"})
+		return nil, false
+	}
+	if err == sql.ErrNoRows || !page.IsPublished {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status page not found"})
+		return nil, false
+	}
+
+	now := time.Now().UTC()
+	view, err := h.buildView(page, now)
+	if err != nil {
+		log.Printf("Failed to build status page %s: %v", page.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build status page"})
+		return nil, false
+	}
+	body, err := json.Marshal(view)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build status page"})
+		return nil, false
+	}
+
+	cached = &cachedStatusPage{page: page, body: body, builtAt: now}
+	h.cacheMu.Lock()
+	h.cache[slug] = cached
+	h.cacheMu.Unlock()
+
+	return cached, true
+}
+
+// invalidate drops the cached copies of pages at the given slugs, so edits
+// show up straight away
+func (h *StatusPageHandler) invalidate(slugs ...string) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+	for _, slug := range slugs {
+		delete(h.cache, slug)
+	}
+}
+
+// buildView works out what a page shows at now: each component's status and
+// uptime bars, and the open incidents affecting its services
+func (h *StatusPageHandler) buildView(page *models.StatusPage, now time.Time) (*StatusPageView, error) {
+	services, err := h.serviceRepo.ListByOrganization(page.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	servicesByID := make(map[uuid.UUID]*models.Service, len(services))
+	for _, service := range services {
+		servicesByID[service.ID] = service
+	}
+
+	states, err := h.stateRepo.ListByOrganization(page.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := h.maintenanceRepo.ListByOrganization(page.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceIDs []uuid.UUID
+	for _, component := range page.Components {
+		serviceIDs = append(serviceIDs, component.ServiceIDs...)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	days, err := h.healthCheckRepo.DailyUptime(serviceIDs, today.AddDate(0, 0, -(statuspage.DefaultDays-1)))
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := h.postRepo.ListVisible(page.ID, now.Add(-statuspage.PostHistory))
+	if err != nil {
+		return nil, err
+	}
+	// postStatuses is the status open posts put each component in
+	postStatuses := make(map[uuid.UUID]string)
+	for _, post := range posts {
+		for _, id := range post.ComponentIDs {
+			postStatuses[id] = statuspage.Worse(postStatuses[id], statuspage.PostComponentStatus(post.Kind, post.Status, post.Impact))
+		}
+	}
+
+	view := &StatusPageView{
+		Slug:        page.Slug,
+		Title:       page.Title,
+		Description: page.Description,
+		Branding: StatusPageBranding{
+			LogoURL:    page.LogoURL,
+			FaviconURL: page.FaviconURL,
+			BrandColor: page.BrandColor,
+			SupportURL: page.SupportURL,
+			FooterText: page.FooterText,
+		},
+		Components: make([]StatusPageComponentView, 0, len(page.Components)),
+		Incidents:  make([]StatusPageIncidentView, 0),
+		Posts:      make([]StatusPagePostView, 0, len(posts)),
+		UpdatedAt:  now,
+	}
+
+	// componentsByService names the components each service appears in, for
+	// listing the components an incident affects
+	componentsByService := make(map[uuid.UUID][]string)
+	var componentStatuses []string
+	for _, component := range page.Components {
+		componentView := StatusPageComponentView{
+			ID:          component.ID,
+			Name:        component.Name,
+			Description: component.Description,
+			Services:    make([]StatusPageServiceView, 0, len(component.ServiceIDs)),
+		}
+
+		var serviceStatuses []string
+		var checks []statuspage.Day
+		for _, id := range component.ServiceIDs {
+			// Services deleted since the page was saved drop out
+			service, ok := servicesByID[id]
+			if !ok {
+				continue
+			}
+
+			lastStatus := ""
+			if state, ok := states[id]; ok {
+				lastStatus = state.LastStatus
+			}
+			var applicable []*models.MaintenanceWindow
+			for _, w := range windows {
+				if maintenance.AppliesTo(w, service) {
+					applicable = append(applicable, w)
+				}
+			}
+			status := statuspage.ServiceStatus(lastStatus, maintenance.ActiveWindow(applicable, now) != nil)
+
+			serviceStatuses = append(serviceStatuses, status)
+			for _, day := range days[id] {
+				checks = append(checks, statuspage.Day{
+					Date: day.Date, Total: day.Total, Up: day.Up, Degraded: day.Degraded, Down: day.Down, Maintenance: day.Maintenance,
+				})
+			}
+			componentView.Services = append(componentView.Services, StatusPageServiceView{Name: service.Name, Status: status})
+			componentsByService[id] = append(componentsByService[id], component.Name)
+		}
+
+		componentView.Status = statuspage.Worse(statuspage.ComponentStatus(serviceStatuses), postStatuses[component.ID])
+		componentView.UptimeBars, componentView.UptimePercent = statuspage.Bars(checks, now, statuspage.DefaultDays)
+		componentStatuses = append(componentStatuses, componentView.Status)
+		view.Components = append(view.Components, componentView)
+	}
+	view.Status = statuspage.Overall(componentStatuses)
+
+	incidents, err := h.incidentRepo.ListByOrganization(page.OrganizationID, "", 100)
+	if err != nil {
+		return nil, err
+	}
+	for _, incident := range incidents {
+		if incident.Status == "resolved" {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		components := make([]string, 0)
+		for _, affected := range incident.AffectedServices {
+			for _, name := range componentsByService[affected.ServiceID] {
+				if !seen[name] {
+					seen[name] = true
+					components = append(components, name)
+				}
+			}
+		}
+		if len(components) == 0 {
+			continue
+		}
+
+		view.Incidents = append(view.Incidents, StatusPageIncidentView{
+			ID:         incident.ID,
+			Title:      incident.Title,
+			Status:     incident.Status,
+			StartedAt:  incident.StartedAt,
+			Components: components,
+		})
+	}
+
+	for _, post := range posts {
+		view.Posts = append(view.Posts, postView(page, post))
+	}
+
+	return view, nil
+}
+
+// postView renders a post for the public, naming its components
+func postView(page *models.StatusPage, post *models.StatusPagePost) StatusPagePostView {
+	view := StatusPagePostView{
+		ID:             post.ID,
+		Kind:           post.Kind,
+		Title:          post.Title,
+		Status:         post.Status,
+		Impact:         post.Impact,
+		Components:     make([]string, 0, len(post.ComponentIDs)),
+		ScheduledFor:   post.ScheduledFor,
+		ScheduledUntil: post.ScheduledUntil,
+		ResolvedAt:     post.ResolvedAt,
+		CreatedAt:      post.CreatedAt,
+		Updates:        make([]StatusPagePostUpdateView, 0, len(post.Updates)),
+	}
+	for _, id := range post.ComponentIDs {
+		for _, component := range page.Components {
+			if component.ID == id {
+				view.Components = append(view.Components, component.Name)
+			}
+		}
+	}
+	for _, update := range post.Updates {
+		view.Updates = append(view.Updates, StatusPagePostUpdateView{Status: update.Status, Body: update.Body, CreatedAt: update.CreatedAt})
+	}
+	return view
+}
+
+// loadPage fetches the page named in the path and checks it belongs to the
+// caller's organization
+func (h *StatusPageHandler) loadPage(c *gin.Context) (*models.StatusPage, bool) {
+	orgID, ok := organizationIDFromContext(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status page ID"})
+		return nil, false
+	}
+
+	page, err := h.pageRepo.GetByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Status page not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status page"})
+		}
+		return nil, false
+	}
+
+	if page.OrganizationID != orgID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return page, true
+}
+
+// applyRequest validates a request and copies it onto page
+func (h *StatusPageHandler) applyRequest(c *gin.Context, page *models.StatusPage, req *StatusPageRequest) bool {
+	checks := []error{
+		statuspage.ValidateSlug(req.Slug),
+		statuspage.ValidateColor(req.BrandColor),
+		statuspage.ValidateURL("logo_url", req.LogoURL),
+		statuspage.ValidateURL("favicon_url", req.FaviconURL),
+		statuspage.ValidateURL("support_url", req.SupportURL),
+	}
+	for _, err := range checks {
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+
+	if len(req.Components) > statuspage.MaxComponents {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A status page can have at most %d components", statuspage.MaxComponents)})
+		return false
+	}
+
+	services, err := h.serviceRepo.ListByOrganization(page.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch services"})
+		return false
+	}
+	orgServices := make(map[uuid.UUID]bool, len(services))
+	for _, service := range services {
+		orgServices[service.ID] = true
+	}
+	existing := make(map[uuid.UUID]bool, len(page.Components))
+	for _, component := range page.Components {
+		existing[component.ID] = true
+	}
+
+	components := make([]models.StatusPageComponent, 0, len(req.Components))
+	for _, r := range req.Components {
+		component := models.StatusPageComponent{Name: r.Name, Description: r.Description}
+		if r.ID != nil {
+			if id, err := uuid.Parse(*r.ID); err == nil && existing[id] {
+				component.ID = id
+			}
+		}
+
+		if len(r.ServiceIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Component %q must include at least one service", r.Name)})
+			return false
+		}
+		component.ServiceIDs = make([]uuid.UUID, 0, len(r.ServiceIDs))
+		for _, s := range r.ServiceIDs {
+			serviceID, err := uuid.Parse(s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+				return false
+			}
+			if !orgServices[serviceID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Service not found"})
+				return false
+			}
+			component.ServiceIDs = append(component.ServiceIDs, serviceID)
+		}
+
+		components = append(components, component)
+	}
+
+	if req.Password != nil {
+		page.PasswordHash = ""
+		if *req.Password != "" {
+			hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+				return false
+			}
+			page.PasswordHash = string(hash)
+		}
+	}
+	page.PasswordProtected = page.PasswordHash != ""
+
+	page.Slug = req.Slug
+	page.Title = req.Title
+	page.Description = req.Description
+	page.IsPublished = true
+	if req.IsPublished != nil {
+		page.IsPublished = *req.IsPublished
+	}
+	page.LogoURL = req.LogoURL
+	page.FaviconURL = req.FaviconURL
+	page.BrandColor = req.BrandColor
+	page.SupportURL = req.SupportURL
+	page.FooterText = req.FooterText
+	page.Components = components
+
+	return true
+}
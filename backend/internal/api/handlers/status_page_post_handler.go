@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/statuspage"
+	"pulsegrid/backend/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateStatusPagePostRequest opens an incident or announces maintenance.
+// Body is the first entry of its timeline.
+type CreateStatusPagePostRequest struct {
+	Kind           string     `json:"kind" binding:"required"`
+	Title          string     `json:"title" binding:"required,max=255"`
+	Status         string     `json:"status"`
+	Impact         string     `json:"impact"`
+	ComponentIDs   []string   `json:"component_ids"`
+	ScheduledFor   *time.Time `json:"scheduled_for"`
+	ScheduledUntil *time.Time `json:"scheduled_until"`
+	Body           string     `json:"body" binding:"required,max=10000"`
+	// Notify sends the post to the page's subscribers, which it does unless
+	// set to false
+	Notify *bool `json:"notify"`
+}
+
+// UpdateStatusPagePostRequest edits a post's details without adding to its
+// timeline or notifying anyone
+type UpdateStatusPagePostRequest struct {
+	Title          string     `json:"title" binding:"required,max=255"`
+	Impact         string     `json:"impact"`
+	ComponentIDs   []string   `json:"component_ids"`
+	ScheduledFor   *time.Time `json:"scheduled_for"`
+	ScheduledUntil *time.Time `json:"scheduled_until"`
+}
+
+type StatusPagePostUpdateRequest struct {
+	Status string `json:"status" binding:"required"`
+	Body   string `json:"body" binding:"required,max=10000"`
+	Notify *bool  `json:"notify"`
+}
+
+type StatusPageSubscribeRequest struct {
+	Channel     string `json:"channel" binding:"required"`
+	Destination string `json:"destination" binding:"required,max=2048"`
+}
+
+func (h *StatusPageHandler) ListPosts(c *gin.Context) {
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	posts, err := h.postRepo.ListByPage(page.ID, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status page posts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// CreatePost opens an incident or announces maintenance on a page and tells
+// its subscribers
+func (h *StatusPageHandler) CreatePost(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	var req CreateStatusPagePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := statuspage.ValidateKind(req.Kind); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status == "" {
+		req.Status = statuspage.InitialStatus(req.Kind)
+	}
+	if err := statuspage.ValidatePostStatus(req.Kind, req.Status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	post := &models.StatusPagePost{PageID: page.ID, Kind: req.Kind, CreatedBy: &userID}
+	details := UpdateStatusPagePostRequest{
+		Title:          req.Title,
+		Impact:         req.Impact,
+		ComponentIDs:   req.ComponentIDs,
+		ScheduledFor:   req.ScheduledFor,
+		ScheduledUntil: req.ScheduledUntil,
+	}
+	if !applyPostDetails(c, page, post, &details) {
+		return
+	}
+
+	update := &models.StatusPagePostUpdate{Status: req.Status, Body: req.Body, CreatedBy: &userID}
+	if err := h.postRepo.Create(post, update); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create status page post"})
+		return
+	}
+	h.invalidate(page.Slug)
+
+	if req.Notify == nil || *req.Notify {
+		go h.notifySubscribers(page, post, notifier.StatusPageEventPostCreated)
+	}
+
+	c.JSON(http.StatusCreated, post)
+}
+
+func (h *StatusPageHandler) UpdatePost(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, post, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateStatusPagePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyPostDetails(c, page, post, &req) {
+		return
+	}
+
+	if err := h.postRepo.Update(post); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status page post"})
+		return
+	}
+	h.invalidate(page.Slug)
+
+	c.JSON(http.StatusOK, post)
+}
+
+// AddPostUpdate posts an update to an incident or maintenance, moving it to
+// the update's status, and tells the page's subscribers
+func (h *StatusPageHandler) AddPostUpdate(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, post, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	var req StatusPagePostUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := statuspage.ValidatePostStatus(post.Kind, req.Status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	update := &models.StatusPagePostUpdate{Status: req.Status, Body: req.Body, CreatedBy: &userID}
+	if err := h.postRepo.AddUpdate(post, update); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add status page post update"})
+		return
+	}
+	h.invalidate(page.Slug)
+
+	if req.Notify == nil || *req.Notify {
+		go h.notifySubscribers(page, post, notifier.StatusPageEventPostUpdated)
+	}
+
+	c.JSON(http.StatusCreated, post)
+}
+
+func (h *StatusPageHandler) DeletePost(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, post, ok := h.loadPost(c)
+	if !ok {
+		return
+	}
+
+	if err := h.postRepo.Delete(post.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete status page post"})
+		return
+	}
+	h.invalidate(page.Slug)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Status page post deleted successfully"})
+}
+
+// ListSubscribers lists a page's subscribers. Their addresses belong to end
+// users, so only admins see them.
+func (h *StatusPageHandler) ListSubscribers(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	subscribers, err := h.subscriberRepo.ListByPage(page.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscribers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscribers)
+}
+
+func (h *StatusPageHandler) DeleteSubscriber(c *gin.Context) {
+	if !isOrgAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only Organization Admin or Super Admin can manage status pages"})
+		return
+	}
+
+	page, ok := h.loadPage(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("subscriberId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscriber ID"})
+		return
+	}
+
+	sub, err := h.subscriberRepo.GetByID(id)
+	if err != nil || sub.PageID != page.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscriber not found"})
+		return
+	}
+
+	if err := h.subscriberRepo.Delete(sub.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscriber"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscriber deleted successfully"})
+}
+
+// Subscribe signs an end user up for a page's posts by email or webhook and
+// sends them a link to confirm. The answer is the same whether or not the
+// destination already follows the page, so the form can't be used to find
+// out who does.
+// POST /api/v1/status/:slug/subscribers
+func (h *StatusPageHandler) Subscribe(c *gin.Context) {
+	if !checkRateLimit(c.ClientIP()) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Rate limit exceeded. Maximum 60 requests per minute per IP address.",
+			"retry_after": 60,
+		})
+		return
+	}
+
+	cached, ok := h.cachedPage(c)
+	if !ok {
+		return
+	}
+	page := cached.page
+	if page.PasswordProtected && !h.validAccessToken(page, c.GetHeader("X-Status-Page-Token")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "This status page is password protected", "password_required": true})
+		return
+	}
+
+	var req StatusPageSubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	destination := strings.TrimSpace(req.Destination)
+	switch req.Channel {
+	case statuspage.ChannelEmail:
+		destination = strings.ToLower(destination)
+		if addr, err := mail.ParseAddress(destination); err != nil || addr.Address != destination {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "destination must be an email address"})
+			return
+		}
+	case statuspage.ChannelWebhook:
+		if err := webhook.ValidateURL(destination); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Anyone can subscribe a webhook, so it must not reach into our
+		// own network
+		u, _ := url.Parse(destination)
+		if isLocalhost(u.Hostname()) || isPrivateIP(u.Hostname()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "destination must be a public URL"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be email or webhook"})
+		return
+	}
+
+	now := time.Now().UTC()
+	token, err := statuspage.NewToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	}
+
+	sub, err := h.subscriberRepo.GetByDestination(page.ID, req.Channel, destination)
+	switch {
+	case err == sql.ErrNoRows:
+		unsubscribeToken, err := statuspage.NewToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+			return
+		}
+		sub = &models.StatusPageSubscriber{
+			PageID:           page.ID,
+			Channel:          req.Channel,
+			Destination:      destination,
+			ConfirmToken:     &token,
+			ConfirmSentAt:    &now,
+			UnsubscribeToken: unsubscribeToken,
+		}
+		// A duplicate lost a race with the same request, which sent the link
+		if err := h.subscriberRepo.Create(sub); err == nil {
+			go h.notifierService.SendStatusPageConfirmation(page, sub)
+		} else if err != repository.ErrDuplicateEntry {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+			return
+		}
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		return
+	case !sub.Confirmed && (sub.ConfirmSentAt == nil || now.Sub(*sub.ConfirmSentAt) >= statuspage.ConfirmResendInterval):
+		// Still pending: send a fresh link, which replaces the old one
+		if err := h.subscriberRepo.SetConfirmToken(sub, token, now); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+			return
+		}
+		go h.notifierService.SendStatusPageConfirmation(page, sub)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Check your inbox or webhook for a link to confirm your subscription"})
+}
+
+// ConfirmSubscription confirms a subscriber through the link they were sent
+// GET /api/v1/status/:slug/subscribers/confirm?token=...
+func (h *StatusPageHandler) ConfirmSubscription(c *gin.Context) {
+	page, ok := h.pageBySlug(c)
+	if !ok {
+		return
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Confirmation token is required"})
+		return
+	}
+
+	_, err := h.subscriberRepo.Confirm(page.ID, token, time.Now().UTC().Add(-statuspage.ConfirmTTL))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "This confirmation link is invalid or has expired"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm subscription"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription confirmed. You will now receive " + page.Title + " status updates."})
+}
+
+// Unsubscribe removes a subscriber in one click, through the link in every
+// notification. It answers POST too, for mail clients' one-click
+// unsubscribe.
+// GET/POST /api/v1/status/:slug/subscribers/unsubscribe?token=...
+func (h *StatusPageHandler) Unsubscribe(c *gin.Context) {
+	page, ok := h.pageBySlug(c)
+	if !ok {
+		return
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsubscribe token is required"})
+		return
+	}
+
+	removed, err := h.subscriberRepo.Unsubscribe(page.ID, token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This unsubscribe link is invalid or was already used"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You have been unsubscribed from " + page.Title + " status updates"})
+}
+
+// pageBySlug fetches the page named in the path whether or not it is
+// published, so subscription links keep working after a page is taken down
+func (h *StatusPageHandler) pageBySlug(c *gin.Context) (*models.StatusPage, bool) {
+	page, err := h.pageRepo.GetBySlug(c.Param("slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Status page not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status page"})
+		}
+		return nil, false
+	}
+	return page, true
+}
+
+// notifySubscribers sends a post to every confirmed subscriber of its page
+func (h *StatusPageHandler) notifySubscribers(page *models.StatusPage, post *models.StatusPagePost, event string) {
+	subscribers, err := h.subscriberRepo.ListConfirmed(page.ID)
+	if err != nil {
+		log.Printf("Error fetching subscribers of status page %s: %v", page.ID, err)
+		return
+	}
+	h.notifierService.NotifyStatusPageSubscribers(page, post, event, subscribers)
+}
+
+// loadPost fetches the page and post named in the path, checking the page
+// belongs to the caller's organization and the post to the page
+func (h *StatusPageHandler) loadPost(c *gin.Context) (*models.StatusPage, *models.StatusPagePost, bool) {
+	page, ok := h.loadPage(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return nil, nil, false
+	}
+
+	post, err := h.postRepo.GetByID(id)
+	if err != nil || post.PageID != page.ID {
+		if err != nil && err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status page post"})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Status page post not found"})
+		}
+		return nil, nil, false
+	}
+
+	return page, post, true
+}
+
+// applyPostDetails validates a post's details and copies them onto post
+func applyPostDetails(c *gin.Context, page *models.StatusPage, post *models.StatusPagePost, req *UpdateStatusPagePostRequest) bool {
+	if req.Impact == "" {
+		req.Impact = statuspage.ImpactNone
+	}
+	if err := statuspage.ValidateImpact(req.Impact); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if post.Kind == statuspage.KindMaintenance {
+		if req.ScheduledFor == nil || req.ScheduledUntil == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Maintenance must have scheduled_for and scheduled_until"})
+			return false
+		}
+		if !req.ScheduledUntil.After(*req.ScheduledFor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scheduled_until must be after scheduled_for"})
+			return false
+		}
+		scheduledFor, scheduledUntil := req.ScheduledFor.UTC(), req.ScheduledUntil.UTC()
+		post.ScheduledFor, post.ScheduledUntil = &scheduledFor, &scheduledUntil
+	} else {
+		post.ScheduledFor, post.ScheduledUntil = nil, nil
+	}
+
+	components := make(map[uuid.UUID]bool, len(page.Components))
+	for _, component := range page.Components {
+		components[component.ID] = true
+	}
+	post.ComponentIDs = make([]uuid.UUID, 0, len(req.ComponentIDs))
+	for _, s := range req.ComponentIDs {
+		id, err := uuid.Parse(s)
+		if err != nil || !components[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Component not found on this status page"})
+			return false
+		}
+		post.ComponentIDs = append(post.ComponentIDs, id)
+	}
+
+	post.Title = req.Title
+	post.Impact = req.Impact
+	return true
+}
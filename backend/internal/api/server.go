@@ -6,12 +6,17 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"pulsegrid/backend/internal/ai"
 	"pulsegrid/backend/internal/api/handlers"
 	"pulsegrid/backend/internal/api/middleware"
 	"pulsegrid/backend/internal/config"
+	"pulsegrid/backend/internal/dependency"
+	"pulsegrid/backend/internal/escalation"
+	"pulsegrid/backend/internal/monitor"
 	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/oncall"
 	"pulsegrid/backend/internal/repository"
 
 	"github.com/gin-gonic/gin"
@@ -65,9 +70,37 @@ func (s *Server) setupRoutes() {
 	serviceRepo := repository.NewServiceRepository(s.db)
 	healthCheckRepo := repository.NewHealthCheckRepository(s.db)
 	alertRepo := repository.NewAlertRepository(s.db)
+	stateRepo := repository.NewServiceStateRepository(s.db)
+	maintenanceRepo := repository.NewMaintenanceWindowRepository(s.db)
+	dependencyRepo := repository.NewServiceDependencyRepository(s.db)
+	alertRuleRepo := repository.NewAlertRuleRepository(s.db)
+	escalationRepo := repository.NewEscalationRepository(s.db)
+	oncallRepo := repository.NewOnCallRepository(s.db)
+	incidentRepo := repository.NewIncidentRepository(s.db)
+	notificationRepo := repository.NewNotificationRepository(s.db)
+	notificationTemplateRepo := repository.NewNotificationTemplateRepository(s.db)
+	notificationHoldRepo := repository.NewNotificationHoldRepository(s.db)
+	pushSubscriptionRepo := repository.NewPushSubscriptionRepository(s.db)
+	contactMethodRepo := repository.NewContactMethodRepository(s.db)
+	notificationRuleRepo := repository.NewNotificationRuleRepository(s.db)
+	statusPageRepo := repository.NewStatusPageRepository(s.db)
+	statusPagePostRepo := repository.NewStatusPagePostRepository(s.db)
+	statusPageSubscriberRepo := repository.NewStatusPageSubscriberRepository(s.db)
+	badgeTokenRepo := repository.NewBadgeTokenRepository(s.db)
 
 	// Initialize supporting services
-	notifierService := notifier.NewNotifierService(alertRepo)
+	oncallResolver := oncall.NewResolver(oncallRepo, userRepo)
+	notifierService := notifier.NewNotifierService(alertRepo, serviceRepo, notificationRepo, notificationTemplateRepo, notificationHoldRepo, pushSubscriptionRepo, notificationRuleRepo, oncallResolver)
+	// Retry failed notifications here too; the outbox lets this run
+	// alongside the scheduler
+	go notifierService.RunOutbox(30 * time.Second)
+	suppressor := dependency.NewSuppressor(dependencyRepo, stateRepo, serviceRepo)
+	escalator := escalation.NewEscalator(escalationRepo, alertRepo, notifierService)
+	alertProcessor := monitor.NewAlertProcessor(alertRepo, alertRuleRepo, healthCheckRepo, incidentRepo, suppressor, escalator, notifierService)
+	checkRecorder := monitor.NewRecorder(healthCheckRepo, serviceRepo, stateRepo, maintenanceRepo, alertProcessor)
+	// Record the Lambda worker's checks so they alert like the scheduler's.
+	// Only the API server records them, so the scheduler does not race it.
+	go checkRecorder.RunPending(5 * time.Second)
 
 	// Initialize AI client (OpenAI or Ollama) if configured
 	var aiClient ai.AIClient
@@ -100,14 +133,26 @@ func (s *Server) setupRoutes() {
 	}
 
 	authHandler := handlers.NewAuthHandler(userRepo, orgRepo, s.cfg)
-	serviceHandler := handlers.NewServiceHandler(serviceRepo, s.cfg)
-	healthCheckHandler := handlers.NewHealthCheckHandler(healthCheckRepo, serviceRepo, alertRepo, notifierService, s.cfg)
-	alertHandler := handlers.NewAlertHandler(alertRepo, serviceRepo, notifierService, s.cfg)
+	serviceHandler := handlers.NewServiceHandler(serviceRepo, userRepo, s.cfg)
+	healthCheckHandler := handlers.NewHealthCheckHandler(healthCheckRepo, serviceRepo, stateRepo, checkRecorder, s.cfg)
+	alertHandler := handlers.NewAlertHandler(alertRepo, serviceRepo, escalationRepo, oncallRepo, userRepo, incidentRepo, notificationRepo, notificationHoldRepo, notifierService, s.cfg)
 	statsHandler := handlers.NewStatsHandler(serviceRepo, healthCheckRepo, s.cfg)
 	reportHandler := handlers.NewReportHandler(serviceRepo, healthCheckRepo, s.cfg)
 	adminHandler := handlers.NewAdminHandler(userRepo, orgRepo, serviceRepo, healthCheckRepo, alertRepo, s.cfg)
 	predictionHandler := handlers.NewPredictionHandler(serviceRepo, healthCheckRepo, s.cfg, aiClient)
 	metricsHandler := handlers.NewMetricsHandler(healthCheckRepo, s.cfg)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceRepo, serviceRepo, s.cfg)
+	dependencyHandler := handlers.NewDependencyHandler(dependencyRepo, serviceRepo, stateRepo, s.cfg)
+	alertRuleHandler := handlers.NewAlertRuleHandler(alertRuleRepo, serviceRepo, s.cfg)
+	escalationHandler := handlers.NewEscalationHandler(escalationRepo, serviceRepo, userRepo, s.cfg)
+	oncallHandler := handlers.NewOnCallHandler(oncallRepo, userRepo, oncallResolver, s.cfg)
+	incidentHandler := handlers.NewIncidentHandler(incidentRepo, alertRepo, escalationRepo, notifierService, s.cfg)
+	integrationHandler := handlers.NewIntegrationHandler(alertRepo, serviceRepo, escalationRepo, notificationRepo, s.cfg)
+	notificationTemplateHandler := handlers.NewNotificationTemplateHandler(notificationTemplateRepo, s.cfg)
+	pushHandler := handlers.NewPushHandler(pushSubscriptionRepo, notifierService, s.cfg)
+	notificationRuleHandler := handlers.NewNotificationRuleHandler(contactMethodRepo, notificationRuleRepo, userRepo, serviceRepo, notifierService, s.cfg)
+	statusPageHandler := handlers.NewStatusPageHandler(statusPageRepo, statusPagePostRepo, statusPageSubscriberRepo, serviceRepo, stateRepo, healthCheckRepo, incidentRepo, maintenanceRepo, notifierService, s.cfg)
+	badgeHandler := handlers.NewBadgeHandler(badgeTokenRepo, serviceRepo, healthCheckRepo, s.cfg)
 
 	api := s.router.Group("/api/v1")
 	{
@@ -117,6 +162,21 @@ func (s *Server) setupRoutes() {
 		api.GET("/health/detailed", handlers.DetailedHealthCheck(s.db))
 		api.GET("/public/status", handlers.CheckPublicStatus)
 		api.GET("/public/info", handlers.GetPublicInfo)
+		// Hosted status pages, open to anyone unless password protected
+		api.GET("/status/:slug", statusPageHandler.GetPublicPage)
+		api.POST("/status/:slug/access", statusPageHandler.AccessPage)
+		api.POST("/status/:slug/subscribers", statusPageHandler.Subscribe)
+		api.GET("/status/:slug/subscribers/confirm", statusPageHandler.ConfirmSubscription)
+		api.GET("/status/:slug/subscribers/unsubscribe", statusPageHandler.Unsubscribe)
+		api.POST("/status/:slug/subscribers/unsubscribe", statusPageHandler.Unsubscribe)
+		api.GET("/badges/:token/status.svg", badgeHandler.StatusBadge)
+		api.GET("/badges/:token/uptime.svg", badgeHandler.UptimeBadge)
+		api.GET("/badges/:token/response-time.svg", badgeHandler.ResponseTimeBadge)
+		// PagerDuty and Opsgenie acknowledgements, authenticated by the
+		// subscription's inbound token rather than a session
+		api.POST("/integrations/:id/events", integrationHandler.ReceiveEvent)
+		// Twilio's SMS delivery receipts, authenticated by their signature
+		api.POST("/sms/twilio/status", integrationHandler.ReceiveSMSStatus)
 		// Serve OpenAPI specification with dynamic server URL
 		api.GET("/openapi.yaml", func(c *gin.Context) {
 			// Determine the server URL from request or environment
@@ -193,18 +253,116 @@ func (s *Server) setupRoutes() {
 		protected.PUT("/services/:id", serviceHandler.UpdateService)
 		protected.DELETE("/services/:id", serviceHandler.DeleteService)
 
+		protected.GET("/services/graph", dependencyHandler.GetGraph)
+		protected.GET("/services/:id/dependencies", dependencyHandler.GetDependencies)
+		protected.PUT("/services/:id/dependencies", dependencyHandler.SetDependencies)
+
+		protected.GET("/services/:id/alert-rules", alertRuleHandler.ListRules)
+		protected.POST("/services/:id/alert-rules", alertRuleHandler.CreateRule)
+		protected.GET("/services/:id/alert-rules/:ruleId", alertRuleHandler.GetRule)
+		protected.PUT("/services/:id/alert-rules/:ruleId", alertRuleHandler.UpdateRule)
+		protected.DELETE("/services/:id/alert-rules/:ruleId", alertRuleHandler.DeleteRule)
+
 		protected.GET("/services/:id/health-checks", healthCheckHandler.GetHealthChecks)
 		protected.POST("/services/:id/health-checks/trigger", healthCheckHandler.TriggerHealthCheck)
 
 		protected.GET("/services/:id/stats", statsHandler.GetServiceStats)
+
+		protected.GET("/services/:id/badges", badgeHandler.ListBadgeTokens)
+		protected.POST("/services/:id/badges", badgeHandler.CreateBadgeToken)
+		protected.DELETE("/services/:id/badges/:badgeId", badgeHandler.DeleteBadgeToken)
 		protected.GET("/stats/overview", statsHandler.GetOverview)
 
 		protected.GET("/alerts", alertHandler.ListAlerts)
 		protected.GET("/alerts/:id", alertHandler.GetAlert)
 		protected.PUT("/alerts/:id/resolve", alertHandler.ResolveAlert)
+		protected.POST("/alerts/:id/acknowledge", alertHandler.AcknowledgeAlert)
+		protected.POST("/alerts/:id/snooze", alertHandler.SnoozeAlert)
+		protected.DELETE("/alerts/:id/snooze", alertHandler.UnsnoozeAlert)
+		protected.PUT("/alerts/:id/assignee", alertHandler.AssignAlert)
+		protected.GET("/alerts/:id/timeline", alertHandler.GetAlertTimeline)
+		protected.POST("/alerts/:id/notes", alertHandler.AddAlertNote)
+		protected.GET("/alerts/:id/deliveries", alertHandler.ListDeliveries)
+		protected.POST("/alerts/:id/deliveries/:deliveryId/resend", alertHandler.ResendDelivery)
 		protected.POST("/alerts/subscriptions", alertHandler.CreateSubscription)
 		protected.GET("/alerts/subscriptions", alertHandler.ListSubscriptions)
 		protected.DELETE("/alerts/subscriptions/:id", alertHandler.DeleteSubscription)
+		protected.PUT("/alerts/subscriptions/:id/routing", alertHandler.UpdateSubscriptionRouting)
+		protected.PUT("/alerts/subscriptions/:id/quiet-hours", alertHandler.UpdateSubscriptionQuietHours)
+		protected.GET("/alerts/subscriptions/:id/held", alertHandler.ListHeldNotifications)
+		protected.POST("/alerts/subscriptions/:id/test", alertHandler.TestSubscription)
+		protected.POST("/alerts/subscriptions/:id/verify", alertHandler.VerifySubscription)
+
+		protected.GET("/push/vapid-public-key", pushHandler.GetVAPIDPublicKey)
+		protected.GET("/push/subscriptions", pushHandler.ListPushSubscriptions)
+		protected.POST("/push/subscriptions", pushHandler.SavePushSubscription)
+		protected.DELETE("/push/subscriptions/:id", pushHandler.DeletePushSubscription)
+
+		// Personal notification rules
+		protected.GET("/me/contact-methods", notificationRuleHandler.ListContactMethods)
+		protected.POST("/me/contact-methods", notificationRuleHandler.CreateContactMethod)
+		protected.DELETE("/me/contact-methods/:id", notificationRuleHandler.DeleteContactMethod)
+		protected.POST("/me/contact-methods/:id/test", notificationRuleHandler.TestContactMethod)
+		protected.POST("/me/contact-methods/:id/verify", notificationRuleHandler.VerifyContactMethod)
+		protected.GET("/me/notification-rules", notificationRuleHandler.ListNotificationRules)
+		protected.POST("/me/notification-rules", notificationRuleHandler.CreateNotificationRule)
+		protected.PUT("/me/notification-rules/:id", notificationRuleHandler.UpdateNotificationRule)
+		protected.DELETE("/me/notification-rules/:id", notificationRuleHandler.DeleteNotificationRule)
+
+		// Status pages
+		protected.GET("/status-pages", statusPageHandler.ListPages)
+		protected.POST("/status-pages", statusPageHandler.CreatePage)
+		protected.GET("/status-pages/:id", statusPageHandler.GetPage)
+		protected.PUT("/status-pages/:id", statusPageHandler.UpdatePage)
+		protected.DELETE("/status-pages/:id", statusPageHandler.DeletePage)
+		protected.GET("/status-pages/:id/preview", statusPageHandler.PreviewPage)
+		protected.GET("/status-pages/:id/posts", statusPageHandler.ListPosts)
+		protected.POST("/status-pages/:id/posts", statusPageHandler.CreatePost)
+		protected.PUT("/status-pages/:id/posts/:postId", statusPageHandler.UpdatePost)
+		protected.DELETE("/status-pages/:id/posts/:postId", statusPageHandler.DeletePost)
+		protected.POST("/status-pages/:id/posts/:postId/updates", statusPageHandler.AddPostUpdate)
+		protected.GET("/status-pages/:id/subscribers", statusPageHandler.ListSubscribers)
+		protected.DELETE("/status-pages/:id/subscribers/:subscriberId", statusPageHandler.DeleteSubscriber)
+
+		// Incidents
+		protected.GET("/incidents", incidentHandler.ListIncidents)
+		protected.GET("/incidents/correlation-rules", incidentHandler.GetCorrelationRules)
+		protected.PUT("/incidents/correlation-rules", incidentHandler.UpdateCorrelationRules)
+		protected.GET("/incidents/:id", incidentHandler.GetIncident)
+		protected.POST("/incidents/:id/acknowledge", incidentHandler.AcknowledgeIncident)
+		protected.PUT("/incidents/:id/resolve", incidentHandler.ResolveIncident)
+		protected.GET("/incidents/:id/timeline", incidentHandler.GetIncidentTimeline)
+		protected.POST("/incidents/:id/notes", incidentHandler.AddIncidentNote)
+
+		protected.GET("/maintenance-windows", maintenanceHandler.ListWindows)
+		protected.POST("/maintenance-windows", maintenanceHandler.CreateWindow)
+		protected.GET("/maintenance-windows/active", maintenanceHandler.ListActiveWindows)
+		protected.GET("/maintenance-windows/:id", maintenanceHandler.GetWindow)
+		protected.PUT("/maintenance-windows/:id", maintenanceHandler.UpdateWindow)
+		protected.DELETE("/maintenance-windows/:id", maintenanceHandler.DeleteWindow)
+
+		protected.GET("/escalation-policies", escalationHandler.ListPolicies)
+		protected.POST("/escalation-policies", escalationHandler.CreatePolicy)
+		protected.GET("/escalation-policies/:id", escalationHandler.GetPolicy)
+		protected.PUT("/escalation-policies/:id", escalationHandler.UpdatePolicy)
+		protected.DELETE("/escalation-policies/:id", escalationHandler.DeletePolicy)
+
+		protected.GET("/notification-templates", notificationTemplateHandler.ListTemplates)
+		protected.PUT("/notification-templates", notificationTemplateHandler.SaveTemplate)
+		protected.POST("/notification-templates/preview", notificationTemplateHandler.PreviewTemplate)
+		protected.DELETE("/notification-templates/:id", notificationTemplateHandler.DeleteTemplate)
+
+		protected.GET("/oncall/schedules", oncallHandler.ListSchedules)
+		protected.POST("/oncall/schedules", oncallHandler.CreateSchedule)
+		protected.GET("/oncall/schedules/:id", oncallHandler.GetSchedule)
+		protected.PUT("/oncall/schedules/:id", oncallHandler.UpdateSchedule)
+		protected.DELETE("/oncall/schedules/:id", oncallHandler.DeleteSchedule)
+		protected.GET("/oncall/schedules/:id/on-call", oncallHandler.GetOnCall)
+		protected.GET("/oncall/schedules/:id/shifts", oncallHandler.ListShifts)
+		protected.GET("/oncall/schedules/:id/overrides", oncallHandler.ListOverrides)
+		protected.POST("/oncall/schedules/:id/overrides", oncallHandler.CreateOverride)
+		protected.DELETE("/oncall/schedules/:id/overrides/:overrideId", oncallHandler.DeleteOverride)
+		protected.GET("/oncall/users/:userId/calendar.ics", oncallHandler.ExportCalendar)
 
 		protected.GET("/services/:id/reports/csv", reportHandler.ExportCSV)
 		protected.GET("/services/:id/reports/pdf", reportHandler.ExportPDF)
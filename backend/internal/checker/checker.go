@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -12,13 +15,64 @@ type HealthCheckResult struct {
 	ResponseTimeMs *int
 	StatusCode    *int
 	ErrorMessage  *string
+	ResolvedIP    *string // address dialed, also set when the connection failed; unset behind a proxy
+}
+
+// ipRecorder remembers the last address a dialer connected to, so results
+// carry the IP even when the connection or request fails. Requests sent
+// through a proxy dial the proxy rather than the target, so they record none.
+type ipRecorder struct {
+	mu      sync.Mutex
+	ip      string
+	proxied bool
+}
+
+// proxy picks the proxy from the environment, noting whether one is used
+func (r *ipRecorder) proxy(req *http.Request) (*url.URL, error) {
+	proxyURL, err := http.ProxyFromEnvironment(req)
+	if proxyURL != nil {
+		r.mu.Lock()
+		r.proxied = true
+		r.mu.Unlock()
+	}
+	return proxyURL, err
+}
+
+func (r *ipRecorder) dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if host, _, err := net.SplitHostPort(address); err == nil {
+				r.mu.Lock()
+				r.ip = host
+				r.mu.Unlock()
+			}
+			return nil
+		},
+	}
+}
+
+func (r *ipRecorder) get() *string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ip == "" || r.proxied {
+		return nil
+	}
+	ip := r.ip
+	return &ip
 }
 
 func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *HealthCheckResult {
 	start := time.Now()
 	
+	var recorder ipRecorder
 	client := &http.Client{
 		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:             recorder.proxy,
+			DialContext:       recorder.dialer(timeout).DialContext,
+			DisableKeepAlives: true,
+		},
 	}
 
 	resp, err := client.Get(url)
@@ -31,6 +85,7 @@ func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *Heal
 			Status:        "down",
 			ResponseTimeMs: &responseTimeMs,
 			ErrorMessage:  &errMsg,
+			ResolvedIP:    recorder.get(),
 		}
 	}
 	defer resp.Body.Close()
@@ -44,6 +99,7 @@ func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *Heal
 			ResponseTimeMs: &responseTimeMs,
 			StatusCode:    &statusCode,
 			ErrorMessage:  &errMsg,
+			ResolvedIP:    recorder.get(),
 		}
 	}
 
@@ -52,6 +108,7 @@ func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *Heal
 			Status:        "up",
 			ResponseTimeMs: &responseTimeMs,
 			StatusCode:    &statusCode,
+			ResolvedIP:    recorder.get(),
 		}
 	}
 
@@ -61,13 +118,15 @@ func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *Heal
 		ResponseTimeMs: &responseTimeMs,
 		StatusCode:    &statusCode,
 		ErrorMessage:  &errMsg,
+		ResolvedIP:    recorder.get(),
 	}
 }
 
 func CheckTCP(url string, timeout time.Duration) *HealthCheckResult {
 	start := time.Now()
 	
-	conn, err := net.DialTimeout("tcp", url, timeout)
+	var recorder ipRecorder
+	conn, err := recorder.dialer(timeout).Dial("tcp", url)
 	responseTime := time.Since(start)
 	responseTimeMs := int(responseTime.Milliseconds())
 
@@ -77,6 +136,7 @@ func CheckTCP(url string, timeout time.Duration) *HealthCheckResult {
 			Status:        "down",
 			ResponseTimeMs: &responseTimeMs,
 			ErrorMessage:  &errMsg,
+			ResolvedIP:    recorder.get(),
 		}
 	}
 	defer conn.Close()
@@ -84,6 +144,7 @@ func CheckTCP(url string, timeout time.Duration) *HealthCheckResult {
 	return &HealthCheckResult{
 		Status:        "up",
 		ResponseTimeMs: &responseTimeMs,
+		ResolvedIP:    recorder.get(),
 	}
 }
 
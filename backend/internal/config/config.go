@@ -17,6 +17,7 @@ type Config struct {
 	CORS        CORSConfig
 	OpenAI      OpenAIConfig
 	Ollama      OllamaConfig
+	SMS         SMSConfig
 }
 
 type ServerConfig struct {
@@ -54,6 +55,15 @@ type SMTPConfig struct {
 	FromEmail string
 }
 
+// SMSConfig authenticates the delivery receipts Twilio posts back for SMS
+// notifications
+type SMSConfig struct {
+	TwilioAuthToken string
+	// StatusCallbackURL is the receipt URL sent with each message, which
+	// Twilio signs its receipts with
+	StatusCallbackURL string
+}
+
 type HealthCheckConfig struct {
 	Interval   int
 	Timeout    int
@@ -121,6 +131,10 @@ func Load() (*Config, error) {
 		},
 		OpenAI: LoadOpenAIConfig(),
 		Ollama: LoadOllamaConfig(),
+		SMS: SMSConfig{
+			TwilioAuthToken:   getEnv("TWILIO_AUTH_TOKEN", ""),
+			StatusCallbackURL: getEnv("SMS_STATUS_CALLBACK_URL", ""),
+		},
 	}
 
 	return cfg, nil
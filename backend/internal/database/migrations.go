@@ -16,6 +16,34 @@ func RunMigrations(db *sql.DB) error {
 		createIndexes,
 		addLatencyThresholdColumn, // Add latency_threshold_ms if it doesn't exist
 		addEmailVerificationColumns, // Add email verification fields
+		addAdaptiveIntervalColumns,
+		createServiceStatesTable,
+		createMaintenanceWindowsTable,
+		addHealthCheckMaintenanceColumns,
+		createServiceDependenciesTable,
+		addAlertSuppressionColumns,
+		addAutoResolveColumns,
+		createAlertRulesTable,
+		createEscalationTables,
+		createOnCallTables,
+		createAlertLifecycle,
+		createIncidentTables,
+		addFlapDetectionColumns,
+		createNotificationOutbox,
+		addWebhookChannel,
+		createNotificationTemplates,
+		addSubscriptionVerification,
+		addSubscriptionRouting,
+		addSubscriptionQuietHours,
+		createNotificationHolds,
+		addDeliveryProviderStatus,
+		createPushSubscriptions,
+		createNotificationRules,
+		createStatusPages,
+		createStatusPagePosts,
+		createBadgeTokens,
+		addMaintenanceWindowTimeZone,
+		createPendingHealthChecks,
 	}
 	for _, migration := range migrations {
 		if _, err := db.Exec(migration); err != nil {
@@ -133,3 +161,610 @@ ADD COLUMN IF NOT EXISTS verification_token VARCHAR(255),
 ADD COLUMN IF NOT EXISTS verification_token_expires TIMESTAMP;
 `
 
+
+const addAdaptiveIntervalColumns = `
+ALTER TABLE services
+ADD COLUMN IF NOT EXISTS escalated_check_interval INTEGER DEFAULT NULL;
+
+ALTER TABLE health_checks
+ADD COLUMN IF NOT EXISTS check_interval INTEGER,
+ADD COLUMN IF NOT EXISTS interval_reason TEXT;
+`
+
+const createServiceStatesTable = `
+CREATE TABLE IF NOT EXISTS service_states (
+    service_id UUID PRIMARY KEY,
+    last_status VARCHAR(50) NOT NULL DEFAULT 'unknown',
+    effective_check_interval INTEGER NOT NULL,
+    interval_reason TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    consecutive_successes INTEGER NOT NULL DEFAULT 0,
+    last_checked_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+`
+
+const createMaintenanceWindowsTable = `
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL,
+    service_id UUID,
+    tag VARCHAR(255),
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    recurrence VARCHAR(20) NOT NULL DEFAULT 'none',
+    recurrence_ends_at TIMESTAMP,
+    created_by UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_organization_id ON maintenance_windows(organization_id);
+`
+
+const addHealthCheckMaintenanceColumns = `
+ALTER TABLE health_checks
+ADD COLUMN IF NOT EXISTS in_maintenance BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS maintenance_window_id UUID;
+`
+
+const createServiceDependenciesTable = `
+CREATE TABLE IF NOT EXISTS service_dependencies (
+    service_id UUID NOT NULL,
+    depends_on_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (service_id, depends_on_id),
+    CHECK (service_id <> depends_on_id),
+    FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE,
+    FOREIGN KEY (depends_on_id) REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_dependencies_depends_on_id ON service_dependencies(depends_on_id);
+`
+
+const addAlertSuppressionColumns = `
+ALTER TABLE alerts
+ADD COLUMN IF NOT EXISTS is_suppressed BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS suppressed_reason TEXT,
+ADD COLUMN IF NOT EXISTS caused_by_service_id UUID REFERENCES services(id) ON DELETE SET NULL;
+`
+
+const addAutoResolveColumns = `
+ALTER TABLE services
+ADD COLUMN IF NOT EXISTS auto_resolve BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE alerts
+ADD COLUMN IF NOT EXISTS resolved_by VARCHAR(255),
+ADD COLUMN IF NOT EXISTS outage_duration_seconds INTEGER;
+`
+
+const createAlertRulesTable = `
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(50) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    status_codes VARCHAR(3),
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    severity VARCHAR(20) NOT NULL DEFAULT 'medium',
+    is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_triggered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_service_id ON alert_rules(service_id);
+
+ALTER TABLE alerts
+ADD COLUMN IF NOT EXISTS alert_rule_id UUID REFERENCES alert_rules(id) ON DELETE SET NULL;
+`
+
+const createEscalationTables = `
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL,
+    service_id UUID,
+    tag VARCHAR(255),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_escalation_policies_organization_id ON escalation_policies(organization_id);
+
+CREATE TABLE IF NOT EXISTS escalation_steps (
+    policy_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    delay_minutes INTEGER NOT NULL DEFAULT 0,
+    channel VARCHAR(50) NOT NULL,
+    destination TEXT NOT NULL,
+    PRIMARY KEY (policy_id, position),
+    FOREIGN KEY (policy_id) REFERENCES escalation_policies(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS alert_escalations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL,
+    policy_id UUID NOT NULL,
+    next_step INTEGER NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (alert_id) REFERENCES alerts(id) ON DELETE CASCADE,
+    FOREIGN KEY (policy_id) REFERENCES escalation_policies(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_escalations_due ON alert_escalations(next_run_at) WHERE status = 'pending';
+
+ALTER TABLE alerts
+ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS acknowledged_by UUID REFERENCES users(id) ON DELETE SET NULL;
+`
+
+const createOnCallTables = `
+CREATE TABLE IF NOT EXISTS oncall_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_oncall_schedules_organization_id ON oncall_schedules(organization_id);
+
+CREATE TABLE IF NOT EXISTS oncall_layers (
+    schedule_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    rotation VARCHAR(20) NOT NULL,
+    handoff_time VARCHAR(5) NOT NULL,
+    handoff_weekday INTEGER NOT NULL DEFAULT 1,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP,
+    participant_ids UUID[] NOT NULL,
+    PRIMARY KEY (schedule_id, position),
+    FOREIGN KEY (schedule_id) REFERENCES oncall_schedules(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS oncall_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES oncall_schedules(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oncall_overrides_schedule_id ON oncall_overrides(schedule_id, ends_at);
+
+ALTER TABLE alert_subscriptions
+ADD COLUMN IF NOT EXISTS oncall_schedule_id UUID REFERENCES oncall_schedules(id) ON DELETE CASCADE;
+`
+
+const createAlertLifecycle = `
+ALTER TABLE alerts
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'triggered',
+ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP,
+ADD COLUMN IF NOT EXISTS assignee_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE alerts SET status = 'acknowledged'
+WHERE status = 'triggered' AND is_resolved = FALSE AND acknowledged_at IS NOT NULL;
+
+UPDATE alerts SET status = 'resolved'
+WHERE status <> 'resolved' AND is_resolved = TRUE;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_assignee_id ON alerts(assignee_id) WHERE assignee_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS alert_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    user_id UUID,
+    body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (alert_id) REFERENCES alerts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_events_alert_id ON alert_events(alert_id, created_at);
+`
+
+const createIncidentTables = `
+ALTER TABLE health_checks
+ADD COLUMN IF NOT EXISTS resolved_ip VARCHAR(45);
+
+CREATE TABLE IF NOT EXISTS incidents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL,
+    title TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'triggered',
+    lead_alert_id UUID,
+    started_at TIMESTAMP NOT NULL,
+    last_alert_at TIMESTAMP NOT NULL,
+    acknowledged_at TIMESTAMP,
+    acknowledged_by UUID,
+    resolved_at TIMESTAMP,
+    resolved_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (lead_alert_id) REFERENCES alerts(id) ON DELETE SET NULL,
+    FOREIGN KEY (acknowledged_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_organization_id ON incidents(organization_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_incidents_open ON incidents(organization_id, last_alert_at) WHERE status <> 'resolved';
+
+ALTER TABLE alerts
+ADD COLUMN IF NOT EXISTS incident_id UUID REFERENCES incidents(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_incident_id ON alerts(incident_id) WHERE incident_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS incident_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    incident_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    user_id UUID,
+    alert_id UUID,
+    body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (alert_id) REFERENCES alerts(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_events_incident_id ON incident_events(incident_id, created_at);
+
+CREATE TABLE IF NOT EXISTS incident_correlation_rules (
+    organization_id UUID PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    window_seconds INTEGER NOT NULL DEFAULT 300,
+    match_tags BOOLEAN NOT NULL DEFAULT TRUE,
+    match_ips BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE
+);
+`
+
+const addFlapDetectionColumns = `
+ALTER TABLE services
+ADD COLUMN IF NOT EXISTS flap_detection BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS flap_window INTEGER NOT NULL DEFAULT 21,
+ADD COLUMN IF NOT EXISTS flap_high_threshold DOUBLE PRECISION NOT NULL DEFAULT 50,
+ADD COLUMN IF NOT EXISTS flap_low_threshold DOUBLE PRECISION NOT NULL DEFAULT 25;
+`
+
+const createNotificationOutbox = `
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID,
+    channel VARCHAR(50) NOT NULL,
+    destination TEXT NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempt_count INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (alert_id) REFERENCES alerts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_alert_id ON notification_deliveries(alert_id, created_at);
+
+CREATE TABLE IF NOT EXISTS notification_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id UUID NOT NULL,
+    number INTEGER NOT NULL,
+    channel VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (delivery_id) REFERENCES notification_deliveries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_attempts_delivery_id ON notification_attempts(delivery_id, number);
+`
+
+const addWebhookChannel = `
+ALTER TABLE alert_subscriptions
+ALTER COLUMN destination TYPE TEXT,
+ADD COLUMN IF NOT EXISTS webhook_template TEXT,
+ADD COLUMN IF NOT EXISTS webhook_headers JSONB,
+ADD COLUMN IF NOT EXISTS webhook_secret VARCHAR(128);
+
+ALTER TABLE notification_deliveries
+ADD COLUMN IF NOT EXISTS subscription_id UUID REFERENCES alert_subscriptions(id) ON DELETE SET NULL;
+`
+
+const createNotificationTemplates = `
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL,
+    channel VARCHAR(50) NOT NULL,
+    alert_type VARCHAR(50) NOT NULL DEFAULT '',
+    event VARCHAR(20) NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    html_body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    UNIQUE (organization_id, channel, alert_type, event)
+);
+
+ALTER TABLE notification_deliveries
+ADD COLUMN IF NOT EXISTS html_body TEXT;
+`
+
+const addSubscriptionVerification = `
+-- Subscriptions made before verification existed keep receiving alerts
+ALTER TABLE alert_subscriptions
+ADD COLUMN IF NOT EXISTS verification_status VARCHAR(20) NOT NULL DEFAULT 'verified',
+ADD COLUMN IF NOT EXISTS verification_code VARCHAR(12),
+ADD COLUMN IF NOT EXISTS verification_expires_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS verification_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS disabled_reason TEXT;
+`
+
+const addSubscriptionRouting = `
+ALTER TABLE alert_subscriptions
+ADD COLUMN IF NOT EXISTS min_severity VARCHAR(20) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS alert_types TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS service_tags TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS events TEXT[] NOT NULL DEFAULT '{}';
+`
+
+const addSubscriptionQuietHours = `
+ALTER TABLE alert_subscriptions
+ADD COLUMN IF NOT EXISTS quiet_hours_start VARCHAR(5) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS quiet_hours_end VARCHAR(5) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+ADD COLUMN IF NOT EXISTS digest_minutes INTEGER NOT NULL DEFAULT 0;
+`
+
+const createNotificationHolds = `
+CREATE TABLE IF NOT EXISTS notification_holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES alert_subscriptions(id) ON DELETE CASCADE,
+    alert_id UUID REFERENCES alerts(id) ON DELETE SET NULL,
+    event VARCHAR(20) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    service_name VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    release_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_holds_release ON notification_holds(release_at);
+CREATE INDEX IF NOT EXISTS idx_notification_holds_subscription ON notification_holds(subscription_id);
+`
+
+const addDeliveryProviderStatus = `
+ALTER TABLE notification_deliveries
+ADD COLUMN IF NOT EXISTS provider_message_id VARCHAR(255),
+ADD COLUMN IF NOT EXISTS provider_status VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_provider_message ON notification_deliveries(provider_message_id);
+`
+
+const createPushSubscriptions = `
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh VARCHAR(255) NOT NULL,
+    auth VARCHAR(255) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user ON push_subscriptions(user_id);
+
+-- The VAPID key pair browsers subscribe with, generated on first use
+CREATE TABLE IF NOT EXISTS vapid_keys (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    public_key VARCHAR(255) NOT NULL,
+    private_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+const createNotificationRules = `
+ALTER TABLE services
+ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_services_owner ON services(owner_id);
+
+CREATE TABLE IF NOT EXISTS contact_methods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    destination VARCHAR(255) NOT NULL,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    verification_status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    verification_code VARCHAR(12),
+    verification_expires_at TIMESTAMP,
+    verification_attempts INTEGER NOT NULL DEFAULT 0,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, channel, destination)
+);
+
+CREATE TABLE IF NOT EXISTS notification_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_method_id UUID NOT NULL REFERENCES contact_methods(id) ON DELETE CASCADE,
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    owned_services_only BOOLEAN NOT NULL DEFAULT FALSE,
+    min_severity VARCHAR(20) NOT NULL DEFAULT '',
+    alert_types TEXT[] NOT NULL DEFAULT '{}',
+    service_tags TEXT[] NOT NULL DEFAULT '{}',
+    events TEXT[] NOT NULL DEFAULT '{}',
+    delay_minutes INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_rules_user ON notification_rules(user_id);
+
+-- Delayed rules' notifications, sent when due if the alert is still open
+CREATE TABLE IF NOT EXISTS notification_rule_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES notification_rules(id) ON DELETE CASCADE,
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    run_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (rule_id, alert_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_rule_runs_run_at ON notification_rule_runs(run_at);
+`
+
+const createStatusPages = `
+CREATE TABLE IF NOT EXISTS status_pages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_published BOOLEAN NOT NULL DEFAULT TRUE,
+    password_hash VARCHAR(255) NOT NULL DEFAULT '',
+    logo_url TEXT NOT NULL DEFAULT '',
+    favicon_url TEXT NOT NULL DEFAULT '',
+    brand_color VARCHAR(7) NOT NULL DEFAULT '',
+    support_url TEXT NOT NULL DEFAULT '',
+    footer_text TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_pages_org ON status_pages(organization_id);
+
+CREATE TABLE IF NOT EXISTS status_page_components (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    page_id UUID NOT NULL REFERENCES status_pages(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    service_ids UUID[] NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_page_components_page ON status_page_components(page_id, position);
+
+-- Daily uptime bars read 90 days of checks per service
+CREATE INDEX IF NOT EXISTS idx_health_checks_service_checked ON health_checks(service_id, checked_at);
+`
+
+const createStatusPagePosts = `
+CREATE TABLE IF NOT EXISTS status_page_posts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    page_id UUID NOT NULL REFERENCES status_pages(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    impact VARCHAR(20) NOT NULL DEFAULT 'none',
+    component_ids UUID[] NOT NULL DEFAULT '{}',
+    scheduled_for TIMESTAMP,
+    scheduled_until TIMESTAMP,
+    resolved_at TIMESTAMP,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_page_posts_page ON status_page_posts(page_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS status_page_post_updates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL REFERENCES status_page_posts(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    body TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_page_post_updates_post ON status_page_post_updates(post_id, created_at);
+
+-- End users following a page, kept apart from staff alert_subscriptions
+CREATE TABLE IF NOT EXISTS status_page_subscribers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    page_id UUID NOT NULL REFERENCES status_pages(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    destination TEXT NOT NULL,
+    confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+    confirm_token VARCHAR(64) UNIQUE,
+    confirm_sent_at TIMESTAMP,
+    unsubscribe_token VARCHAR(64) NOT NULL UNIQUE,
+    confirmed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (page_id, channel, destination)
+);
+`
+
+const createBadgeTokens = `
+CREATE TABLE IF NOT EXISTS badge_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_badge_tokens_service ON badge_tokens(service_id);
+`
+
+const addMaintenanceWindowTimeZone = `
+ALTER TABLE maintenance_windows
+ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+`
+
+// pending_health_checks holds the Lambda worker's raw results until the
+// backend records them like its own checks. A check is leased while it is
+// being recorded and deleted once it has been.
+const createPendingHealthChecks = `
+CREATE TABLE IF NOT EXISTS pending_health_checks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL,
+    response_time_ms INTEGER,
+    status_code INTEGER,
+    error_message TEXT,
+    resolved_ip VARCHAR(45),
+    checked_at TIMESTAMP NOT NULL,
+    leased_until TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_health_checks_checked_at ON pending_health_checks(checked_at);
+`
@@ -0,0 +1,109 @@
+package dependency
+
+import (
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+)
+
+// Graph maps each service to the services it depends on (its upstreams)
+type Graph map[uuid.UUID][]uuid.UUID
+
+// NewGraph builds a graph from dependency edges
+func NewGraph(edges []*models.ServiceDependency) Graph {
+	graph := make(Graph)
+	for _, edge := range edges {
+		graph[edge.ServiceID] = append(graph[edge.ServiceID], edge.DependsOnID)
+	}
+	return graph
+}
+
+// WithDependencies returns a copy of the graph in which serviceID depends on
+// exactly dependsOn, for checking a write before it is made
+func (g Graph) WithDependencies(serviceID uuid.UUID, dependsOn []uuid.UUID) Graph {
+	next := make(Graph, len(g)+1)
+	for id, upstreams := range g {
+		next[id] = upstreams
+	}
+	next[serviceID] = dependsOn
+	return next
+}
+
+// FindCycle returns a dependency cycle reachable from start, as the list of
+// services along it ending where it began, or nil if there is none
+func (g Graph) FindCycle(start uuid.UUID) []uuid.UUID {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := make(map[uuid.UUID]int)
+	var path []uuid.UUID
+
+	var visit func(id uuid.UUID) []uuid.UUID
+	visit = func(id uuid.UUID) []uuid.UUID {
+		switch marks[id] {
+		case visiting:
+			for i, p := range path {
+				if p == id {
+					return append(append([]uuid.UUID{}, path[i:]...), id)
+				}
+			}
+		case done:
+			return nil
+		}
+
+		marks[id] = visiting
+		path = append(path, id)
+		for _, upstream := range g[id] {
+			if cycle := visit(upstream); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		marks[id] = done
+		return nil
+	}
+
+	return visit(start)
+}
+
+// RootCause walks the upstreams of serviceID and returns the down service
+// that explains the outage: a down upstream whose own upstreams are all up.
+// It returns nil if no upstream is down.
+func (g Graph) RootCause(serviceID uuid.UUID, isDown func(uuid.UUID) bool) *uuid.UUID {
+	seen := map[uuid.UUID]bool{serviceID: true}
+	queue := append([]uuid.UUID{}, g[serviceID]...)
+	var firstDown *uuid.UUID
+
+	// Breadth-first, so the nearest down upstream is the fallback cause if
+	// every down upstream has a down upstream of its own (a cycle)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if !isDown(id) {
+			continue
+		}
+		if firstDown == nil {
+			cause := id
+			firstDown = &cause
+		}
+
+		upstreamDown := false
+		for _, upstream := range g[id] {
+			if isDown(upstream) {
+				upstreamDown = true
+			}
+			queue = append(queue, upstream)
+		}
+		if !upstreamDown {
+			cause := id
+			return &cause
+		}
+	}
+
+	return firstDown
+}
@@ -0,0 +1,176 @@
+package dependency
+
+import (
+	"testing"
+
+	"pulsegrid/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// services returns n fresh service IDs
+func services(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	return ids
+}
+
+func edge(service, dependsOn uuid.UUID) *models.ServiceDependency {
+	return &models.ServiceDependency{ServiceID: service, DependsOnID: dependsOn}
+}
+
+func TestFindCycle(t *testing.T) {
+	ids := services(5)
+	a, b, c, d, e := ids[0], ids[1], ids[2], ids[3], ids[4]
+
+	tests := []struct {
+		name string
+		// the stored edges, then the write being checked: service now
+		// depends on exactly dependsOn
+		edges     []*models.ServiceDependency
+		service   uuid.UUID
+		dependsOn []uuid.UUID
+		want      []uuid.UUID
+	}{
+		{
+			name:    "depends on itself",
+			service: a, dependsOn: []uuid.UUID{a},
+			want: []uuid.UUID{a, a},
+		},
+		{
+			name:    "two services depend on each other",
+			edges:   []*models.ServiceDependency{edge(a, b)},
+			service: b, dependsOn: []uuid.UUID{a},
+			want: []uuid.UUID{b, a, b},
+		},
+		{
+			name:    "longer cycle closed by the write",
+			edges:   []*models.ServiceDependency{edge(a, b), edge(b, c), edge(c, d)},
+			service: d, dependsOn: []uuid.UUID{e, a},
+			want: []uuid.UUID{d, a, b, c, d},
+		},
+		{
+			name:    "cycle further upstream",
+			edges:   []*models.ServiceDependency{edge(b, c), edge(c, b)},
+			service: a, dependsOn: []uuid.UUID{b},
+			want: []uuid.UUID{b, c, b},
+		},
+		{
+			name:    "diamond",
+			edges:   []*models.ServiceDependency{edge(a, b), edge(a, c), edge(b, d)},
+			service: c, dependsOn: []uuid.UUID{d},
+		},
+		{
+			name:    "chain",
+			edges:   []*models.ServiceDependency{edge(a, b), edge(b, c)},
+			service: c, dependsOn: []uuid.UUID{d, e},
+		},
+		{
+			name:    "write removes the cycle",
+			edges:   []*models.ServiceDependency{edge(a, b), edge(b, a)},
+			service: b, dependsOn: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := NewGraph(tt.edges)
+			assert.Equal(t, tt.want, graph.WithDependencies(tt.service, tt.dependsOn).FindCycle(tt.service))
+		})
+	}
+}
+
+func TestWithDependenciesLeavesGraphAlone(t *testing.T) {
+	ids := services(3)
+	a, b, c := ids[0], ids[1], ids[2]
+	graph := NewGraph([]*models.ServiceDependency{edge(a, b)})
+
+	next := graph.WithDependencies(a, []uuid.UUID{c})
+	assert.Equal(t, []uuid.UUID{c}, next[a])
+	assert.Equal(t, []uuid.UUID{b}, graph[a])
+	assert.Nil(t, graph.FindCycle(a))
+}
+
+func TestRootCause(t *testing.T) {
+	ids := services(5)
+	a, b, c, d, e := ids[0], ids[1], ids[2], ids[3], ids[4]
+
+	tests := []struct {
+		name  string
+		edges []*models.ServiceDependency
+		down  []uuid.UUID
+		// the cause for a, nil if none
+		want *uuid.UUID
+	}{
+		{
+			name:  "no upstreams",
+			edges: nil,
+			down:  []uuid.UUID{b},
+		},
+		{
+			name:  "upstreams all up",
+			edges: []*models.ServiceDependency{edge(a, b), edge(b, c)},
+		},
+		{
+			name:  "direct upstream down",
+			edges: []*models.ServiceDependency{edge(a, b), edge(b, c)},
+			down:  []uuid.UUID{b},
+			want:  &b,
+		},
+		{
+			name:  "deepest down upstream",
+			edges: []*models.ServiceDependency{edge(a, b), edge(b, c), edge(c, d)},
+			down:  []uuid.UUID{b, c, d},
+			want:  &d,
+		},
+		{
+			name:  "an up service breaks the chain",
+			edges: []*models.ServiceDependency{edge(a, b), edge(b, c), edge(c, d)},
+			down:  []uuid.UUID{b, d},
+			want:  &b,
+		},
+		{
+			name:  "diamond with a shared down upstream",
+			edges: []*models.ServiceDependency{edge(a, b), edge(a, c), edge(b, d), edge(c, d)},
+			down:  []uuid.UUID{b, c, d},
+			want:  &d,
+		},
+		{
+			name:  "down upstream on one branch",
+			edges: []*models.ServiceDependency{edge(a, b), edge(a, c), edge(c, e)},
+			down:  []uuid.UUID{c, e},
+			want:  &e,
+		},
+		{
+			name:  "cycle of down upstreams falls back to the nearest",
+			edges: []*models.ServiceDependency{edge(a, b), edge(b, c), edge(c, b)},
+			down:  []uuid.UUID{b, c},
+			want:  &b,
+		},
+		{
+			name:  "the service itself being down is not a cause",
+			edges: []*models.ServiceDependency{edge(a, b)},
+			down:  []uuid.UUID{a},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			down := make(map[uuid.UUID]bool)
+			for _, id := range tt.down {
+				down[id] = true
+			}
+			cause := NewGraph(tt.edges).RootCause(a, func(id uuid.UUID) bool { return down[id] })
+			if tt.want == nil {
+				assert.Nil(t, cause)
+				return
+			}
+			require.NotNil(t, cause)
+			assert.Equal(t, *tt.want, *cause)
+		})
+	}
+}
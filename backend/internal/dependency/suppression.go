@@ -0,0 +1,70 @@
+package dependency
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+)
+
+// Suppressor marks alerts as suppressed when an upstream dependency of the
+// alerting service is already down, so one outage notifies once
+type Suppressor struct {
+	dependencyRepo *repository.ServiceDependencyRepository
+	stateRepo      *repository.ServiceStateRepository
+	serviceRepo    *repository.ServiceRepository
+}
+
+func NewSuppressor(
+	dependencyRepo *repository.ServiceDependencyRepository,
+	stateRepo *repository.ServiceStateRepository,
+	serviceRepo *repository.ServiceRepository,
+) *Suppressor {
+	return &Suppressor{
+		dependencyRepo: dependencyRepo,
+		stateRepo:      stateRepo,
+		serviceRepo:    serviceRepo,
+	}
+}
+
+// SuppressAlert marks alert as "suppressed (caused by X)" if an upstream of
+// service is down, and reports whether it did. Lookup failures are logged
+// and leave the alert unsuppressed, so an alert is never lost to them.
+func (s *Suppressor) SuppressAlert(service *models.Service, alert *models.Alert) bool {
+	edges, err := s.dependencyRepo.ListByOrganization(service.OrganizationID)
+	if err != nil {
+		log.Printf("Failed to fetch dependencies for %s: %v", service.Name, err)
+		return false
+	}
+	graph := NewGraph(edges)
+	if len(graph[service.ID]) == 0 {
+		return false
+	}
+
+	states, err := s.stateRepo.ListByOrganization(service.OrganizationID)
+	if err != nil {
+		log.Printf("Failed to fetch service states for %s: %v", service.Name, err)
+		return false
+	}
+
+	cause := graph.RootCause(service.ID, func(id uuid.UUID) bool {
+		state, ok := states[id]
+		return ok && state.LastStatus == "down"
+	})
+	if cause == nil {
+		return false
+	}
+
+	causeName := cause.String()
+	if upstream, err := s.serviceRepo.GetByID(*cause); err == nil {
+		causeName = upstream.Name
+	}
+
+	reason := fmt.Sprintf("suppressed (caused by %s)", causeName)
+	alert.IsSuppressed = true
+	alert.SuppressedReason = &reason
+	alert.CausedByServiceID = cause
+	return true
+}
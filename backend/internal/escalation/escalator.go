@@ -0,0 +1,197 @@
+package escalation
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/alerting"
+)
+
+// claimLease is how long a claimed escalation stays hidden from other
+// workers. A worker that dies mid-step leaves the row to be retried after it.
+const claimLease = 2 * time.Minute
+
+// batchSize caps the escalations handled per run
+const batchSize = 100
+
+type Escalator struct {
+	escalationRepo *repository.EscalationRepository
+	alertRepo      *repository.AlertRepository
+	notifier       *notifier.NotifierService
+}
+
+func NewEscalator(escalationRepo *repository.EscalationRepository, alertRepo *repository.AlertRepository, notifierService *notifier.NotifierService) *Escalator {
+	return &Escalator{
+		escalationRepo: escalationRepo,
+		alertRepo:      alertRepo,
+		notifier:       notifierService,
+	}
+}
+
+// Start hands a new alert to the escalation policy covering its service. It
+// returns false when no policy applies, in which case the caller should
+// notify subscriptions as before. A first step without a delay is notified
+// straight away.
+func (e *Escalator) Start(service *models.Service, alert *models.Alert) bool {
+	policies, err := e.escalationRepo.ListPoliciesForService(service)
+	if err != nil {
+		log.Printf("Error fetching escalation policies for %s: %v", service.Name, err)
+		return false
+	}
+	policy := Select(policies, service)
+	if policy == nil {
+		return false
+	}
+
+	now := time.Now().UTC()
+	runAt, _ := NextRun(policy.Steps, 0, alert.CreatedAt)
+	immediate := !runAt.After(now)
+	if immediate {
+		// Claimed from the start, so a worker only picks it up if this
+		// process dies before the first step is sent
+		runAt = now.Add(claimLease)
+	}
+
+	escalation := &models.AlertEscalation{
+		AlertID:   alert.ID,
+		PolicyID:  policy.ID,
+		NextStep:  0,
+		NextRunAt: runAt,
+		Status:    StatusPending,
+	}
+	if err := e.escalationRepo.CreateEscalation(escalation); err != nil {
+		log.Printf("Error starting escalation for alert %s: %v", alert.ID, err)
+		return false
+	}
+
+	log.Printf("⏫ Alert %s following escalation policy %q", alert.ID, policy.Name)
+	if immediate {
+		e.runStep(escalation, alert, policy, now)
+	}
+	return true
+}
+
+// Renotify tells the step an alert's escalation last notified that the alert
+// was raised to a higher severity. It returns false when the alert has no
+// running escalation, in which case the caller should notify subscriptions
+// as before. An escalation that has not notified its first step yet sends
+// nothing: that step goes out with the new severity when it comes due.
+func (e *Escalator) Renotify(alert *models.Alert) bool {
+	escalation, err := e.escalationRepo.GetPendingEscalation(alert.ID)
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error fetching escalation for alert %s: %v", alert.ID, err)
+		return false
+	}
+	policy, err := e.escalationRepo.GetPolicy(escalation.PolicyID)
+	if err != nil {
+		log.Printf("Error fetching escalation policy %s: %v", escalation.PolicyID, err)
+		return false
+	}
+
+	last := escalation.NextStep - 1
+	if last < 0 || last >= len(policy.Steps) {
+		return true
+	}
+
+	step := policy.Steps[last]
+	if e.notifier != nil {
+		e.notifier.SendEscalationNotification(alert, step)
+	}
+	log.Printf("⏫ Alert %s raised to %s, escalation step %d re-notified via %s", alert.ID, alert.Severity, step.Position, step.Channel)
+
+	event := &models.AlertEvent{
+		AlertID: alert.ID,
+		Kind:    alerting.EventNotified,
+		Body:    fmt.Sprintf("Escalation step %d re-notified %s via %s of the raise to %s", step.Position, step.Destination, step.Channel, alert.Severity),
+	}
+	if err := e.alertRepo.AddEvent(event); err != nil {
+		log.Printf("Error recording escalation re-notification for alert %s: %v", alert.ID, err)
+	}
+	return true
+}
+
+// RunDue notifies every escalation step that has come due. The scheduler
+// calls it on each tick.
+func (e *Escalator) RunDue() {
+	now := time.Now().UTC()
+	escalations, err := e.escalationRepo.ClaimDue(now, claimLease, batchSize)
+	if err != nil {
+		log.Printf("Error claiming due escalations: %v", err)
+		return
+	}
+
+	for _, escalation := range escalations {
+		alert, err := e.alertRepo.GetByID(escalation.AlertID)
+		if err != nil {
+			log.Printf("Error fetching alert %s for escalation: %v", escalation.AlertID, err)
+			continue
+		}
+		policy, err := e.escalationRepo.GetPolicy(escalation.PolicyID)
+		if err != nil {
+			log.Printf("Error fetching escalation policy %s: %v", escalation.PolicyID, err)
+			continue
+		}
+		e.runStep(escalation, alert, policy, now)
+	}
+}
+
+// runStep notifies the escalation's next step, or stops the escalation if
+// the alert no longer needs it, then schedules the step after
+func (e *Escalator) runStep(escalation *models.AlertEscalation, alert *models.Alert, policy *models.EscalationPolicy, now time.Time) {
+	switch {
+	case alert.IsResolved:
+		e.finish(escalation, StatusResolved)
+		return
+	case alert.Status == alerting.StatusAcknowledged:
+		e.finish(escalation, StatusAcknowledged)
+		return
+	case escalation.NextStep >= len(policy.Steps):
+		e.finish(escalation, StatusCompleted)
+		return
+	case alerting.Snoozed(alert.SnoozedUntil, now):
+		// Hold the step until the snooze ends rather than skipping it
+		if err := e.escalationRepo.AdvanceEscalation(escalation.ID, escalation.NextStep, *alert.SnoozedUntil); err != nil {
+			log.Printf("Error deferring escalation for snoozed alert %s: %v", alert.ID, err)
+		}
+		return
+	}
+
+	step := policy.Steps[escalation.NextStep]
+	if e.notifier != nil {
+		e.notifier.SendEscalationNotification(alert, step)
+	}
+	log.Printf("⏫ Alert %s escalation step %d sent to %s", alert.ID, step.Position, step.Channel)
+
+	event := &models.AlertEvent{
+		AlertID: alert.ID,
+		Kind:    alerting.EventNotified,
+		Body:    fmt.Sprintf("Escalation step %d notified %s via %s", step.Position, step.Destination, step.Channel),
+	}
+	if err := e.alertRepo.AddEvent(event); err != nil {
+		log.Printf("Error recording escalation step for alert %s: %v", alert.ID, err)
+	}
+
+	next := escalation.NextStep + 1
+	runAt, ok := NextRun(policy.Steps, next, now)
+	if !ok {
+		e.finish(escalation, StatusCompleted)
+		return
+	}
+	if err := e.escalationRepo.AdvanceEscalation(escalation.ID, next, runAt); err != nil {
+		log.Printf("Error scheduling escalation step for alert %s: %v", alert.ID, err)
+	}
+}
+
+func (e *Escalator) finish(escalation *models.AlertEscalation, status string) {
+	if err := e.escalationRepo.FinishEscalation(escalation.ID, status); err != nil {
+		log.Printf("Error finishing escalation %s: %v", escalation.ID, err)
+	}
+}
@@ -0,0 +1,142 @@
+// Package escalation walks unacknowledged alerts through their escalation
+// policy: step 1 is notified when the alert opens, and each later step is
+// notified its delay after the one before, until someone acknowledges the
+// alert, it resolves, or the steps run out.
+package escalation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/chat"
+	"pulsegrid/backend/pkg/paging"
+	"pulsegrid/backend/pkg/sms"
+	"pulsegrid/backend/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+// Escalation statuses
+const (
+	StatusPending      = "pending"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+	StatusCompleted    = "completed"
+)
+
+// MaxSteps bounds the length of a policy
+const MaxSteps = 10
+
+// Channels lists the channels a step can notify: every channel a
+// subscription can use
+var Channels = []string{"email", "sms", "slack", "webhook", "teams", "discord", "telegram", "mattermost", "pagerduty", "opsgenie", "webpush"}
+
+// IsChannel reports whether a step can notify channel
+func IsChannel(channel string) bool {
+	for _, c := range Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a policy's name and steps
+func Validate(policy *models.EscalationPolicy) error {
+	if strings.TrimSpace(policy.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(policy.Steps) == 0 {
+		return fmt.Errorf("a policy needs at least one step")
+	}
+	if len(policy.Steps) > MaxSteps {
+		return fmt.Errorf("a policy can have at most %d steps", MaxSteps)
+	}
+
+	for i, step := range policy.Steps {
+		if step.DelayMinutes < 0 {
+			return fmt.Errorf("step %d: delay_minutes cannot be negative", i+1)
+		}
+		if !IsChannel(step.Channel) {
+			return fmt.Errorf("step %d: channel must be one of %s", i+1, strings.Join(Channels, ", "))
+		}
+		if strings.TrimSpace(step.Destination) == "" {
+			return fmt.Errorf("step %d: destination is required", i+1)
+		}
+		if err := validateDestination(step.Channel, step.Destination); err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// validateDestination checks a step's destination the way a subscription's
+// is checked on the same channel
+func validateDestination(channel, destination string) error {
+	switch {
+	case channel == "sms":
+		return sms.ValidateNumber(destination)
+	case channel == "slack" || channel == "webhook":
+		return webhook.ValidateURL(destination)
+	case channel == "webpush":
+		if _, err := uuid.Parse(destination); err != nil {
+			return fmt.Errorf("destination must be a user ID")
+		}
+	case chat.IsChannel(channel):
+		return chat.ValidateDestination(channel, destination)
+	case paging.IsChannel(channel):
+		return paging.ValidateKey(channel, destination)
+	}
+	return nil
+}
+
+// Select picks the policy that applies to a service from candidates: one
+// scoped to the service beats one scoped to a tag it carries, which beats an
+// organization-wide one. Ties go to the earliest candidate. It returns nil
+// when none applies.
+func Select(policies []*models.EscalationPolicy, service *models.Service) *models.EscalationPolicy {
+	var best *models.EscalationPolicy
+	bestRank := 0
+	for _, policy := range policies {
+		rank := specificity(policy, service)
+		if rank > bestRank {
+			best, bestRank = policy, rank
+		}
+	}
+	return best
+}
+
+// specificity ranks how closely a policy targets a service, 0 meaning it
+// doesn't apply at all
+func specificity(policy *models.EscalationPolicy, service *models.Service) int {
+	if policy.OrganizationID != service.OrganizationID || len(policy.Steps) == 0 {
+		return 0
+	}
+	if policy.ServiceID != nil {
+		if *policy.ServiceID == service.ID {
+			return 3
+		}
+		return 0
+	}
+	if policy.Tag != nil {
+		for _, tag := range service.Tags {
+			if tag == *policy.Tag {
+				return 2
+			}
+		}
+		return 0
+	}
+	return 1
+}
+
+// NextRun returns when the step at index next is due, given that the step
+// before it ran at from. ok is false once there are no steps left.
+func NextRun(steps []models.EscalationStep, next int, from time.Time) (time.Time, bool) {
+	if next < 0 || next >= len(steps) {
+		return time.Time{}, false
+	}
+	return from.Add(time.Duration(steps[next].DelayMinutes) * time.Minute), true
+}
@@ -0,0 +1,115 @@
+package escalation
+
+import (
+	"testing"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func steps(delays ...int) []models.EscalationStep {
+	var out []models.EscalationStep
+	for i, delay := range delays {
+		out = append(out, models.EscalationStep{Position: i + 1, DelayMinutes: delay, Channel: "email", Destination: "ops@example.com"})
+	}
+	return out
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(&models.EscalationPolicy{Name: "Primary", Steps: steps(0, 15, 30)}))
+
+	// Every subscription channel can be a step
+	valid := []models.EscalationStep{
+		{Channel: "email", Destination: "ops@example.com"},
+		{Channel: "sms", Destination: "+447700900123"},
+		{Channel: "slack", Destination: "https://hooks.slack.com/services/T0/B0/x"},
+		{Channel: "webhook", Destination: "https://example.com/hooks/pulsegrid"},
+		{Channel: "teams", Destination: "https://example.webhook.office.com/webhookb2/x"},
+		{Channel: "discord", Destination: "https://discord.com/api/webhooks/1/x"},
+		{Channel: "telegram", Destination: "-1001234567890"},
+		{Channel: "mattermost", Destination: "https://chat.example.com/hooks/x"},
+		{Channel: "pagerduty", Destination: "0123456789abcdef0123456789abcdef"},
+		{Channel: "opsgenie", Destination: "01234567-89ab-cdef-0123-456789abcdef"},
+		{Channel: "webpush", Destination: uuid.New().String()},
+	}
+	for _, step := range valid {
+		t.Run(step.Channel, func(t *testing.T) {
+			assert.NoError(t, Validate(&models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{step}}))
+		})
+	}
+
+	tests := []struct {
+		name   string
+		policy models.EscalationPolicy
+	}{
+		{"missing name", models.EscalationPolicy{Name: " ", Steps: steps(0)}},
+		{"no steps", models.EscalationPolicy{Name: "Primary"}},
+		{"too many steps", models.EscalationPolicy{Name: "Primary", Steps: steps(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)}},
+		{"negative delay", models.EscalationPolicy{Name: "Primary", Steps: steps(0, -5)}},
+		{"unknown channel", models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{{Channel: "pager", Destination: "x"}}}},
+		{"missing destination", models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{{Channel: "sms"}}}},
+		{"SMS to a number not in E.164", models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{{Channel: "sms", Destination: "555-0123"}}}},
+		{"webhook to a non-URL", models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{{Channel: "webhook", Destination: "example.com"}}}},
+		{"Telegram to a non-chat ID", models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{{Channel: "telegram", Destination: "ops team"}}}},
+		{"PagerDuty to a malformed key", models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{{Channel: "pagerduty", Destination: "short"}}}},
+		{"web push to a non-user", models.EscalationPolicy{Name: "Primary", Steps: []models.EscalationStep{{Channel: "webpush", Destination: "ops@example.com"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Validate(&tt.policy))
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	orgID := uuid.New()
+	service := &models.Service{ID: uuid.New(), OrganizationID: orgID, Tags: []string{"payments", "prod"}}
+	otherServiceID := uuid.New()
+	prod := "prod"
+	staging := "staging"
+
+	orgWide := &models.EscalationPolicy{Name: "org", OrganizationID: orgID, Steps: steps(0)}
+	byTag := &models.EscalationPolicy{Name: "tag", OrganizationID: orgID, Tag: &prod, Steps: steps(0)}
+	otherTag := &models.EscalationPolicy{Name: "other tag", OrganizationID: orgID, Tag: &staging, Steps: steps(0)}
+	byService := &models.EscalationPolicy{Name: "service", OrganizationID: orgID, ServiceID: &service.ID, Steps: steps(0)}
+	otherService := &models.EscalationPolicy{Name: "other service", OrganizationID: orgID, ServiceID: &otherServiceID, Steps: steps(0)}
+	otherOrg := &models.EscalationPolicy{Name: "other org", OrganizationID: uuid.New(), Steps: steps(0)}
+	empty := &models.EscalationPolicy{Name: "empty", OrganizationID: orgID, ServiceID: &service.ID}
+
+	tests := []struct {
+		name     string
+		policies []*models.EscalationPolicy
+		want     *models.EscalationPolicy
+	}{
+		{"none", nil, nil},
+		{"org wide", []*models.EscalationPolicy{orgWide}, orgWide},
+		{"tag beats org", []*models.EscalationPolicy{orgWide, byTag}, byTag},
+		{"service beats tag", []*models.EscalationPolicy{byTag, byService, orgWide}, byService},
+		{"non-matching scopes ignored", []*models.EscalationPolicy{otherTag, otherService, otherOrg}, nil},
+		{"policy without steps ignored", []*models.EscalationPolicy{empty, byTag}, byTag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Select(tt.policies, service))
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	from := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	policySteps := steps(0, 15, 30)
+
+	at, ok := NextRun(policySteps, 0, from)
+	assert.True(t, ok)
+	assert.Equal(t, from, at)
+
+	at, ok = NextRun(policySteps, 2, from)
+	assert.True(t, ok)
+	assert.Equal(t, from.Add(30*time.Minute), at)
+
+	_, ok = NextRun(policySteps, 3, from)
+	assert.False(t, ok)
+}
@@ -0,0 +1,201 @@
+package maintenance
+
+import (
+	"fmt"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+
+	// Windows name IANA time zones; embed the database so lookups work in
+	// minimal containers
+	_ "time/tzdata"
+)
+
+// Recurrence values accepted on a maintenance window
+const (
+	RecurrenceNone    = "none"
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// Validate checks that a window's times, time zone and recurrence are
+// consistent
+func Validate(w *models.MaintenanceWindow) error {
+	if !w.EndsAt.After(w.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if w.TimeZone != "" {
+		if _, err := time.LoadLocation(w.TimeZone); err != nil || w.TimeZone == "Local" {
+			return fmt.Errorf("time_zone must be an IANA time zone such as Europe/London")
+		}
+	}
+
+	switch w.Recurrence {
+	case RecurrenceNone:
+		return nil
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly:
+	default:
+		return fmt.Errorf("recurrence must be one of none, daily, weekly, monthly")
+	}
+
+	// Occurrences must not overlap, otherwise a window never really ends
+	if w.EndsAt.Sub(w.StartsAt) >= shortestGap[w.Recurrence] {
+		return fmt.Errorf("window is longer than its %s recurrence", w.Recurrence)
+	}
+	if w.RecurrenceEndsAt != nil && w.RecurrenceEndsAt.Before(w.StartsAt) {
+		return fmt.Errorf("recurrence_ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+// shortestGap is the least time between the starts of two occurrences: a
+// day loses an hour when clocks go forward, and a monthly window that starts
+// on the 31st comes round again on 28 February
+var shortestGap = map[string]time.Duration{
+	RecurrenceDaily:   23 * time.Hour,
+	RecurrenceWeekly:  7*24*time.Hour - time.Hour,
+	RecurrenceMonthly: 28*24*time.Hour - time.Hour,
+}
+
+// AppliesTo reports whether a window covers the given service
+func AppliesTo(w *models.MaintenanceWindow, service *models.Service) bool {
+	if w.OrganizationID != service.OrganizationID {
+		return false
+	}
+	if w.ServiceID != nil {
+		return *w.ServiceID == service.ID
+	}
+	if w.Tag != nil {
+		for _, tag := range service.Tags {
+			if tag == *w.Tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// OccurrenceAt returns the occurrence of w that contains t, if any
+func OccurrenceAt(w *models.MaintenanceWindow, t time.Time) (start, end time.Time, ok bool) {
+	t = t.UTC()
+	duration := w.EndsAt.Sub(w.StartsAt)
+
+	if w.Recurrence == "" || w.Recurrence == RecurrenceNone {
+		start, end = w.StartsAt.UTC(), w.EndsAt.UTC()
+		return start, end, !t.Before(start) && t.Before(end)
+	}
+
+	if t.Before(w.StartsAt) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	// Only the occurrence that started most recently can contain t, since
+	// occurrences never overlap
+	loc := location(w)
+	start = occurrenceStart(w, loc, occurrenceIndex(w, loc, t))
+	if w.RecurrenceEndsAt != nil && start.After(*w.RecurrenceEndsAt) {
+		return time.Time{}, time.Time{}, false
+	}
+	end = start.Add(duration)
+	if t.Before(end) {
+		return start.UTC(), end.UTC(), true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// ActiveWindow returns the first window in windows that is active at t
+func ActiveWindow(windows []*models.MaintenanceWindow, t time.Time) *models.MaintenanceWindow {
+	for _, w := range windows {
+		if _, _, ok := OccurrenceAt(w, t); ok {
+			return w
+		}
+	}
+	return nil
+}
+
+// location is the zone w recurs in, UTC if it has none or it can't be
+// loaded
+func location(w *models.MaintenanceWindow) *time.Location {
+	if w.TimeZone == "" || w.TimeZone == "UTC" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(w.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// occurrenceStart returns the start of the ith occurrence of w, at the same
+// wall-clock time in loc as the first. Monthly windows starting on a day a
+// shorter month lacks fall on that month's last day instead.
+func occurrenceStart(w *models.MaintenanceWindow, loc *time.Location, i int) time.Time {
+	start := w.StartsAt.In(loc)
+	year, month, day := start.Date()
+	hour, minute, sec := start.Clock()
+
+	switch w.Recurrence {
+	case RecurrenceDaily:
+		day += i
+	case RecurrenceWeekly:
+		day += 7 * i
+	case RecurrenceMonthly:
+		month += time.Month(i)
+		if last := daysIn(year, month); day > last {
+			day = last
+		}
+	}
+	return time.Date(year, month, day, hour, minute, sec, start.Nanosecond(), loc)
+}
+
+// daysIn returns the number of days in a month, which may be out of range
+// and is normalized like time.Date does
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// occurrenceIndex returns the index of the last occurrence of w to start at
+// or before t, which must not be before w starts
+func occurrenceIndex(w *models.MaintenanceWindow, loc *time.Location, t time.Time) int {
+	start := w.StartsAt.In(loc)
+	var n int
+	switch w.Recurrence {
+	case RecurrenceDaily:
+		n = int(t.Sub(start) / (24 * time.Hour))
+	case RecurrenceWeekly:
+		n = int(t.Sub(start) / (7 * 24 * time.Hour))
+	case RecurrenceMonthly:
+		local := t.In(loc)
+		n = (local.Year()-start.Year())*12 + int(local.Month()) - int(start.Month())
+	}
+
+	// The estimate is off by one when a DST change or a short month falls
+	// in between
+	for n > 0 && occurrenceStart(w, loc, n).After(t) {
+		n--
+	}
+	for !occurrenceStart(w, loc, n+1).After(t) {
+		n++
+	}
+	return n
+}
+
+// MarkCheck flags check as taken during whichever of windows is active at
+// its check time, and returns that window (nil if none is active)
+func MarkCheck(check *models.HealthCheck, windows []*models.MaintenanceWindow) *models.MaintenanceWindow {
+	at := check.CheckedAt
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	active := ActiveWindow(windows, at)
+	if active != nil {
+		check.InMaintenance = true
+		check.MaintenanceWindowID = &active.ID
+	}
+	return active
+}
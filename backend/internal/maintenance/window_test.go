@@ -0,0 +1,220 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utc(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func window(recurrence string, start time.Time, duration time.Duration) *models.MaintenanceWindow {
+	return &models.MaintenanceWindow{
+		ID:         uuid.New(),
+		StartsAt:   start,
+		EndsAt:     start.Add(duration),
+		Recurrence: recurrence,
+		TimeZone:   "UTC",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	start := utc(2024, 1, 1, 2, 0)
+	before := start.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		window  *models.MaintenanceWindow
+		wantErr string
+	}{
+		{"one-off", window(RecurrenceNone, start, 30*time.Hour), ""},
+		{"daily", window(RecurrenceDaily, start, 2*time.Hour), ""},
+		{"weekly", window(RecurrenceWeekly, start, 6*24*time.Hour), ""},
+		{"monthly", window(RecurrenceMonthly, start, 27*24*time.Hour), ""},
+		{"ends before it starts", window(RecurrenceNone, start, -time.Hour), "ends_at must be after starts_at"},
+		{"ends as it starts", window(RecurrenceNone, start, 0), "ends_at must be after starts_at"},
+		{"unknown recurrence", window("yearly", start, time.Hour), "recurrence must be one of none, daily, weekly, monthly"},
+		{"daily occurrences overlap", window(RecurrenceDaily, start, 24*time.Hour), "window is longer than its daily recurrence"},
+		// A day is 23 hours when clocks go forward
+		{"daily occurrences overlap across DST", window(RecurrenceDaily, start, 23*time.Hour+30*time.Minute), "window is longer than its daily recurrence"},
+		{"weekly occurrences overlap", window(RecurrenceWeekly, start, 7*24*time.Hour), "window is longer than its weekly recurrence"},
+		// February is the shortest month
+		{"monthly occurrences overlap", window(RecurrenceMonthly, start, 28*24*time.Hour), "window is longer than its monthly recurrence"},
+		{"recurrence ends before it starts", func() *models.MaintenanceWindow {
+			w := window(RecurrenceDaily, start, time.Hour)
+			w.RecurrenceEndsAt = &before
+			return w
+		}(), "recurrence_ends_at must be after starts_at"},
+		{"unknown time zone", func() *models.MaintenanceWindow {
+			w := window(RecurrenceDaily, start, time.Hour)
+			w.TimeZone = "Mars/Olympus_Mons"
+			return w
+		}(), "time_zone must be an IANA time zone such as Europe/London"},
+		{"local time zone", func() *models.MaintenanceWindow {
+			w := window(RecurrenceDaily, start, time.Hour)
+			w.TimeZone = "Local"
+			return w
+		}(), "time_zone must be an IANA time zone such as Europe/London"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.window)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOccurrenceAt(t *testing.T) {
+	recurrenceEnd := utc(2024, 1, 3, 2, 15)
+	limited := window(RecurrenceDaily, utc(2024, 1, 1, 2, 0), time.Hour)
+	limited.RecurrenceEndsAt = &recurrenceEnd
+
+	london := window(RecurrenceDaily, utc(2024, 3, 1, 2, 0), time.Hour)
+	london.TimeZone = "Europe/London"
+
+	// 23:00 on the last day of the month in New York is already the 1st in
+	// UTC, so the month end has to be found in local time
+	newYork := window(RecurrenceMonthly, time.Date(2024, 1, 31, 23, 0, 0, 0, mustLoad(t, "America/New_York")), 2*time.Hour)
+	newYork.TimeZone = "America/New_York"
+
+	tests := []struct {
+		name   string
+		window *models.MaintenanceWindow
+		at     time.Time
+		// the occurrence containing at, zero if none does
+		start time.Time
+	}{
+		{"one-off before it starts", window(RecurrenceNone, utc(2024, 1, 1, 10, 0), 2*time.Hour), utc(2024, 1, 1, 9, 59), time.Time{}},
+		{"one-off as it starts", window(RecurrenceNone, utc(2024, 1, 1, 10, 0), 2*time.Hour), utc(2024, 1, 1, 10, 0), utc(2024, 1, 1, 10, 0)},
+		{"one-off just before it ends", window(RecurrenceNone, utc(2024, 1, 1, 10, 0), 2*time.Hour), utc(2024, 1, 1, 11, 59), utc(2024, 1, 1, 10, 0)},
+		{"one-off as it ends", window(RecurrenceNone, utc(2024, 1, 1, 10, 0), 2*time.Hour), utc(2024, 1, 1, 12, 0), time.Time{}},
+
+		{"daily before the first occurrence", window(RecurrenceDaily, utc(2024, 1, 1, 2, 0), time.Hour), utc(2023, 12, 31, 2, 30), time.Time{}},
+		{"daily in a later occurrence", window(RecurrenceDaily, utc(2024, 1, 1, 2, 0), time.Hour), utc(2024, 1, 5, 2, 30), utc(2024, 1, 5, 2, 0)},
+		{"daily as an occurrence ends", window(RecurrenceDaily, utc(2024, 1, 1, 2, 0), time.Hour), utc(2024, 1, 5, 3, 0), time.Time{}},
+		{"daily between occurrences", window(RecurrenceDaily, utc(2024, 1, 1, 2, 0), time.Hour), utc(2024, 1, 5, 1, 59), time.Time{}},
+
+		{"weekly across midnight", window(RecurrenceWeekly, utc(2024, 1, 1, 22, 0), 4*time.Hour), utc(2024, 1, 9, 1, 0), utc(2024, 1, 8, 22, 0)},
+		{"weekly the day after", window(RecurrenceWeekly, utc(2024, 1, 1, 22, 0), 4*time.Hour), utc(2024, 1, 2, 3, 0), time.Time{}},
+		{"weekly on another weekday", window(RecurrenceWeekly, utc(2024, 1, 1, 22, 0), 4*time.Hour), utc(2024, 1, 10, 23, 0), time.Time{}},
+
+		{"monthly mid-month", window(RecurrenceMonthly, utc(2024, 1, 15, 1, 0), 2*time.Hour), utc(2024, 6, 15, 2, 0), utc(2024, 6, 15, 1, 0)},
+		{"monthly from the 31st in a leap February", window(RecurrenceMonthly, utc(2024, 1, 31, 1, 0), 2*time.Hour), utc(2024, 2, 29, 1, 30), utc(2024, 2, 29, 1, 0)},
+		{"monthly from the 31st in February", window(RecurrenceMonthly, utc(2024, 1, 31, 1, 0), 2*time.Hour), utc(2025, 2, 28, 1, 30), utc(2025, 2, 28, 1, 0)},
+		{"monthly from the 31st does not roll into March", window(RecurrenceMonthly, utc(2024, 1, 31, 1, 0), 2*time.Hour), utc(2024, 3, 2, 1, 30), time.Time{}},
+		{"monthly from the 31st in March", window(RecurrenceMonthly, utc(2024, 1, 31, 1, 0), 2*time.Hour), utc(2024, 3, 31, 1, 30), utc(2024, 3, 31, 1, 0)},
+		{"monthly from the 31st in April", window(RecurrenceMonthly, utc(2024, 1, 31, 1, 0), 2*time.Hour), utc(2024, 4, 30, 1, 30), utc(2024, 4, 30, 1, 0)},
+		{"monthly from the 31st in local time", newYork, utc(2024, 3, 1, 4, 30), utc(2024, 3, 1, 4, 0)},
+
+		{"last occurrence before the recurrence ends", limited, utc(2024, 1, 3, 2, 30), utc(2024, 1, 3, 2, 0)},
+		{"after the recurrence ends", limited, utc(2024, 1, 4, 2, 30), time.Time{}},
+
+		// 02:00 in London is 02:00 UTC in winter and 01:00 UTC in summer
+		{"local time in winter", london, utc(2024, 3, 10, 2, 30), utc(2024, 3, 10, 2, 0)},
+		{"local time in summer", london, utc(2024, 4, 10, 1, 30), utc(2024, 4, 10, 1, 0)},
+		{"local time in summer, UTC hour", london, utc(2024, 4, 10, 2, 30), time.Time{}},
+		{"local time the day clocks go forward", london, utc(2024, 3, 31, 1, 30), utc(2024, 3, 31, 1, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, Validate(tt.window))
+			start, end, ok := OccurrenceAt(tt.window, tt.at)
+			if tt.start.IsZero() {
+				assert.False(t, ok, "got occurrence %s to %s", start, end)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.start.Add(tt.window.EndsAt.Sub(tt.window.StartsAt)), end)
+		})
+	}
+}
+
+func TestOccurrenceAtEveryMonth(t *testing.T) {
+	// One occurrence each month, on the 31st or the last day
+	w := window(RecurrenceMonthly, utc(2023, 1, 31, 1, 0), time.Hour)
+	for month := time.January; month <= time.December; month++ {
+		last := daysIn(2023, month)
+		for day := 1; day <= last; day++ {
+			_, _, ok := OccurrenceAt(w, utc(2023, month, day, 1, 30))
+			assert.Equal(t, day == last, ok, "%s %d", month, day)
+		}
+	}
+}
+
+func TestActiveWindow(t *testing.T) {
+	nightly := window(RecurrenceDaily, utc(2024, 1, 1, 2, 0), time.Hour)
+	weekend := window(RecurrenceWeekly, utc(2024, 1, 6, 0, 0), 48*time.Hour)
+	windows := []*models.MaintenanceWindow{nightly, weekend}
+
+	assert.Equal(t, nightly, ActiveWindow(windows, utc(2024, 1, 3, 2, 30)))
+	assert.Equal(t, weekend, ActiveWindow(windows, utc(2024, 1, 14, 12, 0)))
+	assert.Nil(t, ActiveWindow(windows, utc(2024, 1, 3, 12, 0)))
+	assert.Nil(t, ActiveWindow(nil, utc(2024, 1, 3, 2, 30)))
+}
+
+func TestMarkCheck(t *testing.T) {
+	nightly := window(RecurrenceDaily, utc(2024, 1, 1, 2, 0), time.Hour)
+	windows := []*models.MaintenanceWindow{nightly}
+
+	check := &models.HealthCheck{Status: "down", CheckedAt: utc(2024, 1, 3, 2, 30)}
+	assert.Equal(t, nightly, MarkCheck(check, windows))
+	assert.True(t, check.InMaintenance)
+	require.NotNil(t, check.MaintenanceWindowID)
+	assert.Equal(t, nightly.ID, *check.MaintenanceWindowID)
+
+	check = &models.HealthCheck{Status: "down", CheckedAt: utc(2024, 1, 3, 3, 0)}
+	assert.Nil(t, MarkCheck(check, windows))
+	assert.False(t, check.InMaintenance)
+	assert.Nil(t, check.MaintenanceWindowID)
+
+	// A check not yet saved is marked as of now
+	now := window(RecurrenceNone, time.Now().Add(-time.Minute), time.Hour)
+	check = &models.HealthCheck{Status: "down"}
+	assert.Equal(t, now, MarkCheck(check, []*models.MaintenanceWindow{now}))
+	assert.True(t, check.InMaintenance)
+}
+
+func TestAppliesTo(t *testing.T) {
+	orgID := uuid.New()
+	service := &models.Service{ID: uuid.New(), OrganizationID: orgID, Tags: []string{"db"}}
+	otherID := uuid.New()
+	db, web := "db", "web"
+
+	tests := []struct {
+		name   string
+		window models.MaintenanceWindow
+		want   bool
+	}{
+		{"organization-wide", models.MaintenanceWindow{OrganizationID: orgID}, true},
+		{"another organization", models.MaintenanceWindow{OrganizationID: uuid.New()}, false},
+		{"the service", models.MaintenanceWindow{OrganizationID: orgID, ServiceID: &service.ID}, true},
+		{"another service", models.MaintenanceWindow{OrganizationID: orgID, ServiceID: &otherID}, false},
+		{"one of its tags", models.MaintenanceWindow{OrganizationID: orgID, Tag: &db}, true},
+		{"another tag", models.MaintenanceWindow{OrganizationID: orgID, Tag: &web}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AppliesTo(&tt.window, service))
+		})
+	}
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
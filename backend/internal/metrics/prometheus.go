@@ -52,7 +52,8 @@ func (e *PrometheusExporter) getUptimeMetrics() (string, error) {
 			s.id,
 			s.name,
 			COUNT(hc.id) as total_checks,
-			COUNT(CASE WHEN hc.status = 'up' THEN 1 END) as up_checks
+			COUNT(CASE WHEN hc.status = 'up' AND NOT hc.in_maintenance THEN 1 END) as up_checks,
+			COUNT(CASE WHEN hc.in_maintenance THEN 1 END) as maintenance_checks
 		FROM services s
 		LEFT JOIN health_checks hc ON s.id = hc.service_id
 		WHERE s.is_active = true
@@ -68,15 +69,18 @@ func (e *PrometheusExporter) getUptimeMetrics() (string, error) {
 	var output string
 	for rows.Next() {
 		var serviceID, serviceName string
-		var totalChecks, upChecks int
+		var totalChecks, upChecks, maintenanceChecks int
 
-		if err := rows.Scan(&serviceID, &serviceName, &totalChecks, &upChecks); err != nil {
+		if err := rows.Scan(&serviceID, &serviceName, &totalChecks, &upChecks, &maintenanceChecks); err != nil {
 			continue
 		}
 
+		// Checks during maintenance windows don't count towards uptime
 		uptime := 0.0
-		if totalChecks > 0 {
-			uptime = float64(upChecks) / float64(totalChecks) * 100
+		if countedChecks := totalChecks - maintenanceChecks; countedChecks > 0 {
+			uptime = float64(upChecks) / float64(countedChecks) * 100
+		} else if totalChecks > 0 {
+			uptime = 100
 		}
 
 		output += fmt.Sprintf(
@@ -87,6 +91,10 @@ func (e *PrometheusExporter) getUptimeMetrics() (string, error) {
 			"pulsegrid_service_total_checks{service_id=\"%s\",service_name=\"%s\"} %d\n",
 			serviceID, serviceName, totalChecks,
 		)
+		output += fmt.Sprintf(
+			"pulsegrid_service_maintenance_checks{service_id=\"%s\",service_name=\"%s\"} %d\n",
+			serviceID, serviceName, maintenanceChecks,
+		)
 	}
 
 	return output, nil
@@ -105,6 +113,7 @@ func (e *PrometheusExporter) getResponseTimeMetrics() (string, error) {
 		INNER JOIN health_checks hc ON s.id = hc.service_id
 		WHERE s.is_active = true 
 			AND hc.response_time_ms IS NOT NULL
+			AND NOT hc.in_maintenance
 			AND hc.checked_at > NOW() - INTERVAL '24 hours'
 		GROUP BY s.id, s.name
 	`
@@ -38,7 +38,14 @@ type Service struct {
 	Timeout           int        `json:"timeout"`
 	ExpectedStatusCode *int       `json:"expected_status_code,omitempty"`
 	LatencyThresholdMs *int       `json:"latency_threshold_ms,omitempty"`
+	EscalatedCheckInterval *int   `json:"escalated_check_interval,omitempty"` // interval used while the service is down
+	AutoResolve       bool       `json:"auto_resolve"` // resolve alerts automatically when the service recovers
+	FlapDetection     bool       `json:"flap_detection"`      // hold individual alerts while the service flaps
+	FlapWindow        int        `json:"flap_window"`         // checks the state-change percentage covers
+	FlapHighThreshold float64    `json:"flap_high_threshold"` // percent at which flapping starts
+	FlapLowThreshold  float64    `json:"flap_low_threshold"`  // percent below which flapping stops
 	Tags              []string   `json:"tags,omitempty"`
+	OwnerID           *uuid.UUID `json:"owner_id,omitempty"` // the user whose "services I own" rules cover it
 	IsActive          bool       `json:"is_active"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
@@ -51,39 +58,552 @@ type HealthCheck struct {
 	ResponseTimeMs *int       `json:"response_time_ms,omitempty"`
 	StatusCode    *int       `json:"status_code,omitempty"`
 	ErrorMessage  *string    `json:"error_message,omitempty"`
+	CheckInterval *int       `json:"check_interval,omitempty"`  // effective interval after this check
+	IntervalReason *string   `json:"interval_reason,omitempty"` // set when this check changed the interval
+	InMaintenance bool       `json:"in_maintenance"`             // ran during a maintenance window
+	MaintenanceWindowID *uuid.UUID `json:"maintenance_window_id,omitempty"`
+	ResolvedIP    *string    `json:"resolved_ip,omitempty"` // address the check connected to, used to correlate incidents
 	CheckedAt     time.Time  `json:"checked_at"`
 }
 
+// ServiceState is the scheduler's running view of a service between checks
+type ServiceState struct {
+	ServiceID              uuid.UUID  `json:"service_id"`
+	LastStatus             string     `json:"last_status"`
+	EffectiveCheckInterval int        `json:"effective_check_interval"`
+	IntervalReason         *string    `json:"interval_reason,omitempty"`
+	ConsecutiveFailures    int        `json:"consecutive_failures"`
+	ConsecutiveSuccesses   int        `json:"consecutive_successes"`
+	LastCheckedAt          *time.Time `json:"last_checked_at,omitempty"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+}
+
 type Alert struct {
 	ID         uuid.UUID  `json:"id"`
 	ServiceID  uuid.UUID  `json:"service_id"`
-	Type       string     `json:"type"` // downtime, latency, threshold
+	Type       string     `json:"type"` // downtime, latency, threshold, flapping
 	Message    string     `json:"message"`
 	Severity   string     `json:"severity"` // low, medium, high, critical
 	IsResolved bool       `json:"is_resolved"`
 	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy *string    `json:"resolved_by,omitempty"` // "auto" on recovery, otherwise the resolving user's ID
+	OutageDurationSeconds *int `json:"outage_duration_seconds,omitempty"`
+	// Suppressed alerts are recorded but not notified, because an upstream
+	// dependency is down and already alerting
+	IsSuppressed      bool       `json:"is_suppressed"`
+	SuppressedReason  *string    `json:"suppressed_reason,omitempty"`
+	CausedByServiceID *uuid.UUID `json:"caused_by_service_id,omitempty"`
+	AlertRuleID       *uuid.UUID `json:"alert_rule_id,omitempty"` // set for alerts raised by an alert rule
+	// Status is triggered, acknowledged or resolved. Acknowledging an alert
+	// stops its escalation policy and repeat notifications.
+	Status         string     `json:"status"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *uuid.UUID `json:"acknowledged_by,omitempty"`
+	SnoozedUntil   *time.Time `json:"snoozed_until,omitempty"` // no notifications are sent while snoozed
+	AssigneeID     *uuid.UUID `json:"assignee_id,omitempty"`
+	IncidentID     *uuid.UUID `json:"incident_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AlertEvent is an entry on an alert's timeline: a lifecycle change or a
+// note left by a user
+type AlertEvent struct {
+	ID        uuid.UUID  `json:"id"`
+	AlertID   uuid.UUID  `json:"alert_id"`
+	Kind      string     `json:"kind"` // triggered, acknowledged, resolved, escalated, notified, snoozed, unsnoozed, assigned, unassigned, note
+	UserID    *uuid.UUID `json:"user_id,omitempty"` // unset for events caused by the system
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Incident groups related alerts, typically one failure seen by several
+// services. Only the alert that opened an incident notifies; alerts grouped
+// into it afterwards are suppressed.
+type Incident struct {
+	ID               uuid.UUID         `json:"id"`
+	OrganizationID   uuid.UUID         `json:"organization_id"`
+	Title            string            `json:"title"`
+	Status           string            `json:"status"` // triggered, acknowledged, resolved
+	LeadAlertID      *uuid.UUID        `json:"lead_alert_id,omitempty"`
+	StartedAt        time.Time         `json:"started_at"`
+	LastAlertAt      time.Time         `json:"last_alert_at"`
+	AcknowledgedAt   *time.Time        `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy   *uuid.UUID        `json:"acknowledged_by,omitempty"`
+	ResolvedAt       *time.Time        `json:"resolved_at,omitempty"`
+	ResolvedBy       *string           `json:"resolved_by,omitempty"` // "auto" when its last alert recovered, otherwise a user ID
+	DurationSeconds  int               `json:"duration_seconds"`      // until resolved, or so far
+	AlertCount       int               `json:"alert_count"`
+	AffectedServices []IncidentService `json:"affected_services"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+type IncidentService struct {
+	ServiceID  uuid.UUID `json:"service_id"`
+	Name       string    `json:"name"`
+	AlertCount int       `json:"alert_count"`
+	OpenAlerts int       `json:"open_alerts"`
+}
+
+// IncidentEvent is an entry on an incident's timeline
+type IncidentEvent struct {
+	ID         uuid.UUID  `json:"id"`
+	IncidentID uuid.UUID  `json:"incident_id"`
+	Kind       string     `json:"kind"` // opened, alert_added, alert_resolved, acknowledged, resolved, note
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	AlertID    *uuid.UUID `json:"alert_id,omitempty"`
+	Body       string     `json:"body"`
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
+// CorrelationRules decide how an organization's alerts are grouped into
+// incidents; see pkg/correlation
+type CorrelationRules struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Enabled        bool      `json:"enabled"`
+	WindowSeconds  int       `json:"window_seconds"`
+	MatchTags      bool      `json:"match_tags"`
+	MatchIPs       bool      `json:"match_ips"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 type AlertSubscription struct {
 	ID             uuid.UUID  `json:"id"`
 	OrganizationID uuid.UUID  `json:"organization_id"`
 	ServiceID      *uuid.UUID `json:"service_id,omitempty"`
-	Channel        string     `json:"channel"` // email, sms, slack
+	Channel        string     `json:"channel"` // email, sms, slack, webhook, teams, discord, telegram, mattermost, pagerduty, opsgenie
+	Destination    string     `json:"destination"`
+	// OnCallScheduleID sends to whoever is on call for the schedule when the
+	// alert fires, instead of Destination
+	OnCallScheduleID *uuid.UUID `json:"oncall_schedule_id,omitempty"`
+	// Webhook configures the webhook channel, whose Destination is the URL
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	// InboundToken authenticates the acknowledgements a PagerDuty or
+	// Opsgenie subscription sends back; it is only shown when the
+	// subscription is created
+	InboundToken string `json:"-"`
+	// Routing filters narrow the alerts a subscription is notified about;
+	// each one left empty matches everything
+	MinSeverity string   `json:"min_severity,omitempty"` // low, medium, high, critical
+	AlertTypes  []string `json:"alert_types,omitempty"`  // downtime, latency, threshold, flapping
+	ServiceTags []string `json:"service_tags,omitempty"` // services with any of the tags
+	Events      []string `json:"events,omitempty"`       // triggered, escalated, resolved
+	// Quiet hours hold everything but critical alerts between
+	// QuietHoursStart and QuietHoursEnd ("HH:MM" in TimeZone) and send them
+	// as one digest when the window ends. DigestMinutes batches low-severity
+	// alerts into a digest sent at most that often. Email only.
+	QuietHoursStart string `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   string `json:"quiet_hours_end,omitempty"`
+	TimeZone        string `json:"time_zone"`
+	DigestMinutes   int    `json:"digest_minutes"`
+	// VerificationStatus is pending until the destination is confirmed,
+	// then verified. Only verified subscriptions are notified.
+	VerificationStatus string     `json:"verification_status"`
+	VerifiedAt         *time.Time `json:"verified_at,omitempty"`
+	// The confirmation code sent to an email or SMS destination
+	VerificationCode      string     `json:"-"`
+	VerificationExpiresAt *time.Time `json:"-"`
+	VerificationAttempts  int        `json:"-"`
+	// ConsecutiveFailures counts the dead-lettered notifications since the
+	// last delivered one; too many disable the subscription
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	DisabledReason      *string   `json:"disabled_reason,omitempty"`
+	IsActive            bool      `json:"is_active"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// WebhookConfig shapes and signs the requests of a webhook subscription
+type WebhookConfig struct {
+	// Template is a text/template for the request body; when empty the
+	// body is the JSON alert payload
+	Template string            `json:"template,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	// Secret signs each request; it is only shown when the subscription
+	// is created
+	Secret string `json:"-"`
+}
+
+// NotificationDelivery is one notification in the outbox: a message bound
+// for a single destination, retried until it is delivered or dead-lettered
+type NotificationDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	AlertID        *uuid.UUID `json:"alert_id,omitempty"`        // unset for notifications not about an alert
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"` // set for subscriptions; a webhook's headers and secret are read from it when sending
+	Channel        string     `json:"channel"`
 	Destination    string     `json:"destination"`
-	IsActive       bool       `json:"is_active"`
+	Subject        string     `json:"subject"`
+	Body           string     `json:"body"`
+	HTMLBody       string     `json:"html_body,omitempty"` // the HTML part of an email
+	Status         string     `json:"status"`              // pending, delivered, dead
+	AttemptCount   int        `json:"attempt_count"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	// ProviderMessageID and ProviderStatus are what an SMS provider said
+	// about the message, kept up to date by its delivery receipts
+	ProviderMessageID *string                `json:"provider_message_id,omitempty"`
+	ProviderStatus    *string                `json:"provider_status,omitempty"` // e.g. queued, delivered, undelivered
+	CreatedAt         time.Time              `json:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+	Attempts          []*NotificationAttempt `json:"attempts"`
+}
+
+// NotificationTemplate overrides the text an organization's notifications
+// carry on a channel. AlertType is empty for a template covering every type;
+// one for a specific type wins over it.
+type NotificationTemplate struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Channel        string    `json:"channel"`
+	AlertType      string    `json:"alert_type"`
+	Event          string    `json:"event"` // triggered, resolved
+	Subject        string    `json:"subject"`
+	Body           string    `json:"body"`
+	HTMLBody       string    `json:"html_body"` // email only
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// NotificationHold is a subscription's notification held back by quiet
+// hours or digest mode, to go out in a digest at ReleaseAt
+type NotificationHold struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	AlertID        *uuid.UUID `json:"alert_id,omitempty"`
+	Event          string     `json:"event"` // triggered, resolved
+	Severity       string     `json:"severity"`
+	ServiceName    string     `json:"service_name"`
+	Message        string     `json:"message"`
+	ReleaseAt      time.Time  `json:"release_at"`
 	CreatedAt      time.Time  `json:"created_at"`
 }
 
+// PushSubscription is a browser a user has allowed to show desktop
+// notifications, as its Web Push subscription. The keys encrypt payloads
+// for it and are never returned.
+type PushSubscription struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Endpoint   string     `json:"endpoint"`
+	P256dh     string     `json:"-"`
+	Auth       string     `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// ContactMethod is a way of reaching a user, which their notification rules
+// send to once it is verified
+type ContactMethod struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Channel is email, sms or webpush. A webpush method's Destination is
+	// the user's ID: it reaches every browser they registered.
+	Channel     string `json:"channel"`
+	Destination string `json:"destination"`
+	Label       string `json:"label,omitempty"`
+	// VerificationStatus is pending until the destination is confirmed,
+	// then verified
+	VerificationStatus    string     `json:"verification_status"`
+	VerifiedAt            *time.Time `json:"verified_at,omitempty"`
+	VerificationCode      string     `json:"-"`
+	VerificationExpiresAt *time.Time `json:"-"`
+	VerificationAttempts  int        `json:"-"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+// NotificationRule sends a user the alerts it matches at one of their
+// contact methods, DelayMinutes after they trigger if they are still
+// unacknowledged by then
+type NotificationRule struct {
+	ID              uuid.UUID `json:"id"`
+	UserID          uuid.UUID `json:"user_id"`
+	ContactMethodID uuid.UUID `json:"contact_method_id"`
+	// ServiceID limits the rule to one service, OwnedServicesOnly to the
+	// services the user owns
+	ServiceID         *uuid.UUID `json:"service_id,omitempty"`
+	OwnedServicesOnly bool       `json:"owned_services_only"`
+	// Routing filters, as on subscriptions; each one left empty matches
+	// everything
+	MinSeverity  string    `json:"min_severity,omitempty"`
+	AlertTypes   []string  `json:"alert_types,omitempty"`
+	ServiceTags  []string  `json:"service_tags,omitempty"`
+	Events       []string  `json:"events,omitempty"`
+	DelayMinutes int       `json:"delay_minutes"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// ContactMethod is filled in when rules are listed
+	ContactMethod *ContactMethod `json:"contact_method,omitempty"`
+}
+
+// NotificationRuleRun is a delayed rule's notification about an alert,
+// sent at RunAt unless the alert has been acknowledged or resolved
+type NotificationRuleRun struct {
+	ID        uuid.UUID `json:"id"`
+	RuleID    uuid.UUID `json:"rule_id"`
+	AlertID   uuid.UUID `json:"alert_id"`
+	RunAt     time.Time `json:"run_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StatusPage is an organization's public status page, published at
+// /status/:slug, showing the state of selected services grouped into
+// components
+type StatusPage struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Slug           string    `json:"slug"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description,omitempty"`
+	// IsPublished pages can be viewed; drafts are only seen by their
+	// organization
+	IsPublished bool `json:"is_published"`
+	// PasswordHash is the bcrypt hash of the password viewers must enter,
+	// empty for an open page
+	PasswordHash      string `json:"-"`
+	PasswordProtected bool   `json:"password_protected"`
+	// Branding
+	LogoURL    string                `json:"logo_url,omitempty"`
+	FaviconURL string                `json:"favicon_url,omitempty"`
+	BrandColor string                `json:"brand_color,omitempty"` // #RRGGBB
+	SupportURL string                `json:"support_url,omitempty"`
+	FooterText string                `json:"footer_text,omitempty"`
+	Components []StatusPageComponent `json:"components"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+}
+
+// StatusPageComponent groups services shown as one line of a status page
+type StatusPageComponent struct {
+	ID          uuid.UUID   `json:"id"`
+	Position    int         `json:"position"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	ServiceIDs  []uuid.UUID `json:"service_ids"`
+}
+
+// DailyUptime counts a service's checks on one UTC day, by status. Checks
+// made during maintenance are only counted as Maintenance.
+type DailyUptime struct {
+	Date        time.Time
+	Total       int
+	Up          int
+	Degraded    int
+	Down        int
+	Maintenance int
+}
+
+// StatusPagePost is an incident or scheduled maintenance written up by hand
+// on a status page, with the updates posted as it went along. It is separate
+// from the incidents correlated from alerts.
+type StatusPagePost struct {
+	ID             uuid.UUID   `json:"id"`
+	PageID         uuid.UUID   `json:"page_id"`
+	Kind           string      `json:"kind"` // incident, maintenance
+	Title          string      `json:"title"`
+	Status         string      `json:"status"` // investigating, identified, monitoring, resolved; or scheduled, in_progress, verifying, completed
+	Impact         string      `json:"impact"` // none, minor, major, critical
+	ComponentIDs   []uuid.UUID `json:"component_ids"`
+	ScheduledFor   *time.Time  `json:"scheduled_for,omitempty"`   // maintenance only
+	ScheduledUntil *time.Time  `json:"scheduled_until,omitempty"` // maintenance only
+	ResolvedAt     *time.Time  `json:"resolved_at,omitempty"`
+	CreatedBy      *uuid.UUID  `json:"created_by,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	// Updates is the post's timeline, oldest first
+	Updates []StatusPagePostUpdate `json:"updates"`
+}
+
+type StatusPagePostUpdate struct {
+	ID        uuid.UUID  `json:"id"`
+	PostID    uuid.UUID  `json:"post_id"`
+	Status    string     `json:"status"`
+	Body      string     `json:"body"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// StatusPageSubscriber is an end user following a page's posts by email or
+// webhook. Nothing is sent until the subscriber confirms through the link
+// they were sent.
+type StatusPageSubscriber struct {
+	ID               uuid.UUID  `json:"id"`
+	PageID           uuid.UUID  `json:"page_id"`
+	Channel          string     `json:"channel"` // email, webhook
+	Destination      string     `json:"destination"`
+	Confirmed        bool       `json:"confirmed"`
+	ConfirmToken     *string    `json:"-"`
+	ConfirmSentAt    *time.Time `json:"-"`
+	UnsubscribeToken string     `json:"-"`
+	ConfirmedAt      *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// BadgeToken lets SVG badges for a service be embedded without logging in.
+// Each token can be revoked on its own, breaking only the badges using it.
+type BadgeToken struct {
+	ID        uuid.UUID `json:"id"`
+	ServiceID uuid.UUID `json:"service_id"`
+	Name      string    `json:"name"` // where it is used, e.g. "README"
+	// Token is the secret part of the badge URLs; it is only shown when the
+	// token is created
+	Token      string     `json:"-"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NotificationAttempt records a single try at delivering a notification
+type NotificationAttempt struct {
+	ID          uuid.UUID `json:"id"`
+	DeliveryID  uuid.UUID `json:"delivery_id"`
+	Number      int       `json:"number"` // counts from 1 again after a manual resend
+	Channel     string    `json:"channel"`
+	Status      string    `json:"status"`                // succeeded, failed
+	StatusCode  *int      `json:"status_code,omitempty"` // set when the channel answered over HTTP
+	Error       *string   `json:"error,omitempty"`
+	LatencyMs   int       `json:"latency_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
 type ServiceStats struct {
 	ServiceID      uuid.UUID `json:"service_id"`
 	ServiceName    string    `json:"service_name"`
 	UptimePercent  float64   `json:"uptime_percent"`
 	AvgResponseTime float64   `json:"avg_response_time_ms"`
 	TotalChecks    int       `json:"total_checks"`
-	UpChecks       int       `json:"up_checks"`
-	DownChecks     int       `json:"down_checks"`
+	UpChecks       int       `json:"up_checks"`   // excludes checks during maintenance
+	DownChecks     int       `json:"down_checks"` // excludes checks during maintenance
+	MaintenanceChecks int    `json:"maintenance_checks"`
 	LastCheck      *time.Time `json:"last_check,omitempty"`
 	Status         string    `json:"status"`
 }
 
+
+// MaintenanceWindow suppresses alerts and excludes uptime for a service, every
+// service carrying a tag, or (with neither set) the whole organization.
+// Recurring windows repeat StartsAt/EndsAt every day, week or month (in UTC)
+// until RecurrenceEndsAt.
+type MaintenanceWindow struct {
+	ID               uuid.UUID  `json:"id"`
+	OrganizationID   uuid.UUID  `json:"organization_id"`
+	ServiceID        *uuid.UUID `json:"service_id,omitempty"`
+	Tag              *string    `json:"tag,omitempty"`
+	Title            string     `json:"title"`
+	Description      *string    `json:"description,omitempty"`
+	StartsAt         time.Time  `json:"starts_at"`
+	EndsAt           time.Time  `json:"ends_at"`
+	Recurrence       string     `json:"recurrence"` // none, daily, weekly, monthly
+	RecurrenceEndsAt *time.Time `json:"recurrence_ends_at,omitempty"`
+	// TimeZone is the IANA zone recurring windows keep their wall-clock
+	// start time in, so a 02:00 window stays at 02:00 across DST changes
+	TimeZone         string     `json:"time_zone"`
+	CreatedBy        *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// ServiceDependency records that ServiceID depends on DependsOnID
+type ServiceDependency struct {
+	ServiceID   uuid.UUID `json:"service_id"`
+	DependsOnID uuid.UUID `json:"depends_on_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AlertRule is a user-defined alert condition evaluated after every check of
+// its service, e.g. "uptime below 99% over 1h" or "more than 5 responses with
+// status 5xx in 15m". See pkg/alerting for the kinds and their thresholds.
+type AlertRule struct {
+	ID              uuid.UUID  `json:"id"`
+	ServiceID       uuid.UUID  `json:"service_id"`
+	Name            string     `json:"name"`
+	Kind            string     `json:"kind"` // uptime_below, latency_p95_above, consecutive_failures, status_code_count
+	Threshold       float64    `json:"threshold"`
+	StatusCodes     *string    `json:"status_codes,omitempty"` // "503" or "5xx", for status_code_count
+	WindowSeconds   int        `json:"window_seconds"`
+	CooldownSeconds int        `json:"cooldown_seconds"`
+	Severity        string     `json:"severity"`
+	IsEnabled       bool       `json:"is_enabled"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// EscalationPolicy notifies its steps one after another until an alert is
+// acknowledged or resolved. Like a maintenance window it applies to one
+// service, every service carrying a tag, or (with neither set) the whole
+// organization; the most specific policy wins. Services without a policy
+// notify their subscriptions instead.
+type EscalationPolicy struct {
+	ID             uuid.UUID        `json:"id"`
+	OrganizationID uuid.UUID        `json:"organization_id"`
+	ServiceID      *uuid.UUID       `json:"service_id,omitempty"`
+	Tag            *string          `json:"tag,omitempty"`
+	Name           string           `json:"name"`
+	Description    *string          `json:"description,omitempty"`
+	Steps          []EscalationStep `json:"steps"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// EscalationStep notifies one destination DelayMinutes after the previous
+// step (or after the alert opened, for the first step)
+type EscalationStep struct {
+	Position     int    `json:"position"`
+	DelayMinutes int    `json:"delay_minutes"`
+	Channel      string `json:"channel"` // any subscription channel
+	Destination  string `json:"destination"`
+}
+
+// AlertEscalation is an alert's progress through its escalation policy. The
+// scheduler picks up pending rows once NextRunAt passes, so steps survive a
+// restart.
+type AlertEscalation struct {
+	ID        uuid.UUID `json:"id"`
+	AlertID   uuid.UUID `json:"alert_id"`
+	PolicyID  uuid.UUID `json:"policy_id"`
+	NextStep  int       `json:"next_step"` // index into the policy's steps
+	NextRunAt time.Time `json:"next_run_at"`
+	Status    string    `json:"status"` // pending, acknowledged, resolved, completed
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OnCallSchedule is a stack of rotation layers in one time zone. Where layers
+// overlap the last one wins, and overrides beat every layer. See pkg/rotation
+// for the handoff rules.
+type OnCallSchedule struct {
+	ID             uuid.UUID     `json:"id"`
+	OrganizationID uuid.UUID     `json:"organization_id"`
+	Name           string        `json:"name"`
+	Description    *string       `json:"description,omitempty"`
+	TimeZone       string        `json:"time_zone"` // IANA name, e.g. Europe/London
+	Layers         []OnCallLayer `json:"layers"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// OnCallLayer hands off between its participants, in order, at HandoffTime
+// every day or every week on HandoffWeekday
+type OnCallLayer struct {
+	Position       int         `json:"position"`
+	Name           string      `json:"name"`
+	Rotation       string      `json:"rotation"`        // daily, weekly
+	HandoffTime    string      `json:"handoff_time"`    // HH:MM in the schedule's time zone
+	HandoffWeekday int         `json:"handoff_weekday"` // 0 (Sunday) to 6, for weekly rotations
+	StartsAt       time.Time   `json:"starts_at"`
+	EndsAt         *time.Time  `json:"ends_at,omitempty"`
+	ParticipantIDs []uuid.UUID `json:"participant_ids"`
+}
+
+// OnCallOverride puts a user on call for a stretch of time, ahead of every layer
+type OnCallOverride struct {
+	ID         uuid.UUID  `json:"id"`
+	ScheduleID uuid.UUID  `json:"schedule_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     time.Time  `json:"ends_at"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
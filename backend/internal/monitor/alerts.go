@@ -0,0 +1,354 @@
+// Package monitor records health checks and applies their outcome to stored
+// alerts. The scheduler, the manual trigger endpoint and the Lambda worker's
+// checks share it so all follow the same rules from pkg/alerting.
+package monitor
+
+import (
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/dependency"
+	"pulsegrid/backend/internal/escalation"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/notifier"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/correlation"
+
+	"github.com/google/uuid"
+)
+
+type AlertProcessor struct {
+	alertRepo       *repository.AlertRepository
+	ruleRepo        *repository.AlertRuleRepository
+	healthCheckRepo *repository.HealthCheckRepository
+	incidentRepo    *repository.IncidentRepository
+	suppressor      *dependency.Suppressor
+	escalator       *escalation.Escalator
+	notifier        *notifier.NotifierService
+}
+
+func NewAlertProcessor(
+	alertRepo *repository.AlertRepository,
+	ruleRepo *repository.AlertRuleRepository,
+	healthCheckRepo *repository.HealthCheckRepository,
+	incidentRepo *repository.IncidentRepository,
+	suppressor *dependency.Suppressor,
+	escalator *escalation.Escalator,
+	notifierService *notifier.NotifierService,
+) *AlertProcessor {
+	return &AlertProcessor{
+		alertRepo:       alertRepo,
+		ruleRepo:        ruleRepo,
+		healthCheckRepo: healthCheckRepo,
+		incidentRepo:    incidentRepo,
+		suppressor:      suppressor,
+		escalator:       escalator,
+		notifier:        notifierService,
+	}
+}
+
+// Process evaluates the current check against the previous one, the
+// service's alert rules and its open alerts, then opens, resolves or
+// escalates alerts to match. The current check must already be saved and
+// state must already include it.
+func (p *AlertProcessor) Process(service *models.Service, current, previous *models.HealthCheck, state *models.ServiceState) {
+	openAlerts, err := p.alertRepo.ListOpenByService(service.ID)
+	if err != nil {
+		log.Printf("Error fetching open alerts for %s: %v", service.Name, err)
+		return
+	}
+
+	byID := make(map[string]*models.Alert, len(openAlerts))
+	engineState := alerting.State{}
+	if previous != nil {
+		check := toCheck(previous)
+		engineState.PreviousCheck = &check
+	}
+	if state != nil {
+		engineState.ConsecutiveFailures = state.ConsecutiveFailures
+	}
+	for _, alert := range openAlerts {
+		byID[alert.ID.String()] = alert
+		openAlert := alerting.OpenAlert{
+			ID:       alert.ID.String(),
+			Type:     alert.Type,
+			Severity: alert.Severity,
+		}
+		if alert.AlertRuleID != nil {
+			openAlert.RuleID = alert.AlertRuleID.String()
+		}
+		engineState.OpenAlerts = append(engineState.OpenAlerts, openAlert)
+	}
+
+	engineService := alerting.Service{
+		Name:               service.Name,
+		LatencyThresholdMs: service.LatencyThresholdMs,
+		AutoResolve:        service.AutoResolve,
+		Flap:               ToFlapSettings(service),
+	}
+	if engineService.Flap.Enabled {
+		recent, err := p.healthCheckRepo.GetByServiceID(service.ID, engineService.Flap.WindowChecks)
+		if err != nil {
+			log.Printf("Error fetching recent checks for %s: %v", service.Name, err)
+		}
+		for _, check := range recent {
+			engineState.RecentChecks = append(engineState.RecentChecks, toCheck(check))
+		}
+	}
+
+	actions := alerting.Evaluate(engineService, toCheck(current), engineState)
+	actions = append(actions, p.evaluateRules(service, engineService, current, engineState)...)
+
+	for _, action := range actions {
+		switch action.Kind {
+		case alerting.ActionOpen:
+			p.open(service, action)
+		case alerting.ActionResolve:
+			p.resolve(service, byID[action.AlertID])
+		case alerting.ActionEscalate:
+			p.escalate(service, byID[action.AlertID], action)
+		}
+	}
+}
+
+// evaluateRules runs the service's enabled alert rules over the checks in
+// the longest rule window
+func (p *AlertProcessor) evaluateRules(service *models.Service, engineService alerting.Service, current *models.HealthCheck, state alerting.State) []alerting.Action {
+	rules, err := p.ruleRepo.ListEnabledByService(service.ID)
+	if err != nil {
+		log.Printf("Error fetching alert rules for %s: %v", service.Name, err)
+		return nil
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	engineRules := make([]alerting.Rule, 0, len(rules))
+	longest := 0
+	for _, rule := range rules {
+		engineRules = append(engineRules, ToRule(rule))
+		if rule.WindowSeconds > longest {
+			longest = rule.WindowSeconds
+		}
+	}
+
+	now := time.Now().UTC()
+	var window []alerting.Check
+	if longest > 0 {
+		checks, err := p.healthCheckRepo.ListSince(service.ID, now.Add(-time.Duration(longest)*time.Second))
+		if err != nil {
+			log.Printf("Error fetching recent checks for %s: %v", service.Name, err)
+			return nil
+		}
+		for _, check := range checks {
+			window = append(window, toCheck(check))
+		}
+	}
+
+	return alerting.EvaluateRules(engineService, engineRules, toCheck(current), window, state, now)
+}
+
+func (p *AlertProcessor) open(service *models.Service, action alerting.Action) {
+	alert := &models.Alert{
+		ServiceID:  service.ID,
+		Type:       action.AlertType,
+		Message:    action.Message,
+		Severity:   action.Severity,
+		IsResolved: false,
+	}
+	if ruleID, err := uuid.Parse(action.RuleID); err == nil {
+		alert.AlertRuleID = &ruleID
+	}
+
+	p.suppressor.SuppressAlert(service, alert)
+	if err := p.alertRepo.Create(alert); err != nil {
+		log.Printf("Error creating %s alert for %s: %v", alert.Type, service.Name, err)
+		return
+	}
+
+	// The cooldown runs from when the rule fired, whether or not the alert
+	// was suppressed
+	if alert.AlertRuleID != nil {
+		if err := p.ruleRepo.MarkTriggered(*alert.AlertRuleID, alert.CreatedAt); err != nil {
+			log.Printf("Error recording alert rule trigger for %s: %v", service.Name, err)
+		}
+	}
+
+	// Alerts that join an open incident come back suppressed; the alert
+	// that opened the incident has already notified
+	if p.incidentRepo != nil {
+		if _, err := p.incidentRepo.AttachAlert(service, alert); err != nil {
+			log.Printf("Error correlating %s alert for %s: %v", alert.Type, service.Name, err)
+		}
+	}
+
+	if alert.IsSuppressed {
+		log.Printf("⚠ %s alert for %s %s", alert.Type, service.Name, *alert.SuppressedReason)
+		return
+	}
+	log.Printf("⚠ %s alert created for %s", alert.Type, service.Name)
+
+	// An escalation policy takes over notifying from the subscriptions
+	go func() {
+		if p.escalator != nil && p.escalator.Start(service, alert) {
+			return
+		}
+		p.dispatch(alert, false)
+	}()
+}
+
+func (p *AlertProcessor) resolve(service *models.Service, alert *models.Alert) {
+	if alert == nil {
+		return
+	}
+
+	resolved, err := p.alertRepo.Resolve(alert.ID, alerting.ResolvedByAuto)
+	if err != nil {
+		log.Printf("Error resolving alert %s for %s: %v", alert.ID, service.Name, err)
+		return
+	}
+	// Someone resolved it by hand since it was loaded
+	if !resolved {
+		return
+	}
+
+	message := alerting.RecoveryMessage(service.Name, alert.Type, time.Since(alert.CreatedAt))
+	log.Printf("✓ %s", message)
+
+	if alert.IncidentID != nil && p.incidentRepo != nil {
+		var ok bool
+		if alert, message, ok = p.incidentRecovery(alert, message); !ok {
+			return
+		}
+	}
+
+	// Subscribers never heard about a suppressed alert, so don't announce its end
+	if p.notifier == nil || alert.IsSuppressed {
+		return
+	}
+	go func() {
+		if err := p.notifier.SendRecoveryNotifications(alert, message); err != nil {
+			log.Printf("Error sending recovery notifications: %v", err)
+		}
+	}()
+}
+
+// incidentRecovery records a resolved alert on its incident. An incident's
+// recovery is announced once, when its last alert resolves, on behalf of the
+// alert that opened it; ok is false while the incident stays open.
+func (p *AlertProcessor) incidentRecovery(alert *models.Alert, message string) (*models.Alert, string, bool) {
+	incident, err := p.incidentRepo.AlertResolved(*alert.IncidentID, alert.ID, alerting.ResolvedByAuto, message)
+	if err != nil {
+		log.Printf("Error recording recovery on incident %s: %v", *alert.IncidentID, err)
+		return alert, message, true
+	}
+	if incident == nil || incident.LeadAlertID == nil {
+		return nil, "", false
+	}
+
+	log.Printf("✓ Incident %q resolved", incident.Title)
+	lead := alert
+	if *incident.LeadAlertID != alert.ID {
+		if lead, err = p.alertRepo.GetByID(*incident.LeadAlertID); err != nil {
+			log.Printf("Error fetching lead alert of incident %s: %v", incident.ID, err)
+			return nil, "", false
+		}
+	}
+	if len(incident.AffectedServices) <= 1 {
+		return lead, message, true
+	}
+
+	names := make([]string, 0, len(incident.AffectedServices))
+	for _, affected := range incident.AffectedServices {
+		names = append(names, affected.Name)
+	}
+	duration := time.Duration(incident.DurationSeconds) * time.Second
+	return lead, correlation.RecoveryMessage(incident.Title, names, duration), true
+}
+
+func (p *AlertProcessor) escalate(service *models.Service, alert *models.Alert, action alerting.Action) {
+	if alert == nil {
+		return
+	}
+
+	if err := p.alertRepo.Escalate(alert.ID, action.Severity, action.Message); err != nil {
+		log.Printf("Error escalating alert %s for %s: %v", alert.ID, service.Name, err)
+		return
+	}
+	alert.Severity = action.Severity
+	alert.Message = action.Message
+
+	log.Printf("⚠ %s alert escalated to %s for %s", alert.Type, alert.Severity, service.Name)
+	// Acknowledged and snoozed alerts already have someone on them
+	if alert.IsSuppressed || !alerting.ShouldRenotify(alert.Status, alert.SnoozedUntil, time.Now()) {
+		return
+	}
+
+	// An alert its escalation policy owns stays with the policy's steps,
+	// as when it opened
+	go func() {
+		if p.escalator != nil && p.escalator.Renotify(alert) {
+			return
+		}
+		p.dispatch(alert, true)
+	}()
+}
+
+// dispatch notifies the alert's subscribers in the background, as an
+// escalation if escalated is set
+func (p *AlertProcessor) dispatch(alert *models.Alert, escalated bool) {
+	if p.notifier == nil {
+		return
+	}
+
+	go func() {
+		send := p.notifier.SendAlertNotifications
+		if escalated {
+			send = p.notifier.SendEscalatedNotifications
+		}
+		if err := send(alert); err != nil {
+			log.Printf("Error sending alert notifications: %v", err)
+		}
+	}()
+}
+
+func toCheck(check *models.HealthCheck) alerting.Check {
+	return alerting.Check{
+		Status:         check.Status,
+		ResponseTimeMs: check.ResponseTimeMs,
+		StatusCode:     check.StatusCode,
+		ErrorMessage:   check.ErrorMessage,
+		InMaintenance:  check.InMaintenance,
+		CheckedAt:      check.CheckedAt,
+	}
+}
+
+// ToFlapSettings reads a service's flap detection settings for the alerting
+// engine
+func ToFlapSettings(service *models.Service) alerting.FlapSettings {
+	return alerting.FlapSettings{
+		Enabled:       service.FlapDetection,
+		WindowChecks:  service.FlapWindow,
+		HighThreshold: service.FlapHighThreshold,
+		LowThreshold:  service.FlapLowThreshold,
+	}
+}
+
+// ToRule converts a stored alert rule for the alerting engine
+func ToRule(rule *models.AlertRule) alerting.Rule {
+	engineRule := alerting.Rule{
+		ID:              rule.ID.String(),
+		Name:            rule.Name,
+		Kind:            rule.Kind,
+		Threshold:       rule.Threshold,
+		WindowSeconds:   rule.WindowSeconds,
+		CooldownSeconds: rule.CooldownSeconds,
+		Severity:        rule.Severity,
+		LastTriggeredAt: rule.LastTriggeredAt,
+	}
+	if rule.StatusCodes != nil {
+		engineRule.StatusCodes = *rule.StatusCodes
+	}
+	return engineRule
+}
@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/maintenance"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/internal/scheduler"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// pendingBatchSize caps the Lambda worker's checks recorded per run
+	pendingBatchSize = 100
+	// pendingLease is how long a run has to record its batch before the
+	// checks are claimed again
+	pendingLease = 2 * time.Minute
+)
+
+// Recorder saves the result of a health check and applies it: it flags
+// checks taken during maintenance, tracks the service's adaptive interval
+// and opens or resolves alerts. The scheduler, the manual trigger endpoint
+// and the Lambda worker's checks all go through it, so a check has the same
+// effect wherever it ran.
+type Recorder struct {
+	healthCheckRepo *repository.HealthCheckRepository
+	serviceRepo     *repository.ServiceRepository
+	stateRepo       *repository.ServiceStateRepository
+	maintenanceRepo *repository.MaintenanceWindowRepository
+	alertProcessor  *AlertProcessor
+}
+
+func NewRecorder(
+	healthCheckRepo *repository.HealthCheckRepository,
+	serviceRepo *repository.ServiceRepository,
+	stateRepo *repository.ServiceStateRepository,
+	maintenanceRepo *repository.MaintenanceWindowRepository,
+	alertProcessor *AlertProcessor,
+) *Recorder {
+	return &Recorder{
+		healthCheckRepo: healthCheckRepo,
+		serviceRepo:     serviceRepo,
+		stateRepo:       stateRepo,
+		maintenanceRepo: maintenanceRepo,
+		alertProcessor:  alertProcessor,
+	}
+}
+
+// Record saves check, taken at its CheckedAt or now if that is zero, and
+// applies it to the service's state and alerts. state is the service's state
+// before the check, nil if it has never been checked; the new state is
+// returned. Only a failure to save the check is returned as an error.
+func (r *Recorder) Record(service *models.Service, check *models.HealthCheck, state *models.ServiceState) (*models.ServiceState, error) {
+	check.ServiceID = service.ID
+	if check.CheckedAt.IsZero() {
+		check.CheckedAt = time.Now().UTC()
+	}
+
+	// Checks during a maintenance window still run but are flagged so they
+	// neither alert nor count against uptime
+	if windows, err := r.maintenanceRepo.ListForService(service, check.CheckedAt); err != nil {
+		log.Printf("Error fetching maintenance windows for %s: %v", service.Name, err)
+	} else {
+		maintenance.MarkCheck(check, windows)
+	}
+
+	// Track the effective interval; it tightens while the service is down
+	state = scheduler.ApplyCheck(state, service, check)
+
+	if err := r.healthCheckRepo.Create(check); err != nil {
+		return state, err
+	}
+
+	if err := r.stateRepo.Upsert(state); err != nil {
+		log.Printf("Error saving state for %s: %v", service.Name, err)
+	}
+
+	// Open, resolve or escalate alerts
+	previous, err := r.healthCheckRepo.GetPreviousCheckBefore(service.ID, check.CheckedAt)
+	if err != nil {
+		log.Printf("Error fetching previous health check for %s: %v", service.Name, err)
+	}
+	r.alertProcessor.Process(service, check, previous, state)
+
+	return state, nil
+}
+
+// RecordPending records the checks the Lambda worker has run since the last
+// call, oldest first. Checks of services deleted or paused since are dropped.
+// A check is deleted only once it is recorded; if recording one fails, it and
+// the rest of the batch are released so the next call retries them in order.
+// Calls must not overlap, or a service's checks could be applied out of order.
+func (r *Recorder) RecordPending() {
+	checks, err := r.healthCheckRepo.ClaimPending(time.Now().UTC(), pendingLease, pendingBatchSize)
+	if err != nil {
+		log.Printf("Error claiming pending health checks: %v", err)
+		return
+	}
+
+	services := make(map[uuid.UUID]*models.Service)
+	for i, check := range checks {
+		// Record gives the check its own ID
+		pendingID := check.ID
+
+		service, ok := services[check.ServiceID]
+		if !ok {
+			service, err = r.serviceRepo.GetByID(check.ServiceID)
+			if err == sql.ErrNoRows {
+				service = nil
+			} else if err != nil {
+				log.Printf("Error fetching service %s for a pending health check: %v", check.ServiceID, err)
+				r.release(checks[i:])
+				return
+			}
+			services[check.ServiceID] = service
+		}
+
+		if service != nil && service.IsActive {
+			state, err := r.stateRepo.GetByServiceID(service.ID)
+			if err != nil {
+				log.Printf("Error fetching state for %s: %v", service.Name, err)
+			}
+			if _, err := r.Record(service, check, state); err != nil {
+				log.Printf("Error saving health check for %s: %v", service.Name, err)
+				check.ID = pendingID
+				r.release(checks[i:])
+				return
+			}
+		}
+
+		if err := r.healthCheckRepo.DeletePending(pendingID); err != nil {
+			log.Printf("Error deleting pending health check %s: %v", pendingID, err)
+		}
+	}
+}
+
+// release ends the lease on checks not yet recorded
+func (r *Recorder) release(checks []*models.HealthCheck) {
+	ids := make([]uuid.UUID, len(checks))
+	for i, check := range checks {
+		ids[i] = check.ID
+	}
+	if err := r.healthCheckRepo.ReleasePending(ids); err != nil {
+		log.Printf("Error releasing pending health checks: %v", err)
+	}
+}
+
+// RunPending calls RecordPending every interval, one call at a time. Only
+// one process should run it, so each service's checks are recorded in order.
+func (r *Recorder) RunPending(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.RecordPending()
+	}
+}
@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"log"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/chat"
+	"pulsegrid/backend/pkg/message"
+)
+
+// chatBody renders a notification as a chat channel's rich message, titled
+// with the rendered subject and linking to the service on the dashboard
+func (ns *NotifierService) chatBody(channel, destination string, n *notification, rendered message.Rendered) string {
+	msg := chat.Message{
+		Title:    rendered.Subject,
+		Service:  n.data.Service.Name,
+		Severity: n.alert.Severity,
+		Resolved: n.data.Event == message.EventResolved,
+		Error:    rendered.Body,
+		Link:     n.data.Link,
+	}
+
+	body, err := chat.Render(channel, destination, msg)
+	if err != nil {
+		log.Printf("Error rendering %s message: %v", channel, err)
+		return rendered.Body
+	}
+	return string(body)
+}
+
+// sendChat posts a message rendered by chatBody
+func (ns *NotifierService) sendChat(delivery *models.NotificationDelivery) (int, error) {
+	statusCode, err := ns.chat.Post(delivery.Channel, delivery.Destination, []byte(delivery.Body))
+	if err != nil {
+		return statusCode, err
+	}
+	log.Printf("✅ %s notification sent to %s", delivery.Channel, delivery.Destination)
+	return statusCode, nil
+}
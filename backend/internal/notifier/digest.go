@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/digest"
+	"pulsegrid/backend/pkg/verification"
+
+	"github.com/google/uuid"
+)
+
+// subscriptionSchedule returns a subscription's quiet hours and digest
+// settings
+func subscriptionSchedule(sub *models.AlertSubscription) digest.Schedule {
+	return digest.Schedule{
+		Start:           sub.QuietHoursStart,
+		End:             sub.QuietHoursEnd,
+		TimeZone:        sub.TimeZone,
+		IntervalMinutes: sub.DigestMinutes,
+	}
+}
+
+// hold keeps a notification back for the subscription's next digest when
+// its quiet hours or digest interval call for it, and reports whether it
+// did. A notification that cannot be held is sent instead.
+func (ns *NotifierService) hold(sub *models.AlertSubscription, n *notification) bool {
+	if ns.holds == nil || sub.Channel != "email" || (sub.QuietHoursStart == "" && sub.DigestMinutes == 0) {
+		return false
+	}
+
+	now := time.Now().UTC()
+	pending, err := ns.holds.NextRelease(sub.ID)
+	if err != nil {
+		log.Printf("Error checking held notifications for subscription %s: %v", sub.ID, err)
+		return false
+	}
+	until := subscriptionSchedule(sub).HoldUntil(n.alert.Severity, now, pending)
+	if until.IsZero() {
+		return false
+	}
+
+	hold := &models.NotificationHold{
+		SubscriptionID: sub.ID,
+		AlertID:        &n.alert.ID,
+		Event:          n.data.Event,
+		Severity:       n.alert.Severity,
+		ServiceName:    n.data.Service.Name,
+		Message:        n.data.Message,
+		ReleaseAt:      until,
+	}
+	if err := ns.holds.Create(hold); err != nil {
+		log.Printf("Error holding notification for subscription %s, sending it now: %v", sub.ID, err)
+		return false
+	}
+	return true
+}
+
+// releaseHolds sends each subscription's held notifications that have come
+// due as one digest
+func (ns *NotifierService) releaseHolds() {
+	if ns.holds == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	holds, err := ns.holds.ClaimDue(now, claimLease, batchSize)
+	if err != nil {
+		log.Printf("Error claiming held notifications: %v", err)
+		return
+	}
+
+	bySubscription := make(map[uuid.UUID][]*models.NotificationHold)
+	var order []uuid.UUID
+	for _, hold := range holds {
+		if _, ok := bySubscription[hold.SubscriptionID]; !ok {
+			order = append(order, hold.SubscriptionID)
+		}
+		bySubscription[hold.SubscriptionID] = append(bySubscription[hold.SubscriptionID], hold)
+	}
+
+	for _, id := range order {
+		ns.sendDigest(id, bySubscription[id], now)
+	}
+}
+
+// sendDigest queues one digest of a subscription's held notifications and
+// removes them. Holds it fails on stay claimed, and are tried again once
+// the claim runs out.
+func (ns *NotifierService) sendDigest(subscriptionID uuid.UUID, holds []*models.NotificationHold, now time.Time) {
+	ids := make([]uuid.UUID, 0, len(holds))
+	items := make([]digest.Item, 0, len(holds))
+	for _, hold := range holds {
+		ids = append(ids, hold.ID)
+		items = append(items, digest.Item{
+			Event:    hold.Event,
+			Severity: hold.Severity,
+			Service:  hold.ServiceName,
+			Message:  hold.Message,
+			At:       hold.CreatedAt,
+		})
+	}
+
+	sub, err := ns.alertRepo.GetSubscription(subscriptionID)
+	if err != nil {
+		// Deleting the subscription deletes its holds
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error fetching subscription %s for its digest: %v", subscriptionID, err)
+		}
+		return
+	}
+
+	schedule := subscriptionSchedule(sub)
+	// Quiet hours that began after a digest was scheduled hold it until
+	// they end
+	if until := schedule.QuietUntil(now); !until.IsZero() {
+		if err := ns.holds.Reschedule(ids, until); err != nil {
+			log.Printf("Error rescheduling digest for subscription %s: %v", sub.ID, err)
+		}
+		return
+	}
+
+	destination := sub.Destination
+	if sub.OnCallScheduleID != nil {
+		destination = ns.onCallDestination(*sub.OnCallScheduleID)
+	}
+	if !sub.IsActive || sub.VerificationStatus != verification.StatusVerified || destination == "" {
+		log.Printf("Dropping %d held notifications for subscription %s, which can no longer be notified", len(holds), sub.ID)
+		ns.deleteHolds(sub.ID, ids)
+		return
+	}
+
+	rendered, err := schedule.Render(items, ns.dashboardURL)
+	if err != nil {
+		log.Printf("Error rendering digest for subscription %s: %v", sub.ID, err)
+		return
+	}
+	ns.send(&models.NotificationDelivery{
+		SubscriptionID: &sub.ID,
+		Channel:        sub.Channel,
+		Destination:    destination,
+		Subject:        rendered.Subject,
+		Body:           rendered.Body,
+		HTMLBody:       rendered.HTML,
+	})
+	ns.deleteHolds(sub.ID, ids)
+}
+
+func (ns *NotifierService) deleteHolds(subscriptionID uuid.UUID, ids []uuid.UUID) {
+	if err := ns.holds.Delete(ids); err != nil {
+		log.Printf("Error removing held notifications for subscription %s: %v", subscriptionID, err)
+	}
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailBytes(t *testing.T) {
+	e := &email{
+		From:    "alerts@pulsegrid.com",
+		To:      "ops@example.com",
+		Subject: "🔴 PulseGrid Alert: Checkout API is down",
+		Text:    "Service is down: Checkout API",
+		HTML:    "<p>Service is down: <b>Checkout API</b></p>",
+		Date:    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	raw, err := e.bytes()
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, e.Subject, subject)
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 +0000", msg.Header.Get("Date"))
+	assert.Regexp(t, `^<[0-9a-f]{32}@pulsegrid\.com>$`, msg.Header.Get("Message-ID"))
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	// multipart.Reader undoes the quoted-printable encoding
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", e.Text},
+		{"text/html; charset=utf-8", e.HTML},
+	} {
+		part, err := parts.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, want.contentType, part.Header.Get("Content-Type"))
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, want.body, string(body))
+	}
+	_, err = parts.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMessageID(t *testing.T) {
+	assert.Contains(t, messageID("PulseGrid <noreply@example.org>"), "@example.org>")
+	assert.Contains(t, messageID("not an address"), "@pulsegrid.local>")
+	assert.NotEqual(t, messageID("a@b.com"), messageID("a@b.com"))
+}
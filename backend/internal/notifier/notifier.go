@@ -3,28 +3,58 @@ package notifier
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/smtp"
 	"os"
+	"sync"
+	"time"
 
 	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/oncall"
 	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/chat"
+	"pulsegrid/backend/pkg/message"
+	"pulsegrid/backend/pkg/paging"
+	"pulsegrid/backend/pkg/sms"
+	"pulsegrid/backend/pkg/webhook"
+	"pulsegrid/backend/pkg/webpush"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/google/uuid"
 )
 
 // NotifierService handles sending notifications for alerts
 type NotifierService struct {
-	alertRepo *repository.AlertRepository
-	sesClient *ses.SES
-	snsClient *sns.SNS
+	alertRepo   *repository.AlertRepository
+	serviceRepo *repository.ServiceRepository
+	outbox      *repository.NotificationRepository
+	templates   *repository.NotificationTemplateRepository
+	holds       *repository.NotificationHoldRepository
+	// pushSubscriptions holds the browsers webpush notifications go to
+	pushSubscriptions *repository.PushSubscriptionRepository
+	rules             *repository.NotificationRuleRepository
+	oncall            *oncall.Resolver
+	httpClient        *http.Client
+	chat              *chat.Sender
+	paging            *paging.Sender
+	sesClient         *ses.SES
+	sms               *sms.Sender
+	// vapid caches the VAPID keys once loaded
+	vapid     webpush.Keys
+	vapidMu   sync.Mutex
 	fromEmail string
-	topicARN  string
+	// dashboardURL is linked from chat messages and templates
+	dashboardURL string
+	// apiURL is where the confirmation and unsubscribe links sent to status
+	// page subscribers point
+	apiURL string
 	// SMTP configuration for local development
 	smtpHost      string
 	smtpPort      string
@@ -35,7 +65,7 @@ type NotifierService struct {
 	useConsoleLog bool
 }
 
-func NewNotifierService(alertRepo *repository.AlertRepository) *NotifierService {
+func NewNotifierService(alertRepo *repository.AlertRepository, serviceRepo *repository.ServiceRepository, outbox *repository.NotificationRepository, templates *repository.NotificationTemplateRepository, holds *repository.NotificationHoldRepository, pushSubscriptions *repository.PushSubscriptionRepository, rules *repository.NotificationRuleRepository, oncallResolver *oncall.Resolver) *NotifierService {
 	sess := session.Must(session.NewSession())
 
 	// Check if SMTP is configured
@@ -57,26 +87,160 @@ func NewNotifierService(alertRepo *repository.AlertRepository) *NotifierService
 		log.Println("📧 Email notifications configured via AWS SES")
 	}
 
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
 	return &NotifierService{
-		alertRepo:     alertRepo,
-		sesClient:     ses.New(sess),
-		snsClient:     sns.New(sess),
-		fromEmail:     getEnv("SES_FROM_EMAIL", "noreply@pulsegrid.com"),
-		topicARN:      getEnv("SNS_TOPIC_ARN", ""),
-		smtpHost:      smtpHost,
-		smtpPort:      smtpPort,
-		smtpUser:      smtpUser,
-		smtpPassword:  smtpPassword,
-		smtpFromEmail: smtpFromEmail,
-		useSMTP:       useSMTP,
-		useConsoleLog: useConsoleLog,
+		alertRepo:         alertRepo,
+		serviceRepo:       serviceRepo,
+		outbox:            outbox,
+		templates:         templates,
+		holds:             holds,
+		pushSubscriptions: pushSubscriptions,
+		rules:             rules,
+		oncall:            oncallResolver,
+		httpClient:        httpClient,
+		chat:              &chat.Sender{Client: httpClient, TelegramToken: getEnv("TELEGRAM_BOT_TOKEN", "")},
+		paging:            &paging.Sender{Client: httpClient},
+		sesClient:         ses.New(sess),
+		sms:               newSMSSender(sess, httpClient, getEnv("AWS_ACCESS_KEY_ID", "") != "" && getEnv("AWS_SECRET_ACCESS_KEY", "") != ""),
+		fromEmail:         getEnv("SES_FROM_EMAIL", "noreply@pulsegrid.com"),
+		dashboardURL:      getEnv("FRONTEND_URL", "http://localhost:3000"),
+		apiURL:            getEnv("BACKEND_URL", "http://localhost:8080") + "/api/v1",
+		smtpHost:          smtpHost,
+		smtpPort:          smtpPort,
+		smtpUser:          smtpUser,
+		smtpPassword:      smtpPassword,
+		smtpFromEmail:     smtpFromEmail,
+		useSMTP:           useSMTP,
+		useConsoleLog:     useConsoleLog,
 	}
 }
 
+// notification is an alert notification before it is rendered for each
+// channel
+type notification struct {
+	alert *models.Alert
+	// service is the alert's service, nil if it could not be loaded
+	service *models.Service
+	// orgID owns the alert's service, and picks the templates used
+	orgID uuid.UUID
+	data  message.Data
+}
+
+// newNotification gathers the template data for an alert event. msg is the
+// alert message, or the recovery message once resolved.
+func (ns *NotifierService) newNotification(alert *models.Alert, event, msg string) *notification {
+	n := &notification{
+		alert: alert,
+		data: message.Data{
+			Event:   event,
+			Message: msg,
+			Alert: message.AlertData{
+				ID:        alert.ID.String(),
+				Type:      alert.Type,
+				Severity:  alert.Severity,
+				Message:   alert.Message,
+				Status:    alert.Status,
+				CreatedAt: alert.CreatedAt,
+			},
+			Service: message.ServiceData{ID: alert.ServiceID.String(), Name: alert.ServiceID.String()},
+			Link:    chat.ServiceLink(ns.dashboardURL, alert.ServiceID.String()),
+		},
+	}
+	if event == message.EventResolved {
+		outage := time.Since(alert.CreatedAt)
+		if alert.OutageDurationSeconds != nil {
+			outage = time.Duration(*alert.OutageDurationSeconds) * time.Second
+		}
+		n.data.Duration = alerting.FormatDuration(outage)
+	}
+	if ns.serviceRepo != nil {
+		if service, err := ns.serviceRepo.GetByID(alert.ServiceID); err == nil {
+			n.service = service
+			n.orgID = service.OrganizationID
+			n.data.Service.Name = service.Name
+			n.data.Service.URL = service.URL
+		}
+	}
+	return n
+}
+
+// render renders a notification for a channel with the organization's
+// template, or the default one when it has none. A custom template that
+// fails to render falls back to the default, so the alert still goes out.
+// Emails always get an HTML part.
+func (ns *NotifierService) render(n *notification, channel string) message.Rendered {
+	tmpl := message.Default(channel, n.data.Event)
+	if custom := ns.customTemplate(n, channel); custom != nil {
+		rendered, err := message.Render(*custom, n.data)
+		if err == nil {
+			return withHTML(channel, rendered)
+		}
+		log.Printf("⚠️ Notification template for %s failed to render, using the default: %v", channel, err)
+	}
+
+	rendered, err := message.Render(tmpl, n.data)
+	if err != nil {
+		log.Printf("Error rendering %s notification: %v", channel, err)
+		rendered = message.Rendered{Subject: n.data.Message, Body: n.data.Message}
+	}
+	return withHTML(channel, rendered)
+}
+
+// customTemplate returns the organization's template for a channel, with
+// any field it leaves empty taken from the default
+func (ns *NotifierService) customTemplate(n *notification, channel string) *message.Template {
+	if ns.templates == nil || n.orgID == uuid.Nil || !message.IsChannel(channel) {
+		return nil
+	}
+	custom, err := ns.templates.Find(n.orgID, channel, n.alert.Type, n.data.Event)
+	if err != nil {
+		log.Printf("Error fetching notification template: %v", err)
+		return nil
+	}
+	if custom == nil {
+		return nil
+	}
+
+	tmpl := message.Override(channel, n.data.Event, message.Template{Subject: custom.Subject, Body: custom.Body, HTML: custom.HTMLBody})
+	return &tmpl
+}
+
+func withHTML(channel string, rendered message.Rendered) message.Rendered {
+	if channel == "email" && rendered.HTML == "" {
+		rendered.HTML = message.TextToHTML(rendered.Body)
+	}
+	return rendered
+}
+
 // SendAlertNotifications sends notifications for an alert to all relevant subscriptions
 func (ns *NotifierService) SendAlertNotifications(alert *models.Alert) error {
+	return ns.notifySubscriptions(ns.newNotification(alert, message.EventTriggered, alert.Message), alerting.EventTriggered)
+}
+
+// SendEscalatedNotifications notifies the alert's subscribers again after
+// its severity escalated. It reads like a new alert; subscriptions can
+// route escalations separately.
+func (ns *NotifierService) SendEscalatedNotifications(alert *models.Alert) error {
+	return ns.notifySubscriptions(ns.newNotification(alert, message.EventTriggered, alert.Message), alerting.EventEscalated)
+}
+
+// SendRecoveryNotifications tells the alert's subscribers, and the paging
+// escalation steps that opened incidents for it, that it resolved because
+// the service recovered
+func (ns *NotifierService) SendRecoveryNotifications(alert *models.Alert, msg string) error {
+	ns.resolveEscalationIncidents(alert, "PulseGrid Resolved: "+alert.Message, msg)
+	return ns.notifySubscriptions(ns.newNotification(alert, message.EventResolved, msg), alerting.EventResolved)
+}
+
+// notifySubscriptions sends a notification to every subscription covering
+// the alert's service whose filters route it the event (triggered, escalated
+// or resolved), rendered for each subscription's channel, then to the users
+// whose notification rules route it to them
+func (ns *NotifierService) notifySubscriptions(n *notification, event string) error {
+	alert := n.alert
 	// Get subscriptions for this service (or all services if service_id is null)
-	subscriptions, err := ns.alertRepo.GetSubscriptionsByService(alert.ServiceID)
+	subscriptions, err := ns.alertRepo.GetSubscriptionsForAlert(alert, event)
 	if err != nil {
 		log.Printf("Error fetching subscriptions: %v", err)
 		return err
@@ -84,63 +248,168 @@ func (ns *NotifierService) SendAlertNotifications(alert *models.Alert) error {
 
 	if len(subscriptions) == 0 {
 		log.Printf("No subscriptions found for alert %s", alert.ID)
-		return nil
 	}
 
+	// sent holds the destinations notified, so users' rules do not notify
+	// them twice
+	sent := make(map[string]bool)
+
 	// Send notification to each subscription
 	for _, sub := range subscriptions {
 		if !sub.IsActive {
 			continue
 		}
 
-		message := formatAlertMessage(alert)
-
-		switch sub.Channel {
-		case "email":
-			ns.sendEmail(sub.Destination, "PulseGrid Alert: "+alert.Message, message)
-		case "sms":
-			ns.sendSMS(sub.Destination, message)
-		case "slack":
-			ns.sendSlack(sub.Destination, message)
-		default:
-			log.Printf("Unknown channel type: %s", sub.Channel)
+		destination := sub.Destination
+		if sub.OnCallScheduleID != nil {
+			destination = ns.onCallDestination(*sub.OnCallScheduleID)
+			if destination == "" {
+				continue
+			}
+		}
+		sent[sentKey(sub.Channel, destination)] = true
+
+		// Quiet hours and digests send this later, in a digest
+		if ns.hold(sub, n) {
+			continue
 		}
+
+		delivery := ns.newDelivery(n, sub.Channel, destination, sub.Webhook)
+		delivery.SubscriptionID = &sub.ID
+		ns.send(delivery)
 	}
 
+	ns.notifyRules(n, event, sent)
 	return nil
 }
 
-func (ns *NotifierService) sendEmail(to, subject, body string) {
+// onCallDestination returns the email of whoever is on call for a schedule
+// right now, or "" if nobody is
+func (ns *NotifierService) onCallDestination(scheduleID uuid.UUID) string {
+	if ns.oncall == nil {
+		return ""
+	}
+
+	user, err := ns.oncall.UserAt(scheduleID, time.Now().UTC())
+	if err != nil {
+		log.Printf("Error resolving on-call user for schedule %s: %v", scheduleID, err)
+		return ""
+	}
+	if user == nil {
+		log.Printf("Nobody is on call for schedule %s, skipping notification", scheduleID)
+		return ""
+	}
+	return user.Email
+}
+
+// SendEscalationNotification notifies a single escalation step about an
+// alert. Steps after the first say how many have gone unanswered.
+func (ns *NotifierService) SendEscalationNotification(alert *models.Alert, step models.EscalationStep) {
+	n := ns.newNotification(alert, message.EventTriggered, alert.Message)
+	n.data.EscalationStep = step.Position
+
+	ns.send(ns.newDelivery(n, step.Channel, step.Destination, nil))
+}
+
+// newDelivery renders a notification for a channel and destination, in the
+// body format the channel expects. webhookConfig is the subscription's
+// webhook settings, nil for a webhook that has none.
+func (ns *NotifierService) newDelivery(n *notification, channel, destination string, webhookConfig *models.WebhookConfig) *models.NotificationDelivery {
+	rendered := ns.render(n, channel)
+	delivery := &models.NotificationDelivery{
+		AlertID:     &n.alert.ID,
+		Channel:     channel,
+		Destination: destination,
+		Subject:     rendered.Subject,
+		Body:        rendered.Body,
+		HTMLBody:    rendered.HTML,
+	}
+
+	resolved := n.data.Event == message.EventResolved
+	switch {
+	case channel == "webhook":
+		event := webhook.EventAlertTriggered
+		if resolved {
+			event = webhook.EventAlertResolved
+		}
+		delivery.Body = webhookBody(webhookConfig, event, n.alert, rendered.Subject, rendered.Body)
+	case chat.IsChannel(channel):
+		delivery.Body = ns.chatBody(channel, destination, n, rendered)
+	case paging.IsChannel(channel):
+		delivery.Body = ns.pagingBody(n.alert, rendered.Subject, n.data.Message, resolved)
+	case channel == "webpush":
+		delivery.Body = webPushBody(n, rendered)
+	}
+	return delivery
+}
+
+// deliver makes a single attempt to send a notification. statusCode is set
+// for channels that answer over HTTP.
+func (ns *NotifierService) deliver(delivery *models.NotificationDelivery) (statusCode int, err error) {
+	switch delivery.Channel {
+	case "email":
+		return 0, ns.sendEmail(delivery.Destination, delivery.Subject, delivery.Body, delivery.HTMLBody)
+	case "sms":
+		return ns.sendSMS(delivery)
+	case "slack":
+		return ns.sendSlack(delivery.Destination, delivery.Body)
+	case "webhook":
+		return ns.sendWebhook(delivery)
+	case statusPageWebhook:
+		return ns.sendStatusPageWebhook(delivery)
+	case "webpush":
+		return ns.sendWebPush(delivery)
+	case chat.Teams, chat.Discord, chat.Telegram, chat.Mattermost:
+		return ns.sendChat(delivery)
+	case paging.PagerDuty, paging.Opsgenie:
+		return ns.sendPaging(delivery)
+	default:
+		return 0, permanent(fmt.Errorf("unknown channel type: %s", delivery.Channel))
+	}
+}
+
+// sendEmail sends an email with a plain text and an HTML part. html may be
+// empty, in which case one is made from the text.
+func (ns *NotifierService) sendEmail(to, subject, body, html string) error {
+	if html == "" {
+		html = message.TextToHTML(body)
+	}
+
+	lastErr := fmt.Errorf("no email service configured")
+
 	// Try SMTP first (for local development)
 	if ns.useSMTP {
-		if err := ns.sendEmailSMTP(to, subject, body); err == nil {
+		if err := ns.sendEmailSMTP(to, subject, body, html); err == nil {
 			log.Printf("✅ Email sent via SMTP to %s", to)
-			return
+			return nil
 		} else {
 			log.Printf("⚠️ SMTP failed, trying fallback: %v", err)
+			lastErr = err
 		}
 	}
 
 	// Try AWS SES if configured
 	if ns.sesClient != nil && ns.fromEmail != "" && getEnv("AWS_ACCESS_KEY_ID", "") != "" {
-		if err := ns.sendEmailSES(to, subject, body); err == nil {
+		if err := ns.sendEmailSES(to, subject, body, html); err == nil {
 			log.Printf("✅ Email sent via AWS SES to %s", to)
-			return
+			return nil
 		} else {
 			log.Printf("⚠️ AWS SES failed: %v", err)
+			lastErr = err
 		}
 	}
 
 	// Fallback to console logging for development
 	if ns.useConsoleLog || (ns.sesClient == nil && !ns.useSMTP) {
 		ns.sendEmailConsole(to, subject, body)
-		return
+		return nil
 	}
 
-	log.Printf("❌ Failed to send email to %s: No email service configured", to)
+	log.Printf("❌ Failed to send email to %s: %v", to, lastErr)
+	return lastErr
 }
 
-func (ns *NotifierService) sendEmailSES(to, subject, body string) error {
+func (ns *NotifierService) sendEmailSES(to, subject, body, html string) error {
 	if ns.sesClient == nil {
 		return fmt.Errorf("SES client not initialized")
 	}
@@ -152,11 +421,17 @@ func (ns *NotifierService) sendEmailSES(to, subject, body string) error {
 		},
 		Message: &ses.Message{
 			Subject: &ses.Content{
-				Data: aws.String(subject),
+				Charset: aws.String("UTF-8"),
+				Data:    aws.String(subject),
 			},
 			Body: &ses.Body{
 				Text: &ses.Content{
-					Data: aws.String(body),
+					Charset: aws.String("UTF-8"),
+					Data:    aws.String(body),
+				},
+				Html: &ses.Content{
+					Charset: aws.String("UTF-8"),
+					Data:    aws.String(html),
 				},
 			},
 		},
@@ -166,7 +441,7 @@ func (ns *NotifierService) sendEmailSES(to, subject, body string) error {
 	return err
 }
 
-func (ns *NotifierService) sendEmailSMTP(to, subject, body string) error {
+func (ns *NotifierService) sendEmailSMTP(to, subject, body, html string) error {
 	if ns.smtpHost == "" || ns.smtpUser == "" || ns.smtpPassword == "" {
 		return fmt.Errorf("SMTP not configured")
 	}
@@ -175,16 +450,21 @@ func (ns *NotifierService) sendEmailSMTP(to, subject, body string) error {
 	auth := smtp.PlainAuth("", ns.smtpUser, ns.smtpPassword, ns.smtpHost)
 
 	// Create email message
-	msg := []byte(fmt.Sprintf("To: %s\r\n", to) +
-		fmt.Sprintf("From: %s\r\n", ns.smtpFromEmail) +
-		fmt.Sprintf("Subject: %s\r\n", subject) +
-		"\r\n" +
-		body + "\r\n")
+	msg, err := (&email{
+		From:    ns.smtpFromEmail,
+		To:      to,
+		Subject: subject,
+		Text:    body,
+		HTML:    html,
+		Date:    time.Now(),
+	}).bytes()
+	if err != nil {
+		return err
+	}
 
 	// Send email
 	addr := fmt.Sprintf("%s:%s", ns.smtpHost, ns.smtpPort)
-	err := smtp.SendMail(addr, auth, ns.smtpFromEmail, []string{to}, msg)
-	return err
+	return smtp.SendMail(addr, auth, ns.smtpFromEmail, []string{to}, msg)
 }
 
 func (ns *NotifierService) sendEmailConsole(to, subject, body string) {
@@ -204,36 +484,60 @@ Subject: %s
 	log.Print(emailContent)
 }
 
-func (ns *NotifierService) sendSMS(phoneNumber, message string) {
-	if ns.snsClient == nil {
-		log.Printf("SNS client not initialized, skipping SMS to %s", phoneNumber)
-		return
-	}
-
-	if ns.topicARN != "" {
-		_, err := ns.snsClient.Publish(&sns.PublishInput{
-			TopicArn: aws.String(ns.topicARN),
-			Message:  aws.String(message),
-			Subject:  aws.String("PulseGrid Alert"),
-			MessageAttributes: map[string]*sns.MessageAttributeValue{
-				"phone": {
-					DataType:    aws.String("String"),
-					StringValue: aws.String(phoneNumber),
-				},
-			},
-		})
-		if err != nil {
-			log.Printf("Failed to send SMS via SNS: %v", err)
-		} else {
-			log.Printf("SMS sent to %s via SNS", phoneNumber)
-		}
-		return
+// newSMSSender sets up the SMS provider named by SMS_PROVIDER. With none
+// named, SMS goes through SNS when AWS is configured and is logged
+// otherwise.
+func newSMSSender(sess *session.Session, client *http.Client, awsConfigured bool) *sms.Sender {
+	cfg, err := sms.LoadConfig(os.Getenv)
+	if err != nil {
+		log.Printf("⚠️ Invalid SMS configuration, SMS will be logged to console: %v", err)
+		return nil
+	}
+	if cfg.Provider == "" && awsConfigured {
+		cfg.Provider = sms.ProviderSNS
+	}
+	cfg.SNS.Client = sns.New(sess)
+
+	sender, err := sms.New(cfg, client)
+	if err != nil {
+		log.Printf("⚠️ Invalid SMS configuration, SMS will be logged to console: %v", err)
+		return nil
+	}
+	if sender == nil {
+		log.Println("📱 SMS notifications will be logged to console (no SMS provider configured)")
+	} else {
+		log.Printf("📱 SMS notifications configured via %s", cfg.Provider)
+	}
+	return sender
+}
+
+// sendSMS texts a notification to its phone number, keeping the provider's
+// message ID and status on the notification for its delivery receipts
+func (ns *NotifierService) sendSMS(delivery *models.NotificationDelivery) (int, error) {
+	if ns.sms == nil {
+		log.Printf("SMS notification to %s: %s", delivery.Destination, delivery.Body)
+		return 0, nil
+	}
+
+	result, err := ns.sms.Send(delivery.Destination, delivery.Body)
+	if errors.Is(err, sms.ErrInvalidNumber) {
+		return 0, permanent(err)
+	}
+	if result.MessageID != "" {
+		delivery.ProviderMessageID = &result.MessageID
+	}
+	if result.Status != "" {
+		delivery.ProviderStatus = &result.Status
+	}
+	if err != nil {
+		return result.StatusCode, err
 	}
 
-	log.Printf("SMS notification to %s: %s", phoneNumber, message)
+	log.Printf("SMS sent to %s (%d segments, message %s)", delivery.Destination, result.Segments, result.MessageID)
+	return result.StatusCode, nil
 }
 
-func (ns *NotifierService) sendSlack(webhookURL, message string) {
+func (ns *NotifierService) sendSlack(webhookURL, message string) (int, error) {
 	payload := map[string]interface{}{
 		"text":       message,
 		"username":   "PulseGrid",
@@ -242,23 +546,23 @@ func (ns *NotifierService) sendSlack(webhookURL, message string) {
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Failed to marshal Slack payload: %v", err)
-		return
+		return 0, fmt.Errorf("failed to marshal Slack payload: %w", err)
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := ns.httpClient.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		log.Printf("Failed to send Slack notification: %v", err)
-		return
+		return 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("Slack webhook returned status %d", resp.StatusCode)
-		return
+		return resp.StatusCode, fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
 	}
 
 	log.Printf("Slack notification sent successfully")
+	return resp.StatusCode, nil
 }
 
 // SendCustomEmail sends a custom email without creating an alert record (e.g., subscription confirmation)
@@ -266,26 +570,16 @@ func (ns *NotifierService) SendCustomEmail(to, subject, body string) error {
 	if to == "" {
 		return fmt.Errorf("email destination is required")
 	}
-	ns.sendEmail(to, subject, body)
+	ns.send(&models.NotificationDelivery{
+		Channel:     "email",
+		Destination: to,
+		Subject:     subject,
+		Body:        body,
+		HTMLBody:    message.TextToHTML(body),
+	})
 	return nil
 }
 
-func formatAlertMessage(alert *models.Alert) string {
-	severity := "⚠️"
-	switch alert.Severity {
-	case "critical":
-		severity = "🔴 CRITICAL"
-	case "high":
-		severity = "🟠 HIGH"
-	case "medium":
-		severity = "🟡 MEDIUM"
-	case "low":
-		severity = "🔵 LOW"
-	}
-
-	return severity + " Alert: " + alert.Message
-}
-
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
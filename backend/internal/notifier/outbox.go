@@ -0,0 +1,136 @@
+package notifier
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// claimLease is how long a claimed notification stays hidden from other
+// workers. A worker that dies mid-send leaves it to be retried after it.
+const claimLease = 2 * time.Minute
+
+// batchSize caps the notifications retried per run
+const batchSize = 100
+
+// send queues a notification in the outbox and makes its first attempt
+// straight away. Failed attempts are retried by DeliverDue.
+func (ns *NotifierService) send(delivery *models.NotificationDelivery) {
+	delivery.Status = DeliveryPending
+	// Claimed from the start, so a worker only picks it up if this process
+	// dies before the first attempt
+	delivery.NextAttemptAt = time.Now().UTC().Add(claimLease)
+
+	if ns.outbox == nil {
+		if _, err := ns.deliver(delivery); err != nil {
+			log.Printf("❌ Failed to send %s notification to %s: %v", delivery.Channel, delivery.Destination, err)
+		}
+		return
+	}
+	// Losing the retries beats losing the notification
+	if err := ns.outbox.Create(delivery); err != nil {
+		log.Printf("Error queueing %s notification to %s, sending without retries: %v", delivery.Channel, delivery.Destination, err)
+		if _, err := ns.deliver(delivery); err != nil {
+			log.Printf("❌ Failed to send %s notification to %s: %v", delivery.Channel, delivery.Destination, err)
+		}
+		return
+	}
+
+	ns.attempt(delivery)
+}
+
+// DeliverDue retries every queued notification that has come due, and sends
+// the digests of held notifications and the delayed notifications of users'
+// rules that have. The scheduler calls it on each tick.
+func (ns *NotifierService) DeliverDue() {
+	ns.releaseHolds()
+	ns.runDelayedRules()
+	if ns.outbox == nil {
+		return
+	}
+
+	deliveries, err := ns.outbox.ClaimDue(time.Now().UTC(), claimLease, batchSize)
+	if err != nil {
+		log.Printf("Error claiming due notifications: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		ns.attempt(delivery)
+	}
+}
+
+// RunOutbox calls DeliverDue every interval, for processes that send
+// notifications without running the scheduler loop
+func (ns *NotifierService) RunOutbox(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ns.DeliverDue()
+	}
+}
+
+// Resend puts a notification back in the outbox with a fresh set of attempts
+// and tries it once straight away, returning it with the outcome
+func (ns *NotifierService) Resend(id uuid.UUID) (*models.NotificationDelivery, error) {
+	delivery, err := ns.outbox.Requeue(id, time.Now().UTC().Add(claimLease))
+	if err != nil {
+		return nil, err
+	}
+
+	ns.attempt(delivery)
+	return delivery, nil
+}
+
+// attempt tries a queued notification once and records the outcome. A
+// failure is retried with exponential backoff until it runs out of attempts
+// or the destination rejects it outright, when it is dead-lettered.
+func (ns *NotifierService) attempt(delivery *models.NotificationDelivery) {
+	started := time.Now()
+	statusCode, err := ns.deliver(delivery)
+	now := time.Now().UTC()
+
+	attempt := &models.NotificationAttempt{
+		Number:      delivery.AttemptCount + 1,
+		Channel:     delivery.Channel,
+		Status:      AttemptSucceeded,
+		LatencyMs:   int(now.Sub(started).Milliseconds()),
+		AttemptedAt: now,
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	delivery.AttemptCount = attempt.Number
+
+	if err == nil {
+		delivery.Status = DeliveryDelivered
+		delivery.DeliveredAt = &now
+	} else {
+		message := err.Error()
+		attempt.Status = AttemptFailed
+		attempt.Error = &message
+		delivery.LastError = &message
+
+		var perm permanentError
+		next, ok := NextAttempt(delivery.AttemptCount, now)
+		if ok && Retryable(statusCode) && !errors.As(err, &perm) {
+			delivery.NextAttemptAt = next
+			log.Printf("⚠️ %s notification to %s failed (attempt %d), retrying at %s: %v",
+				delivery.Channel, delivery.Destination, delivery.AttemptCount, next.Format(time.RFC3339), err)
+		} else {
+			delivery.Status = DeliveryDead
+			log.Printf("❌ %s notification to %s dead-lettered after %d attempts: %v",
+				delivery.Channel, delivery.Destination, delivery.AttemptCount, err)
+		}
+	}
+
+	if err := ns.outbox.RecordAttempt(delivery, attempt); err != nil {
+		log.Printf("Error recording notification attempt for %s: %v", delivery.ID, err)
+	}
+	ns.trackSubscription(delivery)
+}
@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/chat"
+	"pulsegrid/backend/pkg/paging"
+)
+
+// pagingBody builds the PagerDuty or Opsgenie event for an alert. The alert
+// ID is the dedup key, so the resolve event closes the incident the trigger
+// opened.
+func (ns *NotifierService) pagingBody(alert *models.Alert, subject, detail string, resolved bool) string {
+	event := paging.Event{
+		Action:   paging.ActionTrigger,
+		DedupKey: alert.ID.String(),
+		Summary:  alert.Message,
+		Details:  detail,
+		Service:  alert.ServiceID.String(),
+		Severity: alert.Severity,
+		Link:     chat.ServiceLink(ns.dashboardURL, alert.ServiceID.String()),
+	}
+	if resolved {
+		event.Action = paging.ActionResolve
+		event.Summary = subject
+	}
+	if ns.serviceRepo != nil {
+		if service, err := ns.serviceRepo.GetByID(alert.ServiceID); err == nil {
+			event.Service = service.Name
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding paging event: %v", err)
+		return detail
+	}
+	return string(body)
+}
+
+// sendPaging sends an event built by pagingBody to the integration key in
+// the delivery's destination
+func (ns *NotifierService) sendPaging(delivery *models.NotificationDelivery) (int, error) {
+	var event paging.Event
+	if err := json.Unmarshal([]byte(delivery.Body), &event); err != nil {
+		return 0, permanent(fmt.Errorf("invalid %s event: %v", delivery.Channel, err))
+	}
+
+	statusCode, err := ns.paging.Post(delivery.Channel, delivery.Destination, event)
+	if err != nil {
+		return statusCode, err
+	}
+	log.Printf("✅ %s %s event sent for alert %s", delivery.Channel, event.Action, event.DedupKey)
+	return statusCode, nil
+}
+
+// ResolveRemoteIncidents resolves the PagerDuty and Opsgenie incidents opened
+// for an alert that was resolved by hand. Automatic recoveries go through
+// SendRecoveryNotifications, which covers them along with the other channels.
+func (ns *NotifierService) ResolveRemoteIncidents(alert *models.Alert, message string) {
+	subscriptions, err := ns.alertRepo.GetSubscriptionsByService(alert.ServiceID)
+	if err != nil {
+		log.Printf("Error fetching subscriptions: %v", err)
+		return
+	}
+
+	subject := "PulseGrid Resolved: " + alert.Message
+	for _, sub := range subscriptions {
+		if !sub.IsActive || !paging.IsChannel(sub.Channel) {
+			continue
+		}
+		ns.send(&models.NotificationDelivery{
+			AlertID:        &alert.ID,
+			SubscriptionID: &sub.ID,
+			Channel:        sub.Channel,
+			Destination:    sub.Destination,
+			Subject:        subject,
+			Body:           ns.pagingBody(alert, subject, message, true),
+		})
+	}
+	ns.resolveEscalationIncidents(alert, subject, message)
+}
+
+// resolveEscalationIncidents resolves the PagerDuty and Opsgenie incidents
+// escalation steps opened for an alert. Steps are not subscriptions, so the
+// outbox is the only record of where they paged.
+func (ns *NotifierService) resolveEscalationIncidents(alert *models.Alert, subject, message string) {
+	if ns.outbox == nil {
+		return
+	}
+	deliveries, err := ns.outbox.ListByAlert(alert.ID)
+	if err != nil {
+		log.Printf("Error fetching notifications sent for alert %s: %v", alert.ID, err)
+		return
+	}
+
+	resolved := make(map[string]bool)
+	for _, delivery := range deliveries {
+		key := sentKey(delivery.Channel, delivery.Destination)
+		if delivery.SubscriptionID != nil || !paging.IsChannel(delivery.Channel) || resolved[key] {
+			continue
+		}
+		resolved[key] = true
+		ns.send(&models.NotificationDelivery{
+			AlertID:     &alert.ID,
+			Channel:     delivery.Channel,
+			Destination: delivery.Destination,
+			Subject:     subject,
+			Body:        ns.pagingBody(alert, subject, message, true),
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead is a notification that ran out of attempts or was
+	// rejected outright; only a manual resend tries it again
+	DeliveryDead = "dead"
+)
+
+// Attempt outcomes
+const (
+	AttemptSucceeded = "succeeded"
+	AttemptFailed    = "failed"
+)
+
+// MaxAttempts is how many times a notification is tried before it is
+// dead-lettered
+const MaxAttempts = 8
+
+// Retry delays double from retryBase after each failed attempt, up to
+// retryCap
+const (
+	retryBase = 30 * time.Second
+	retryCap  = time.Hour
+)
+
+// NextAttempt returns when to retry a notification that has failed attempts
+// times so far, or false once it has used all MaxAttempts
+func NextAttempt(attempts int, now time.Time) (time.Time, bool) {
+	if attempts >= MaxAttempts {
+		return time.Time{}, false
+	}
+
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryCap; i++ {
+		delay *= 2
+	}
+	if delay > retryCap {
+		delay = retryCap
+	}
+	return now.Add(delay), true
+}
+
+// Retryable reports whether a failed attempt is worth repeating. A status
+// code of 0 means the request never got a response. Client errors other
+// than rate limiting mean the destination itself is wrong, so retrying would
+// fail the same way.
+func Retryable(statusCode int) bool {
+	if statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode == http.StatusRequestTimeout {
+		return true
+	}
+	return statusCode < 400 || statusCode >= 500
+}
+
+// permanentError marks a failure that retrying cannot fix, such as a
+// notification whose subscription has been deleted
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
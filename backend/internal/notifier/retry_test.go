@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextAttempt(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{7, 32 * time.Minute},
+	}
+	for _, tt := range tests {
+		next, ok := NextAttempt(tt.attempts, now)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, next.Sub(now), "after %d attempts", tt.attempts)
+	}
+
+	_, ok := NextAttempt(MaxAttempts, now)
+	assert.False(t, ok, "dead-lettered after the last attempt")
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, Retryable(0))
+	assert.True(t, Retryable(500))
+	assert.True(t, Retryable(503))
+	assert.True(t, Retryable(429))
+	assert.False(t, Retryable(400))
+	assert.False(t, Retryable(404))
+}
@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/message"
+	"pulsegrid/backend/pkg/verification"
+)
+
+// sentKey identifies a destination notified about an alert
+func sentKey(channel, destination string) string {
+	return channel + "|" + destination
+}
+
+// notifyRules sends a notification to every user whose notification rules
+// route them the event, at each rule's contact method. Destinations in sent
+// have already been notified and are skipped. Delayed rules are queued to
+// run once their delay is up, unless the alert is acknowledged or resolved
+// by then.
+func (ns *NotifierService) notifyRules(n *notification, event string, sent map[string]bool) {
+	if ns.rules == nil || n.service == nil {
+		return
+	}
+
+	resolved := n.data.Event == message.EventResolved
+	if resolved {
+		if _, err := ns.rules.CancelRuns(n.alert.ID); err != nil {
+			log.Printf("Error cancelling delayed notifications for alert %s: %v", n.alert.ID, err)
+		}
+	}
+
+	rules, err := ns.rules.ListForAlert(n.alert, n.service, event)
+	if err != nil {
+		log.Printf("Error fetching notification rules: %v", err)
+		return
+	}
+
+	for _, rule := range rules {
+		method := rule.ContactMethod
+		key := sentKey(method.Channel, method.Destination)
+		if sent[key] {
+			continue
+		}
+
+		if rule.DelayMinutes > 0 {
+			if !resolved {
+				runAt := time.Now().UTC().Add(time.Duration(rule.DelayMinutes) * time.Minute)
+				if err := ns.rules.ScheduleRun(rule.ID, n.alert.ID, runAt); err != nil {
+					log.Printf("Error scheduling notification rule %s for alert %s: %v", rule.ID, n.alert.ID, err)
+				}
+				continue
+			}
+			// Only users a delayed rule told about the alert hear that it
+			// resolved
+			if !ns.notifiedSince(n.alert, method, n.alert.CreatedAt) {
+				continue
+			}
+		}
+
+		sent[key] = true
+		ns.send(ns.ruleDelivery(n, method))
+	}
+}
+
+// ruleDelivery renders a notification for a rule's contact method
+func (ns *NotifierService) ruleDelivery(n *notification, method *models.ContactMethod) *models.NotificationDelivery {
+	return ns.newDelivery(n, method.Channel, method.Destination, nil)
+}
+
+// notifiedSince reports whether a contact method's destination has been
+// sent a notification about the alert since a time
+func (ns *NotifierService) notifiedSince(alert *models.Alert, method *models.ContactMethod, since time.Time) bool {
+	if ns.outbox == nil {
+		return false
+	}
+	notified, err := ns.outbox.HasDeliverySince(alert.ID, method.Channel, method.Destination, since)
+	if err != nil {
+		log.Printf("Error checking notifications sent for alert %s: %v", alert.ID, err)
+		return false
+	}
+	return notified
+}
+
+// runDelayedRules sends the delayed notifications of users' rules that have
+// come due
+func (ns *NotifierService) runDelayedRules() {
+	if ns.rules == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	runs, err := ns.rules.ClaimDueRuns(now, claimLease, batchSize)
+	if err != nil {
+		log.Printf("Error claiming delayed notifications: %v", err)
+		return
+	}
+
+	for _, run := range runs {
+		ns.runDelayedRule(run, now)
+	}
+}
+
+// runDelayedRule notifies a rule's contact method about an alert that is
+// still unacknowledged, unless someone already told it since the rule was
+// triggered. A snoozed alert waits until the snooze ends. Runs it fails on
+// stay claimed, and are tried again once the claim runs out.
+func (ns *NotifierService) runDelayedRule(run *models.NotificationRuleRun, now time.Time) {
+	alert, err := ns.alertRepo.GetByID(run.AlertID)
+	if err != nil {
+		// Deleting the alert deletes its runs
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error fetching alert %s for a delayed notification: %v", run.AlertID, err)
+		}
+		return
+	}
+	if alerting.Snoozed(alert.SnoozedUntil, now) {
+		if err := ns.rules.RescheduleRun(run.ID, *alert.SnoozedUntil); err != nil {
+			log.Printf("Error deferring delayed notification for snoozed alert %s: %v", alert.ID, err)
+		}
+		return
+	}
+
+	rule, err := ns.rules.GetByID(run.RuleID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error fetching notification rule %s: %v", run.RuleID, err)
+		}
+		return
+	}
+
+	method := rule.ContactMethod
+	switch {
+	case alert.Status != alerting.StatusTriggered:
+		// Acknowledged or resolved in time
+	case !rule.IsActive || method.VerificationStatus != verification.StatusVerified:
+		log.Printf("Dropping delayed notification for rule %s, which can no longer notify", rule.ID)
+	case ns.notifiedSince(alert, method, run.CreatedAt):
+		// Already told, by a subscription or escalation
+	default:
+		n := ns.newNotification(alert, message.EventTriggered, alert.Message)
+		ns.send(ns.ruleDelivery(n, method))
+		log.Printf("⏰ Alert %s still unacknowledged after %d minutes, notified %s via %s", alert.ID, rule.DelayMinutes, method.Destination, method.Channel)
+	}
+
+	if err := ns.rules.DeleteRun(run.ID); err != nil {
+		log.Printf("Error removing delayed notification %s: %v", run.ID, err)
+	}
+}
+
+// TestContactMethod sends a test message to a contact method straight away,
+// bypassing the outbox, and reports how the destination answered
+func (ns *NotifierService) TestContactMethod(method *models.ContactMethod, subject, text string) (statusCode int, err error) {
+	delivery := &models.NotificationDelivery{
+		Channel:     method.Channel,
+		Destination: method.Destination,
+		Subject:     subject,
+		Body:        text,
+	}
+	if method.Channel == "webpush" {
+		delivery.Body = ns.testPushBody(subject, text)
+	}
+	return ns.deliver(delivery)
+}
@@ -0,0 +1,190 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/message"
+	"pulsegrid/backend/pkg/statuspage"
+	"pulsegrid/backend/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+// statusPageWebhook is the channel of notifications to status page
+// subscribers' webhooks. Unlike alert webhooks they carry no custom headers
+// or signature.
+const statusPageWebhook = "status_page_webhook"
+
+// Events sent to status page subscribers' webhooks
+const (
+	StatusPageEventConfirm     = "subscription.confirm"
+	StatusPageEventPostCreated = "post.created"
+	StatusPageEventPostUpdated = "post.updated"
+)
+
+// statusPagePayload is the JSON body posted to a status page subscriber's
+// webhook
+type statusPagePayload struct {
+	Event          string                 `json:"event"`
+	Page           statusPagePayloadPage  `json:"page"`
+	Post           *statusPagePayloadPost `json:"post,omitempty"`
+	ConfirmURL     string                 `json:"confirm_url,omitempty"`
+	UnsubscribeURL string                 `json:"unsubscribe_url"`
+	SentAt         time.Time              `json:"sent_at"`
+}
+
+type statusPagePayloadPage struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type statusPagePayloadPost struct {
+	ID             uuid.UUID  `json:"id"`
+	Kind           string     `json:"kind"`
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	Impact         string     `json:"impact"`
+	Components     []string   `json:"components"`
+	ScheduledFor   *time.Time `json:"scheduled_for,omitempty"`
+	ScheduledUntil *time.Time `json:"scheduled_until,omitempty"`
+	Update         string     `json:"update"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// statusPageURL is the page's public address on the dashboard
+func (ns *NotifierService) statusPageURL(page *models.StatusPage) string {
+	return ns.dashboardURL + "/status/" + url.PathEscape(page.Slug)
+}
+
+// statusPageLink is a confirmation or unsubscribe link for one of the page's
+// subscribers
+func (ns *NotifierService) statusPageLink(page *models.StatusPage, action, token string) string {
+	return ns.apiURL + "/status/" + url.PathEscape(page.Slug) + "/subscribers/" + action + "?token=" + url.QueryEscape(token)
+}
+
+// SendStatusPageConfirmation asks a new subscriber to confirm they want a
+// page's updates. Nothing else is sent to them until they do.
+func (ns *NotifierService) SendStatusPageConfirmation(page *models.StatusPage, sub *models.StatusPageSubscriber) {
+	if sub.ConfirmToken == nil {
+		return
+	}
+	confirmURL := ns.statusPageLink(page, "confirm", *sub.ConfirmToken)
+
+	if sub.Channel == statuspage.ChannelWebhook {
+		ns.sendStatusPagePayload(page, sub, statusPagePayload{Event: StatusPageEventConfirm, ConfirmURL: confirmURL})
+		return
+	}
+
+	subject := fmt.Sprintf("Confirm your subscription to %s status updates", page.Title)
+	body := fmt.Sprintf(`Someone, hopefully you, asked for %s status updates to be sent to this address.
+
+Confirm your subscription: %s
+
+The link expires in %d days. If this wasn't you, ignore this email and you won't hear from us again.
+`, page.Title, confirmURL, int(statuspage.ConfirmTTL.Hours()/24))
+	ns.sendStatusPageEmail(sub, subject, body)
+}
+
+// NotifyStatusPageSubscribers tells a page's confirmed subscribers about a
+// new post, or the latest update to one
+func (ns *NotifierService) NotifyStatusPageSubscribers(page *models.StatusPage, post *models.StatusPagePost, event string, subscribers []*models.StatusPageSubscriber) {
+	if len(subscribers) == 0 || len(post.Updates) == 0 {
+		return
+	}
+	update := post.Updates[len(post.Updates)-1]
+
+	componentNames := make(map[uuid.UUID]string, len(page.Components))
+	for _, component := range page.Components {
+		componentNames[component.ID] = component.Name
+	}
+	components := make([]string, 0, len(post.ComponentIDs))
+	for _, id := range post.ComponentIDs {
+		if name, ok := componentNames[id]; ok {
+			components = append(components, name)
+		}
+	}
+
+	payloadPost := &statusPagePayloadPost{
+		ID:             post.ID,
+		Kind:           post.Kind,
+		Title:          post.Title,
+		Status:         post.Status,
+		Impact:         post.Impact,
+		Components:     components,
+		ScheduledFor:   post.ScheduledFor,
+		ScheduledUntil: post.ScheduledUntil,
+		Update:         update.Body,
+		UpdatedAt:      update.CreatedAt,
+	}
+
+	subject := fmt.Sprintf("[%s] %s: %s", page.Title, post.Title, statuspage.StatusLabel(post.Status))
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s\nStatus: %s\n", post.Title, statuspage.StatusLabel(post.Status))
+	if len(components) > 0 {
+		fmt.Fprintf(&text, "Affected: %s\n", strings.Join(components, ", "))
+	}
+	if post.ScheduledFor != nil && post.ScheduledUntil != nil {
+		fmt.Fprintf(&text, "Scheduled: %s to %s\n",
+			post.ScheduledFor.UTC().Format("Jan 2, 15:04 MST"), post.ScheduledUntil.UTC().Format("Jan 2, 15:04 MST"))
+	}
+	fmt.Fprintf(&text, "\n%s\n\nView the status page: %s\n", update.Body, ns.statusPageURL(page))
+
+	for _, sub := range subscribers {
+		if !sub.Confirmed {
+			continue
+		}
+		if sub.Channel == statuspage.ChannelWebhook {
+			ns.sendStatusPagePayload(page, sub, statusPagePayload{Event: event, Post: payloadPost})
+			continue
+		}
+		body := text.String() + fmt.Sprintf("\nYou are receiving this because you subscribed to %s status updates.\nUnsubscribe: %s\n",
+			page.Title, ns.statusPageLink(page, "unsubscribe", sub.UnsubscribeToken))
+		ns.sendStatusPageEmail(sub, subject, body)
+	}
+}
+
+func (ns *NotifierService) sendStatusPageEmail(sub *models.StatusPageSubscriber, subject, body string) {
+	ns.send(&models.NotificationDelivery{
+		Channel:     "email",
+		Destination: sub.Destination,
+		Subject:     subject,
+		Body:        body,
+		HTMLBody:    message.TextToHTML(body),
+	})
+}
+
+func (ns *NotifierService) sendStatusPagePayload(page *models.StatusPage, sub *models.StatusPageSubscriber, payload statusPagePayload) {
+	payload.Page = statusPagePayloadPage{Slug: page.Slug, Title: page.Title, URL: ns.statusPageURL(page)}
+	payload.UnsubscribeURL = ns.statusPageLink(page, "unsubscribe", sub.UnsubscribeToken)
+	payload.SentAt = time.Now().UTC()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding status page webhook for %s: %v", sub.Destination, err)
+		return
+	}
+	ns.send(&models.NotificationDelivery{
+		Channel:     statusPageWebhook,
+		Destination: sub.Destination,
+		Subject:     payload.Event,
+		Body:        string(body),
+	})
+}
+
+// sendStatusPageWebhook posts a notification to a status page subscriber's
+// webhook
+func (ns *NotifierService) sendStatusPageWebhook(delivery *models.NotificationDelivery) (int, error) {
+	statusCode, err := webhook.Post(ns.httpClient, delivery.Destination, delivery.Body, nil, "", time.Now())
+	if err != nil {
+		return statusCode, err
+	}
+	log.Printf("✅ Status page webhook sent to %s", delivery.Destination)
+	return statusCode, nil
+}
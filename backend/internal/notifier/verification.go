@@ -0,0 +1,152 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/chat"
+	"pulsegrid/backend/pkg/paging"
+	"pulsegrid/backend/pkg/verification"
+	"pulsegrid/backend/pkg/webhook"
+)
+
+// TestSubscription sends a test message to a subscription straight away,
+// bypassing the outbox, and reports how the destination answered. statusCode
+// is set for channels that answer over HTTP. PagerDuty and Opsgenie get a
+// test incident that is resolved as soon as it opens.
+func (ns *NotifierService) TestSubscription(sub *models.AlertSubscription, subject, text string) (statusCode int, err error) {
+	deliveries, err := ns.testDeliveries(sub, subject, text)
+	if err != nil {
+		return 0, err
+	}
+	for _, delivery := range deliveries {
+		if statusCode, err = ns.deliver(delivery); err != nil {
+			return statusCode, err
+		}
+	}
+	return statusCode, nil
+}
+
+// QueueSubscriptionTest sends a test message to a subscription through the
+// outbox without waiting for the destination to answer. Unless
+// verifiedByCode is set, the subscription is marked verified once the
+// destination accepts the message on its first attempt.
+func (ns *NotifierService) QueueSubscriptionTest(sub *models.AlertSubscription, subject, text string, verifiedByCode bool) error {
+	deliveries, err := ns.testDeliveries(sub, subject, text)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for _, delivery := range deliveries {
+			// A paging test incident is only resolved once it has opened
+			if ns.send(delivery); delivery.Status != DeliveryDelivered {
+				return
+			}
+		}
+		if verifiedByCode || ns.alertRepo == nil {
+			return
+		}
+		if err := ns.alertRepo.MarkSubscriptionVerified(sub.ID); err != nil {
+			log.Printf("Error marking subscription %s verified: %v", sub.ID, err)
+		}
+	}()
+	return nil
+}
+
+// testDeliveries builds the notifications that make up a subscription's test
+// message, in the order they are sent
+func (ns *NotifierService) testDeliveries(sub *models.AlertSubscription, subject, text string) ([]*models.NotificationDelivery, error) {
+	destination := sub.Destination
+	if sub.OnCallScheduleID != nil {
+		destination = ns.onCallDestination(*sub.OnCallScheduleID)
+		if destination == "" {
+			return nil, fmt.Errorf("nobody is on call for the subscription's schedule")
+		}
+	}
+
+	delivery := &models.NotificationDelivery{
+		SubscriptionID: &sub.ID,
+		Channel:        sub.Channel,
+		Destination:    destination,
+		Subject:        subject,
+		Body:           text,
+	}
+	switch {
+	case sub.Channel == "webhook":
+		payload := webhook.Payload{Event: webhook.EventTest, Subject: subject, Message: text, SentAt: time.Now().UTC()}
+		var tmpl string
+		if sub.Webhook != nil {
+			tmpl = sub.Webhook.Template
+		}
+		body, err := webhook.Render(tmpl, payload)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Body = body
+	case chat.IsChannel(sub.Channel):
+		body, err := chat.Render(sub.Channel, destination, chat.Message{Title: subject, Error: text, Link: ns.dashboardURL})
+		if err != nil {
+			return nil, err
+		}
+		delivery.Body = string(body)
+	case paging.IsChannel(sub.Channel):
+		return testPaging(delivery, subject, text)
+	case sub.Channel == "webpush":
+		delivery.Body = ns.testPushBody(subject, text)
+	}
+	return []*models.NotificationDelivery{delivery}, nil
+}
+
+// testPaging builds a low-severity test incident and the event that
+// resolves it straight away
+func testPaging(delivery *models.NotificationDelivery, subject, text string) ([]*models.NotificationDelivery, error) {
+	var deliveries []*models.NotificationDelivery
+	for _, action := range []string{paging.ActionTrigger, paging.ActionResolve} {
+		body, err := json.Marshal(paging.Event{
+			Action:   action,
+			DedupKey: "pulsegrid-test-" + delivery.SubscriptionID.String(),
+			Summary:  subject,
+			Details:  text,
+			Severity: "low",
+		})
+		if err != nil {
+			return nil, err
+		}
+		event := *delivery
+		event.Body = string(body)
+		deliveries = append(deliveries, &event)
+	}
+	return deliveries, nil
+}
+
+// trackSubscription keeps count of the notifications a subscription fails
+// to receive, disabling it once verification.FailureLimit in a row have been
+// dead-lettered. A delivered notification starts the count again.
+func (ns *NotifierService) trackSubscription(delivery *models.NotificationDelivery) {
+	if delivery.SubscriptionID == nil || ns.alertRepo == nil {
+		return
+	}
+
+	switch delivery.Status {
+	case DeliveryDelivered:
+		if err := ns.alertRepo.ResetSubscriptionFailures(*delivery.SubscriptionID); err != nil {
+			log.Printf("Error resetting failures for subscription %s: %v", delivery.SubscriptionID, err)
+		}
+	case DeliveryDead:
+		reason := fmt.Sprintf("Disabled after %d notifications in a row could not be delivered", verification.FailureLimit)
+		if delivery.LastError != nil {
+			reason += ": " + *delivery.LastError
+		}
+		disabled, err := ns.alertRepo.RecordSubscriptionFailure(*delivery.SubscriptionID, verification.FailureLimit, reason)
+		if err != nil {
+			log.Printf("Error recording failure for subscription %s: %v", delivery.SubscriptionID, err)
+		} else if disabled {
+			log.Printf("🚫 Subscription %s (%s to %s) disabled after %d failed notifications",
+				delivery.SubscriptionID, delivery.Channel, delivery.Destination, verification.FailureLimit)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/webhook"
+)
+
+// webhookBody renders the request body for a webhook subscription. A custom
+// template that fails to render falls back to the default JSON payload, so
+// the receiver still hears about the alert.
+func webhookBody(config *models.WebhookConfig, event string, alert *models.Alert, subject, message string) string {
+	payload := webhook.Payload{Event: event, Subject: subject, Message: message, Alert: alert, SentAt: time.Now().UTC()}
+
+	if config != nil && config.Template != "" {
+		body, err := webhook.Render(config.Template, payload)
+		if err == nil {
+			return body
+		}
+		log.Printf("⚠️ Webhook template failed to render, sending the default payload: %v", err)
+	}
+
+	body, err := webhook.Render("", payload)
+	if err != nil {
+		log.Printf("Error rendering webhook payload: %v", err)
+		return message
+	}
+	return body
+}
+
+// sendWebhook posts a webhook notification with its subscription's headers,
+// signed with its secret. The subscription is loaded on every attempt so
+// retries pick up a rotated secret. Webhooks without a subscription, such as an
+// escalation step's, go out unsigned.
+func (ns *NotifierService) sendWebhook(delivery *models.NotificationDelivery) (int, error) {
+	var headers map[string]string
+	var secret string
+	if delivery.SubscriptionID != nil {
+		sub, err := ns.alertRepo.GetSubscription(*delivery.SubscriptionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, permanent(fmt.Errorf("webhook subscription no longer exists"))
+		}
+		if err != nil {
+			return 0, err
+		}
+		if sub.Webhook != nil {
+			headers = sub.Webhook.Headers
+			secret = sub.Webhook.Secret
+		}
+	}
+
+	statusCode, err := webhook.Post(ns.httpClient, delivery.Destination, delivery.Body, headers, secret, time.Now())
+	if err != nil {
+		return statusCode, err
+	}
+	log.Printf("✅ Webhook notification sent to %s", delivery.Destination)
+	return statusCode, nil
+}
@@ -0,0 +1,170 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/message"
+	"pulsegrid/backend/pkg/webpush"
+
+	"github.com/google/uuid"
+)
+
+// pushPayload is what the dashboard's service worker receives and shows as
+// a desktop notification
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url,omitempty"`
+	// Tag replaces an earlier notification about the same alert
+	Tag      string `json:"tag,omitempty"`
+	AlertID  string `json:"alert_id,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Event    string `json:"event,omitempty"`
+}
+
+// webPushBody builds the push payload for an alert notification
+func webPushBody(n *notification, rendered message.Rendered) string {
+	return encodePush(pushPayload{
+		Title:    rendered.Subject,
+		Body:     rendered.Body,
+		URL:      n.data.Link,
+		Tag:      "pulsegrid-" + n.data.Alert.ID,
+		AlertID:  n.data.Alert.ID,
+		Severity: n.data.Alert.Severity,
+		Event:    n.data.Event,
+	})
+}
+
+func encodePush(payload pushPayload) string {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding push payload: %v", err)
+		return payload.Body
+	}
+	return string(body)
+}
+
+// PushPublicKey returns the VAPID public key browsers subscribe with
+func (ns *NotifierService) PushPublicKey() (string, error) {
+	keys, err := ns.vapidKeys()
+	return keys.PublicKey, err
+}
+
+// vapidKeys returns VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY when they are
+// set, and otherwise the pair stored in the database, generated the first
+// time it is needed
+func (ns *NotifierService) vapidKeys() (webpush.Keys, error) {
+	ns.vapidMu.Lock()
+	defer ns.vapidMu.Unlock()
+	if ns.vapid.PublicKey != "" {
+		return ns.vapid, nil
+	}
+
+	keys := webpush.Keys{PublicKey: getEnv("VAPID_PUBLIC_KEY", ""), PrivateKey: getEnv("VAPID_PRIVATE_KEY", "")}
+	if keys.PublicKey == "" && keys.PrivateKey == "" {
+		if ns.pushSubscriptions == nil {
+			return webpush.Keys{}, fmt.Errorf("web push is not configured")
+		}
+		generated, err := webpush.GenerateKeys()
+		if err != nil {
+			return webpush.Keys{}, err
+		}
+		if keys, err = ns.pushSubscriptions.VAPIDKeys(generated); err != nil {
+			return webpush.Keys{}, err
+		}
+	}
+	if err := keys.Validate(); err != nil {
+		return webpush.Keys{}, err
+	}
+
+	ns.vapid = keys
+	return keys, nil
+}
+
+// sendWebPush pushes a notification to every browser the user in the
+// delivery's destination has subscribed, deleting those the push service
+// says are gone. It succeeds if any browser received it.
+func (ns *NotifierService) sendWebPush(delivery *models.NotificationDelivery) (int, error) {
+	userID, err := uuid.Parse(delivery.Destination)
+	if err != nil {
+		return 0, permanent(fmt.Errorf("web push destination must be a user ID"))
+	}
+	var payload pushPayload
+	if err := json.Unmarshal([]byte(delivery.Body), &payload); err != nil {
+		return 0, permanent(fmt.Errorf("invalid push payload: %v", err))
+	}
+	if ns.pushSubscriptions == nil {
+		return 0, permanent(fmt.Errorf("web push is not configured"))
+	}
+	keys, err := ns.vapidKeys()
+	if err != nil {
+		return 0, err
+	}
+
+	browsers, err := ns.pushSubscriptions.ListByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+	if len(browsers) == 0 {
+		return 0, permanent(fmt.Errorf("the user has no browsers subscribed to notifications"))
+	}
+
+	sender := &webpush.Sender{Client: ns.httpClient, Keys: keys, Subject: getEnv("VAPID_SUBJECT", "mailto:"+ns.fromEmail)}
+	msg := webpush.Message{Payload: []byte(delivery.Body), Urgency: pushUrgency(payload), Topic: strings.ReplaceAll(payload.AlertID, "-", "")}
+
+	var delivered, gone, deliveredStatus, failedStatus int
+	var lastErr error
+	for _, browser := range browsers {
+		sub := webpush.Subscription{Endpoint: browser.Endpoint}
+		sub.Keys.P256dh = browser.P256dh
+		sub.Keys.Auth = browser.Auth
+
+		code, err := sender.Send(sub, msg)
+		switch {
+		case errors.Is(err, webpush.ErrGone):
+			gone++
+			if err := ns.pushSubscriptions.DeleteByEndpoint(browser.Endpoint); err != nil {
+				log.Printf("Error deleting expired push subscription %s: %v", browser.ID, err)
+			}
+		case err != nil:
+			failedStatus, lastErr = code, err
+		default:
+			delivered++
+			deliveredStatus = code
+			if err := ns.pushSubscriptions.MarkUsed(browser.ID); err != nil {
+				log.Printf("Error updating push subscription %s: %v", browser.ID, err)
+			}
+		}
+	}
+	if gone > 0 {
+		log.Printf("🧹 Removed %d expired push subscriptions for user %s", gone, userID)
+	}
+
+	switch {
+	case delivered > 0:
+		log.Printf("✅ Push notification sent to %d of %d browsers for user %s", delivered, len(browsers), userID)
+		return deliveredStatus, nil
+	case lastErr != nil:
+		return failedStatus, lastErr
+	default:
+		return 0, permanent(fmt.Errorf("every browser the user subscribed has unsubscribed"))
+	}
+}
+
+// pushUrgency wakes devices straight away for serious alerts
+func pushUrgency(payload pushPayload) string {
+	if payload.Event == message.EventTriggered && (payload.Severity == "critical" || payload.Severity == "high") {
+		return webpush.UrgencyHigh
+	}
+	return webpush.UrgencyNormal
+}
+
+// testPushBody builds the push payload for a test message
+func (ns *NotifierService) testPushBody(subject, text string) string {
+	return encodePush(pushPayload{Title: subject, Body: text, URL: ns.dashboardURL, Tag: "pulsegrid-test"})
+}
@@ -0,0 +1,122 @@
+// Package oncall connects stored on-call schedules to the rotation math in
+// pkg/rotation, answering who is on call for the API and for notifications
+// addressed to a schedule.
+package oncall
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/internal/repository"
+	"pulsegrid/backend/pkg/rotation"
+
+	"github.com/google/uuid"
+)
+
+// MaxLayers bounds the layers of a schedule
+const MaxLayers = 10
+
+// Validate checks a schedule's name, time zone and layers
+func Validate(schedule *models.OnCallSchedule) error {
+	if strings.TrimSpace(schedule.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if _, err := time.LoadLocation(schedule.TimeZone); err != nil || schedule.TimeZone == "" || schedule.TimeZone == "Local" {
+		return fmt.Errorf("time_zone must be an IANA time zone such as Europe/London")
+	}
+	if len(schedule.Layers) == 0 {
+		return fmt.Errorf("a schedule needs at least one layer")
+	}
+	if len(schedule.Layers) > MaxLayers {
+		return fmt.Errorf("a schedule can have at most %d layers", MaxLayers)
+	}
+	for i, layer := range schedule.Layers {
+		if err := rotation.ValidateLayer(toLayer(layer)); err != nil {
+			return fmt.Errorf("layer %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ToRotation converts a stored schedule and its overrides for pkg/rotation
+func ToRotation(schedule *models.OnCallSchedule, overrides []*models.OnCallOverride) (rotation.Schedule, error) {
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return rotation.Schedule{}, err
+	}
+
+	s := rotation.Schedule{Location: loc}
+	for _, layer := range schedule.Layers {
+		s.Layers = append(s.Layers, toLayer(layer))
+	}
+	for _, o := range overrides {
+		s.Overrides = append(s.Overrides, rotation.Override{
+			UserID:   o.UserID.String(),
+			StartsAt: o.StartsAt,
+			EndsAt:   o.EndsAt,
+		})
+	}
+	return s, nil
+}
+
+func toLayer(layer models.OnCallLayer) rotation.Layer {
+	participants := make([]string, 0, len(layer.ParticipantIDs))
+	for _, id := range layer.ParticipantIDs {
+		participants = append(participants, id.String())
+	}
+	return rotation.Layer{
+		Name:           layer.Name,
+		Rotation:       layer.Rotation,
+		HandoffTime:    layer.HandoffTime,
+		HandoffWeekday: time.Weekday(layer.HandoffWeekday),
+		StartsAt:       layer.StartsAt,
+		EndsAt:         layer.EndsAt,
+		Participants:   participants,
+	}
+}
+
+type Resolver struct {
+	oncallRepo *repository.OnCallRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewResolver(oncallRepo *repository.OnCallRepository, userRepo *repository.UserRepository) *Resolver {
+	return &Resolver{
+		oncallRepo: oncallRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// Load builds the rotation for a schedule with the overrides overlapping from..to
+func (r *Resolver) Load(schedule *models.OnCallSchedule, from, to time.Time) (rotation.Schedule, error) {
+	overrides, err := r.oncallRepo.ListOverrides(schedule.ID, from, to)
+	if err != nil {
+		return rotation.Schedule{}, err
+	}
+	return ToRotation(schedule, overrides)
+}
+
+// UserAt returns who is on call for a schedule at t, or nil if nobody is
+func (r *Resolver) UserAt(scheduleID uuid.UUID, t time.Time) (*models.User, error) {
+	schedule, err := r.oncallRepo.GetSchedule(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := r.Load(schedule, t, t.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _, ok := s.At(t)
+	if !ok {
+		return nil, nil
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	return r.userRepo.GetByID(id)
+}
@@ -2,12 +2,31 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/verification"
 )
 
+// alertColumns lists the columns read by scanAlert, in scan order
+const alertColumns = `id, service_id, type, message, severity, is_resolved, resolved_at,
+	resolved_by, outage_duration_seconds, is_suppressed, suppressed_reason, caused_by_service_id, alert_rule_id,
+	status, acknowledged_at, acknowledged_by, snoozed_until, assignee_id, incident_id, created_at`
+
+var qualifiedAlertColumns = qualifyColumns("a", alertColumns)
+
+const alertEventColumns = `id, alert_id, kind, user_id, body, created_at`
+
+// subscriptionColumns lists the columns read by scanSubscription, in scan order
+const subscriptionColumns = `id, organization_id, service_id, channel, destination, oncall_schedule_id,
+	webhook_template, webhook_headers, webhook_secret, min_severity, alert_types, service_tags, events,
+	quiet_hours_start, quiet_hours_end, time_zone, digest_minutes, verification_status, verified_at, verification_code,
+	verification_expires_at, verification_attempts, consecutive_failures, disabled_reason, is_active, created_at`
+
 type AlertRepository struct {
 	db *sql.DB
 }
@@ -16,162 +35,521 @@ func NewAlertRepository(db *sql.DB) *AlertRepository {
 	return &AlertRepository{db: db}
 }
 
+// Create opens an alert and starts its timeline
 func (r *AlertRepository) Create(alert *models.Alert) error {
 	query := `
-		INSERT INTO alerts (id, service_id, type, message, severity, is_resolved, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO alerts (id, service_id, type, message, severity, is_resolved, is_suppressed, suppressed_reason, caused_by_service_id, alert_rule_id, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, created_at
 	`
 
 	alert.ID = uuid.New()
 	alert.CreatedAt = time.Now().UTC()
+	if alert.Status == "" {
+		alert.Status = alerting.StatusTriggered
+	}
 
-	err := r.db.QueryRow(
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		query,
 		alert.ID, alert.ServiceID, alert.Type, alert.Message,
-		alert.Severity, alert.IsResolved, alert.CreatedAt,
+		alert.Severity, alert.IsResolved, alert.IsSuppressed, alert.SuppressedReason,
+		alert.CausedByServiceID, alert.AlertRuleID, alert.Status, alert.CreatedAt,
 	).Scan(&alert.ID, &alert.CreatedAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	body := alert.Message
+	if alert.SuppressedReason != nil {
+		body += " (" + *alert.SuppressedReason + ")"
+	}
+	event := &models.AlertEvent{AlertID: alert.ID, Kind: alerting.EventTriggered, Body: body}
+	if err := insertAlertEvent(tx, event, alert.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *AlertRepository) GetByID(id uuid.UUID) (*models.Alert, error) {
 	query := `
-		SELECT id, service_id, type, message, severity, is_resolved, resolved_at, created_at
+		SELECT ` + alertColumns + `
 		FROM alerts
 		WHERE id = $1
 	`
 
-	alert := &models.Alert{}
-	var resolvedAt sql.NullTime
+	return scanAlert(r.db.QueryRow(query, id))
+}
 
-	err := r.db.QueryRow(query, id).Scan(
-		&alert.ID, &alert.ServiceID, &alert.Type, &alert.Message,
-		&alert.Severity, &alert.IsResolved, &resolvedAt, &alert.CreatedAt,
-	)
+// ListByOrganization returns the organization's newest alerts, limited to
+// one status unless status is empty
+func (r *AlertRepository) ListByOrganization(orgID uuid.UUID, status string, limit int) ([]*models.Alert, error) {
+	query := `
+		SELECT ` + qualifiedAlertColumns + `
+		FROM alerts a
+		JOIN services s ON a.service_id = s.id
+		WHERE s.organization_id = $1 AND ($2 = '' OR a.status = $2)
+		ORDER BY a.created_at DESC
+		LIMIT $3
+	`
 
+	rows, err := r.db.Query(query, orgID, status, limit)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	if resolvedAt.Valid {
-		alert.ResolvedAt = &resolvedAt.Time
+	alerts := make([]*models.Alert, 0) // Initialize as empty slice, not nil
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		alerts = append(alerts, alert)
 	}
 
-	return alert, nil
+	return alerts, rows.Err()
 }
 
-func (r *AlertRepository) ListByOrganization(orgID uuid.UUID, limit int) ([]*models.Alert, error) {
+// Resolve marks an alert resolved, recording who or what resolved it and how
+// long it was open. It reports false, changing nothing, if the alert was
+// already resolved.
+func (r *AlertRepository) Resolve(id uuid.UUID, resolvedBy string) (bool, error) {
+	now := time.Now().UTC()
 	query := `
-		SELECT a.id, a.service_id, a.type, a.message, a.severity, a.is_resolved, a.resolved_at, a.created_at
-		FROM alerts a
-		JOIN services s ON a.service_id = s.id
-		WHERE s.organization_id = $1
-		ORDER BY a.created_at DESC
-		LIMIT $2
+		UPDATE alerts
+		SET is_resolved = TRUE, status = 'resolved', resolved_at = $2, resolved_by = $3,
+			outage_duration_seconds = GREATEST(0, EXTRACT(EPOCH FROM ($2 - created_at)))::INTEGER
+		WHERE id = $1 AND is_resolved = FALSE
+		RETURNING id
 	`
 
-	rows, err := r.db.Query(query, orgID, limit)
+	event := &models.AlertEvent{AlertID: id, Kind: alerting.EventResolved}
+	if resolvedBy == alerting.ResolvedByAuto {
+		event.Body = "Resolved automatically after the service recovered"
+	} else if userID, err := uuid.Parse(resolvedBy); err == nil {
+		event.UserID = &userID
+	}
+
+	return r.transition(query, []interface{}{id, now, resolvedBy}, event, now)
+}
+
+// Acknowledge records that a user has taken an alert, which stops its
+// escalation and repeat notifications. It reports false for resolved and
+// already acknowledged alerts, which are left alone.
+func (r *AlertRepository) Acknowledge(id, userID uuid.UUID) (bool, error) {
+	now := time.Now().UTC()
+	query := `
+		UPDATE alerts
+		SET status = 'acknowledged', acknowledged_at = $2, acknowledged_by = $3
+		WHERE id = $1 AND is_resolved = FALSE AND acknowledged_at IS NULL
+		RETURNING id
+	`
+
+	event := &models.AlertEvent{AlertID: id, Kind: alerting.EventAcknowledged, UserID: &userID}
+	return r.transition(query, []interface{}{id, now, userID}, event, now)
+}
+
+// AcknowledgeRemotely acknowledges an alert on behalf of an external paging
+// tool, which has no PulseGrid user to record; note says who took it there
+func (r *AlertRepository) AcknowledgeRemotely(id uuid.UUID, note string) (bool, error) {
+	now := time.Now().UTC()
+	query := `
+		UPDATE alerts
+		SET status = 'acknowledged', acknowledged_at = $2
+		WHERE id = $1 AND is_resolved = FALSE AND acknowledged_at IS NULL
+		RETURNING id
+	`
+
+	event := &models.AlertEvent{AlertID: id, Kind: alerting.EventAcknowledged, Body: note}
+	return r.transition(query, []interface{}{id, now}, event, now)
+}
+
+// Snooze silences an open alert's notifications until until, or lifts the
+// snooze when until is nil. It reports false for resolved alerts.
+func (r *AlertRepository) Snooze(id, userID uuid.UUID, until *time.Time) (bool, error) {
+	now := time.Now().UTC()
+	query := `
+		UPDATE alerts
+		SET snoozed_until = $2
+		WHERE id = $1 AND is_resolved = FALSE
+		RETURNING id
+	`
+
+	event := &models.AlertEvent{AlertID: id, Kind: alerting.EventUnsnoozed, UserID: &userID}
+	if until != nil {
+		event.Kind = alerting.EventSnoozed
+		event.Body = "Snoozed until " + until.UTC().Format(time.RFC3339)
+	}
+
+	return r.transition(query, []interface{}{id, until}, event, now)
+}
+
+// Assign makes assignee responsible for an alert, or clears the assignee when
+// it is nil. actorID is the user making the change.
+func (r *AlertRepository) Assign(id, actorID uuid.UUID, assignee *models.User) (bool, error) {
+	now := time.Now().UTC()
+	query := `
+		UPDATE alerts
+		SET assignee_id = $2
+		WHERE id = $1
+		RETURNING id
+	`
+
+	var assigneeID *uuid.UUID
+	event := &models.AlertEvent{AlertID: id, Kind: alerting.EventUnassigned, UserID: &actorID}
+	if assignee != nil {
+		assigneeID = &assignee.ID
+		event.Kind = alerting.EventAssigned
+		event.Body = "Assigned to " + assignee.Name
+	}
+
+	return r.transition(query, []interface{}{id, assigneeID}, event, now)
+}
+
+// ListOpenByService returns the unresolved alerts for a service, oldest first
+func (r *AlertRepository) ListOpenByService(serviceID uuid.UUID) ([]*models.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts
+		WHERE service_id = $1 AND is_resolved = FALSE
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, serviceID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	alerts := make([]*models.Alert, 0) // Initialize as empty slice, not nil
+	alerts := make([]*models.Alert, 0)
 	for rows.Next() {
-		alert := &models.Alert{}
-		var resolvedAt sql.NullTime
-
-		err := rows.Scan(
-			&alert.ID, &alert.ServiceID, &alert.Type, &alert.Message,
-			&alert.Severity, &alert.IsResolved, &resolvedAt, &alert.CreatedAt,
-		)
+		alert, err := scanAlert(rows)
 		if err != nil {
 			return nil, err
 		}
+		alerts = append(alerts, alert)
+	}
 
-		if resolvedAt.Valid {
-			alert.ResolvedAt = &resolvedAt.Time
-		}
+	return alerts, rows.Err()
+}
 
+// ListByIncident returns the alerts grouped into an incident, oldest first
+func (r *AlertRepository) ListByIncident(incidentID uuid.UUID) ([]*models.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts
+		WHERE incident_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]*models.Alert, 0)
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
 		alerts = append(alerts, alert)
 	}
 
 	return alerts, rows.Err()
 }
 
-func (r *AlertRepository) Resolve(id uuid.UUID) error {
+// Escalate raises an alert's severity and replaces its message
+func (r *AlertRepository) Escalate(id uuid.UUID, severity, message string) error {
+	now := time.Now().UTC()
 	query := `
 		UPDATE alerts
-		SET is_resolved = TRUE, resolved_at = $2
+		SET severity = $2, message = $3
 		WHERE id = $1
+		RETURNING id
 	`
-	_, err := r.db.Exec(query, id, time.Now().UTC())
+
+	event := &models.AlertEvent{AlertID: id, Kind: alerting.EventEscalated, Body: message}
+	_, err := r.transition(query, []interface{}{id, severity, message}, event, now)
 	return err
 }
 
-func (r *AlertRepository) GetSubscriptionsByOrganization(orgID uuid.UUID) ([]*models.AlertSubscription, error) {
+// AddEvent appends an event, such as a note, to an alert's timeline
+func (r *AlertRepository) AddEvent(event *models.AlertEvent) error {
+	return insertAlertEvent(r.db, event, time.Now().UTC())
+}
+
+// ListEvents returns an alert's timeline, oldest first
+func (r *AlertRepository) ListEvents(alertID uuid.UUID) ([]*models.AlertEvent, error) {
 	query := `
-		SELECT id, organization_id, service_id, channel, destination, is_active, created_at
-		FROM alert_subscriptions
-		WHERE organization_id = $1 AND is_active = TRUE
+		SELECT ` + alertEventColumns + `
+		FROM alert_events
+		WHERE alert_id = $1
+		ORDER BY created_at, id
 	`
 
-	rows, err := r.db.Query(query, orgID)
+	rows, err := r.db.Query(query, alertID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var subscriptions []*models.AlertSubscription
+	events := make([]*models.AlertEvent, 0)
 	for rows.Next() {
-		sub := &models.AlertSubscription{}
-		var serviceID sql.NullString
-
-		err := rows.Scan(
-			&sub.ID, &sub.OrganizationID, &serviceID,
-			&sub.Channel, &sub.Destination, &sub.IsActive, &sub.CreatedAt,
-		)
-		if err != nil {
+		event := &models.AlertEvent{}
+		var userID uuid.NullUUID
+		if err := rows.Scan(&event.ID, &event.AlertID, &event.Kind, &userID, &event.Body, &event.CreatedAt); err != nil {
 			return nil, err
 		}
-
-		if serviceID.Valid {
-			id, _ := uuid.Parse(serviceID.String)
-			sub.ServiceID = &id
+		if userID.Valid {
+			event.UserID = &userID.UUID
 		}
+		events = append(events, event)
+	}
 
-		subscriptions = append(subscriptions, sub)
+	return events, rows.Err()
+}
+
+// transition runs an UPDATE ... RETURNING id on one alert and, if it changed
+// a row, records event on the timeline in the same transaction. It reports
+// whether the alert changed.
+func (r *AlertRepository) transition(query string, args []interface{}, event *models.AlertEvent, now time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
 	}
+	defer tx.Rollback()
 
-	return subscriptions, rows.Err()
+	var id uuid.UUID
+	err = tx.QueryRow(query, args...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := insertAlertEvent(tx, event, now); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func insertAlertEvent(db execer, event *models.AlertEvent, now time.Time) error {
+	event.ID = uuid.New()
+	event.CreatedAt = now
+	_, err := db.Exec(
+		`INSERT INTO alert_events (`+alertEventColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		event.ID, event.AlertID, event.Kind, event.UserID, event.Body, event.CreatedAt,
+	)
+	return err
+}
+
+// GetSubscriptionsByOrganization lists every subscription in an
+// organization, including those still pending verification and those
+// disabled after failing
+func (r *AlertRepository) GetSubscriptionsByOrganization(orgID uuid.UUID) ([]*models.AlertSubscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM alert_subscriptions
+		WHERE organization_id = $1
+		ORDER BY created_at
+	`
+
+	return r.listSubscriptions(query, orgID)
+}
+
+// GetSubscription returns a subscription, including its webhook secret
+func (r *AlertRepository) GetSubscription(id uuid.UUID) (*models.AlertSubscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM alert_subscriptions
+		WHERE id = $1
+	`
+
+	return scanSubscription(r.db.QueryRow(query, id))
 }
 
 func (r *AlertRepository) CreateSubscription(sub *models.AlertSubscription) error {
 	query := `
-		INSERT INTO alert_subscriptions (id, organization_id, service_id, channel, destination, is_active, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO alert_subscriptions (id, organization_id, service_id, channel, destination, oncall_schedule_id,
+			webhook_template, webhook_headers, webhook_secret, min_severity, alert_types, service_tags, events,
+			quiet_hours_start, quiet_hours_end, time_zone, digest_minutes, verification_status, verified_at, is_active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 		RETURNING id, created_at
 	`
 
 	sub.ID = uuid.New()
 	sub.CreatedAt = time.Now().UTC()
+	if sub.VerificationStatus == "" {
+		sub.VerificationStatus = verification.StatusPending
+	}
+	if sub.TimeZone == "" {
+		sub.TimeZone = "UTC"
+	}
+
+	var template sql.NullString
+	var headers []byte
+	// Webhooks sign with the secret; PagerDuty and Opsgenie subscriptions
+	// keep their inbound token in the same column
+	secret := sql.NullString{String: sub.InboundToken, Valid: sub.InboundToken != ""}
+	if sub.Webhook != nil {
+		template = sql.NullString{String: sub.Webhook.Template, Valid: sub.Webhook.Template != ""}
+		secret = sql.NullString{String: sub.Webhook.Secret, Valid: sub.Webhook.Secret != ""}
+		if len(sub.Webhook.Headers) > 0 {
+			var err error
+			if headers, err = json.Marshal(sub.Webhook.Headers); err != nil {
+				return err
+			}
+		}
+	}
 
 	err := r.db.QueryRow(
 		query,
 		sub.ID, sub.OrganizationID, sub.ServiceID, sub.Channel,
-		sub.Destination, sub.IsActive, sub.CreatedAt,
+		sub.Destination, sub.OnCallScheduleID, template, headers, secret, sub.MinSeverity, stringArray(sub.AlertTypes),
+		stringArray(sub.ServiceTags), stringArray(sub.Events), sub.QuietHoursStart, sub.QuietHoursEnd, sub.TimeZone,
+		sub.DigestMinutes, sub.VerificationStatus, sub.VerifiedAt, sub.IsActive, sub.CreatedAt,
 	).Scan(&sub.ID, &sub.CreatedAt)
 
 	return err
 }
 
+// SetVerificationCode stores a newly sent confirmation code, resetting the
+// wrong guesses counted against the last one
+func (r *AlertRepository) SetVerificationCode(id uuid.UUID, code string, expiresAt time.Time) error {
+	query := `
+		UPDATE alert_subscriptions
+		SET verification_code = $2, verification_expires_at = $3, verification_attempts = 0
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, code, expiresAt)
+	return err
+}
+
+// RecordVerificationAttempt counts a wrong confirmation code
+func (r *AlertRepository) RecordVerificationAttempt(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE alert_subscriptions SET verification_attempts = verification_attempts + 1 WHERE id = $1`, id)
+	return err
+}
+
+// MarkSubscriptionVerified records that a subscription's destination was
+// confirmed, re-enabling it if failures had disabled it
+func (r *AlertRepository) MarkSubscriptionVerified(id uuid.UUID) error {
+	query := `
+		UPDATE alert_subscriptions
+		SET verification_status = $2, verified_at = $3, verification_code = NULL, verification_expires_at = NULL,
+			verification_attempts = 0, consecutive_failures = 0, disabled_reason = NULL, is_active = TRUE
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, verification.StatusVerified, time.Now().UTC())
+	return err
+}
+
+// ResetSubscriptionFailures clears a subscription's failure count after a
+// notification to it is delivered
+func (r *AlertRepository) ResetSubscriptionFailures(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE alert_subscriptions SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures > 0`, id)
+	return err
+}
+
+// RecordSubscriptionFailure counts a dead-lettered notification against a
+// subscription, disabling it with reason once limit are counted in a row.
+// It reports whether this failure disabled the subscription.
+func (r *AlertRepository) RecordSubscriptionFailure(id uuid.UUID, limit int, reason string) (bool, error) {
+	query := `
+		UPDATE alert_subscriptions
+		SET consecutive_failures = consecutive_failures + 1,
+			disabled_reason = CASE WHEN is_active AND consecutive_failures + 1 >= $2 THEN $3 ELSE disabled_reason END,
+			is_active = is_active AND consecutive_failures + 1 < $2
+		WHERE id = $1
+		RETURNING NOT is_active AND consecutive_failures = $2
+	`
+
+	var disabled bool
+	err := r.db.QueryRow(query, id, limit, reason).Scan(&disabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return disabled, err
+}
+
 func (r *AlertRepository) DeleteSubscription(id uuid.UUID) error {
 	query := `DELETE FROM alert_subscriptions WHERE id = $1`
 	_, err := r.db.Exec(query, id)
 	return err
 }
 
+// GetSubscriptionsForAlert returns the subscriptions to notify about an
+// alert event (triggered, escalated or resolved): those covering the alert's
+// service whose routing filters let it through
+func (r *AlertRepository) GetSubscriptionsForAlert(alert *models.Alert, event string) ([]*models.AlertSubscription, error) {
+	var tags pq.StringArray
+	if err := r.db.QueryRow(`SELECT tags FROM services WHERE id = $1`, alert.ServiceID).Scan(&tags); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := r.GetSubscriptionsByService(alert.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	route := alerting.Route{Event: event, Severity: alert.Severity, AlertType: alert.Type, ServiceTags: tags}
+	return routeSubscriptions(subscriptions, route), nil
+}
+
+// routeSubscriptions keeps the subscriptions whose filters match route
+func routeSubscriptions(subscriptions []*models.AlertSubscription, route alerting.Route) []*models.AlertSubscription {
+	matched := make([]*models.AlertSubscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		filter := alerting.RouteFilter{
+			MinSeverity: sub.MinSeverity,
+			AlertTypes:  sub.AlertTypes,
+			ServiceTags: sub.ServiceTags,
+			Events:      sub.Events,
+		}
+		if filter.Matches(route) {
+			matched = append(matched, sub)
+		}
+	}
+	return matched
+}
+
+// UpdateSubscriptionRouting replaces a subscription's routing filters
+func (r *AlertRepository) UpdateSubscriptionRouting(sub *models.AlertSubscription) error {
+	query := `
+		UPDATE alert_subscriptions
+		SET min_severity = $2, alert_types = $3, service_tags = $4, events = $5
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, sub.ID, sub.MinSeverity, stringArray(sub.AlertTypes), stringArray(sub.ServiceTags), stringArray(sub.Events))
+	return err
+}
+
+// UpdateSubscriptionQuietHours replaces a subscription's quiet hours and
+// digest interval
+func (r *AlertRepository) UpdateSubscriptionQuietHours(sub *models.AlertSubscription) error {
+	query := `
+		UPDATE alert_subscriptions
+		SET quiet_hours_start = $2, quiet_hours_end = $3, time_zone = $4, digest_minutes = $5
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, sub.ID, sub.QuietHoursStart, sub.QuietHoursEnd, sub.TimeZone, sub.DigestMinutes)
+	return err
+}
+
+// GetSubscriptionsByService returns the active, verified subscriptions
+// covering a service, whatever their routing filters
 func (r *AlertRepository) GetSubscriptionsByService(serviceID uuid.UUID) ([]*models.AlertSubscription, error) {
 	var orgID uuid.UUID
 	if err := r.db.QueryRow(`SELECT organization_id FROM services WHERE id = $1`, serviceID).Scan(&orgID); err != nil {
@@ -179,14 +557,19 @@ func (r *AlertRepository) GetSubscriptionsByService(serviceID uuid.UUID) ([]*mod
 	}
 
 	query := `
-		SELECT id, organization_id, service_id, channel, destination, is_active, created_at
+		SELECT ` + subscriptionColumns + `
 		FROM alert_subscriptions
 		WHERE organization_id = $1
 		  AND (service_id = $2 OR service_id IS NULL)
 		  AND is_active = TRUE
+		  AND verification_status = $3
 	`
 
-	rows, err := r.db.Query(query, orgID, serviceID)
+	return r.listSubscriptions(query, orgID, serviceID, verification.StatusVerified)
+}
+
+func (r *AlertRepository) listSubscriptions(query string, args ...interface{}) ([]*models.AlertSubscription, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -194,22 +577,10 @@ func (r *AlertRepository) GetSubscriptionsByService(serviceID uuid.UUID) ([]*mod
 
 	var subscriptions []*models.AlertSubscription
 	for rows.Next() {
-		sub := &models.AlertSubscription{}
-		var serviceID sql.NullString
-
-		err := rows.Scan(
-			&sub.ID, &sub.OrganizationID, &serviceID,
-			&sub.Channel, &sub.Destination, &sub.IsActive, &sub.CreatedAt,
-		)
+		sub, err := scanSubscription(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if serviceID.Valid {
-			id, _ := uuid.Parse(serviceID.String)
-			sub.ServiceID = &id
-		}
-
 		subscriptions = append(subscriptions, sub)
 	}
 
@@ -219,3 +590,120 @@ func (r *AlertRepository) GetSubscriptionsByService(serviceID uuid.UUID) ([]*mod
 func (r *AlertRepository) GetDB() *sql.DB {
 	return r.db
 }
+
+func scanAlert(row rowScanner) (*models.Alert, error) {
+	alert := &models.Alert{}
+	var resolvedAt sql.NullTime
+	var resolvedBy, suppressedReason sql.NullString
+	var outageDuration sql.NullInt64
+	var acknowledgedAt, snoozedUntil sql.NullTime
+	var causedBy, alertRuleID, acknowledgedBy, assigneeID, incidentID uuid.NullUUID
+
+	err := row.Scan(
+		&alert.ID, &alert.ServiceID, &alert.Type, &alert.Message,
+		&alert.Severity, &alert.IsResolved, &resolvedAt,
+		&resolvedBy, &outageDuration, &alert.IsSuppressed, &suppressedReason, &causedBy, &alertRuleID,
+		&alert.Status, &acknowledgedAt, &acknowledgedBy, &snoozedUntil, &assigneeID, &incidentID, &alert.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedAt.Valid {
+		alert.ResolvedAt = &resolvedAt.Time
+	}
+	if resolvedBy.Valid {
+		alert.ResolvedBy = &resolvedBy.String
+	}
+	if outageDuration.Valid {
+		duration := int(outageDuration.Int64)
+		alert.OutageDurationSeconds = &duration
+	}
+	if suppressedReason.Valid {
+		alert.SuppressedReason = &suppressedReason.String
+	}
+	if causedBy.Valid {
+		alert.CausedByServiceID = &causedBy.UUID
+	}
+	if alertRuleID.Valid {
+		alert.AlertRuleID = &alertRuleID.UUID
+	}
+	if acknowledgedAt.Valid {
+		alert.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	if acknowledgedBy.Valid {
+		alert.AcknowledgedBy = &acknowledgedBy.UUID
+	}
+	if snoozedUntil.Valid {
+		alert.SnoozedUntil = &snoozedUntil.Time
+	}
+	if assigneeID.Valid {
+		alert.AssigneeID = &assigneeID.UUID
+	}
+	if incidentID.Valid {
+		alert.IncidentID = &incidentID.UUID
+	}
+
+	return alert, nil
+}
+
+func scanSubscription(row rowScanner) (*models.AlertSubscription, error) {
+	sub := &models.AlertSubscription{}
+	var serviceID, scheduleID uuid.NullUUID
+	var template, secret, code, disabledReason sql.NullString
+	var verifiedAt, codeExpiresAt sql.NullTime
+	var alertTypes, serviceTags, events pq.StringArray
+	var headers []byte
+
+	err := row.Scan(
+		&sub.ID, &sub.OrganizationID, &serviceID, &sub.Channel, &sub.Destination, &scheduleID,
+		&template, &headers, &secret, &sub.MinSeverity, &alertTypes, &serviceTags, &events, &sub.QuietHoursStart,
+		&sub.QuietHoursEnd, &sub.TimeZone, &sub.DigestMinutes, &sub.VerificationStatus, &verifiedAt, &code,
+		&codeExpiresAt, &sub.VerificationAttempts, &sub.ConsecutiveFailures, &disabledReason, &sub.IsActive, &sub.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.AlertTypes = []string(alertTypes)
+	sub.ServiceTags = []string(serviceTags)
+	sub.Events = []string(events)
+	sub.VerificationCode = code.String
+	if verifiedAt.Valid {
+		sub.VerifiedAt = &verifiedAt.Time
+	}
+	if codeExpiresAt.Valid {
+		sub.VerificationExpiresAt = &codeExpiresAt.Time
+	}
+	if disabledReason.Valid {
+		sub.DisabledReason = &disabledReason.String
+	}
+
+	if serviceID.Valid {
+		sub.ServiceID = &serviceID.UUID
+	}
+	if scheduleID.Valid {
+		sub.OnCallScheduleID = &scheduleID.UUID
+	}
+	if sub.Channel != "webhook" {
+		sub.InboundToken = secret.String
+	} else {
+		sub.Webhook = &models.WebhookConfig{Template: template.String, Secret: secret.String}
+		if len(headers) > 0 {
+			if err := json.Unmarshal(headers, &sub.Webhook.Headers); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return sub, nil
+}
+
+// stringArray binds a list to a NOT NULL array column, writing nil as an
+// empty array
+func stringArray(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+	return pq.Array(values)
+}
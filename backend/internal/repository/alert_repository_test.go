@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"testing"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/alerting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func channels(subs []*models.AlertSubscription) []string {
+	names := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		names = append(names, sub.Channel)
+	}
+	return names
+}
+
+func TestRouteSubscriptions(t *testing.T) {
+	slack := &models.AlertSubscription{Channel: "slack"}
+	sms := &models.AlertSubscription{
+		Channel:     "sms",
+		MinSeverity: alerting.SeverityCritical,
+		AlertTypes:  []string{alerting.TypeDowntime},
+	}
+	payments := &models.AlertSubscription{Channel: "email", ServiceTags: []string{"payments", "checkout"}}
+	resolutions := &models.AlertSubscription{Channel: "webhook", Events: []string{alerting.EventResolved}}
+	highAndUp := &models.AlertSubscription{Channel: "teams", MinSeverity: alerting.SeverityHigh}
+	subs := []*models.AlertSubscription{slack, sms, payments, resolutions, highAndUp}
+
+	tests := []struct {
+		name  string
+		route alerting.Route
+		want  []string
+	}{
+		{
+			name:  "critical downtime reaches sms",
+			route: alerting.Route{Event: alerting.EventTriggered, Severity: "critical", AlertType: "downtime"},
+			want:  []string{"slack", "sms", "teams"},
+		},
+		{
+			name:  "high downtime is below the sms minimum",
+			route: alerting.Route{Event: alerting.EventTriggered, Severity: "high", AlertType: "downtime"},
+			want:  []string{"slack", "teams"},
+		},
+		{
+			name:  "critical latency is the wrong type for sms",
+			route: alerting.Route{Event: alerting.EventTriggered, Severity: "critical", AlertType: "latency"},
+			want:  []string{"slack", "teams"},
+		},
+		{
+			name:  "an escalation to critical reaches sms",
+			route: alerting.Route{Event: alerting.EventEscalated, Severity: "critical", AlertType: "downtime"},
+			want:  []string{"slack", "sms", "teams"},
+		},
+		{
+			name:  "resolved events reach subscriptions that only want resolutions",
+			route: alerting.Route{Event: alerting.EventResolved, Severity: "medium", AlertType: "latency"},
+			want:  []string{"slack", "webhook"},
+		},
+		{
+			name:  "services with any of the tags match",
+			route: alerting.Route{Event: alerting.EventTriggered, Severity: "low", AlertType: "threshold", ServiceTags: []string{"internal", "checkout"}},
+			want:  []string{"slack", "email"},
+		},
+		{
+			name:  "untagged services miss tag filters",
+			route: alerting.Route{Event: alerting.EventTriggered, Severity: "low", AlertType: "threshold"},
+			want:  []string{"slack"},
+		},
+		{
+			name:  "unknown severities rank below every minimum",
+			route: alerting.Route{Event: alerting.EventTriggered, Severity: "", AlertType: "downtime"},
+			want:  []string{"slack"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, channels(routeSubscriptions(subs, tt.route)))
+		})
+	}
+}
+
+func TestRouteFilterValidate(t *testing.T) {
+	assert.NoError(t, alerting.RouteFilter{}.Validate())
+	assert.NoError(t, alerting.RouteFilter{
+		MinSeverity: "high",
+		AlertTypes:  []string{"downtime", "flapping"},
+		ServiceTags: []string{"payments"},
+		Events:      []string{"triggered", "escalated"},
+	}.Validate())
+	assert.Error(t, alerting.RouteFilter{MinSeverity: "urgent"}.Validate())
+	assert.Error(t, alerting.RouteFilter{AlertTypes: []string{"outage"}}.Validate())
+	assert.Error(t, alerting.RouteFilter{Events: []string{"acknowledged"}}.Validate())
+	assert.Error(t, alerting.RouteFilter{ServiceTags: []string{" "}}.Validate())
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+)
+
+type AlertRuleRepository struct {
+	db *sql.DB
+}
+
+func NewAlertRuleRepository(db *sql.DB) *AlertRuleRepository {
+	return &AlertRuleRepository{db: db}
+}
+
+const alertRuleColumns = `id, service_id, name, kind, threshold, status_codes, window_seconds, cooldown_seconds,
+	severity, is_enabled, last_triggered_at, created_at, updated_at`
+
+func (r *AlertRuleRepository) Create(rule *models.AlertRule) error {
+	query := `
+		INSERT INTO alert_rules (` + alertRuleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at
+	`
+
+	now := time.Now().UTC()
+	rule.ID = uuid.New()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	return r.db.QueryRow(
+		query,
+		rule.ID, rule.ServiceID, rule.Name, rule.Kind, rule.Threshold, rule.StatusCodes, rule.WindowSeconds,
+		rule.CooldownSeconds, rule.Severity, rule.IsEnabled, rule.LastTriggeredAt, rule.CreatedAt, rule.UpdatedAt,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+func (r *AlertRuleRepository) GetByID(id uuid.UUID) (*models.AlertRule, error) {
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM alert_rules
+		WHERE id = $1
+	`
+
+	return scanAlertRule(r.db.QueryRow(query, id))
+}
+
+func (r *AlertRuleRepository) ListByService(serviceID uuid.UUID) ([]*models.AlertRule, error) {
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM alert_rules
+		WHERE service_id = $1
+		ORDER BY created_at
+	`
+
+	return r.list(query, serviceID)
+}
+
+// ListEnabledByService returns the rules evaluated after each check of a service
+func (r *AlertRuleRepository) ListEnabledByService(serviceID uuid.UUID) ([]*models.AlertRule, error) {
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM alert_rules
+		WHERE service_id = $1 AND is_enabled = TRUE
+		ORDER BY created_at
+	`
+
+	return r.list(query, serviceID)
+}
+
+func (r *AlertRuleRepository) Update(rule *models.AlertRule) error {
+	query := `
+		UPDATE alert_rules
+		SET name = $2, kind = $3, threshold = $4, status_codes = $5, window_seconds = $6,
+			cooldown_seconds = $7, severity = $8, is_enabled = $9, updated_at = $10
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	rule.UpdatedAt = time.Now().UTC()
+	return r.db.QueryRow(
+		query,
+		rule.ID, rule.Name, rule.Kind, rule.Threshold, rule.StatusCodes, rule.WindowSeconds,
+		rule.CooldownSeconds, rule.Severity, rule.IsEnabled, rule.UpdatedAt,
+	).Scan(&rule.UpdatedAt)
+}
+
+// MarkTriggered records when a rule last opened an alert, which starts its cooldown
+func (r *AlertRuleRepository) MarkTriggered(id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE alert_rules SET last_triggered_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+func (r *AlertRuleRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM alert_rules WHERE id = $1`, id)
+	return err
+}
+
+func (r *AlertRuleRepository) list(query string, args ...interface{}) ([]*models.AlertRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]*models.AlertRule, 0)
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func scanAlertRule(row rowScanner) (*models.AlertRule, error) {
+	rule := &models.AlertRule{}
+	var statusCodes sql.NullString
+	var lastTriggeredAt sql.NullTime
+
+	err := row.Scan(
+		&rule.ID, &rule.ServiceID, &rule.Name, &rule.Kind, &rule.Threshold, &statusCodes, &rule.WindowSeconds,
+		&rule.CooldownSeconds, &rule.Severity, &rule.IsEnabled, &lastTriggeredAt, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCodes.Valid {
+		rule.StatusCodes = &statusCodes.String
+	}
+	if lastTriggeredAt.Valid {
+		rule.LastTriggeredAt = &lastTriggeredAt.Time
+	}
+
+	return rule, nil
+}
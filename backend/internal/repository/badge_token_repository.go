@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+)
+
+// BadgeTokenRepository stores the tokens that gate services' SVG badges
+type BadgeTokenRepository struct {
+	db *sql.DB
+}
+
+func NewBadgeTokenRepository(db *sql.DB) *BadgeTokenRepository {
+	return &BadgeTokenRepository{db: db}
+}
+
+const badgeTokenColumns = `id, service_id, name, token, created_by, last_used_at, created_at`
+
+// badgeUseResolution is how stale last_used_at may get, so a popular badge
+// doesn't write on every view
+const badgeUseResolution = time.Hour
+
+func (r *BadgeTokenRepository) Create(token *models.BadgeToken) error {
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now().UTC()
+
+	_, err := r.db.Exec(
+		`INSERT INTO badge_tokens (`+badgeTokenColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		token.ID, token.ServiceID, token.Name, token.Token, token.CreatedBy, token.LastUsedAt, token.CreatedAt,
+	)
+	return err
+}
+
+// GetByToken returns the badge token with the given secret, or
+// sql.ErrNoRows if it doesn't exist or was revoked
+func (r *BadgeTokenRepository) GetByToken(token string) (*models.BadgeToken, error) {
+	return scanBadgeToken(r.db.QueryRow(`SELECT `+badgeTokenColumns+` FROM badge_tokens WHERE token = $1`, token))
+}
+
+func (r *BadgeTokenRepository) ListByService(serviceID uuid.UUID) ([]*models.BadgeToken, error) {
+	rows, err := r.db.Query(`
+		SELECT `+badgeTokenColumns+`
+		FROM badge_tokens
+		WHERE service_id = $1
+		ORDER BY created_at
+	`, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]*models.BadgeToken, 0)
+	for rows.Next() {
+		token, err := scanBadgeToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// MarkUsed records that a token's badges were viewed at now
+func (r *BadgeTokenRepository) MarkUsed(id uuid.UUID, now time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE badge_tokens
+		SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`, id, now, now.Add(-badgeUseResolution))
+	return err
+}
+
+// Delete revokes one of a service's badge tokens, returning ErrNotFound if
+// the service has no such token
+func (r *BadgeTokenRepository) Delete(serviceID, id uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM badge_tokens WHERE id = $1 AND service_id = $2`, id, serviceID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanBadgeToken(row rowScanner) (*models.BadgeToken, error) {
+	token := &models.BadgeToken{}
+	err := row.Scan(&token.ID, &token.ServiceID, &token.Name, &token.Token, &token.CreatedBy, &token.LastUsedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/verification"
+)
+
+// ContactMethodRepository stores the ways users can be reached by their
+// notification rules
+type ContactMethodRepository struct {
+	db *sql.DB
+}
+
+func NewContactMethodRepository(db *sql.DB) *ContactMethodRepository {
+	return &ContactMethodRepository{db: db}
+}
+
+const contactMethodColumns = `id, user_id, channel, destination, label, verification_status, verified_at,
+	verification_code, verification_expires_at, verification_attempts, created_at`
+
+// Create stores a contact method, returning ErrDuplicateEntry if the user
+// already has the same one
+func (r *ContactMethodRepository) Create(method *models.ContactMethod) error {
+	query := `
+		INSERT INTO contact_methods (id, user_id, channel, destination, label, verification_status, verified_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	method.ID = uuid.New()
+	method.CreatedAt = time.Now().UTC()
+	if method.VerificationStatus == "" {
+		method.VerificationStatus = verification.StatusPending
+	}
+
+	_, err := r.db.Exec(
+		query,
+		method.ID, method.UserID, method.Channel, method.Destination, method.Label, method.VerificationStatus,
+		method.VerifiedAt, method.CreatedAt,
+	)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrDuplicateEntry
+	}
+	return err
+}
+
+func (r *ContactMethodRepository) GetByID(id uuid.UUID) (*models.ContactMethod, error) {
+	query := `
+		SELECT ` + contactMethodColumns + `
+		FROM contact_methods
+		WHERE id = $1
+	`
+
+	return scanContactMethod(r.db.QueryRow(query, id))
+}
+
+// ListByUser returns a user's contact methods, oldest first
+func (r *ContactMethodRepository) ListByUser(userID uuid.UUID) ([]*models.ContactMethod, error) {
+	query := `
+		SELECT ` + contactMethodColumns + `
+		FROM contact_methods
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	methods := make([]*models.ContactMethod, 0)
+	for rows.Next() {
+		method, err := scanContactMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+	return methods, rows.Err()
+}
+
+// Delete removes one of a user's contact methods, and the rules that send
+// to it
+func (r *ContactMethodRepository) Delete(id, userID uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM contact_methods WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetVerificationCode stores a newly sent confirmation code, resetting the
+// wrong guesses counted against the last one
+func (r *ContactMethodRepository) SetVerificationCode(id uuid.UUID, code string, expiresAt time.Time) error {
+	query := `
+		UPDATE contact_methods
+		SET verification_code = $2, verification_expires_at = $3, verification_attempts = 0
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, code, expiresAt)
+	return err
+}
+
+// RecordVerificationAttempt counts a wrong confirmation code
+func (r *ContactMethodRepository) RecordVerificationAttempt(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE contact_methods SET verification_attempts = verification_attempts + 1 WHERE id = $1`, id)
+	return err
+}
+
+// MarkVerified records that a contact method's destination was confirmed
+func (r *ContactMethodRepository) MarkVerified(id uuid.UUID) error {
+	query := `
+		UPDATE contact_methods
+		SET verification_status = $2, verified_at = $3, verification_code = NULL, verification_expires_at = NULL,
+			verification_attempts = 0
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, verification.StatusVerified, time.Now().UTC())
+	return err
+}
+
+func scanContactMethod(row rowScanner) (*models.ContactMethod, error) {
+	method := &models.ContactMethod{}
+	var verifiedAt, expiresAt sql.NullTime
+	var code sql.NullString
+	err := row.Scan(
+		&method.ID, &method.UserID, &method.Channel, &method.Destination, &method.Label, &method.VerificationStatus,
+		&verifiedAt, &code, &expiresAt, &method.VerificationAttempts, &method.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	method.VerificationCode = code.String
+	if verifiedAt.Valid {
+		method.VerifiedAt = &verifiedAt.Time
+	}
+	if expiresAt.Valid {
+		method.VerificationExpiresAt = &expiresAt.Time
+	}
+	return method, nil
+}
@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+)
+
+type EscalationRepository struct {
+	db *sql.DB
+}
+
+func NewEscalationRepository(db *sql.DB) *EscalationRepository {
+	return &EscalationRepository{db: db}
+}
+
+const escalationPolicyColumns = `id, organization_id, service_id, tag, name, description, created_at, updated_at`
+
+const alertEscalationColumns = `id, alert_id, policy_id, next_step, next_run_at, status, created_at, updated_at`
+
+func (r *EscalationRepository) CreatePolicy(policy *models.EscalationPolicy) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	policy.ID = uuid.New()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	_, err = tx.Exec(
+		`INSERT INTO escalation_policies (`+escalationPolicyColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		policy.ID, policy.OrganizationID, policy.ServiceID, policy.Tag, policy.Name, policy.Description,
+		policy.CreatedAt, policy.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertSteps(tx, policy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *EscalationRepository) GetPolicy(id uuid.UUID) (*models.EscalationPolicy, error) {
+	query := `
+		SELECT ` + escalationPolicyColumns + `
+		FROM escalation_policies
+		WHERE id = $1
+	`
+
+	policy, err := scanEscalationPolicy(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadSteps([]*models.EscalationPolicy{policy}); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (r *EscalationRepository) ListPoliciesByOrganization(orgID uuid.UUID) ([]*models.EscalationPolicy, error) {
+	query := `
+		SELECT ` + escalationPolicyColumns + `
+		FROM escalation_policies
+		WHERE organization_id = $1
+		ORDER BY name
+	`
+
+	return r.listPolicies(query, orgID)
+}
+
+// ListPoliciesForService returns the policies that could cover a service:
+// those scoped to it, to one of its tags, or to its whole organization
+func (r *EscalationRepository) ListPoliciesForService(service *models.Service) ([]*models.EscalationPolicy, error) {
+	query := `
+		SELECT ` + escalationPolicyColumns + `
+		FROM escalation_policies
+		WHERE organization_id = $1
+		  AND (
+			service_id = $2
+			OR (service_id IS NULL AND tag = ANY($3))
+			OR (service_id IS NULL AND tag IS NULL)
+		  )
+		ORDER BY created_at
+	`
+
+	return r.listPolicies(query, service.OrganizationID, service.ID, pq.Array(service.Tags))
+}
+
+// UpdatePolicy saves a policy and replaces its steps. Escalations already in
+// progress carry on from their next step under the new steps.
+func (r *EscalationRepository) UpdatePolicy(policy *models.EscalationPolicy) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	policy.UpdatedAt = time.Now().UTC()
+	_, err = tx.Exec(
+		`UPDATE escalation_policies
+		SET service_id = $2, tag = $3, name = $4, description = $5, updated_at = $6
+		WHERE id = $1`,
+		policy.ID, policy.ServiceID, policy.Tag, policy.Name, policy.Description, policy.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM escalation_steps WHERE policy_id = $1`, policy.ID); err != nil {
+		return err
+	}
+	if err := insertSteps(tx, policy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *EscalationRepository) DeletePolicy(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM escalation_policies WHERE id = $1`, id)
+	return err
+}
+
+// CreateEscalation records an alert's place in its policy
+func (r *EscalationRepository) CreateEscalation(e *models.AlertEscalation) error {
+	query := `
+		INSERT INTO alert_escalations (` + alertEscalationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	now := time.Now().UTC()
+	e.ID = uuid.New()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	_, err := r.db.Exec(
+		query,
+		e.ID, e.AlertID, e.PolicyID, e.NextStep, e.NextRunAt, e.Status, e.CreatedAt, e.UpdatedAt,
+	)
+	return err
+}
+
+// ClaimDue returns up to limit pending escalations whose next step is due and
+// pushes their next run out by lease, so concurrent workers skip them and a
+// worker that dies mid-step is retried once the lease runs out
+func (r *EscalationRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.AlertEscalation, error) {
+	query := `
+		UPDATE alert_escalations
+		SET next_run_at = $2, updated_at = $1
+		WHERE id IN (
+			SELECT id
+			FROM alert_escalations
+			WHERE status = 'pending' AND next_run_at <= $1
+			ORDER BY next_run_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + alertEscalationColumns
+
+	rows, err := r.db.Query(query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	escalations := make([]*models.AlertEscalation, 0)
+	for rows.Next() {
+		e, err := scanAlertEscalation(rows)
+		if err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, e)
+	}
+
+	return escalations, rows.Err()
+}
+
+// GetPendingEscalation returns the escalation still running for an alert,
+// or sql.ErrNoRows if there is none
+func (r *EscalationRepository) GetPendingEscalation(alertID uuid.UUID) (*models.AlertEscalation, error) {
+	query := `
+		SELECT ` + alertEscalationColumns + `
+		FROM alert_escalations
+		WHERE alert_id = $1 AND status = 'pending'
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	return scanAlertEscalation(r.db.QueryRow(query, alertID))
+}
+
+// AdvanceEscalation schedules an escalation's next step
+func (r *EscalationRepository) AdvanceEscalation(id uuid.UUID, nextStep int, nextRunAt time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE alert_escalations SET next_step = $2, next_run_at = $3, updated_at = $4 WHERE id = $1`,
+		id, nextStep, nextRunAt, time.Now().UTC(),
+	)
+	return err
+}
+
+// FinishEscalation stops an escalation with the given status
+func (r *EscalationRepository) FinishEscalation(id uuid.UUID, status string) error {
+	_, err := r.db.Exec(
+		`UPDATE alert_escalations SET status = $2, updated_at = $3 WHERE id = $1`,
+		id, status, time.Now().UTC(),
+	)
+	return err
+}
+
+// FinishEscalationsForAlert stops every pending escalation of an alert
+func (r *EscalationRepository) FinishEscalationsForAlert(alertID uuid.UUID, status string) error {
+	_, err := r.db.Exec(
+		`UPDATE alert_escalations SET status = $2, updated_at = $3 WHERE alert_id = $1 AND status = 'pending'`,
+		alertID, status, time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *EscalationRepository) listPolicies(query string, args ...interface{}) ([]*models.EscalationPolicy, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]*models.EscalationPolicy, 0)
+	for rows.Next() {
+		policy, err := scanEscalationPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadSteps(policies); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// loadSteps fills in the steps of each policy with a single query
+func (r *EscalationRepository) loadSteps(policies []*models.EscalationPolicy) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.EscalationPolicy, len(policies))
+	ids := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		policy.Steps = make([]models.EscalationStep, 0)
+		byID[policy.ID] = policy
+		ids = append(ids, policy.ID.String())
+	}
+
+	rows, err := r.db.Query(`
+		SELECT policy_id, position, delay_minutes, channel, destination
+		FROM escalation_steps
+		WHERE policy_id = ANY($1::uuid[])
+		ORDER BY policy_id, position
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var policyID uuid.UUID
+		var step models.EscalationStep
+		if err := rows.Scan(&policyID, &step.Position, &step.DelayMinutes, &step.Channel, &step.Destination); err != nil {
+			return err
+		}
+		if policy, ok := byID[policyID]; ok {
+			policy.Steps = append(policy.Steps, step)
+		}
+	}
+
+	return rows.Err()
+}
+
+// insertSteps writes a policy's steps, numbering them from 1 in order
+func insertSteps(tx *sql.Tx, policy *models.EscalationPolicy) error {
+	for i := range policy.Steps {
+		step := &policy.Steps[i]
+		step.Position = i + 1
+		_, err := tx.Exec(
+			`INSERT INTO escalation_steps (policy_id, position, delay_minutes, channel, destination) VALUES ($1, $2, $3, $4, $5)`,
+			policy.ID, step.Position, step.DelayMinutes, step.Channel, step.Destination,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanEscalationPolicy(row rowScanner) (*models.EscalationPolicy, error) {
+	policy := &models.EscalationPolicy{}
+	var serviceID uuid.NullUUID
+	var tag, description sql.NullString
+
+	err := row.Scan(
+		&policy.ID, &policy.OrganizationID, &serviceID, &tag, &policy.Name, &description,
+		&policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceID.Valid {
+		policy.ServiceID = &serviceID.UUID
+	}
+	if tag.Valid {
+		policy.Tag = &tag.String
+	}
+	if description.Valid {
+		policy.Description = &description.String
+	}
+
+	return policy, nil
+}
+
+func scanAlertEscalation(row rowScanner) (*models.AlertEscalation, error) {
+	e := &models.AlertEscalation{}
+	err := row.Scan(
+		&e.ID, &e.AlertID, &e.PolicyID, &e.NextStep, &e.NextRunAt, &e.Status, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
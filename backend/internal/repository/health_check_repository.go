@@ -2,12 +2,18 @@ package repository
 
 import (
 	"database/sql"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"pulsegrid/backend/internal/models"
 )
 
+// healthCheckColumns lists the columns read by scanHealthCheck, in scan order
+const healthCheckColumns = `id, service_id, status, response_time_ms, status_code, error_message, check_interval, interval_reason,
+	in_maintenance, maintenance_window_id, resolved_ip, checked_at`
+
 type HealthCheckRepository struct {
 	db *sql.DB
 }
@@ -18,26 +24,99 @@ func NewHealthCheckRepository(db *sql.DB) *HealthCheckRepository {
 
 func (r *HealthCheckRepository) Create(check *models.HealthCheck) error {
 	query := `
-		INSERT INTO health_checks (id, service_id, status, response_time_ms, status_code, error_message, checked_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO health_checks (id, service_id, status, response_time_ms, status_code, error_message, check_interval, interval_reason, in_maintenance, maintenance_window_id, resolved_ip, checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, checked_at
 	`
 
 	check.ID = uuid.New()
-	check.CheckedAt = time.Now().UTC()
+	if check.CheckedAt.IsZero() {
+		check.CheckedAt = time.Now().UTC()
+	}
 
 	err := r.db.QueryRow(
 		query,
 		check.ID, check.ServiceID, check.Status, check.ResponseTimeMs,
-		check.StatusCode, check.ErrorMessage, check.CheckedAt,
+		check.StatusCode, check.ErrorMessage, check.CheckInterval, check.IntervalReason,
+		check.InMaintenance, check.MaintenanceWindowID, check.ResolvedIP, check.CheckedAt,
 	).Scan(&check.ID, &check.CheckedAt)
 
 	return err
 }
 
+// ClaimPending leases up to limit of the Lambda worker's pending checks
+// until now+lease and returns them oldest first. Each check's ID is its
+// pending row's, for DeletePending once it is recorded. Checks whose lease
+// ran out, because their caller died or released them, are claimed again.
+func (r *HealthCheckRepository) ClaimPending(now time.Time, lease time.Duration, limit int) ([]*models.HealthCheck, error) {
+	rows, err := r.db.Query(`
+		UPDATE pending_health_checks
+		SET leased_until = $2
+		WHERE id IN (
+			SELECT id
+			FROM pending_health_checks
+			WHERE leased_until IS NULL OR leased_until <= $1
+			ORDER BY checked_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, service_id, status, response_time_ms, status_code, error_message, resolved_ip, checked_at
+	`, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := make([]*models.HealthCheck, 0)
+	for rows.Next() {
+		check := &models.HealthCheck{}
+		var responseTime, statusCode sql.NullInt64
+		var errorMessage, resolvedIP sql.NullString
+		err := rows.Scan(&check.ID, &check.ServiceID, &check.Status, &responseTime, &statusCode, &errorMessage, &resolvedIP, &check.CheckedAt)
+		if err != nil {
+			return nil, err
+		}
+		if responseTime.Valid {
+			ms := int(responseTime.Int64)
+			check.ResponseTimeMs = &ms
+		}
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			check.StatusCode = &code
+		}
+		if errorMessage.Valid {
+			check.ErrorMessage = &errorMessage.String
+		}
+		if resolvedIP.Valid {
+			check.ResolvedIP = &resolvedIP.String
+		}
+		checks = append(checks, check)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING has no order of its own
+	sort.Slice(checks, func(i, j int) bool { return checks[i].CheckedAt.Before(checks[j].CheckedAt) })
+	return checks, nil
+}
+
+// DeletePending removes a pending check once it has been recorded
+func (r *HealthCheckRepository) DeletePending(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM pending_health_checks WHERE id = $1`, id)
+	return err
+}
+
+// ReleasePending ends the lease on pending checks so the next claim takes
+// them again
+func (r *HealthCheckRepository) ReleasePending(ids []uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE pending_health_checks SET leased_until = NULL WHERE id = ANY($1::uuid[])`, pq.Array(uuidStrings(ids)))
+	return err
+}
+
 func (r *HealthCheckRepository) GetByServiceID(serviceID uuid.UUID, limit int) ([]*models.HealthCheck, error) {
 	query := `
-		SELECT id, service_id, status, response_time_ms, status_code, error_message, checked_at
+		SELECT ` + healthCheckColumns + `
 		FROM health_checks
 		WHERE service_id = $1
 		ORDER BY checked_at DESC
@@ -52,30 +131,38 @@ func (r *HealthCheckRepository) GetByServiceID(serviceID uuid.UUID, limit int) (
 
 	checks := make([]*models.HealthCheck, 0) // Initialize as empty slice, not nil
 	for rows.Next() {
-		check := &models.HealthCheck{}
-		var responseTime, statusCode sql.NullInt64
-		var errorMsg sql.NullString
-
-		err := rows.Scan(
-			&check.ID, &check.ServiceID, &check.Status,
-			&responseTime, &statusCode, &errorMsg, &check.CheckedAt,
-		)
+		check, err := scanHealthCheck(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if responseTime.Valid {
-			rt := int(responseTime.Int64)
-			check.ResponseTimeMs = &rt
-		}
-		if statusCode.Valid {
-			sc := int(statusCode.Int64)
-			check.StatusCode = &sc
-		}
-		if errorMsg.Valid {
-			check.ErrorMessage = &errorMsg.String
-		}
+		checks = append(checks, check)
+	}
+
+	return checks, rows.Err()
+}
+
+// ListSince returns a service's checks taken at or after since, newest first
+func (r *HealthCheckRepository) ListSince(serviceID uuid.UUID, since time.Time) ([]*models.HealthCheck, error) {
+	query := `
+		SELECT ` + healthCheckColumns + `
+		FROM health_checks
+		WHERE service_id = $1 AND checked_at >= $2
+		ORDER BY checked_at DESC
+	`
+
+	rows, err := r.db.Query(query, serviceID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	checks := make([]*models.HealthCheck, 0)
+	for rows.Next() {
+		check, err := scanHealthCheck(rows)
+		if err != nil {
+			return nil, err
+		}
 		checks = append(checks, check)
 	}
 
@@ -86,9 +173,10 @@ func (r *HealthCheckRepository) GetStatsByServiceID(serviceID uuid.UUID, since t
 	query := `
 		SELECT 
 			COUNT(*) as total_checks,
-			COUNT(CASE WHEN status = 'up' THEN 1 END) as up_checks,
-			COUNT(CASE WHEN status = 'down' THEN 1 END) as down_checks,
-			AVG(response_time_ms) as avg_response_time,
+			COUNT(CASE WHEN status = 'up' AND NOT in_maintenance THEN 1 END) as up_checks,
+			COUNT(CASE WHEN status = 'down' AND NOT in_maintenance THEN 1 END) as down_checks,
+			COUNT(CASE WHEN in_maintenance THEN 1 END) as maintenance_checks,
+			AVG(response_time_ms) FILTER (WHERE NOT in_maintenance) as avg_response_time,
 			MAX(checked_at) as last_check
 		FROM health_checks
 		WHERE service_id = $1 AND checked_at >= $2
@@ -100,7 +188,7 @@ func (r *HealthCheckRepository) GetStatsByServiceID(serviceID uuid.UUID, since t
 
 	err := r.db.QueryRow(query, serviceID, since).Scan(
 		&stats.TotalChecks, &stats.UpChecks, &stats.DownChecks,
-		&avgResponseTime, &lastCheck,
+		&stats.MaintenanceChecks, &avgResponseTime, &lastCheck,
 	)
 
 	if err != nil {
@@ -114,9 +202,12 @@ func (r *HealthCheckRepository) GetStatsByServiceID(serviceID uuid.UUID, since t
 		stats.LastCheck = &lastCheck.Time
 	}
 
-	// Calculate uptime percentage
-	if stats.TotalChecks > 0 {
-		stats.UptimePercent = (float64(stats.UpChecks) / float64(stats.TotalChecks)) * 100
+	// Calculate uptime percentage; time spent in maintenance windows is
+	// excluded rather than counted as either up or down
+	if countedChecks := stats.TotalChecks - stats.MaintenanceChecks; countedChecks > 0 {
+		stats.UptimePercent = (float64(stats.UpChecks) / float64(countedChecks)) * 100
+	} else if stats.TotalChecks > 0 {
+		stats.UptimePercent = 100
 	}
 
 	// Determine current status from last check
@@ -142,21 +233,14 @@ func (r *HealthCheckRepository) GetStatsByServiceID(serviceID uuid.UUID, since t
 
 func (r *HealthCheckRepository) GetPreviousCheckBefore(serviceID uuid.UUID, before time.Time) (*models.HealthCheck, error) {
 	query := `
-		SELECT id, service_id, status, response_time_ms, status_code, error_message, checked_at
+		SELECT ` + healthCheckColumns + `
 		FROM health_checks
 		WHERE service_id = $1 AND checked_at < $2
 		ORDER BY checked_at DESC
 		LIMIT 1
 	`
 
-	var check models.HealthCheck
-	var responseTime, statusCode sql.NullInt64
-	var errorMsg sql.NullString
-
-	err := r.db.QueryRow(query, serviceID, before).Scan(
-		&check.ID, &check.ServiceID, &check.Status,
-		&responseTime, &statusCode, &errorMsg, &check.CheckedAt,
-	)
+	check, err := scanHealthCheck(r.db.QueryRow(query, serviceID, before))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -164,6 +248,71 @@ func (r *HealthCheckRepository) GetPreviousCheckBefore(serviceID uuid.UUID, befo
 		return nil, err
 	}
 
+	return check, nil
+}
+
+// DailyUptime counts each service's checks per UTC day since a time, for
+// status pages' uptime bars
+func (r *HealthCheckRepository) DailyUptime(serviceIDs []uuid.UUID, since time.Time) (map[uuid.UUID][]models.DailyUptime, error) {
+	days := make(map[uuid.UUID][]models.DailyUptime, len(serviceIDs))
+	if len(serviceIDs) == 0 {
+		return days, nil
+	}
+
+	ids := make([]string, 0, len(serviceIDs))
+	for _, id := range serviceIDs {
+		ids = append(ids, id.String())
+	}
+
+	rows, err := r.db.Query(`
+		SELECT
+			service_id,
+			date_trunc('day', checked_at) AS day,
+			COUNT(*),
+			COUNT(CASE WHEN status = 'up' AND NOT in_maintenance THEN 1 END),
+			COUNT(CASE WHEN status = 'degraded' AND NOT in_maintenance THEN 1 END),
+			COUNT(CASE WHEN status = 'down' AND NOT in_maintenance THEN 1 END),
+			COUNT(CASE WHEN in_maintenance THEN 1 END)
+		FROM health_checks
+		WHERE service_id = ANY($1::uuid[]) AND checked_at >= $2
+		GROUP BY service_id, day
+		ORDER BY day
+	`, pq.Array(ids), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var serviceID uuid.UUID
+		var day models.DailyUptime
+		if err := rows.Scan(&serviceID, &day.Date, &day.Total, &day.Up, &day.Degraded, &day.Down, &day.Maintenance); err != nil {
+			return nil, err
+		}
+		days[serviceID] = append(days[serviceID], day)
+	}
+	return days, rows.Err()
+}
+
+func (r *HealthCheckRepository) GetDB() *sql.DB {
+	return r.db
+}
+
+func scanHealthCheck(row rowScanner) (*models.HealthCheck, error) {
+	check := &models.HealthCheck{}
+	var responseTime, statusCode, checkInterval sql.NullInt64
+	var errorMsg, intervalReason, resolvedIP sql.NullString
+	var maintenanceWindowID uuid.NullUUID
+
+	err := row.Scan(
+		&check.ID, &check.ServiceID, &check.Status,
+		&responseTime, &statusCode, &errorMsg, &checkInterval, &intervalReason,
+		&check.InMaintenance, &maintenanceWindowID, &resolvedIP, &check.CheckedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	if responseTime.Valid {
 		rt := int(responseTime.Int64)
 		check.ResponseTimeMs = &rt
@@ -175,10 +324,19 @@ func (r *HealthCheckRepository) GetPreviousCheckBefore(serviceID uuid.UUID, befo
 	if errorMsg.Valid {
 		check.ErrorMessage = &errorMsg.String
 	}
+	if checkInterval.Valid {
+		interval := int(checkInterval.Int64)
+		check.CheckInterval = &interval
+	}
+	if intervalReason.Valid {
+		check.IntervalReason = &intervalReason.String
+	}
+	if maintenanceWindowID.Valid {
+		check.MaintenanceWindowID = &maintenanceWindowID.UUID
+	}
+	if resolvedIP.Valid {
+		check.ResolvedIP = &resolvedIP.String
+	}
 
-	return &check, nil
-}
-
-func (r *HealthCheckRepository) GetDB() *sql.DB {
-	return r.db
+	return check, nil
 }
@@ -0,0 +1,514 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/correlation"
+)
+
+type IncidentRepository struct {
+	db *sql.DB
+}
+
+func NewIncidentRepository(db *sql.DB) *IncidentRepository {
+	return &IncidentRepository{db: db}
+}
+
+const incidentColumns = `id, organization_id, title, status, lead_alert_id, started_at, last_alert_at,
+	acknowledged_at, acknowledged_by, resolved_at, resolved_by, created_at, updated_at`
+
+const incidentEventColumns = `id, incident_id, kind, user_id, alert_id, body, created_at`
+
+// GetRules returns an organization's correlation rules, or the defaults if
+// it has not configured any
+func (r *IncidentRepository) GetRules(orgID uuid.UUID) (*models.CorrelationRules, error) {
+	return getCorrelationRules(r.db, orgID)
+}
+
+func (r *IncidentRepository) SaveRules(rules *models.CorrelationRules) error {
+	query := `
+		INSERT INTO incident_correlation_rules (organization_id, enabled, window_seconds, match_tags, match_ips, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (organization_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			window_seconds = EXCLUDED.window_seconds,
+			match_tags = EXCLUDED.match_tags,
+			match_ips = EXCLUDED.match_ips,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	rules.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(query, rules.OrganizationID, rules.Enabled, rules.WindowSeconds, rules.MatchTags, rules.MatchIPs, rules.UpdatedAt)
+	return err
+}
+
+// AttachAlert adds a newly created alert to the open incident it correlates
+// with, marking it suppressed so only the incident's first alert notifies,
+// or opens a new incident led by it. It runs under a per-organization lock
+// so alerts raised together agree on one incident, and reports whether a
+// new incident was opened.
+func (r *IncidentRepository) AttachAlert(service *models.Service, alert *models.Alert) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, "incidents:"+service.OrganizationID.String()); err != nil {
+		return false, err
+	}
+
+	stored, err := getCorrelationRules(tx, service.OrganizationID)
+	if err != nil {
+		return false, err
+	}
+	rules := ToCorrelationRules(stored)
+
+	subject := correlation.Alert{RaisedAt: alert.CreatedAt, Tags: service.Tags}
+	err = tx.QueryRow(`
+		SELECT resolved_ip FROM health_checks
+		WHERE service_id = $1 AND resolved_ip IS NOT NULL
+		ORDER BY checked_at DESC
+		LIMIT 1
+	`, service.ID).Scan(&subject.IP)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	var candidates []correlation.Incident
+	if rules.Enabled {
+		candidates, err = openIncidentCandidates(tx, service.OrganizationID, alert.CreatedAt.Add(-rules.Window))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	now := time.Now().UTC()
+	event := &models.IncidentEvent{AlertID: &alert.ID}
+
+	if id, why, ok := correlation.Match(rules, subject, candidates); ok {
+		incidentID, err := uuid.Parse(id)
+		if err != nil {
+			return false, err
+		}
+
+		var title string
+		err = tx.QueryRow(`
+			UPDATE incidents SET last_alert_at = GREATEST(last_alert_at, $2), updated_at = $3
+			WHERE id = $1
+			RETURNING title
+		`, incidentID, alert.CreatedAt, now).Scan(&title)
+		if err != nil {
+			return false, err
+		}
+
+		alert.IncidentID = &incidentID
+		if !alert.IsSuppressed {
+			reason := fmt.Sprintf("grouped into incident %q (%s)", title, why)
+			alert.IsSuppressed = true
+			alert.SuppressedReason = &reason
+		}
+		_, err = tx.Exec(
+			`UPDATE alerts SET incident_id = $2, is_suppressed = $3, suppressed_reason = $4 WHERE id = $1`,
+			alert.ID, incidentID, alert.IsSuppressed, alert.SuppressedReason,
+		)
+		if err != nil {
+			return false, err
+		}
+
+		event.IncidentID = incidentID
+		event.Kind = correlation.EventAlertAdded
+		event.Body = fmt.Sprintf("%s: %s (%s)", service.Name, alert.Message, why)
+		if err := insertIncidentEvent(tx, event, now); err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+
+	incidentID := uuid.New()
+	_, err = tx.Exec(`
+		INSERT INTO incidents (id, organization_id, title, status, lead_alert_id, started_at, last_alert_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $7)
+	`, incidentID, service.OrganizationID, alert.Message, alerting.StatusTriggered, alert.ID, alert.CreatedAt, now)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE alerts SET incident_id = $2 WHERE id = $1`, alert.ID, incidentID); err != nil {
+		return false, err
+	}
+	alert.IncidentID = &incidentID
+
+	event.IncidentID = incidentID
+	event.Kind = correlation.EventOpened
+	event.Body = fmt.Sprintf("%s: %s", service.Name, alert.Message)
+	if err := insertIncidentEvent(tx, event, now); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// AlertResolved records that one of an incident's alerts resolved and
+// resolves the incident once none of its alerts are open. It returns the
+// incident if this closed it, and nil otherwise.
+func (r *IncidentRepository) AlertResolved(incidentID, alertID uuid.UUID, resolvedBy, body string) (*models.Incident, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	event := &models.IncidentEvent{IncidentID: incidentID, Kind: correlation.EventAlertResolved, AlertID: &alertID, Body: body}
+	if userID, err := uuid.Parse(resolvedBy); err == nil {
+		event.UserID = &userID
+	}
+	if err := insertIncidentEvent(tx, event, now); err != nil {
+		return nil, err
+	}
+
+	var id uuid.UUID
+	err = tx.QueryRow(`
+		UPDATE incidents
+		SET status = 'resolved', resolved_at = $2, resolved_by = $3, updated_at = $2
+		WHERE id = $1 AND status <> 'resolved'
+		  AND NOT EXISTS (SELECT 1 FROM alerts WHERE incident_id = $1 AND is_resolved = FALSE)
+		RETURNING id
+	`, incidentID, now, resolvedBy).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, tx.Commit()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	closing := &models.IncidentEvent{IncidentID: incidentID, Kind: correlation.EventResolved, UserID: event.UserID, Body: "All alerts resolved"}
+	if err := insertIncidentEvent(tx, closing, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.Get(incidentID)
+}
+
+// Acknowledge marks a triggered incident acknowledged by a user. It reports
+// false if the incident was already acknowledged or resolved.
+func (r *IncidentRepository) Acknowledge(id, userID uuid.UUID) (bool, error) {
+	now := time.Now().UTC()
+	query := `
+		UPDATE incidents
+		SET status = 'acknowledged', acknowledged_at = $2, acknowledged_by = $3, updated_at = $2
+		WHERE id = $1 AND status = 'triggered'
+		RETURNING id
+	`
+
+	event := &models.IncidentEvent{IncidentID: id, Kind: correlation.EventAcknowledged, UserID: &userID}
+	return r.transition(query, []interface{}{id, now, userID}, event, now)
+}
+
+// Resolve marks an incident resolved by a user, whatever the state of its
+// alerts. It reports false if the incident was already resolved.
+func (r *IncidentRepository) Resolve(id, userID uuid.UUID) (bool, error) {
+	now := time.Now().UTC()
+	query := `
+		UPDATE incidents
+		SET status = 'resolved', resolved_at = $2, resolved_by = $3, updated_at = $2
+		WHERE id = $1 AND status <> 'resolved'
+		RETURNING id
+	`
+
+	event := &models.IncidentEvent{IncidentID: id, Kind: correlation.EventResolved, UserID: &userID}
+	return r.transition(query, []interface{}{id, now, userID.String()}, event, now)
+}
+
+func (r *IncidentRepository) Get(id uuid.UUID) (*models.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incidents
+		WHERE id = $1
+	`
+
+	incident, err := scanIncident(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadServices([]*models.Incident{incident}); err != nil {
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// ListByOrganization returns the organization's newest incidents, limited to
+// one status unless status is empty
+func (r *IncidentRepository) ListByOrganization(orgID uuid.UUID, status string, limit int) ([]*models.Incident, error) {
+	query := `
+		SELECT ` + incidentColumns + `
+		FROM incidents
+		WHERE organization_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY started_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(query, orgID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents := make([]*models.Incident, 0)
+	for rows.Next() {
+		incident, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, incident)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadServices(incidents); err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// AddEvent appends an event, such as a note, to an incident's timeline
+func (r *IncidentRepository) AddEvent(event *models.IncidentEvent) error {
+	return insertIncidentEvent(r.db, event, time.Now().UTC())
+}
+
+// ListEvents returns an incident's timeline, oldest first
+func (r *IncidentRepository) ListEvents(incidentID uuid.UUID) ([]*models.IncidentEvent, error) {
+	query := `
+		SELECT ` + incidentEventColumns + `
+		FROM incident_events
+		WHERE incident_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(query, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*models.IncidentEvent, 0)
+	for rows.Next() {
+		event := &models.IncidentEvent{}
+		var userID, alertID uuid.NullUUID
+		err := rows.Scan(&event.ID, &event.IncidentID, &event.Kind, &userID, &alertID, &event.Body, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			event.UserID = &userID.UUID
+		}
+		if alertID.Valid {
+			event.AlertID = &alertID.UUID
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ToCorrelationRules converts stored rules for pkg/correlation
+func ToCorrelationRules(rules *models.CorrelationRules) correlation.Rules {
+	return correlation.Rules{
+		Enabled:   rules.Enabled,
+		Window:    time.Duration(rules.WindowSeconds) * time.Second,
+		MatchTags: rules.MatchTags,
+		MatchIPs:  rules.MatchIPs,
+	}
+}
+
+// transition runs an UPDATE ... RETURNING id on one incident and, if it
+// changed a row, records event in the same transaction
+func (r *IncidentRepository) transition(query string, args []interface{}, event *models.IncidentEvent, now time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRow(query, args...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := insertIncidentEvent(tx, event, now); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// loadServices fills in the affected services and alert counts of each
+// incident with a single query
+func (r *IncidentRepository) loadServices(incidents []*models.Incident) error {
+	if len(incidents) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.Incident, len(incidents))
+	ids := make([]string, 0, len(incidents))
+	for _, incident := range incidents {
+		incident.AffectedServices = make([]models.IncidentService, 0)
+		byID[incident.ID] = incident
+		ids = append(ids, incident.ID.String())
+	}
+
+	rows, err := r.db.Query(`
+		SELECT a.incident_id, s.id, s.name, COUNT(*), COUNT(*) FILTER (WHERE a.is_resolved = FALSE)
+		FROM alerts a
+		JOIN services s ON s.id = a.service_id
+		WHERE a.incident_id = ANY($1::uuid[])
+		GROUP BY a.incident_id, s.id, s.name
+		ORDER BY s.name
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var incidentID uuid.UUID
+		var service models.IncidentService
+		if err := rows.Scan(&incidentID, &service.ServiceID, &service.Name, &service.AlertCount, &service.OpenAlerts); err != nil {
+			return err
+		}
+		if incident, ok := byID[incidentID]; ok {
+			incident.AffectedServices = append(incident.AffectedServices, service)
+			incident.AlertCount += service.AlertCount
+		}
+	}
+
+	return rows.Err()
+}
+
+func getCorrelationRules(db rowQuerier, orgID uuid.UUID) (*models.CorrelationRules, error) {
+	rules := &models.CorrelationRules{OrganizationID: orgID}
+	err := db.QueryRow(`
+		SELECT enabled, window_seconds, match_tags, match_ips, updated_at
+		FROM incident_correlation_rules
+		WHERE organization_id = $1
+	`, orgID).Scan(&rules.Enabled, &rules.WindowSeconds, &rules.MatchTags, &rules.MatchIPs, &rules.UpdatedAt)
+	if err == sql.ErrNoRows {
+		defaults := correlation.DefaultRules()
+		rules.Enabled = defaults.Enabled
+		rules.WindowSeconds = int(defaults.Window.Seconds())
+		rules.MatchTags = defaults.MatchTags
+		rules.MatchIPs = defaults.MatchIPs
+		return rules, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// openIncidentCandidates loads an organization's open incidents that gained
+// an alert since since, with the tags and last resolved IPs of their services
+func openIncidentCandidates(tx *sql.Tx, orgID uuid.UUID, since time.Time) ([]correlation.Incident, error) {
+	rows, err := tx.Query(`
+		SELECT i.id, i.last_alert_at,
+			COALESCE(array_agg(DISTINCT t.tag) FILTER (WHERE t.tag IS NOT NULL), '{}'),
+			COALESCE(array_agg(DISTINCT ip.resolved_ip) FILTER (WHERE ip.resolved_ip IS NOT NULL), '{}')
+		FROM incidents i
+		JOIN alerts a ON a.incident_id = i.id
+		JOIN services s ON s.id = a.service_id
+		LEFT JOIN LATERAL unnest(s.tags) AS t(tag) ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT hc.resolved_ip FROM health_checks hc
+			WHERE hc.service_id = s.id AND hc.resolved_ip IS NOT NULL
+			ORDER BY hc.checked_at DESC
+			LIMIT 1
+		) ip ON TRUE
+		WHERE i.organization_id = $1 AND i.status <> 'resolved' AND i.last_alert_at >= $2
+		GROUP BY i.id, i.last_alert_at
+	`, orgID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []correlation.Incident
+	for rows.Next() {
+		var candidate correlation.Incident
+		var tags, ips pq.StringArray
+		if err := rows.Scan(&candidate.ID, &candidate.LastAlertAt, &tags, &ips); err != nil {
+			return nil, err
+		}
+		candidate.Tags = []string(tags)
+		candidate.IPs = []string(ips)
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, rows.Err()
+}
+
+func insertIncidentEvent(db execer, event *models.IncidentEvent, now time.Time) error {
+	event.ID = uuid.New()
+	event.CreatedAt = now
+	_, err := db.Exec(
+		`INSERT INTO incident_events (`+incidentEventColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		event.ID, event.IncidentID, event.Kind, event.UserID, event.AlertID, event.Body, event.CreatedAt,
+	)
+	return err
+}
+
+func scanIncident(row rowScanner) (*models.Incident, error) {
+	incident := &models.Incident{}
+	var leadAlertID, acknowledgedBy uuid.NullUUID
+	var acknowledgedAt, resolvedAt sql.NullTime
+	var resolvedBy sql.NullString
+
+	err := row.Scan(
+		&incident.ID, &incident.OrganizationID, &incident.Title, &incident.Status, &leadAlertID,
+		&incident.StartedAt, &incident.LastAlertAt, &acknowledgedAt, &acknowledgedBy,
+		&resolvedAt, &resolvedBy, &incident.CreatedAt, &incident.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if leadAlertID.Valid {
+		incident.LeadAlertID = &leadAlertID.UUID
+	}
+	if acknowledgedAt.Valid {
+		incident.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	if acknowledgedBy.Valid {
+		incident.AcknowledgedBy = &acknowledgedBy.UUID
+	}
+	if resolvedBy.Valid {
+		incident.ResolvedBy = &resolvedBy.String
+	}
+
+	end := time.Now().UTC()
+	if resolvedAt.Valid {
+		incident.ResolvedAt = &resolvedAt.Time
+		end = resolvedAt.Time
+	}
+	if d := end.Sub(incident.StartedAt); d > 0 {
+		incident.DurationSeconds = int(d.Seconds())
+	}
+
+	return incident, nil
+}
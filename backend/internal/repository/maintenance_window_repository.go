@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+)
+
+type MaintenanceWindowRepository struct {
+	db *sql.DB
+}
+
+func NewMaintenanceWindowRepository(db *sql.DB) *MaintenanceWindowRepository {
+	return &MaintenanceWindowRepository{db: db}
+}
+
+const maintenanceWindowColumns = `id, organization_id, service_id, tag, title, description, starts_at, ends_at,
+	recurrence, recurrence_ends_at, time_zone, created_by, created_at, updated_at`
+
+func (r *MaintenanceWindowRepository) Create(w *models.MaintenanceWindow) error {
+	query := `
+		INSERT INTO maintenance_windows (` + maintenanceWindowColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`
+
+	now := time.Now().UTC()
+	w.ID = uuid.New()
+	w.CreatedAt = now
+	w.UpdatedAt = now
+
+	return r.db.QueryRow(
+		query,
+		w.ID, w.OrganizationID, w.ServiceID, w.Tag, w.Title, w.Description, w.StartsAt, w.EndsAt,
+		w.Recurrence, w.RecurrenceEndsAt, w.TimeZone, w.CreatedBy, w.CreatedAt, w.UpdatedAt,
+	).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+func (r *MaintenanceWindowRepository) GetByID(id uuid.UUID) (*models.MaintenanceWindow, error) {
+	query := `
+		SELECT ` + maintenanceWindowColumns + `
+		FROM maintenance_windows
+		WHERE id = $1
+	`
+
+	return scanMaintenanceWindow(r.db.QueryRow(query, id))
+}
+
+func (r *MaintenanceWindowRepository) ListByOrganization(orgID uuid.UUID) ([]*models.MaintenanceWindow, error) {
+	query := `
+		SELECT ` + maintenanceWindowColumns + `
+		FROM maintenance_windows
+		WHERE organization_id = $1
+		ORDER BY starts_at DESC
+	`
+
+	return r.list(query, orgID)
+}
+
+// ListForService returns the windows that could cover a service at a time:
+// those scoped to it, to one of its tags, or to its whole organization.
+// Windows that had not started or had ended for good by then are left out.
+func (r *MaintenanceWindowRepository) ListForService(service *models.Service, at time.Time) ([]*models.MaintenanceWindow, error) {
+	query := `
+		SELECT ` + maintenanceWindowColumns + `
+		FROM maintenance_windows
+		WHERE organization_id = $1
+		  AND (
+			service_id = $2
+			OR (service_id IS NULL AND tag = ANY($3))
+			OR (service_id IS NULL AND tag IS NULL)
+		  )
+		  AND starts_at <= $4
+		  AND (
+			(recurrence = 'none' AND ends_at > $4)
+			OR (recurrence <> 'none' AND (recurrence_ends_at IS NULL OR recurrence_ends_at >= $4 - (ends_at - starts_at)))
+		  )
+		ORDER BY starts_at
+	`
+
+	return r.list(query, service.OrganizationID, service.ID, pq.Array(service.Tags), at.UTC())
+}
+
+func (r *MaintenanceWindowRepository) Update(w *models.MaintenanceWindow) error {
+	query := `
+		UPDATE maintenance_windows
+		SET service_id = $2, tag = $3, title = $4, description = $5, starts_at = $6, ends_at = $7,
+			recurrence = $8, recurrence_ends_at = $9, time_zone = $10, updated_at = $11
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	w.UpdatedAt = time.Now().UTC()
+	return r.db.QueryRow(
+		query,
+		w.ID, w.ServiceID, w.Tag, w.Title, w.Description, w.StartsAt, w.EndsAt,
+		w.Recurrence, w.RecurrenceEndsAt, w.TimeZone, w.UpdatedAt,
+	).Scan(&w.UpdatedAt)
+}
+
+func (r *MaintenanceWindowRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM maintenance_windows WHERE id = $1`, id)
+	return err
+}
+
+func (r *MaintenanceWindowRepository) list(query string, args ...interface{}) ([]*models.MaintenanceWindow, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := make([]*models.MaintenanceWindow, 0)
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+
+	return windows, rows.Err()
+}
+
+func scanMaintenanceWindow(row rowScanner) (*models.MaintenanceWindow, error) {
+	w := &models.MaintenanceWindow{}
+	var serviceID, createdBy uuid.NullUUID
+	var tag, description sql.NullString
+	var recurrenceEndsAt sql.NullTime
+
+	err := row.Scan(
+		&w.ID, &w.OrganizationID, &serviceID, &tag, &w.Title, &description, &w.StartsAt, &w.EndsAt,
+		&w.Recurrence, &recurrenceEndsAt, &w.TimeZone, &createdBy, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceID.Valid {
+		w.ServiceID = &serviceID.UUID
+	}
+	if tag.Valid {
+		w.Tag = &tag.String
+	}
+	if description.Valid {
+		w.Description = &description.String
+	}
+	if recurrenceEndsAt.Valid {
+		w.RecurrenceEndsAt = &recurrenceEndsAt.Time
+	}
+	if createdBy.Valid {
+		w.CreatedBy = &createdBy.UUID
+	}
+
+	return w, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+)
+
+// NotificationHoldRepository stores the notifications held back by
+// subscriptions' quiet hours and digests until they go out
+type NotificationHoldRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationHoldRepository(db *sql.DB) *NotificationHoldRepository {
+	return &NotificationHoldRepository{db: db}
+}
+
+const notificationHoldColumns = `id, subscription_id, alert_id, event, severity, service_name, message, release_at, created_at`
+
+func (r *NotificationHoldRepository) Create(hold *models.NotificationHold) error {
+	query := `
+		INSERT INTO notification_holds (` + notificationHoldColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	hold.ID = uuid.New()
+	hold.CreatedAt = time.Now().UTC()
+	_, err := r.db.Exec(
+		query,
+		hold.ID, hold.SubscriptionID, hold.AlertID, hold.Event, hold.Severity, hold.ServiceName, hold.Message,
+		hold.ReleaseAt, hold.CreatedAt,
+	)
+	return err
+}
+
+// NextRelease returns when a subscription's held notifications are due
+// out, or nil if it has none
+func (r *NotificationHoldRepository) NextRelease(subscriptionID uuid.UUID) (*time.Time, error) {
+	var releaseAt sql.NullTime
+	err := r.db.QueryRow(
+		`SELECT MIN(release_at) FROM notification_holds WHERE subscription_id = $1`, subscriptionID,
+	).Scan(&releaseAt)
+	if err != nil || !releaseAt.Valid {
+		return nil, err
+	}
+	return &releaseAt.Time, nil
+}
+
+// ListBySubscription returns a subscription's held notifications, oldest
+// first
+func (r *NotificationHoldRepository) ListBySubscription(subscriptionID uuid.UUID) ([]*models.NotificationHold, error) {
+	query := `
+		SELECT ` + notificationHoldColumns + `
+		FROM notification_holds
+		WHERE subscription_id = $1
+		ORDER BY created_at
+	`
+
+	return r.list(query, subscriptionID)
+}
+
+// ClaimDue returns the held notifications that have come due, oldest first,
+// and pushes their release back by lease so no other worker claims them
+// while they are sent. The caller deletes them once the digest is queued.
+func (r *NotificationHoldRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.NotificationHold, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE notification_holds
+			SET release_at = $2
+			WHERE id IN (
+				SELECT id
+				FROM notification_holds
+				WHERE release_at <= $1
+				ORDER BY release_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + notificationHoldColumns + `
+		)
+		SELECT ` + notificationHoldColumns + ` FROM claimed ORDER BY created_at
+	`
+
+	return r.list(query, now, now.Add(lease), limit)
+}
+
+// Reschedule moves held notifications' release to until
+func (r *NotificationHoldRepository) Reschedule(ids []uuid.UUID, until time.Time) error {
+	_, err := r.db.Exec(`UPDATE notification_holds SET release_at = $2 WHERE id = ANY($1)`, pq.Array(ids), until)
+	return err
+}
+
+// Delete removes held notifications once they have been sent
+func (r *NotificationHoldRepository) Delete(ids []uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM notification_holds WHERE id = ANY($1)`, pq.Array(ids))
+	return err
+}
+
+func (r *NotificationHoldRepository) list(query string, args ...interface{}) ([]*models.NotificationHold, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := make([]*models.NotificationHold, 0)
+	for rows.Next() {
+		hold := &models.NotificationHold{}
+		var alertID uuid.NullUUID
+		err := rows.Scan(
+			&hold.ID, &hold.SubscriptionID, &alertID, &hold.Event, &hold.Severity, &hold.ServiceName,
+			&hold.Message, &hold.ReleaseAt, &hold.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if alertID.Valid {
+			hold.AlertID = &alertID.UUID
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, rows.Err()
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+)
+
+// NotificationRepository stores the notification outbox and the log of
+// delivery attempts
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+const notificationDeliveryColumns = `id, alert_id, subscription_id, channel, destination, subject, body, html_body, status, attempt_count, next_attempt_at,
+	last_error, delivered_at, provider_message_id, provider_status, created_at, updated_at`
+
+const notificationAttemptColumns = `id, delivery_id, number, channel, status, status_code, error, latency_ms, attempted_at`
+
+// Create adds a notification to the outbox
+func (r *NotificationRepository) Create(delivery *models.NotificationDelivery) error {
+	query := `
+		INSERT INTO notification_deliveries (` + notificationDeliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`
+
+	now := time.Now().UTC()
+	delivery.ID = uuid.New()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	delivery.Attempts = make([]*models.NotificationAttempt, 0)
+
+	_, err := r.db.Exec(
+		query,
+		delivery.ID, delivery.AlertID, delivery.SubscriptionID, delivery.Channel, delivery.Destination, delivery.Subject, delivery.Body, delivery.HTMLBody,
+		delivery.Status, delivery.AttemptCount, delivery.NextAttemptAt, delivery.LastError, delivery.DeliveredAt,
+		delivery.ProviderMessageID, delivery.ProviderStatus, delivery.CreatedAt, delivery.UpdatedAt,
+	)
+	return err
+}
+
+// Get returns a notification with its attempts
+func (r *NotificationRepository) Get(id uuid.UUID) (*models.NotificationDelivery, error) {
+	query := `
+		SELECT ` + notificationDeliveryColumns + `
+		FROM notification_deliveries
+		WHERE id = $1
+	`
+
+	delivery, err := scanNotificationDelivery(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadAttempts([]*models.NotificationDelivery{delivery}); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ListByAlert returns the notifications sent about an alert with their
+// attempts, oldest first
+func (r *NotificationRepository) ListByAlert(alertID uuid.UUID) ([]*models.NotificationDelivery, error) {
+	query := `
+		SELECT ` + notificationDeliveryColumns + `
+		FROM notification_deliveries
+		WHERE alert_id = $1
+		ORDER BY created_at
+	`
+
+	deliveries, err := r.list(query, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadAttempts(deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDue returns up to limit pending notifications that are due and pushes
+// their next attempt out by lease, so concurrent workers skip them and a
+// worker that dies mid-send is retried once the lease runs out
+func (r *NotificationRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries
+		SET next_attempt_at = $2, updated_at = $1
+		WHERE id IN (
+			SELECT id
+			FROM notification_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationDeliveryColumns
+
+	return r.list(query, now, now.Add(lease), limit)
+}
+
+// RecordAttempt logs an attempt and saves the notification's new status,
+// attempt count and next attempt time in one transaction
+func (r *NotificationRepository) RecordAttempt(delivery *models.NotificationDelivery, attempt *models.NotificationAttempt) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	attempt.ID = uuid.New()
+	attempt.DeliveryID = delivery.ID
+	_, err = tx.Exec(
+		`INSERT INTO notification_attempts (`+notificationAttemptColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		attempt.ID, attempt.DeliveryID, attempt.Number, attempt.Channel, attempt.Status, attempt.StatusCode,
+		attempt.Error, attempt.LatencyMs, attempt.AttemptedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	delivery.UpdatedAt = attempt.AttemptedAt
+	_, err = tx.Exec(
+		`UPDATE notification_deliveries
+		SET status = $2, attempt_count = $3, next_attempt_at = $4, last_error = $5, delivered_at = $6,
+			provider_message_id = $7, provider_status = $8, updated_at = $9
+		WHERE id = $1`,
+		delivery.ID, delivery.Status, delivery.AttemptCount, delivery.NextAttemptAt, delivery.LastError,
+		delivery.DeliveredAt, delivery.ProviderMessageID, delivery.ProviderStatus, delivery.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	return tx.Commit()
+}
+
+// RecordProviderStatus saves the status a provider reports for a message it
+// took, such as an SMS delivery receipt. errorMessage, when set, becomes the
+// notification's last error. It reports whether a notification has the
+// provider's message ID.
+func (r *NotificationRepository) RecordProviderStatus(channel, messageID, status string, errorMessage *string) (bool, error) {
+	query := `
+		UPDATE notification_deliveries
+		SET provider_status = $3, last_error = COALESCE($4, last_error), updated_at = $5
+		WHERE channel = $1 AND provider_message_id = $2
+	`
+
+	result, err := r.db.Exec(query, channel, messageID, status, errorMessage, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Requeue puts a notification back in the outbox with a fresh set of
+// attempts, claimed until claimedUntil so the caller can try it straight
+// away. Earlier attempts stay in the log.
+func (r *NotificationRepository) Requeue(id uuid.UUID, claimedUntil time.Time) (*models.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries
+		SET status = 'pending', attempt_count = 0, next_attempt_at = $2, updated_at = $3
+		WHERE id = $1
+		RETURNING ` + notificationDeliveryColumns
+
+	delivery, err := scanNotificationDelivery(r.db.QueryRow(query, id, claimedUntil, time.Now().UTC()))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadAttempts([]*models.NotificationDelivery{delivery}); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// HasDeliverySince reports whether a notification about an alert has been
+// queued for a destination since a time, whoever it was for
+func (r *NotificationRepository) HasDeliverySince(alertID uuid.UUID, channel, destination string, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM notification_deliveries
+			WHERE alert_id = $1 AND channel = $2 AND destination = $3 AND created_at >= $4
+		)
+	`
+	var exists bool
+	err := r.db.QueryRow(query, alertID, channel, destination, since).Scan(&exists)
+	return exists, err
+}
+
+func (r *NotificationRepository) list(query string, args ...interface{}) ([]*models.NotificationDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.NotificationDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// loadAttempts fills in the attempts of each notification with a single query
+func (r *NotificationRepository) loadAttempts(deliveries []*models.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.NotificationDelivery, len(deliveries))
+	ids := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		byID[delivery.ID] = delivery
+		ids = append(ids, delivery.ID.String())
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+notificationAttemptColumns+`
+		FROM notification_attempts
+		WHERE delivery_id = ANY($1::uuid[])
+		ORDER BY delivery_id, attempted_at
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attempt, err := scanNotificationAttempt(rows)
+		if err != nil {
+			return err
+		}
+		if delivery, ok := byID[attempt.DeliveryID]; ok {
+			delivery.Attempts = append(delivery.Attempts, attempt)
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanNotificationDelivery(row rowScanner) (*models.NotificationDelivery, error) {
+	delivery := &models.NotificationDelivery{Attempts: make([]*models.NotificationAttempt, 0)}
+	var alertID, subscriptionID uuid.NullUUID
+	var lastError, htmlBody, providerMessageID, providerStatus sql.NullString
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(
+		&delivery.ID, &alertID, &subscriptionID, &delivery.Channel, &delivery.Destination, &delivery.Subject, &delivery.Body, &htmlBody,
+		&delivery.Status, &delivery.AttemptCount, &delivery.NextAttemptAt, &lastError, &deliveredAt,
+		&providerMessageID, &providerStatus, &delivery.CreatedAt, &delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if alertID.Valid {
+		delivery.AlertID = &alertID.UUID
+	}
+	if subscriptionID.Valid {
+		delivery.SubscriptionID = &subscriptionID.UUID
+	}
+	delivery.HTMLBody = htmlBody.String
+	if lastError.Valid {
+		delivery.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	if providerMessageID.Valid {
+		delivery.ProviderMessageID = &providerMessageID.String
+	}
+	if providerStatus.Valid {
+		delivery.ProviderStatus = &providerStatus.String
+	}
+
+	return delivery, nil
+}
+
+func scanNotificationAttempt(row rowScanner) (*models.NotificationAttempt, error) {
+	attempt := &models.NotificationAttempt{}
+	var statusCode sql.NullInt64
+	var errMsg sql.NullString
+
+	err := row.Scan(
+		&attempt.ID, &attempt.DeliveryID, &attempt.Number, &attempt.Channel, &attempt.Status, &statusCode,
+		&errMsg, &attempt.LatencyMs, &attempt.AttemptedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		attempt.StatusCode = &code
+	}
+	if errMsg.Valid {
+		attempt.Error = &errMsg.String
+	}
+
+	return attempt, nil
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/alerting"
+	"pulsegrid/backend/pkg/verification"
+)
+
+// NotificationRuleRepository stores users' personal notification rules, and
+// the notifications of delayed rules waiting to go out
+type NotificationRuleRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRuleRepository(db *sql.DB) *NotificationRuleRepository {
+	return &NotificationRuleRepository{db: db}
+}
+
+const notificationRuleColumns = `id, user_id, contact_method_id, service_id, owned_services_only, min_severity, alert_types,
+	service_tags, events, delay_minutes, is_active, created_at, updated_at`
+
+// Rules are read with their contact method
+var ruleWithMethodColumns = qualifyColumns("r", notificationRuleColumns) + ", " + qualifyColumns("m", contactMethodColumns)
+
+func (r *NotificationRuleRepository) Create(rule *models.NotificationRule) error {
+	query := `
+		INSERT INTO notification_rules (` + notificationRuleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	now := time.Now().UTC()
+	rule.ID = uuid.New()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	_, err := r.db.Exec(
+		query,
+		rule.ID, rule.UserID, rule.ContactMethodID, rule.ServiceID, rule.OwnedServicesOnly, rule.MinSeverity,
+		stringArray(rule.AlertTypes), stringArray(rule.ServiceTags), stringArray(rule.Events), rule.DelayMinutes,
+		rule.IsActive, rule.CreatedAt, rule.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID returns a rule with its contact method
+func (r *NotificationRuleRepository) GetByID(id uuid.UUID) (*models.NotificationRule, error) {
+	query := `
+		SELECT ` + ruleWithMethodColumns + `
+		FROM notification_rules r
+		JOIN contact_methods m ON m.id = r.contact_method_id
+		WHERE r.id = $1
+	`
+
+	return scanRuleWithMethod(r.db.QueryRow(query, id))
+}
+
+// ListByUser returns a user's rules with their contact methods, oldest
+// first
+func (r *NotificationRuleRepository) ListByUser(userID uuid.UUID) ([]*models.NotificationRule, error) {
+	query := `
+		SELECT ` + ruleWithMethodColumns + `
+		FROM notification_rules r
+		JOIN contact_methods m ON m.id = r.contact_method_id
+		WHERE r.user_id = $1
+		ORDER BY r.created_at
+	`
+
+	return r.list(query, userID)
+}
+
+// ListForOrganization returns the active rules of an organization's users
+// whose contact methods are verified, the rules alerts are matched against
+func (r *NotificationRuleRepository) ListForOrganization(orgID uuid.UUID) ([]*models.NotificationRule, error) {
+	query := `
+		SELECT ` + ruleWithMethodColumns + `
+		FROM notification_rules r
+		JOIN contact_methods m ON m.id = r.contact_method_id
+		JOIN users u ON u.id = r.user_id
+		WHERE u.organization_id = $1 AND r.is_active = TRUE AND m.verification_status = $2
+		ORDER BY r.created_at
+	`
+
+	return r.list(query, orgID, verification.StatusVerified)
+}
+
+// ListForAlert returns the rules of the service's organization whose
+// filters route them the event about the alert
+func (r *NotificationRuleRepository) ListForAlert(alert *models.Alert, service *models.Service, event string) ([]*models.NotificationRule, error) {
+	rules, err := r.ListForOrganization(service.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	route := alerting.Route{
+		Event:       event,
+		Severity:    alert.Severity,
+		AlertType:   alert.Type,
+		ServiceTags: service.Tags,
+		ServiceID:   service.ID.String(),
+	}
+	if service.OwnerID != nil {
+		route.OwnerID = service.OwnerID.String()
+	}
+	return routeRules(rules, route), nil
+}
+
+// routeRules keeps the rules whose filters match route
+func routeRules(rules []*models.NotificationRule, route alerting.Route) []*models.NotificationRule {
+	matched := make([]*models.NotificationRule, 0, len(rules))
+	for _, rule := range rules {
+		filter := alerting.RuleFilter{
+			RouteFilter: alerting.RouteFilter{
+				MinSeverity: rule.MinSeverity,
+				AlertTypes:  rule.AlertTypes,
+				ServiceTags: rule.ServiceTags,
+				Events:      rule.Events,
+			},
+			UserID:    rule.UserID.String(),
+			OwnedOnly: rule.OwnedServicesOnly,
+		}
+		if rule.ServiceID != nil {
+			filter.ServiceID = rule.ServiceID.String()
+		}
+		if filter.Matches(route) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// Update replaces a rule's settings
+func (r *NotificationRuleRepository) Update(rule *models.NotificationRule) error {
+	query := `
+		UPDATE notification_rules
+		SET contact_method_id = $2, service_id = $3, owned_services_only = $4, min_severity = $5, alert_types = $6,
+			service_tags = $7, events = $8, delay_minutes = $9, is_active = $10, updated_at = $11
+		WHERE id = $1
+	`
+
+	rule.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(
+		query,
+		rule.ID, rule.ContactMethodID, rule.ServiceID, rule.OwnedServicesOnly, rule.MinSeverity,
+		stringArray(rule.AlertTypes), stringArray(rule.ServiceTags), stringArray(rule.Events), rule.DelayMinutes,
+		rule.IsActive, rule.UpdatedAt,
+	)
+	return err
+}
+
+// Delete removes one of a user's rules, returning ErrNotFound if the user
+// has no such rule
+func (r *NotificationRuleRepository) Delete(id, userID uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM notification_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ScheduleRun queues a delayed rule's notification about an alert. A rule
+// already waiting to notify about the alert keeps its original time.
+func (r *NotificationRuleRepository) ScheduleRun(ruleID, alertID uuid.UUID, runAt time.Time) error {
+	query := `
+		INSERT INTO notification_rule_runs (id, rule_id, alert_id, run_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (rule_id, alert_id) DO NOTHING
+	`
+	_, err := r.db.Exec(query, uuid.New(), ruleID, alertID, runAt, time.Now().UTC())
+	return err
+}
+
+// CancelRuns drops the notifications still waiting to go out about an
+// alert, returning the rules they belonged to
+func (r *NotificationRuleRepository) CancelRuns(alertID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(`DELETE FROM notification_rule_runs WHERE alert_id = $1 RETURNING rule_id`, alertID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ruleIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ruleIDs = append(ruleIDs, id)
+	}
+	return ruleIDs, rows.Err()
+}
+
+// ClaimDueRuns returns the delayed notifications that have come due and
+// pushes them back by lease so no other worker claims them while they are
+// sent. The caller deletes each once it is handled.
+func (r *NotificationRuleRepository) ClaimDueRuns(now time.Time, lease time.Duration, limit int) ([]*models.NotificationRuleRun, error) {
+	query := `
+		UPDATE notification_rule_runs
+		SET run_at = $2
+		WHERE id IN (
+			SELECT id
+			FROM notification_rule_runs
+			WHERE run_at <= $1
+			ORDER BY run_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, rule_id, alert_id, run_at, created_at
+	`
+
+	rows, err := r.db.Query(query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*models.NotificationRuleRun, 0)
+	for rows.Next() {
+		run := &models.NotificationRuleRun{}
+		if err := rows.Scan(&run.ID, &run.RuleID, &run.AlertID, &run.RunAt, &run.CreatedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// RescheduleRun moves a delayed notification to a later time
+func (r *NotificationRuleRepository) RescheduleRun(id uuid.UUID, runAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE notification_rule_runs SET run_at = $2 WHERE id = $1`, id, runAt)
+	return err
+}
+
+func (r *NotificationRuleRepository) DeleteRun(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM notification_rule_runs WHERE id = $1`, id)
+	return err
+}
+
+func (r *NotificationRuleRepository) list(query string, args ...interface{}) ([]*models.NotificationRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]*models.NotificationRule, 0)
+	for rows.Next() {
+		rule, err := scanRuleWithMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanRuleWithMethod(row rowScanner) (*models.NotificationRule, error) {
+	rule := &models.NotificationRule{}
+	method := &models.ContactMethod{}
+	var serviceID uuid.NullUUID
+	var alertTypes, serviceTags, events pq.StringArray
+	var verifiedAt, expiresAt sql.NullTime
+	var code sql.NullString
+	err := row.Scan(
+		&rule.ID, &rule.UserID, &rule.ContactMethodID, &serviceID, &rule.OwnedServicesOnly, &rule.MinSeverity,
+		&alertTypes, &serviceTags, &events, &rule.DelayMinutes, &rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+		&method.ID, &method.UserID, &method.Channel, &method.Destination, &method.Label, &method.VerificationStatus,
+		&verifiedAt, &code, &expiresAt, &method.VerificationAttempts, &method.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceID.Valid {
+		rule.ServiceID = &serviceID.UUID
+	}
+	rule.AlertTypes = []string(alertTypes)
+	rule.ServiceTags = []string(serviceTags)
+	rule.Events = []string(events)
+	method.VerificationCode = code.String
+	if verifiedAt.Valid {
+		method.VerifiedAt = &verifiedAt.Time
+	}
+	if expiresAt.Valid {
+		method.VerificationExpiresAt = &expiresAt.Time
+	}
+	rule.ContactMethod = method
+	return rule, nil
+}
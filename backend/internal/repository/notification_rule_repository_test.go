@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/alerting"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteRules(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	checkout := uuid.New()
+
+	// "Email me for anything on services I own"
+	owned := &models.NotificationRule{UserID: alice, OwnedServicesOnly: true}
+	// "SMS me only for critical after 5 minutes unacknowledged"
+	critical := &models.NotificationRule{UserID: alice, MinSeverity: alerting.SeverityCritical, DelayMinutes: 5}
+	// Bob follows checkout, whoever owns it
+	following := &models.NotificationRule{UserID: bob, ServiceID: &checkout}
+	rules := []*models.NotificationRule{owned, critical, following}
+
+	route := alerting.Route{Event: alerting.EventTriggered, Severity: alerting.SeverityHigh, ServiceID: checkout.String(), OwnerID: alice.String()}
+	assert.Equal(t, []*models.NotificationRule{owned, following}, routeRules(rules, route))
+
+	route.Severity = alerting.SeverityCritical
+	assert.Equal(t, rules, routeRules(rules, route))
+
+	route = alerting.Route{Event: alerting.EventTriggered, Severity: alerting.SeverityCritical, ServiceID: uuid.NewString()}
+	assert.Equal(t, []*models.NotificationRule{critical}, routeRules(rules, route), "nobody owns the service")
+}
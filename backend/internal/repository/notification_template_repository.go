@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+)
+
+// NotificationTemplateRepository stores organizations' notification
+// templates
+type NotificationTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationTemplateRepository(db *sql.DB) *NotificationTemplateRepository {
+	return &NotificationTemplateRepository{db: db}
+}
+
+const notificationTemplateColumns = `id, organization_id, channel, alert_type, event, subject, body, html_body, created_at, updated_at`
+
+// Save creates the organization's template for the channel, alert type and
+// event, or replaces the one already there
+func (r *NotificationTemplateRepository) Save(tmpl *models.NotificationTemplate) error {
+	query := `
+		INSERT INTO notification_templates (` + notificationTemplateColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (organization_id, channel, alert_type, event) DO UPDATE
+		SET subject = EXCLUDED.subject, body = EXCLUDED.body, html_body = EXCLUDED.html_body,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(
+		query,
+		uuid.New(), tmpl.OrganizationID, tmpl.Channel, tmpl.AlertType, tmpl.Event, tmpl.Subject, tmpl.Body,
+		tmpl.HTMLBody, time.Now().UTC(),
+	).Scan(&tmpl.ID, &tmpl.CreatedAt, &tmpl.UpdatedAt)
+}
+
+func (r *NotificationTemplateRepository) GetByID(id uuid.UUID) (*models.NotificationTemplate, error) {
+	query := `
+		SELECT ` + notificationTemplateColumns + `
+		FROM notification_templates
+		WHERE id = $1
+	`
+
+	return scanNotificationTemplate(r.db.QueryRow(query, id))
+}
+
+func (r *NotificationTemplateRepository) ListByOrganization(orgID uuid.UUID) ([]*models.NotificationTemplate, error) {
+	query := `
+		SELECT ` + notificationTemplateColumns + `
+		FROM notification_templates
+		WHERE organization_id = $1
+		ORDER BY channel, alert_type, event
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]*models.NotificationTemplate, 0)
+	for rows.Next() {
+		tmpl, err := scanNotificationTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, tmpl)
+	}
+
+	return templates, rows.Err()
+}
+
+// Find returns the template an organization's notification uses on a
+// channel: the one for its alert type if there is one, then the one for
+// every type, or nil when the organization has neither
+func (r *NotificationTemplateRepository) Find(orgID uuid.UUID, channel, alertType, event string) (*models.NotificationTemplate, error) {
+	query := `
+		SELECT ` + notificationTemplateColumns + `
+		FROM notification_templates
+		WHERE organization_id = $1 AND channel = $2 AND event = $4 AND alert_type IN ($3, '')
+		ORDER BY alert_type = ''
+		LIMIT 1
+	`
+
+	tmpl, err := scanNotificationTemplate(r.db.QueryRow(query, orgID, channel, alertType, event))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return tmpl, err
+}
+
+func (r *NotificationTemplateRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM notification_templates WHERE id = $1`, id)
+	return err
+}
+
+func scanNotificationTemplate(row rowScanner) (*models.NotificationTemplate, error) {
+	tmpl := &models.NotificationTemplate{}
+	err := row.Scan(
+		&tmpl.ID, &tmpl.OrganizationID, &tmpl.Channel, &tmpl.AlertType, &tmpl.Event, &tmpl.Subject, &tmpl.Body,
+		&tmpl.HTMLBody, &tmpl.CreatedAt, &tmpl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
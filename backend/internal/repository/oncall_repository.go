@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+)
+
+type OnCallRepository struct {
+	db *sql.DB
+}
+
+func NewOnCallRepository(db *sql.DB) *OnCallRepository {
+	return &OnCallRepository{db: db}
+}
+
+const onCallScheduleColumns = `id, organization_id, name, description, time_zone, created_at, updated_at`
+
+const onCallOverrideColumns = `id, schedule_id, user_id, starts_at, ends_at, created_by, created_at`
+
+func (r *OnCallRepository) CreateSchedule(schedule *models.OnCallSchedule) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	schedule.ID = uuid.New()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	_, err = tx.Exec(
+		`INSERT INTO oncall_schedules (`+onCallScheduleColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		schedule.ID, schedule.OrganizationID, schedule.Name, schedule.Description, schedule.TimeZone,
+		schedule.CreatedAt, schedule.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertLayers(tx, schedule); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *OnCallRepository) GetSchedule(id uuid.UUID) (*models.OnCallSchedule, error) {
+	query := `
+		SELECT ` + onCallScheduleColumns + `
+		FROM oncall_schedules
+		WHERE id = $1
+	`
+
+	schedule, err := scanOnCallSchedule(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadLayers([]*models.OnCallSchedule{schedule}); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (r *OnCallRepository) ListSchedulesByOrganization(orgID uuid.UUID) ([]*models.OnCallSchedule, error) {
+	query := `
+		SELECT ` + onCallScheduleColumns + `
+		FROM oncall_schedules
+		WHERE organization_id = $1
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]*models.OnCallSchedule, 0)
+	for rows.Next() {
+		schedule, err := scanOnCallSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadLayers(schedules); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// UpdateSchedule saves a schedule and replaces its layers
+func (r *OnCallRepository) UpdateSchedule(schedule *models.OnCallSchedule) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	schedule.UpdatedAt = time.Now().UTC()
+	_, err = tx.Exec(
+		`UPDATE oncall_schedules SET name = $2, description = $3, time_zone = $4, updated_at = $5 WHERE id = $1`,
+		schedule.ID, schedule.Name, schedule.Description, schedule.TimeZone, schedule.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM oncall_layers WHERE schedule_id = $1`, schedule.ID); err != nil {
+		return err
+	}
+	if err := insertLayers(tx, schedule); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *OnCallRepository) DeleteSchedule(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM oncall_schedules WHERE id = $1`, id)
+	return err
+}
+
+func (r *OnCallRepository) CreateOverride(o *models.OnCallOverride) error {
+	query := `
+		INSERT INTO oncall_overrides (` + onCallOverrideColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	o.ID = uuid.New()
+	o.CreatedAt = time.Now().UTC()
+
+	_, err := r.db.Exec(query, o.ID, o.ScheduleID, o.UserID, o.StartsAt, o.EndsAt, o.CreatedBy, o.CreatedAt)
+	return err
+}
+
+func (r *OnCallRepository) GetOverride(id uuid.UUID) (*models.OnCallOverride, error) {
+	query := `
+		SELECT ` + onCallOverrideColumns + `
+		FROM oncall_overrides
+		WHERE id = $1
+	`
+
+	return scanOnCallOverride(r.db.QueryRow(query, id))
+}
+
+// ListOverrides returns a schedule's overrides that overlap from..to, in the
+// order they were created, which is the order later ones win in
+func (r *OnCallRepository) ListOverrides(scheduleID uuid.UUID, from, to time.Time) ([]*models.OnCallOverride, error) {
+	query := `
+		SELECT ` + onCallOverrideColumns + `
+		FROM oncall_overrides
+		WHERE schedule_id = $1 AND ends_at > $2 AND starts_at < $3
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, scheduleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make([]*models.OnCallOverride, 0)
+	for rows.Next() {
+		o, err := scanOnCallOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, rows.Err()
+}
+
+func (r *OnCallRepository) DeleteOverride(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM oncall_overrides WHERE id = $1`, id)
+	return err
+}
+
+// loadLayers fills in the layers of each schedule with a single query
+func (r *OnCallRepository) loadLayers(schedules []*models.OnCallSchedule) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.OnCallSchedule, len(schedules))
+	ids := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		schedule.Layers = make([]models.OnCallLayer, 0)
+		byID[schedule.ID] = schedule
+		ids = append(ids, schedule.ID.String())
+	}
+
+	rows, err := r.db.Query(`
+		SELECT schedule_id, position, name, rotation, handoff_time, handoff_weekday, starts_at, ends_at, participant_ids
+		FROM oncall_layers
+		WHERE schedule_id = ANY($1::uuid[])
+		ORDER BY schedule_id, position
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var scheduleID uuid.UUID
+		var layer models.OnCallLayer
+		var endsAt sql.NullTime
+		var participants pq.StringArray
+		err := rows.Scan(
+			&scheduleID, &layer.Position, &layer.Name, &layer.Rotation, &layer.HandoffTime, &layer.HandoffWeekday,
+			&layer.StartsAt, &endsAt, &participants,
+		)
+		if err != nil {
+			return err
+		}
+
+		if endsAt.Valid {
+			layer.EndsAt = &endsAt.Time
+		}
+		layer.ParticipantIDs = make([]uuid.UUID, 0, len(participants))
+		for _, p := range participants {
+			id, err := uuid.Parse(p)
+			if err != nil {
+				return err
+			}
+			layer.ParticipantIDs = append(layer.ParticipantIDs, id)
+		}
+
+		if schedule, ok := byID[scheduleID]; ok {
+			schedule.Layers = append(schedule.Layers, layer)
+		}
+	}
+
+	return rows.Err()
+}
+
+// insertLayers writes a schedule's layers, numbering them from 1 in order
+func insertLayers(tx *sql.Tx, schedule *models.OnCallSchedule) error {
+	for i := range schedule.Layers {
+		layer := &schedule.Layers[i]
+		layer.Position = i + 1
+
+		participants := make([]string, 0, len(layer.ParticipantIDs))
+		for _, id := range layer.ParticipantIDs {
+			participants = append(participants, id.String())
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO oncall_layers (schedule_id, position, name, rotation, handoff_time, handoff_weekday, starts_at, ends_at, participant_ids)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::uuid[])
+		`, schedule.ID, layer.Position, layer.Name, layer.Rotation, layer.HandoffTime, layer.HandoffWeekday,
+			layer.StartsAt, layer.EndsAt, pq.Array(participants))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanOnCallSchedule(row rowScanner) (*models.OnCallSchedule, error) {
+	schedule := &models.OnCallSchedule{}
+	var description sql.NullString
+
+	err := row.Scan(
+		&schedule.ID, &schedule.OrganizationID, &schedule.Name, &description, &schedule.TimeZone,
+		&schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if description.Valid {
+		schedule.Description = &description.String
+	}
+
+	return schedule, nil
+}
+
+func scanOnCallOverride(row rowScanner) (*models.OnCallOverride, error) {
+	o := &models.OnCallOverride{}
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(&o.ID, &o.ScheduleID, &o.UserID, &o.StartsAt, &o.EndsAt, &createdBy, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if createdBy.Valid {
+		o.CreatedBy = &createdBy.UUID
+	}
+
+	return o, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/webpush"
+)
+
+// PushSubscriptionRepository stores the browsers users receive desktop
+// notifications in, and the VAPID keys they subscribed with
+type PushSubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewPushSubscriptionRepository(db *sql.DB) *PushSubscriptionRepository {
+	return &PushSubscriptionRepository{db: db}
+}
+
+const pushSubscriptionColumns = `id, user_id, endpoint, p256dh, auth, user_agent, created_at, last_used_at`
+
+// Save stores a browser's subscription for a user. A browser subscribing
+// again, or handed to another user, keeps its endpoint and takes the new
+// keys and owner.
+func (r *PushSubscriptionRepository) Save(sub *models.PushSubscription) error {
+	query := `
+		INSERT INTO push_subscriptions (id, user_id, endpoint, p256dh, auth, user_agent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (endpoint) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth,
+			user_agent = EXCLUDED.user_agent
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		query,
+		uuid.New(), sub.UserID, sub.Endpoint, sub.P256dh, sub.Auth, sub.UserAgent, time.Now().UTC(),
+	).Scan(&sub.ID, &sub.CreatedAt)
+}
+
+// ListByUser returns the browsers a user is subscribed in, newest first
+func (r *PushSubscriptionRepository) ListByUser(userID uuid.UUID) ([]*models.PushSubscription, error) {
+	query := `
+		SELECT ` + pushSubscriptionColumns + `
+		FROM push_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]*models.PushSubscription, 0)
+	for rows.Next() {
+		sub := &models.PushSubscription{}
+		var lastUsedAt sql.NullTime
+		if err := rows.Scan(
+			&sub.ID, &sub.UserID, &sub.Endpoint, &sub.P256dh, &sub.Auth, &sub.UserAgent, &sub.CreatedAt, &lastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		if lastUsedAt.Valid {
+			sub.LastUsedAt = &lastUsedAt.Time
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, rows.Err()
+}
+
+// Delete removes one of a user's subscriptions, returning ErrNotFound if the
+// user has no such subscription
+func (r *PushSubscriptionRepository) Delete(id, userID uuid.UUID) error {
+	result, err := r.db.Exec(`DELETE FROM push_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteByEndpoint removes a subscription the push service says is gone
+func (r *PushSubscriptionRepository) DeleteByEndpoint(endpoint string) error {
+	_, err := r.db.Exec(`DELETE FROM push_subscriptions WHERE endpoint = $1`, endpoint)
+	return err
+}
+
+// MarkUsed records that a message was pushed to a subscription
+func (r *PushSubscriptionRepository) MarkUsed(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE push_subscriptions SET last_used_at = $1 WHERE id = $2`, time.Now().UTC(), id)
+	return err
+}
+
+// VAPIDKeys returns the stored VAPID key pair, storing generated first if
+// there is none yet. Every server shares the first pair stored, since
+// browsers subscribed with one public key can only be sent to with it.
+func (r *PushSubscriptionRepository) VAPIDKeys(generated webpush.Keys) (webpush.Keys, error) {
+	_, err := r.db.Exec(
+		`INSERT INTO vapid_keys (id, public_key, private_key) VALUES (1, $1, $2) ON CONFLICT (id) DO NOTHING`,
+		generated.PublicKey, generated.PrivateKey,
+	)
+	if err != nil {
+		return webpush.Keys{}, err
+	}
+
+	var keys webpush.Keys
+	err = r.db.QueryRow(`SELECT public_key, private_key FROM vapid_keys WHERE id = 1`).Scan(&keys.PublicKey, &keys.PrivateKey)
+	return keys, err
+}
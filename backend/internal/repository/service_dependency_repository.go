@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+)
+
+type ServiceDependencyRepository struct {
+	db *sql.DB
+}
+
+func NewServiceDependencyRepository(db *sql.DB) *ServiceDependencyRepository {
+	return &ServiceDependencyRepository{db: db}
+}
+
+// ListByOrganization returns every dependency edge between the organization's services
+func (r *ServiceDependencyRepository) ListByOrganization(orgID uuid.UUID) ([]*models.ServiceDependency, error) {
+	query := `
+		SELECT d.service_id, d.depends_on_id, d.created_at
+		FROM service_dependencies d
+		JOIN services s ON d.service_id = s.id
+		WHERE s.organization_id = $1
+		ORDER BY d.created_at
+	`
+
+	return r.list(query, orgID)
+}
+
+// ListByService returns the services that serviceID depends on
+func (r *ServiceDependencyRepository) ListByService(serviceID uuid.UUID) ([]*models.ServiceDependency, error) {
+	query := `
+		SELECT service_id, depends_on_id, created_at
+		FROM service_dependencies
+		WHERE service_id = $1
+		ORDER BY created_at
+	`
+
+	return r.list(query, serviceID)
+}
+
+// SetDependencies replaces the upstreams of serviceID with dependsOn
+func (r *ServiceDependencyRepository) SetDependencies(serviceID uuid.UUID, dependsOn []uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM service_dependencies WHERE service_id = $1`, serviceID); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, upstream := range dependsOn {
+		_, err := tx.Exec(
+			`INSERT INTO service_dependencies (service_id, depends_on_id, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			serviceID, upstream, now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ServiceDependencyRepository) list(query string, args ...interface{}) ([]*models.ServiceDependency, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := make([]*models.ServiceDependency, 0)
+	for rows.Next() {
+		dep := &models.ServiceDependency{}
+		if err := rows.Scan(&dep.ServiceID, &dep.DependsOnID, &dep.CreatedAt); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+
+	return deps, rows.Err()
+}
@@ -9,6 +9,11 @@ import (
 	"github.com/lib/pq"
 )
 
+// serviceColumns lists the columns read by scanService, in scan order
+const serviceColumns = `id, organization_id, name, url, type, check_interval, timeout, expected_status_code, latency_threshold_ms,
+	escalated_check_interval, auto_resolve, flap_detection, flap_window, flap_high_threshold, flap_low_threshold,
+	tags, owner_id, is_active, created_at, updated_at`
+
 type ServiceRepository struct {
 	db *sql.DB
 }
@@ -19,8 +24,9 @@ func NewServiceRepository(db *sql.DB) *ServiceRepository {
 
 func (r *ServiceRepository) Create(service *models.Service) error {
 	query := `
-		INSERT INTO services (id, organization_id, name, url, type, check_interval, timeout, expected_status_code, latency_threshold_ms, tags, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO services (id, organization_id, name, url, type, check_interval, timeout, expected_status_code, latency_threshold_ms, escalated_check_interval, auto_resolve,
+			flap_detection, flap_window, flap_high_threshold, flap_low_threshold, tags, owner_id, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		RETURNING id, created_at, updated_at
 	`
 	
@@ -33,7 +39,9 @@ func (r *ServiceRepository) Create(service *models.Service) error {
 		query,
 		service.ID, service.OrganizationID, service.Name, service.URL, service.Type,
 		service.CheckInterval, service.Timeout, service.ExpectedStatusCode, service.LatencyThresholdMs,
-		pq.Array(service.Tags), service.IsActive, service.CreatedAt, service.UpdatedAt,
+		service.EscalatedCheckInterval, service.AutoResolve,
+		service.FlapDetection, service.FlapWindow, service.FlapHighThreshold, service.FlapLowThreshold,
+		pq.Array(service.Tags), service.OwnerID, service.IsActive, service.CreatedAt, service.UpdatedAt,
 	).Scan(&service.ID, &service.CreatedAt, &service.UpdatedAt)
 
 	return err
@@ -41,42 +49,17 @@ func (r *ServiceRepository) Create(service *models.Service) error {
 
 func (r *ServiceRepository) GetByID(id uuid.UUID) (*models.Service, error) {
 	query := `
-		SELECT id, organization_id, name, url, type, check_interval, timeout, expected_status_code, latency_threshold_ms, tags, is_active, created_at, updated_at
+		SELECT ` + serviceColumns + `
 		FROM services
 		WHERE id = $1
 	`
 
-	service := &models.Service{}
-	var tags pq.StringArray
-	var statusCode sql.NullInt64
-	var latencyThreshold sql.NullInt64
-
-	err := r.db.QueryRow(query, id).Scan(
-		&service.ID, &service.OrganizationID, &service.Name, &service.URL, &service.Type,
-		&service.CheckInterval, &service.Timeout, &statusCode, &latencyThreshold, &tags,
-		&service.IsActive, &service.CreatedAt, &service.UpdatedAt,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	service.Tags = []string(tags)
-	if statusCode.Valid {
-		code := int(statusCode.Int64)
-		service.ExpectedStatusCode = &code
-	}
-	if latencyThreshold.Valid {
-		threshold := int(latencyThreshold.Int64)
-		service.LatencyThresholdMs = &threshold
-	}
-
-	return service, nil
+	return scanService(r.db.QueryRow(query, id))
 }
 
 func (r *ServiceRepository) ListByOrganization(orgID uuid.UUID) ([]*models.Service, error) {
 	query := `
-		SELECT id, organization_id, name, url, type, check_interval, timeout, expected_status_code, latency_threshold_ms, tags, is_active, created_at, updated_at
+		SELECT ` + serviceColumns + `
 		FROM services
 		WHERE organization_id = $1
 		ORDER BY created_at DESC
@@ -90,30 +73,11 @@ func (r *ServiceRepository) ListByOrganization(orgID uuid.UUID) ([]*models.Servi
 
 	services := make([]*models.Service, 0) // Initialize as empty slice, not nil
 	for rows.Next() {
-		service := &models.Service{}
-		var tags pq.StringArray
-		var statusCode sql.NullInt64
-		var latencyThreshold sql.NullInt64
-
-		err := rows.Scan(
-			&service.ID, &service.OrganizationID, &service.Name, &service.URL, &service.Type,
-			&service.CheckInterval, &service.Timeout, &statusCode, &latencyThreshold, &tags,
-			&service.IsActive, &service.CreatedAt, &service.UpdatedAt,
-		)
+		service, err := scanService(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		service.Tags = []string(tags)
-		if statusCode.Valid {
-			code := int(statusCode.Int64)
-			service.ExpectedStatusCode = &code
-		}
-		if latencyThreshold.Valid {
-			threshold := int(latencyThreshold.Int64)
-			service.LatencyThresholdMs = &threshold
-		}
-
 		services = append(services, service)
 	}
 
@@ -127,7 +91,9 @@ func (r *ServiceRepository) GetDB() *sql.DB {
 func (r *ServiceRepository) Update(service *models.Service) error {
 	query := `
 		UPDATE services
-		SET name = $2, url = $3, type = $4, check_interval = $5, timeout = $6, expected_status_code = $7, latency_threshold_ms = $8, tags = $9, is_active = $10, updated_at = $11
+		SET name = $2, url = $3, type = $4, check_interval = $5, timeout = $6, expected_status_code = $7, latency_threshold_ms = $8,
+			escalated_check_interval = $9, auto_resolve = $10, flap_detection = $11, flap_window = $12, flap_high_threshold = $13,
+			flap_low_threshold = $14, tags = $15, is_active = $16, updated_at = $17, owner_id = $18
 		WHERE id = $1
 		RETURNING updated_at
 	`
@@ -137,7 +103,9 @@ func (r *ServiceRepository) Update(service *models.Service) error {
 		query,
 		service.ID, service.Name, service.URL, service.Type,
 		service.CheckInterval, service.Timeout, service.ExpectedStatusCode, service.LatencyThresholdMs,
-		pq.Array(service.Tags), service.IsActive, service.UpdatedAt,
+		service.EscalatedCheckInterval, service.AutoResolve,
+		service.FlapDetection, service.FlapWindow, service.FlapHighThreshold, service.FlapLowThreshold,
+		pq.Array(service.Tags), service.IsActive, service.UpdatedAt, service.OwnerID,
 	).Scan(&service.UpdatedAt)
 
 	return err
@@ -151,7 +119,7 @@ func (r *ServiceRepository) Delete(id uuid.UUID) error {
 
 func (r *ServiceRepository) ListActive() ([]*models.Service, error) {
 	query := `
-		SELECT id, organization_id, name, url, type, check_interval, timeout, expected_status_code, latency_threshold_ms, tags, is_active, created_at, updated_at
+		SELECT ` + serviceColumns + `
 		FROM services
 		WHERE is_active = TRUE
 		ORDER BY created_at DESC
@@ -165,33 +133,53 @@ func (r *ServiceRepository) ListActive() ([]*models.Service, error) {
 
 	var services []*models.Service
 	for rows.Next() {
-		service := &models.Service{}
-		var tags pq.StringArray
-		var statusCode sql.NullInt64
-		var latencyThreshold sql.NullInt64
-
-		err := rows.Scan(
-			&service.ID, &service.OrganizationID, &service.Name, &service.URL, &service.Type,
-			&service.CheckInterval, &service.Timeout, &statusCode, &latencyThreshold, &tags,
-			&service.IsActive, &service.CreatedAt, &service.UpdatedAt,
-		)
+		service, err := scanService(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		service.Tags = []string(tags)
-		if statusCode.Valid {
-			code := int(statusCode.Int64)
-			service.ExpectedStatusCode = &code
-		}
-		if latencyThreshold.Valid {
-			threshold := int(latencyThreshold.Int64)
-			service.LatencyThresholdMs = &threshold
-		}
-
 		services = append(services, service)
 	}
 
 	return services, rows.Err()
 }
 
+
+func scanService(row rowScanner) (*models.Service, error) {
+	service := &models.Service{}
+	var tags pq.StringArray
+	var statusCode sql.NullInt64
+	var latencyThreshold sql.NullInt64
+	var escalatedInterval sql.NullInt64
+	var ownerID uuid.NullUUID
+
+	err := row.Scan(
+		&service.ID, &service.OrganizationID, &service.Name, &service.URL, &service.Type,
+		&service.CheckInterval, &service.Timeout, &statusCode, &latencyThreshold,
+		&escalatedInterval, &service.AutoResolve,
+		&service.FlapDetection, &service.FlapWindow, &service.FlapHighThreshold, &service.FlapLowThreshold,
+		&tags, &ownerID, &service.IsActive, &service.CreatedAt, &service.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	service.Tags = []string(tags)
+	if ownerID.Valid {
+		service.OwnerID = &ownerID.UUID
+	}
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		service.ExpectedStatusCode = &code
+	}
+	if latencyThreshold.Valid {
+		threshold := int(latencyThreshold.Int64)
+		service.LatencyThresholdMs = &threshold
+	}
+	if escalatedInterval.Valid {
+		interval := int(escalatedInterval.Int64)
+		service.EscalatedCheckInterval = &interval
+	}
+
+	return service, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+)
+
+type ServiceStateRepository struct {
+	db *sql.DB
+}
+
+func NewServiceStateRepository(db *sql.DB) *ServiceStateRepository {
+	return &ServiceStateRepository{db: db}
+}
+
+const serviceStateColumns = `service_id, last_status, effective_check_interval, interval_reason, consecutive_failures,
+	consecutive_successes, last_checked_at, updated_at`
+
+// GetByServiceID returns the stored state for a service, or nil if the service has never been checked
+func (r *ServiceStateRepository) GetByServiceID(serviceID uuid.UUID) (*models.ServiceState, error) {
+	query := `
+		SELECT ` + serviceStateColumns + `
+		FROM service_states
+		WHERE service_id = $1
+	`
+
+	state, err := scanServiceState(r.db.QueryRow(query, serviceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return state, err
+}
+
+// ListAll returns every stored service state keyed by service ID
+func (r *ServiceStateRepository) ListAll() (map[uuid.UUID]*models.ServiceState, error) {
+	query := `SELECT ` + serviceStateColumns + ` FROM service_states`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[uuid.UUID]*models.ServiceState)
+	for rows.Next() {
+		state, err := scanServiceState(rows)
+		if err != nil {
+			return nil, err
+		}
+		states[state.ServiceID] = state
+	}
+
+	return states, rows.Err()
+}
+
+// ListByOrganization returns the stored states of an organization's services keyed by service ID
+func (r *ServiceStateRepository) ListByOrganization(orgID uuid.UUID) (map[uuid.UUID]*models.ServiceState, error) {
+	query := `
+		SELECT ` + qualifyColumns("ss", serviceStateColumns) + `
+		FROM service_states ss
+		JOIN services s ON ss.service_id = s.id
+		WHERE s.organization_id = $1
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[uuid.UUID]*models.ServiceState)
+	for rows.Next() {
+		state, err := scanServiceState(rows)
+		if err != nil {
+			return nil, err
+		}
+		states[state.ServiceID] = state
+	}
+
+	return states, rows.Err()
+}
+
+// Upsert inserts or replaces the state for a service
+func (r *ServiceStateRepository) Upsert(state *models.ServiceState) error {
+	query := `
+		INSERT INTO service_states (` + serviceStateColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (service_id) DO UPDATE SET
+			last_status = EXCLUDED.last_status,
+			effective_check_interval = EXCLUDED.effective_check_interval,
+			interval_reason = EXCLUDED.interval_reason,
+			consecutive_failures = EXCLUDED.consecutive_failures,
+			consecutive_successes = EXCLUDED.consecutive_successes,
+			last_checked_at = EXCLUDED.last_checked_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	state.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(
+		query,
+		state.ServiceID, state.LastStatus, state.EffectiveCheckInterval, state.IntervalReason,
+		state.ConsecutiveFailures, state.ConsecutiveSuccesses, state.LastCheckedAt, state.UpdatedAt,
+	)
+	return err
+}
+
+func scanServiceState(row rowScanner) (*models.ServiceState, error) {
+	state := &models.ServiceState{}
+	var intervalReason sql.NullString
+	var lastCheckedAt sql.NullTime
+
+	err := row.Scan(
+		&state.ServiceID, &state.LastStatus, &state.EffectiveCheckInterval, &intervalReason,
+		&state.ConsecutiveFailures, &state.ConsecutiveSuccesses, &lastCheckedAt, &state.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if intervalReason.Valid {
+		state.IntervalReason = &intervalReason.String
+	}
+	if lastCheckedAt.Valid {
+		state.LastCheckedAt = &lastCheckedAt.Time
+	}
+
+	return state, nil
+}
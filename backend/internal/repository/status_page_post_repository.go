@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+	"pulsegrid/backend/pkg/statuspage"
+)
+
+// StatusPagePostRepository stores the incident and maintenance posts written
+// on status pages, with their updates
+type StatusPagePostRepository struct {
+	db *sql.DB
+}
+
+func NewStatusPagePostRepository(db *sql.DB) *StatusPagePostRepository {
+	return &StatusPagePostRepository{db: db}
+}
+
+const statusPagePostColumns = `id, page_id, kind, title, status, impact, component_ids, scheduled_for, scheduled_until,
+	resolved_at, created_by, created_at, updated_at`
+
+// Create stores a post together with its first update
+func (r *StatusPagePostRepository) Create(post *models.StatusPagePost, update *models.StatusPagePostUpdate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	post.ID = uuid.New()
+	post.Status = update.Status
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	post.ResolvedAt = nil
+	if statuspage.Closed(post.Status) {
+		post.ResolvedAt = &now
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO status_page_posts (`+statusPagePostColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7::uuid[], $8, $9, $10, $11, $12, $13)`,
+		post.ID, post.PageID, post.Kind, post.Title, post.Status, post.Impact, pq.Array(uuidStrings(post.ComponentIDs)),
+		post.ScheduledFor, post.ScheduledUntil, post.ResolvedAt, post.CreatedBy, post.CreatedAt, post.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPostUpdate(tx, post, update, now); err != nil {
+		return err
+	}
+	post.Updates = []models.StatusPagePostUpdate{*update}
+
+	return tx.Commit()
+}
+
+func (r *StatusPagePostRepository) GetByID(id uuid.UUID) (*models.StatusPagePost, error) {
+	post, err := scanStatusPagePost(r.db.QueryRow(`SELECT `+statusPagePostColumns+` FROM status_page_posts WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadUpdates([]*models.StatusPagePost{post}); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// ListByPage returns a page's newest posts
+func (r *StatusPagePostRepository) ListByPage(pageID uuid.UUID, limit int) ([]*models.StatusPagePost, error) {
+	return r.list(`
+		SELECT `+statusPagePostColumns+`
+		FROM status_page_posts
+		WHERE page_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, pageID, limit)
+}
+
+// ListVisible returns the posts a page shows: every open one, and those
+// closed since closedSince
+func (r *StatusPagePostRepository) ListVisible(pageID uuid.UUID, closedSince time.Time) ([]*models.StatusPagePost, error) {
+	return r.list(`
+		SELECT `+statusPagePostColumns+`
+		FROM status_page_posts
+		WHERE page_id = $1 AND (resolved_at IS NULL OR resolved_at >= $2)
+		ORDER BY created_at DESC
+	`, pageID, closedSince)
+}
+
+// Update saves a post's details. Its status only changes through AddUpdate.
+func (r *StatusPagePostRepository) Update(post *models.StatusPagePost) error {
+	post.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(`
+		UPDATE status_page_posts
+		SET title = $2, impact = $3, component_ids = $4::uuid[], scheduled_for = $5, scheduled_until = $6, updated_at = $7
+		WHERE id = $1
+	`, post.ID, post.Title, post.Impact, pq.Array(uuidStrings(post.ComponentIDs)), post.ScheduledFor, post.ScheduledUntil, post.UpdatedAt)
+	return err
+}
+
+// AddUpdate appends an update to a post's timeline and moves the post to
+// the update's status. Closing a post records when; reopening it clears
+// that.
+func (r *StatusPagePostRepository) AddUpdate(post *models.StatusPagePost, update *models.StatusPagePostUpdate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	post.Status = update.Status
+	post.UpdatedAt = now
+	switch {
+	case !statuspage.Closed(post.Status):
+		post.ResolvedAt = nil
+	case post.ResolvedAt == nil:
+		post.ResolvedAt = &now
+	}
+
+	_, err = tx.Exec(`
+		UPDATE status_page_posts
+		SET status = $2, resolved_at = $3, updated_at = $4
+		WHERE id = $1
+	`, post.ID, post.Status, post.ResolvedAt, post.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPostUpdate(tx, post, update, now); err != nil {
+		return err
+	}
+	post.Updates = append(post.Updates, *update)
+
+	return tx.Commit()
+}
+
+func (r *StatusPagePostRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM status_page_posts WHERE id = $1`, id)
+	return err
+}
+
+func (r *StatusPagePostRepository) list(query string, args ...interface{}) ([]*models.StatusPagePost, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]*models.StatusPagePost, 0)
+	for rows.Next() {
+		post, err := scanStatusPagePost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadUpdates(posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (r *StatusPagePostRepository) loadUpdates(posts []*models.StatusPagePost) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.StatusPagePost, len(posts))
+	ids := make([]string, 0, len(posts))
+	for _, post := range posts {
+		post.Updates = make([]models.StatusPagePostUpdate, 0)
+		byID[post.ID] = post
+		ids = append(ids, post.ID.String())
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, post_id, status, body, created_by, created_at
+		FROM status_page_post_updates
+		WHERE post_id = ANY($1::uuid[])
+		ORDER BY created_at
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var update models.StatusPagePostUpdate
+		if err := rows.Scan(&update.ID, &update.PostID, &update.Status, &update.Body, &update.CreatedBy, &update.CreatedAt); err != nil {
+			return err
+		}
+		if post, ok := byID[update.PostID]; ok {
+			post.Updates = append(post.Updates, update)
+		}
+	}
+	return rows.Err()
+}
+
+func insertPostUpdate(tx *sql.Tx, post *models.StatusPagePost, update *models.StatusPagePostUpdate, now time.Time) error {
+	update.ID = uuid.New()
+	update.PostID = post.ID
+	update.CreatedAt = now
+
+	_, err := tx.Exec(`
+		INSERT INTO status_page_post_updates (id, post_id, status, body, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, update.ID, update.PostID, update.Status, update.Body, update.CreatedBy, update.CreatedAt)
+	return err
+}
+
+// uuidStrings formats ids for a UUID[] column
+func uuidStrings(ids []uuid.UUID) []string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, id.String())
+	}
+	return s
+}
+
+func scanStatusPagePost(row rowScanner) (*models.StatusPagePost, error) {
+	post := &models.StatusPagePost{}
+	var componentIDs pq.StringArray
+	err := row.Scan(
+		&post.ID, &post.PageID, &post.Kind, &post.Title, &post.Status, &post.Impact, &componentIDs,
+		&post.ScheduledFor, &post.ScheduledUntil, &post.ResolvedAt, &post.CreatedBy, &post.CreatedAt, &post.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	post.ComponentIDs = make([]uuid.UUID, 0, len(componentIDs))
+	for _, s := range componentIDs {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		post.ComponentIDs = append(post.ComponentIDs, id)
+	}
+	return post, nil
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pulsegrid/backend/internal/models"
+)
+
+// StatusPageRepository stores organizations' public status pages and their
+// components
+type StatusPageRepository struct {
+	db *sql.DB
+}
+
+func NewStatusPageRepository(db *sql.DB) *StatusPageRepository {
+	return &StatusPageRepository{db: db}
+}
+
+const statusPageColumns = `id, organization_id, slug, title, description, is_published, password_hash, logo_url,
+	favicon_url, brand_color, support_url, footer_text, created_at, updated_at`
+
+// Create stores a page with its components, returning ErrDuplicateEntry if
+// its slug is taken
+func (r *StatusPageRepository) Create(page *models.StatusPage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	page.ID = uuid.New()
+	page.CreatedAt = now
+	page.UpdatedAt = now
+
+	_, err = tx.Exec(
+		`INSERT INTO status_pages (`+statusPageColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		page.ID, page.OrganizationID, page.Slug, page.Title, page.Description, page.IsPublished, page.PasswordHash,
+		page.LogoURL, page.FaviconURL, page.BrandColor, page.SupportURL, page.FooterText, page.CreatedAt, page.UpdatedAt,
+	)
+	if err != nil {
+		return duplicateOr(err)
+	}
+
+	if err := insertComponents(tx, page); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *StatusPageRepository) GetByID(id uuid.UUID) (*models.StatusPage, error) {
+	return r.get(`SELECT `+statusPageColumns+` FROM status_pages WHERE id = $1`, id)
+}
+
+// GetBySlug returns the page published at /status/:slug
+func (r *StatusPageRepository) GetBySlug(slug string) (*models.StatusPage, error) {
+	return r.get(`SELECT `+statusPageColumns+` FROM status_pages WHERE slug = $1`, slug)
+}
+
+func (r *StatusPageRepository) ListByOrganization(orgID uuid.UUID) ([]*models.StatusPage, error) {
+	query := `
+		SELECT ` + statusPageColumns + `
+		FROM status_pages
+		WHERE organization_id = $1
+		ORDER BY title
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := make([]*models.StatusPage, 0)
+	for rows.Next() {
+		page, err := scanStatusPage(rows)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadComponents(pages); err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// Update saves a page and replaces its components, returning
+// ErrDuplicateEntry if its new slug is taken
+func (r *StatusPageRepository) Update(page *models.StatusPage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	page.UpdatedAt = time.Now().UTC()
+	_, err = tx.Exec(`
+		UPDATE status_pages
+		SET slug = $2, title = $3, description = $4, is_published = $5, password_hash = $6, logo_url = $7,
+			favicon_url = $8, brand_color = $9, support_url = $10, footer_text = $11, updated_at = $12
+		WHERE id = $1
+	`, page.ID, page.Slug, page.Title, page.Description, page.IsPublished, page.PasswordHash, page.LogoURL,
+		page.FaviconURL, page.BrandColor, page.SupportURL, page.FooterText, page.UpdatedAt)
+	if err != nil {
+		return duplicateOr(err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM status_page_components WHERE page_id = $1`, page.ID); err != nil {
+		return err
+	}
+	if err := insertComponents(tx, page); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *StatusPageRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM status_pages WHERE id = $1`, id)
+	return err
+}
+
+func (r *StatusPageRepository) get(query string, arg interface{}) (*models.StatusPage, error) {
+	page, err := scanStatusPage(r.db.QueryRow(query, arg))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadComponents([]*models.StatusPage{page}); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (r *StatusPageRepository) loadComponents(pages []*models.StatusPage) error {
+	if len(pages) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.StatusPage, len(pages))
+	ids := make([]string, 0, len(pages))
+	for _, page := range pages {
+		page.Components = make([]models.StatusPageComponent, 0)
+		byID[page.ID] = page
+		ids = append(ids, page.ID.String())
+	}
+
+	rows, err := r.db.Query(`
+		SELECT page_id, id, position, name, description, service_ids
+		FROM status_page_components
+		WHERE page_id = ANY($1::uuid[])
+		ORDER BY page_id, position
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pageID uuid.UUID
+		var component models.StatusPageComponent
+		var serviceIDs pq.StringArray
+		if err := rows.Scan(&pageID, &component.ID, &component.Position, &component.Name, &component.Description, &serviceIDs); err != nil {
+			return err
+		}
+
+		component.ServiceIDs = make([]uuid.UUID, 0, len(serviceIDs))
+		for _, s := range serviceIDs {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return err
+			}
+			component.ServiceIDs = append(component.ServiceIDs, id)
+		}
+
+		if page, ok := byID[pageID]; ok {
+			page.Components = append(page.Components, component)
+		}
+	}
+	return rows.Err()
+}
+
+// insertComponents stores a page's components in order. Components keep
+// their IDs across updates; new ones are given one.
+func insertComponents(tx *sql.Tx, page *models.StatusPage) error {
+	for i := range page.Components {
+		component := &page.Components[i]
+		component.Position = i + 1
+		if component.ID == uuid.Nil {
+			component.ID = uuid.New()
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO status_page_components (id, page_id, position, name, description, service_ids)
+			VALUES ($1, $2, $3, $4, $5, $6::uuid[])
+		`, component.ID, page.ID, component.Position, component.Name, component.Description, pq.Array(uuidStrings(component.ServiceIDs)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duplicateOr returns ErrDuplicateEntry for a unique constraint violation,
+// and err otherwise
+func duplicateOr(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrDuplicateEntry
+	}
+	return err
+}
+
+func scanStatusPage(row rowScanner) (*models.StatusPage, error) {
+	page := &models.StatusPage{}
+	err := row.Scan(
+		&page.ID, &page.OrganizationID, &page.Slug, &page.Title, &page.Description, &page.IsPublished,
+		&page.PasswordHash, &page.LogoURL, &page.FaviconURL, &page.BrandColor, &page.SupportURL, &page.FooterText,
+		&page.CreatedAt, &page.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	page.PasswordProtected = page.PasswordHash != ""
+	return page, nil
+}
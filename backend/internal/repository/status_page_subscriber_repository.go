@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"pulsegrid/backend/internal/models"
+)
+
+// StatusPageSubscriberRepository stores the end users following status
+// pages. They are kept apart from alert subscriptions, which are for staff.
+type StatusPageSubscriberRepository struct {
+	db *sql.DB
+}
+
+func NewStatusPageSubscriberRepository(db *sql.DB) *StatusPageSubscriberRepository {
+	return &StatusPageSubscriberRepository{db: db}
+}
+
+const statusPageSubscriberColumns = `id, page_id, channel, destination, confirmed, confirm_token, confirm_sent_at,
+	unsubscribe_token, confirmed_at, created_at`
+
+// Create stores a pending subscriber, returning ErrDuplicateEntry if the
+// destination already follows the page
+func (r *StatusPageSubscriberRepository) Create(sub *models.StatusPageSubscriber) error {
+	sub.ID = uuid.New()
+	sub.Confirmed = false
+	sub.ConfirmedAt = nil
+	sub.CreatedAt = time.Now().UTC()
+
+	_, err := r.db.Exec(
+		`INSERT INTO status_page_subscribers (`+statusPageSubscriberColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		sub.ID, sub.PageID, sub.Channel, sub.Destination, sub.Confirmed, sub.ConfirmToken, sub.ConfirmSentAt,
+		sub.UnsubscribeToken, sub.ConfirmedAt, sub.CreatedAt,
+	)
+	return duplicateOr(err)
+}
+
+func (r *StatusPageSubscriberRepository) GetByID(id uuid.UUID) (*models.StatusPageSubscriber, error) {
+	return scanStatusPageSubscriber(r.db.QueryRow(`SELECT `+statusPageSubscriberColumns+` FROM status_page_subscribers WHERE id = $1`, id))
+}
+
+// GetByDestination returns the page's subscriber for a destination
+func (r *StatusPageSubscriberRepository) GetByDestination(pageID uuid.UUID, channel, destination string) (*models.StatusPageSubscriber, error) {
+	return scanStatusPageSubscriber(r.db.QueryRow(`
+		SELECT `+statusPageSubscriberColumns+`
+		FROM status_page_subscribers
+		WHERE page_id = $1 AND channel = $2 AND destination = $3
+	`, pageID, channel, destination))
+}
+
+// ListByPage returns every subscriber of a page, pending ones included
+func (r *StatusPageSubscriberRepository) ListByPage(pageID uuid.UUID) ([]*models.StatusPageSubscriber, error) {
+	return r.list(`
+		SELECT `+statusPageSubscriberColumns+`
+		FROM status_page_subscribers
+		WHERE page_id = $1
+		ORDER BY created_at DESC
+	`, pageID)
+}
+
+// ListConfirmed returns the subscribers a page's posts are sent to
+func (r *StatusPageSubscriberRepository) ListConfirmed(pageID uuid.UUID) ([]*models.StatusPageSubscriber, error) {
+	return r.list(`
+		SELECT `+statusPageSubscriberColumns+`
+		FROM status_page_subscribers
+		WHERE page_id = $1 AND confirmed
+		ORDER BY created_at
+	`, pageID)
+}
+
+// SetConfirmToken replaces a pending subscriber's confirmation token when a
+// new confirmation is sent
+func (r *StatusPageSubscriberRepository) SetConfirmToken(sub *models.StatusPageSubscriber, token string, sentAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE status_page_subscribers
+		SET confirm_token = $2, confirm_sent_at = $3
+		WHERE id = $1 AND NOT confirmed
+	`, sub.ID, token, sentAt)
+	if err != nil {
+		return err
+	}
+	sub.ConfirmToken = &token
+	sub.ConfirmSentAt = &sentAt
+	return nil
+}
+
+// Confirm confirms the page's subscriber holding token, if it was sent
+// after sentAfter. It returns sql.ErrNoRows when no subscriber matches.
+func (r *StatusPageSubscriberRepository) Confirm(pageID uuid.UUID, token string, sentAfter time.Time) (*models.StatusPageSubscriber, error) {
+	return scanStatusPageSubscriber(r.db.QueryRow(`
+		UPDATE status_page_subscribers
+		SET confirmed = TRUE, confirmed_at = $4, confirm_token = NULL
+		WHERE page_id = $1 AND confirm_token = $2 AND confirm_sent_at > $3
+		RETURNING `+statusPageSubscriberColumns,
+		pageID, token, sentAfter, time.Now().UTC()))
+}
+
+// Unsubscribe removes the page's subscriber holding an unsubscribe token,
+// reporting whether there was one
+func (r *StatusPageSubscriberRepository) Unsubscribe(pageID uuid.UUID, token string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM status_page_subscribers WHERE page_id = $1 AND unsubscribe_token = $2`, pageID, token)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *StatusPageSubscriberRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM status_page_subscribers WHERE id = $1`, id)
+	return err
+}
+
+func (r *StatusPageSubscriberRepository) list(query string, args ...interface{}) ([]*models.StatusPageSubscriber, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]*models.StatusPageSubscriber, 0)
+	for rows.Next() {
+		sub, err := scanStatusPageSubscriber(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func scanStatusPageSubscriber(row rowScanner) (*models.StatusPageSubscriber, error) {
+	sub := &models.StatusPageSubscriber{}
+	err := row.Scan(
+		&sub.ID, &sub.PageID, &sub.Channel, &sub.Destination, &sub.Confirmed, &sub.ConfirmToken, &sub.ConfirmSentAt,
+		&sub.UnsubscribeToken, &sub.ConfirmedAt, &sub.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
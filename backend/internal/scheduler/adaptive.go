@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+)
+
+// MinCheckInterval is the shortest interval, in seconds, any service is checked at
+const MinCheckInterval = 10
+
+// RelaxAfterSuccesses is how many consecutive successful checks a recovered
+// service needs before its escalated interval relaxes back to normal
+const RelaxAfterSuccesses = 3
+
+// BaseCheckInterval returns the configured interval for a service, clamped to the minimum
+func BaseCheckInterval(service *models.Service) int {
+	if service.CheckInterval < MinCheckInterval {
+		return MinCheckInterval
+	}
+	return service.CheckInterval
+}
+
+// EffectiveCheckInterval returns the interval the service should currently be checked at
+func EffectiveCheckInterval(service *models.Service, state *models.ServiceState) int {
+	if state == nil || state.EffectiveCheckInterval <= 0 {
+		return BaseCheckInterval(service)
+	}
+	return state.EffectiveCheckInterval
+}
+
+// escalatedCheckInterval returns the incident interval for a service, or 0 if
+// adaptive checking is disabled or would not tighten the interval
+func escalatedCheckInterval(service *models.Service) int {
+	if service.EscalatedCheckInterval == nil {
+		return 0
+	}
+
+	interval := *service.EscalatedCheckInterval
+	if interval < MinCheckInterval {
+		interval = MinCheckInterval
+	}
+	if interval >= BaseCheckInterval(service) {
+		return 0
+	}
+	return interval
+}
+
+// ApplyCheck advances a service's state with the result of a new check and
+// adjusts its effective interval. When the interval changes, the new interval
+// and the reason are recorded on the check so they show up in its history.
+// A nil state is treated as a service that has never been checked.
+func ApplyCheck(state *models.ServiceState, service *models.Service, check *models.HealthCheck) *models.ServiceState {
+	if state == nil {
+		state = &models.ServiceState{
+			ServiceID:              service.ID,
+			EffectiveCheckInterval: BaseCheckInterval(service),
+		}
+	}
+
+	if check.Status == "down" {
+		state.ConsecutiveFailures++
+		state.ConsecutiveSuccesses = 0
+	} else {
+		state.ConsecutiveSuccesses++
+		state.ConsecutiveFailures = 0
+	}
+	state.LastStatus = check.Status
+	checkedAt := check.CheckedAt
+	if checkedAt.IsZero() {
+		checkedAt = time.Now().UTC()
+	}
+	state.LastCheckedAt = &checkedAt
+
+	current := EffectiveCheckInterval(service, state)
+	next, reason := nextCheckInterval(service, state, current)
+	if next != current {
+		state.EffectiveCheckInterval = next
+		state.IntervalReason = &reason
+		check.IntervalReason = &reason
+	} else {
+		state.EffectiveCheckInterval = current
+	}
+
+	interval := state.EffectiveCheckInterval
+	check.CheckInterval = &interval
+
+	return state
+}
+
+func nextCheckInterval(service *models.Service, state *models.ServiceState, current int) (int, string) {
+	base := BaseCheckInterval(service)
+	escalated := escalatedCheckInterval(service)
+
+	if escalated == 0 {
+		if current == base {
+			return current, ""
+		}
+		return base, fmt.Sprintf("Adaptive checking disabled: interval reset to %ds", base)
+	}
+
+	if state.LastStatus == "down" {
+		return escalated, fmt.Sprintf("Service down: interval tightened from %ds to %ds", current, escalated)
+	}
+
+	if current == escalated {
+		if state.ConsecutiveSuccesses < RelaxAfterSuccesses {
+			return current, ""
+		}
+		return base, fmt.Sprintf("Service recovered: interval relaxed to %ds after %d successful checks", base, state.ConsecutiveSuccesses)
+	}
+
+	if current == base {
+		return current, ""
+	}
+	return base, fmt.Sprintf("Check interval changed: now %ds", base)
+}
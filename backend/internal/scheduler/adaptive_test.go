@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"pulsegrid/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestApplyCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		checkInterval int
+		escalated     *int
+		statuses      []string
+		// effective interval after each check, and whether that check
+		// recorded an interval change
+		intervals []int
+		changed   []bool
+	}{
+		{
+			name:          "down check tightens the interval",
+			checkInterval: 60, escalated: intPtr(15),
+			statuses:  []string{"down"},
+			intervals: []int{15},
+			changed:   []bool{true},
+		},
+		{
+			name:          "stays tight while down",
+			checkInterval: 60, escalated: intPtr(15),
+			statuses:  []string{"down", "down", "down"},
+			intervals: []int{15, 15, 15},
+			changed:   []bool{true, false, false},
+		},
+		{
+			name:          "escalated interval is floored at the minimum",
+			checkInterval: 60, escalated: intPtr(3),
+			statuses:  []string{"down"},
+			intervals: []int{MinCheckInterval},
+			changed:   []bool{true},
+		},
+		{
+			name:          "check interval is floored at the minimum",
+			checkInterval: 5,
+			statuses:      []string{"up", "down"},
+			intervals:     []int{MinCheckInterval, MinCheckInterval},
+			changed:       []bool{false, false},
+		},
+		{
+			name:          "relaxes only after enough successes in a row",
+			checkInterval: 60, escalated: intPtr(15),
+			statuses:  []string{"down", "up", "up", "up", "up"},
+			intervals: []int{15, 15, 15, 60, 60},
+			changed:   []bool{true, false, false, true, false},
+		},
+		{
+			name:          "a failure during recovery starts the count again",
+			checkInterval: 60, escalated: intPtr(15),
+			statuses:  []string{"down", "up", "up", "down", "up", "up", "up"},
+			intervals: []int{15, 15, 15, 15, 15, 15, 60},
+			changed:   []bool{true, false, false, false, false, false, true},
+		},
+		{
+			name:          "no shorter interval keeps check_interval",
+			checkInterval: 60,
+			statuses:      []string{"down", "down", "up"},
+			intervals:     []int{60, 60, 60},
+			changed:       []bool{false, false, false},
+		},
+		{
+			name:          "escalated interval no shorter than check_interval is ignored",
+			checkInterval: 60, escalated: intPtr(120),
+			statuses:  []string{"down"},
+			intervals: []int{60},
+			changed:   []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Len(t, tt.intervals, len(tt.statuses))
+			require.Len(t, tt.changed, len(tt.statuses))
+
+			service := &models.Service{ID: uuid.New(), CheckInterval: tt.checkInterval, EscalatedCheckInterval: tt.escalated}
+			var state *models.ServiceState
+			for i, status := range tt.statuses {
+				check := &models.HealthCheck{Status: status}
+				state = ApplyCheck(state, service, check)
+
+				assert.Equal(t, tt.intervals[i], state.EffectiveCheckInterval, "check %d", i)
+				assert.Equal(t, tt.intervals[i], EffectiveCheckInterval(service, state), "check %d", i)
+				require.NotNil(t, check.CheckInterval)
+				assert.Equal(t, tt.intervals[i], *check.CheckInterval, "check %d", i)
+				assert.Equal(t, tt.changed[i], check.IntervalReason != nil, "check %d", i)
+				assert.Equal(t, status, state.LastStatus)
+			}
+		})
+	}
+}
+
+func TestApplyCheckRecordsReason(t *testing.T) {
+	service := &models.Service{ID: uuid.New(), CheckInterval: 60, EscalatedCheckInterval: intPtr(15)}
+
+	down := &models.HealthCheck{Status: "down"}
+	state := ApplyCheck(nil, service, down)
+	require.NotNil(t, down.IntervalReason)
+	assert.Equal(t, "Service down: interval tightened from 60s to 15s", *down.IntervalReason)
+	assert.Equal(t, down.IntervalReason, state.IntervalReason)
+	assert.Equal(t, 1, state.ConsecutiveFailures)
+
+	var up *models.HealthCheck
+	for i := 0; i < RelaxAfterSuccesses; i++ {
+		up = &models.HealthCheck{Status: "up"}
+		state = ApplyCheck(state, service, up)
+	}
+	require.NotNil(t, up.IntervalReason)
+	assert.Equal(t, "Service recovered: interval relaxed to 60s after 3 successful checks", *up.IntervalReason)
+	assert.Equal(t, 0, state.ConsecutiveFailures)
+	assert.Equal(t, RelaxAfterSuccesses, state.ConsecutiveSuccesses)
+
+	// Turning adaptive checking off while tightened resets the interval
+	state = ApplyCheck(nil, service, &models.HealthCheck{Status: "down"})
+	service.EscalatedCheckInterval = nil
+	check := &models.HealthCheck{Status: "down"}
+	state = ApplyCheck(state, service, check)
+	assert.Equal(t, 60, state.EffectiveCheckInterval)
+	require.NotNil(t, check.IntervalReason)
+	assert.Equal(t, "Adaptive checking disabled: interval reset to 60s", *check.IntervalReason)
+}
+
+func TestApplyCheckUsesCheckTime(t *testing.T) {
+	service := &models.Service{ID: uuid.New(), CheckInterval: 60}
+	checkedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	state := ApplyCheck(nil, service, &models.HealthCheck{Status: "up", CheckedAt: checkedAt})
+	require.NotNil(t, state.LastCheckedAt)
+	assert.Equal(t, checkedAt, *state.LastCheckedAt)
+	assert.Equal(t, service.ID, state.ServiceID)
+}
+
+func TestEffectiveCheckInterval(t *testing.T) {
+	service := &models.Service{CheckInterval: 60}
+	assert.Equal(t, 60, EffectiveCheckInterval(service, nil))
+	assert.Equal(t, 60, EffectiveCheckInterval(service, &models.ServiceState{}))
+	assert.Equal(t, 15, EffectiveCheckInterval(service, &models.ServiceState{EffectiveCheckInterval: 15}))
+	assert.Equal(t, MinCheckInterval, EffectiveCheckInterval(&models.Service{CheckInterval: 1}, nil))
+}
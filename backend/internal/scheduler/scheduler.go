@@ -98,12 +98,14 @@ func (s *Scheduler) UnscheduleService(serviceID string) error {
 	return nil
 }
 
-// SyncAllServices schedules all active services
+// SyncAllServices schedules all active services at their effective interval,
+// so services whose checks were tightened during an incident are picked up
 func (s *Scheduler) SyncAllServices() error {
 	query := `
-		SELECT id, check_interval
-		FROM services
-		WHERE is_active = TRUE
+		SELECT s.id, COALESCE(ss.effective_check_interval, s.check_interval)
+		FROM services s
+		LEFT JOIN service_states ss ON ss.service_id = s.id
+		WHERE s.is_active = TRUE
 	`
 
 	rows, err := s.db.Query(query)
@@ -120,8 +122,8 @@ func (s *Scheduler) SyncAllServices() error {
 		}
 
 		// Ensure minimum interval of 10 seconds
-		if interval < 10 {
-			interval = 10
+		if interval < MinCheckInterval {
+			interval = MinCheckInterval
 		}
 
 		if err := s.ScheduleService(serviceID, interval); err != nil {
@@ -0,0 +1,259 @@
+// Package alerting decides which alerts to open, resolve or escalate after a
+// health check. It is pure; monitor.AlertProcessor applies its actions for
+// every check, wherever it ran.
+package alerting
+
+import (
+	"fmt"
+	"time"
+)
+
+// Alert types
+const (
+	TypeDowntime = "downtime"
+	TypeLatency  = "latency"
+)
+
+// Alert severities
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// ResolvedByAuto marks alerts resolved because the service recovered,
+// as opposed to by a user
+const ResolvedByAuto = "auto"
+
+// EscalateAfterFailures is the number of consecutive failed checks after
+// which an open downtime alert is escalated to critical
+const EscalateAfterFailures = 5
+
+// ActionKind is what the caller should do with an alert
+type ActionKind string
+
+const (
+	ActionOpen     ActionKind = "open"
+	ActionResolve  ActionKind = "resolve"
+	ActionEscalate ActionKind = "escalate"
+)
+
+// Service is the part of a monitored service the engine needs
+type Service struct {
+	Name               string
+	LatencyThresholdMs *int
+	// AutoResolve resolves open alerts when their condition clears; when
+	// false alerts stay open until someone resolves them
+	AutoResolve bool
+	Flap        FlapSettings
+}
+
+// Check is the outcome of a single health check
+type Check struct {
+	Status         string
+	ResponseTimeMs *int
+	StatusCode     *int
+	ErrorMessage   *string
+	InMaintenance  bool
+	CheckedAt      time.Time
+}
+
+// OpenAlert is an unresolved alert for the service
+type OpenAlert struct {
+	ID       string
+	Type     string
+	Severity string
+	// RuleID is set for alerts raised by a user-defined rule
+	RuleID string
+}
+
+// State is what is known about the service before the current check
+type State struct {
+	// PreviousCheck is the check before the current one, nil if there is none
+	PreviousCheck *Check
+	// ConsecutiveFailures counts down checks in a row, including the current one
+	ConsecutiveFailures int
+	OpenAlerts          []OpenAlert
+	// RecentChecks are the service's latest checks, newest first, including
+	// the current one; flap detection looks at up to Flap.WindowChecks of them
+	RecentChecks []Check
+}
+
+// Action is a single change the caller should apply. AlertID is set for
+// resolve and escalate; Message is set for open and escalate; RuleID is set
+// when opening an alert for a user-defined rule.
+type Action struct {
+	Kind      ActionKind
+	AlertID   string
+	AlertType string
+	Severity  string
+	Message   string
+	RuleID    string
+}
+
+// Evaluate returns the alert actions implied by the current check. Checks
+// taken during maintenance produce no actions, and a previous check taken
+// during maintenance is ignored, so an outage that outlasts its window alerts
+// as soon as the window ends. While the service is flapping its downtime and
+// latency alerts are held back in favour of a single flapping alert; once it
+// settles, the current check is judged afresh.
+func Evaluate(service Service, current Check, state State) []Action {
+	if current.InMaintenance {
+		return nil
+	}
+
+	prev := state.PreviousCheck
+	if prev != nil && prev.InMaintenance {
+		prev = nil
+	}
+
+	actions, flapping, settled := evaluateFlapping(service, state)
+	if flapping {
+		return actions
+	}
+	if settled {
+		prev = nil
+	}
+
+	actions = append(actions, evaluateDowntime(service, current, prev, state)...)
+	actions = append(actions, evaluateLatency(service, current, prev, state)...)
+	return actions
+}
+
+func evaluateDowntime(service Service, current Check, prev *Check, state State) []Action {
+	open := openAlertsOfType(state.OpenAlerts, TypeDowntime)
+
+	if current.Status != "down" {
+		return resolveAll(service, open)
+	}
+
+	// An up→down transition is always a new outage, even if an alert from
+	// an earlier one was left open. Otherwise the outage is continuing:
+	// escalate its open alert, or stay quiet if that alert was resolved by
+	// hand while the service stayed down.
+	newOutage := (prev != nil && prev.Status != "down") || (prev == nil && len(open) == 0)
+	if !newOutage {
+		if len(open) == 0 {
+			return nil
+		}
+		latest := open[len(open)-1]
+		if latest.Severity == SeverityCritical || state.ConsecutiveFailures < EscalateAfterFailures {
+			return nil
+		}
+		return []Action{{
+			Kind:      ActionEscalate,
+			AlertID:   latest.ID,
+			AlertType: TypeDowntime,
+			Severity:  SeverityCritical,
+			Message:   fmt.Sprintf("Service still down after %d consecutive failed checks: %s", state.ConsecutiveFailures, service.Name),
+		}}
+	}
+
+	message := "Service is down: " + service.Name
+	if current.ErrorMessage != nil {
+		message += " - " + *current.ErrorMessage
+	}
+	return []Action{{
+		Kind:      ActionOpen,
+		AlertType: TypeDowntime,
+		Severity:  SeverityHigh,
+		Message:   message,
+	}}
+}
+
+func evaluateLatency(service Service, current Check, prev *Check, state State) []Action {
+	open := openAlertsOfType(state.OpenAlerts, TypeLatency)
+
+	// With no threshold there is nothing to breach
+	if service.LatencyThresholdMs == nil {
+		return resolveAll(service, open)
+	}
+	threshold := *service.LatencyThresholdMs
+
+	// No response time (e.g. a timeout) says nothing about latency
+	if current.ResponseTimeMs == nil {
+		return nil
+	}
+
+	if *current.ResponseTimeMs <= threshold {
+		return resolveAll(service, open)
+	}
+
+	// A breach following a fast check is new even if an earlier latency
+	// alert was left open; otherwise an open alert covers it
+	prevMeasured := prev != nil && prev.ResponseTimeMs != nil
+	if prevMeasured && *prev.ResponseTimeMs > threshold {
+		return nil
+	}
+	if len(open) > 0 && !prevMeasured {
+		return nil
+	}
+
+	return []Action{{
+		Kind:      ActionOpen,
+		AlertType: TypeLatency,
+		Severity:  SeverityMedium,
+		Message:   fmt.Sprintf("Service latency threshold breached: %s (Response time: %dms, Threshold: %dms)", service.Name, *current.ResponseTimeMs, threshold),
+	}}
+}
+
+func openAlertsOfType(alerts []OpenAlert, alertType string) []OpenAlert {
+	var matching []OpenAlert
+	for _, alert := range alerts {
+		if alert.Type == alertType {
+			matching = append(matching, alert)
+		}
+	}
+	return matching
+}
+
+func resolveAll(service Service, alerts []OpenAlert) []Action {
+	if !service.AutoResolve {
+		return nil
+	}
+
+	var actions []Action
+	for _, alert := range alerts {
+		actions = append(actions, Action{
+			Kind:      ActionResolve,
+			AlertID:   alert.ID,
+			AlertType: alert.Type,
+		})
+	}
+	return actions
+}
+
+// RecoveryMessage describes the end of an outage for recovery notifications
+func RecoveryMessage(serviceName, alertType string, outage time.Duration) string {
+	if alertType == TypeFlapping {
+		return fmt.Sprintf("Service stopped flapping: %s (stable again after %s)", serviceName, FormatDuration(outage))
+	}
+	if alertType == TypeLatency {
+		return fmt.Sprintf("Service latency back under threshold: %s (recovered after %s)", serviceName, FormatDuration(outage))
+	}
+	return fmt.Sprintf("Service recovered: %s (recovered after %s)", serviceName, FormatDuration(outage))
+}
+
+// FormatDuration renders an outage length the way people say it: "45s",
+// "12m", "1h 5m", "2d 3h"
+func FormatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	if d < 24*time.Hour {
+		hours := int(d.Hours())
+		if minutes := int(d.Minutes()) % 60; minutes > 0 {
+			return fmt.Sprintf("%dh %dm", hours, minutes)
+		}
+		return fmt.Sprintf("%dh", hours)
+	}
+	days := int(d.Hours()) / 24
+	if hours := int(d.Hours()) % 24; hours > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dd", days)
+}
@@ -0,0 +1,204 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func up(ms int) Check {
+	return Check{Status: "up", ResponseTimeMs: intPtr(ms)}
+}
+
+func down() Check {
+	return Check{Status: "down", ErrorMessage: strPtr("connection refused")}
+}
+
+func kinds(actions []Action) []string {
+	var out []string
+	for _, a := range actions {
+		out = append(out, string(a.Kind)+":"+a.AlertType)
+	}
+	return out
+}
+
+func TestEvaluateDowntimeTransitions(t *testing.T) {
+	service := Service{Name: "api", AutoResolve: true}
+	openDowntime := []OpenAlert{{ID: "a1", Type: TypeDowntime, Severity: SeverityHigh}}
+	openCritical := []OpenAlert{{ID: "a1", Type: TypeDowntime, Severity: SeverityCritical}}
+	prevUp := up(100)
+	prevDown := down()
+	prevMaintenance := Check{Status: "down", InMaintenance: true}
+
+	tests := []struct {
+		name    string
+		current Check
+		state   State
+		want    []string
+	}{
+		{"first check down opens", down(), State{ConsecutiveFailures: 1}, []string{"open:downtime"}},
+		{"up to down opens", down(), State{PreviousCheck: &prevUp, ConsecutiveFailures: 1}, []string{"open:downtime"}},
+		{"down to down with open alert is quiet", down(), State{PreviousCheck: &prevDown, ConsecutiveFailures: 2, OpenAlerts: openDowntime}, nil},
+		{"down to down after manual resolve is quiet", down(), State{PreviousCheck: &prevDown, ConsecutiveFailures: 2}, nil},
+		{"maintenance to down opens", down(), State{PreviousCheck: &prevMaintenance, ConsecutiveFailures: 1}, []string{"open:downtime"}},
+		{"down to up resolves", up(100), State{PreviousCheck: &prevDown, OpenAlerts: openDowntime}, []string{"resolve:downtime"}},
+		{"up to up is quiet", up(100), State{PreviousCheck: &prevUp}, nil},
+		{"escalates at threshold", down(), State{PreviousCheck: &prevDown, ConsecutiveFailures: EscalateAfterFailures, OpenAlerts: openDowntime}, []string{"escalate:downtime"}},
+		{"does not escalate below threshold", down(), State{PreviousCheck: &prevDown, ConsecutiveFailures: EscalateAfterFailures - 1, OpenAlerts: openDowntime}, nil},
+		{"does not escalate twice", down(), State{PreviousCheck: &prevDown, ConsecutiveFailures: EscalateAfterFailures + 1, OpenAlerts: openCritical}, nil},
+		{"critical alert resolves on recovery", up(100), State{PreviousCheck: &prevDown, OpenAlerts: openCritical}, []string{"resolve:downtime"}},
+		{"current check in maintenance is ignored", Check{Status: "down", InMaintenance: true}, State{PreviousCheck: &prevUp, ConsecutiveFailures: 1}, nil},
+		{"recovery during maintenance leaves alert open", Check{Status: "up", InMaintenance: true}, State{PreviousCheck: &prevDown, OpenAlerts: openDowntime}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, kinds(Evaluate(service, tt.current, tt.state)))
+		})
+	}
+}
+
+func TestEvaluateLatencyTransitions(t *testing.T) {
+	service := Service{Name: "api", LatencyThresholdMs: intPtr(500), AutoResolve: true}
+	openLatency := []OpenAlert{{ID: "l1", Type: TypeLatency, Severity: SeverityMedium}}
+	prevFast := up(100)
+	prevSlow := up(900)
+	prevSlowMaintenance := Check{Status: "up", ResponseTimeMs: intPtr(900), InMaintenance: true}
+	prevTimeout := Check{Status: "down"}
+
+	tests := []struct {
+		name    string
+		service Service
+		current Check
+		state   State
+		want    []string
+	}{
+		{"first slow check opens", service, up(900), State{}, []string{"open:latency"}},
+		{"fast to slow opens", service, up(900), State{PreviousCheck: &prevFast}, []string{"open:latency"}},
+		{"slow to slow is quiet", service, up(900), State{PreviousCheck: &prevSlow, OpenAlerts: openLatency}, nil},
+		{"slow to slow after manual resolve is quiet", service, up(900), State{PreviousCheck: &prevSlow}, nil},
+		{"slow in maintenance to slow opens", service, up(900), State{PreviousCheck: &prevSlowMaintenance}, []string{"open:latency"}},
+		{"no previous response time opens", service, up(900), State{PreviousCheck: &prevTimeout}, []string{"open:latency"}},
+		{"at threshold is not a breach", service, up(500), State{PreviousCheck: &prevFast}, nil},
+		{"slow to fast resolves", service, up(100), State{PreviousCheck: &prevSlow, OpenAlerts: openLatency}, []string{"resolve:latency"}},
+		{"timeout leaves latency alert open", service, Check{Status: "down"}, State{PreviousCheck: &prevSlow, ConsecutiveFailures: 1, OpenAlerts: openLatency}, []string{"open:downtime"}},
+		{"no threshold never opens", Service{Name: "api", AutoResolve: true}, up(5000), State{}, nil},
+		{"removed threshold resolves", Service{Name: "api", AutoResolve: true}, up(900), State{PreviousCheck: &prevSlow, OpenAlerts: openLatency}, []string{"resolve:latency"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, kinds(Evaluate(tt.service, tt.current, tt.state)))
+		})
+	}
+}
+
+func TestEvaluateWithoutAutoResolve(t *testing.T) {
+	service := Service{Name: "api", LatencyThresholdMs: intPtr(500)}
+	openDowntime := []OpenAlert{{ID: "a1", Type: TypeDowntime, Severity: SeverityHigh}}
+	openLatency := []OpenAlert{{ID: "l1", Type: TypeLatency, Severity: SeverityMedium}}
+	prevUp := up(100)
+	prevSlow := up(900)
+	prevDown := down()
+
+	tests := []struct {
+		name    string
+		current Check
+		state   State
+		want    []string
+	}{
+		{"recovery leaves downtime alert open", up(100), State{PreviousCheck: &prevDown, OpenAlerts: openDowntime}, nil},
+		{"fast check leaves latency alert open", up(100), State{PreviousCheck: &prevSlow, OpenAlerts: openLatency}, nil},
+		{"new outage opens beside unacknowledged alert", down(), State{PreviousCheck: &prevUp, ConsecutiveFailures: 1, OpenAlerts: openDowntime}, []string{"open:downtime"}},
+		{"new breach opens beside unacknowledged alert", up(900), State{PreviousCheck: &prevUp, OpenAlerts: openLatency}, []string{"open:latency"}},
+		{"continuing outage still escalates", down(), State{PreviousCheck: &prevDown, ConsecutiveFailures: EscalateAfterFailures, OpenAlerts: openDowntime}, []string{"escalate:downtime"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, kinds(Evaluate(service, tt.current, tt.state)))
+		})
+	}
+}
+
+func TestEvaluateEscalatesNewestAlertOnly(t *testing.T) {
+	service := Service{Name: "api"}
+	prevDown := down()
+	state := State{
+		PreviousCheck:       &prevDown,
+		ConsecutiveFailures: EscalateAfterFailures,
+		OpenAlerts: []OpenAlert{
+			{ID: "old", Type: TypeDowntime, Severity: SeverityHigh},
+			{ID: "new", Type: TypeDowntime, Severity: SeverityHigh},
+		},
+	}
+
+	actions := Evaluate(service, down(), state)
+
+	assert.Len(t, actions, 1)
+	assert.Equal(t, "new", actions[0].AlertID)
+}
+
+func TestEvaluateSlowDownCheckOpensBoth(t *testing.T) {
+	service := Service{Name: "api", LatencyThresholdMs: intPtr(500)}
+	current := Check{Status: "down", ResponseTimeMs: intPtr(900)}
+
+	actions := Evaluate(service, current, State{ConsecutiveFailures: 1})
+
+	assert.Equal(t, []string{"open:downtime", "open:latency"}, kinds(actions))
+}
+
+func TestEvaluateMessages(t *testing.T) {
+	service := Service{Name: "api", LatencyThresholdMs: intPtr(500)}
+
+	open := Evaluate(service, down(), State{ConsecutiveFailures: 1})
+	assert.Equal(t, "Service is down: api - connection refused", open[0].Message)
+	assert.Equal(t, SeverityHigh, open[0].Severity)
+
+	slow := Evaluate(service, up(900), State{})
+	assert.Equal(t, "Service latency threshold breached: api (Response time: 900ms, Threshold: 500ms)", slow[0].Message)
+	assert.Equal(t, SeverityMedium, slow[0].Severity)
+
+	escalate := Evaluate(service, down(), State{
+		PreviousCheck:       &Check{Status: "down"},
+		ConsecutiveFailures: 6,
+		OpenAlerts:          []OpenAlert{{ID: "a1", Type: TypeDowntime, Severity: SeverityHigh}},
+	})
+	assert.Equal(t, "a1", escalate[0].AlertID)
+	assert.Equal(t, SeverityCritical, escalate[0].Severity)
+	assert.Equal(t, "Service still down after 6 consecutive failed checks: api", escalate[0].Message)
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{time.Minute, "1m"},
+		{12*time.Minute + 30*time.Second, "12m"},
+		{time.Hour, "1h"},
+		{time.Hour + 5*time.Minute, "1h 5m"},
+		{24 * time.Hour, "1d"},
+		{51 * time.Hour, "2d 3h"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, FormatDuration(tt.in))
+	}
+}
+
+func TestRecoveryMessage(t *testing.T) {
+	assert.Equal(t, "Service recovered: api (recovered after 12m)", RecoveryMessage("api", TypeDowntime, 12*time.Minute))
+	assert.Equal(t, "Service latency back under threshold: api (recovered after 1h 5m)", RecoveryMessage("api", TypeLatency, 65*time.Minute))
+}
@@ -0,0 +1,143 @@
+package alerting
+
+import (
+	"fmt"
+)
+
+// TypeFlapping is the alert type raised while a service keeps switching
+// between up and down
+const TypeFlapping = "flapping"
+
+// Flap detection defaults, after Nagios
+const (
+	DefaultFlapWindow        = 21
+	DefaultFlapHighThreshold = 50.0
+	DefaultFlapLowThreshold  = 25.0
+
+	MinFlapWindow = 5
+	MaxFlapWindow = 100
+)
+
+// FlapSettings configure flap detection for a service. A service starts
+// flapping when the state-change percentage over its last WindowChecks
+// checks reaches HighThreshold, and stops once it falls below LowThreshold.
+// The gap between the two keeps a service hovering near one threshold from
+// flipping in and out of flapping.
+type FlapSettings struct {
+	Enabled       bool
+	WindowChecks  int
+	HighThreshold float64
+	LowThreshold  float64
+}
+
+// DefaultFlapSettings apply to services that have not configured flap
+// detection. It is off until a service opts in, so no alerts are held back
+// that nobody chose to dampen.
+func DefaultFlapSettings() FlapSettings {
+	return FlapSettings{
+		Enabled:       false,
+		WindowChecks:  DefaultFlapWindow,
+		HighThreshold: DefaultFlapHighThreshold,
+		LowThreshold:  DefaultFlapLowThreshold,
+	}
+}
+
+// ValidateFlapSettings reports the first problem with a service's flap
+// detection settings
+func ValidateFlapSettings(settings FlapSettings) error {
+	if settings.WindowChecks < MinFlapWindow || settings.WindowChecks > MaxFlapWindow {
+		return fmt.Errorf("flap_window must be between %d and %d checks", MinFlapWindow, MaxFlapWindow)
+	}
+	if settings.HighThreshold <= 0 || settings.HighThreshold > 100 {
+		return fmt.Errorf("flap_high_threshold must be a percentage between 0 and 100")
+	}
+	if settings.LowThreshold < 0 || settings.LowThreshold >= settings.HighThreshold {
+		return fmt.Errorf("flap_low_threshold must be at least 0 and below flap_high_threshold")
+	}
+	return nil
+}
+
+// StateChangePercent measures how often the service switched between down
+// and not down over its last window checks, given newest first. As in
+// Nagios, recent changes weigh more than old ones (1.2 for the newest down
+// to 0.8 for the oldest), and a window that is not yet full counts its
+// missing checks as unchanged. Checks taken during maintenance are skipped.
+func StateChangePercent(checks []Check, window int) float64 {
+	if window < 2 {
+		return 0
+	}
+
+	var states []bool
+	for _, check := range checks {
+		if check.InMaintenance {
+			continue
+		}
+		states = append(states, check.Status == "down")
+		if len(states) == window {
+			break
+		}
+	}
+
+	transitions := window - 1
+	var weighted float64
+	for k := 0; k+1 < len(states); k++ {
+		if states[k] == states[k+1] {
+			continue
+		}
+		// Position of this change counting from the oldest in a full window
+		position := transitions - k
+		weight := 0.8
+		if transitions > 1 {
+			weight += 0.4 * float64(position-1) / float64(transitions-1)
+		}
+		weighted += weight
+	}
+
+	return weighted / float64(transitions) * 100
+}
+
+// Flapping applies the thresholds to a state-change percentage. wasFlapping
+// is whether the service was flapping before the current check.
+func Flapping(settings FlapSettings, percent float64, wasFlapping bool) bool {
+	if !settings.Enabled {
+		return false
+	}
+	if wasFlapping {
+		return percent >= settings.LowThreshold
+	}
+	return percent >= settings.HighThreshold
+}
+
+// evaluateFlapping opens a flapping alert when the service starts flapping
+// and resolves it once the service settles. Flapping alerts describe the
+// detector rather than an outage, so they resolve whether or not the service
+// auto-resolves. settled is true on the check where flapping ends.
+func evaluateFlapping(service Service, state State) (actions []Action, flapping, settled bool) {
+	open := openAlertsOfType(state.OpenAlerts, TypeFlapping)
+	wasFlapping := len(open) > 0
+
+	percent := StateChangePercent(state.RecentChecks, service.Flap.WindowChecks)
+	flapping = Flapping(service.Flap, percent, wasFlapping)
+
+	switch {
+	case flapping && !wasFlapping:
+		actions = append(actions, Action{
+			Kind:      ActionOpen,
+			AlertType: TypeFlapping,
+			Severity:  SeverityMedium,
+			Message: fmt.Sprintf("Service is flapping: %s (state changed %.0f%% over the last %d checks); individual up/down alerts are held until it stabilizes",
+				service.Name, percent, service.Flap.WindowChecks),
+		})
+	case !flapping && wasFlapping:
+		for _, alert := range open {
+			actions = append(actions, Action{
+				Kind:      ActionResolve,
+				AlertID:   alert.ID,
+				AlertType: alert.Type,
+			})
+		}
+		settled = true
+	}
+
+	return actions, flapping, settled
+}
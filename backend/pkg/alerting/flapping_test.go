@@ -0,0 +1,100 @@
+package alerting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alternating returns n checks, newest first, switching state every check
+func alternating(n int) []Check {
+	checks := make([]Check, n)
+	for i := range checks {
+		if i%2 == 0 {
+			checks[i] = down()
+		} else {
+			checks[i] = up(100)
+		}
+	}
+	return checks
+}
+
+func steady(n int) []Check {
+	checks := make([]Check, n)
+	for i := range checks {
+		checks[i] = up(100)
+	}
+	return checks
+}
+
+func TestValidateFlapSettings(t *testing.T) {
+	assert.NoError(t, ValidateFlapSettings(DefaultFlapSettings()))
+	assert.Error(t, ValidateFlapSettings(FlapSettings{WindowChecks: 3, HighThreshold: 50, LowThreshold: 25}))
+	assert.Error(t, ValidateFlapSettings(FlapSettings{WindowChecks: 21, HighThreshold: 120, LowThreshold: 25}))
+	assert.Error(t, ValidateFlapSettings(FlapSettings{WindowChecks: 21, HighThreshold: 30, LowThreshold: 30}))
+}
+
+func TestStateChangePercent(t *testing.T) {
+	assert.InDelta(t, 100, StateChangePercent(alternating(21), 21), 0.001)
+	assert.InDelta(t, 0, StateChangePercent(steady(21), 21), 0.001)
+
+	// A single change weighs more when it is recent
+	recent := append([]Check{down()}, steady(20)...)
+	old := append(steady(20), down())
+	assert.InDelta(t, 6, StateChangePercent(recent, 21), 0.001)
+	assert.InDelta(t, 4, StateChangePercent(old, 21), 0.001)
+
+	// Missing history counts as unchanged, and maintenance checks are skipped
+	assert.InDelta(t, 55.26, StateChangePercent(alternating(11), 21), 0.01)
+	withMaintenance := []Check{down(), {Status: "up", InMaintenance: true}, down(), up(100)}
+	assert.InDelta(t, StateChangePercent([]Check{down(), down(), up(100)}, 5), StateChangePercent(withMaintenance, 5), 0.001)
+}
+
+func TestFlappingHysteresis(t *testing.T) {
+	settings := DefaultFlapSettings()
+	settings.Enabled = true
+
+	assert.False(t, Flapping(settings, 40, false))
+	assert.True(t, Flapping(settings, 50, false))
+	assert.True(t, Flapping(settings, 30, true), "stays flapping until below the low threshold")
+	assert.False(t, Flapping(settings, 20, true))
+
+	settings.Enabled = false
+	assert.False(t, Flapping(settings, 100, true))
+}
+
+func TestEvaluateFlapping(t *testing.T) {
+	service := Service{Name: "api", AutoResolve: true, Flap: DefaultFlapSettings()}
+	service.Flap.Enabled = true
+	prevUp := up(100)
+
+	// A service that starts flapping gets a flapping alert instead of a
+	// downtime alert
+	actions := Evaluate(service, down(), State{PreviousCheck: &prevUp, RecentChecks: alternating(21)})
+	require.Len(t, actions, 1)
+	assert.Equal(t, []string{"open:flapping"}, kinds(actions))
+
+	// While it keeps flapping nothing else opens, resolves or escalates
+	flappingAlert := OpenAlert{ID: "f1", Type: TypeFlapping, Severity: SeverityMedium}
+	openDowntime := OpenAlert{ID: "d1", Type: TypeDowntime, Severity: SeverityHigh}
+	actions = Evaluate(service, up(100), State{
+		PreviousCheck: &prevUp,
+		RecentChecks:  alternating(21)[1:],
+		OpenAlerts:    []OpenAlert{flappingAlert, openDowntime},
+	})
+	assert.Empty(t, actions)
+
+	// Once it settles the flapping alert resolves, even without auto-resolve,
+	// and the current check is judged afresh
+	service.AutoResolve = false
+	settledDown := append([]Check{down(), down(), down(), down(), down(), down(), down(), down(), down(), down()}, steady(11)...)
+	prevDown := down()
+	actions = Evaluate(service, down(), State{
+		PreviousCheck: &prevDown,
+		RecentChecks:  settledDown,
+		OpenAlerts:    []OpenAlert{flappingAlert},
+	})
+	assert.Equal(t, []string{"resolve:flapping", "open:downtime"}, kinds(actions))
+	assert.Equal(t, "f1", actions[0].AlertID)
+}
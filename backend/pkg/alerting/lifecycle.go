@@ -0,0 +1,42 @@
+package alerting
+
+import "time"
+
+// Alert statuses. An alert is triggered when opened, acknowledged once a
+// user takes it and resolved when it ends, either way.
+const (
+	StatusTriggered    = "triggered"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
+
+// Alert timeline event kinds
+const (
+	EventTriggered    = "triggered"
+	EventAcknowledged = "acknowledged"
+	EventResolved     = "resolved"
+	EventEscalated    = "escalated"
+	EventNotified     = "notified"
+	EventSnoozed      = "snoozed"
+	EventUnsnoozed    = "unsnoozed"
+	EventAssigned     = "assigned"
+	EventUnassigned   = "unassigned"
+	EventNote         = "note"
+)
+
+// MaxSnooze bounds how far ahead an alert can be snoozed
+const MaxSnooze = 7 * 24 * time.Hour
+
+// Snoozed reports whether an alert snoozed until snoozedUntil is still
+// snoozed at now
+func Snoozed(snoozedUntil *time.Time, now time.Time) bool {
+	return snoozedUntil != nil && now.Before(*snoozedUntil)
+}
+
+// ShouldRenotify reports whether an already open alert in status, snoozed
+// until snoozedUntil, should notify again, for example after escalating.
+// Only triggered alerts that are not snoozed do; someone already owns an
+// acknowledged alert.
+func ShouldRenotify(status string, snoozedUntil *time.Time, now time.Time) bool {
+	return status == StatusTriggered && !Snoozed(snoozedUntil, now)
+}
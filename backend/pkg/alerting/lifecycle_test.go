@@ -0,0 +1,33 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldRenotify(t *testing.T) {
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		status  string
+		snoozed *time.Time
+		want    bool
+	}{
+		{"triggered", StatusTriggered, nil, true},
+		{"snooze expired", StatusTriggered, &earlier, true},
+		{"snoozed", StatusTriggered, &later, false},
+		{"acknowledged", StatusAcknowledged, nil, false},
+		{"resolved", StatusResolved, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ShouldRenotify(tt.status, tt.snoozed, now))
+		})
+	}
+
+	assert.False(t, Snoozed(&now, now), "a snooze ends at its deadline")
+}
@@ -0,0 +1,127 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteEvents are the notifications a subscription can choose to receive:
+// an alert opening, escalating in severity, and resolving
+var RouteEvents = []string{EventTriggered, EventEscalated, EventResolved}
+
+// Route describes a notification, to be matched against subscriptions'
+// filters
+type Route struct {
+	Event       string
+	Severity    string
+	AlertType   string
+	ServiceTags []string
+	// ServiceID and OwnerID identify the alert's service and the user who
+	// owns it, if anyone does, for users' notification rules
+	ServiceID string
+	OwnerID   string
+}
+
+// RouteFilter narrows the notifications a subscription receives. Each field
+// left empty matches everything; a notification has to match every field
+// that is set.
+type RouteFilter struct {
+	// MinSeverity lets through alerts of this severity and above
+	MinSeverity string
+	AlertTypes  []string
+	// ServiceTags lets through alerts on services with any of the tags
+	ServiceTags []string
+	Events      []string
+}
+
+// RuleFilter narrows a user's notification rule. Beyond the RouteFilter it
+// can be limited to one service, or to the services the user owns.
+type RuleFilter struct {
+	RouteFilter
+	UserID    string
+	ServiceID string
+	OwnedOnly bool
+}
+
+// Matches reports whether the rule covers a notification
+func (f RuleFilter) Matches(r Route) bool {
+	if f.ServiceID != "" && f.ServiceID != r.ServiceID {
+		return false
+	}
+	if f.OwnedOnly && (r.OwnerID == "" || r.OwnerID != f.UserID) {
+		return false
+	}
+	return f.RouteFilter.Matches(r)
+}
+
+// SeverityRank orders severities from low (1) to critical (4), with 0 for
+// anything else
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// Matches reports whether the filter lets a notification through
+func (f RouteFilter) Matches(r Route) bool {
+	if f.MinSeverity != "" && SeverityRank(r.Severity) < SeverityRank(f.MinSeverity) {
+		return false
+	}
+	if len(f.AlertTypes) > 0 && !contains(f.AlertTypes, r.AlertType) {
+		return false
+	}
+	if len(f.Events) > 0 && !contains(f.Events, r.Event) {
+		return false
+	}
+	if len(f.ServiceTags) > 0 {
+		for _, tag := range r.ServiceTags {
+			if contains(f.ServiceTags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Validate checks a filter only names known severities, alert types and
+// events
+func (f RouteFilter) Validate() error {
+	if f.MinSeverity != "" && SeverityRank(f.MinSeverity) == 0 {
+		return fmt.Errorf("min_severity must be one of low, medium, high, critical")
+	}
+	types := []string{TypeDowntime, TypeLatency, TypeThreshold, TypeFlapping}
+	for _, t := range f.AlertTypes {
+		if !contains(types, t) {
+			return fmt.Errorf("alert_types must be from %s", strings.Join(types, ", "))
+		}
+	}
+	for _, event := range f.Events {
+		if !contains(RouteEvents, event) {
+			return fmt.Errorf("events must be from %s", strings.Join(RouteEvents, ", "))
+		}
+	}
+	for _, tag := range f.ServiceTags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("service_tags cannot contain an empty tag")
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package alerting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteFilterMatches(t *testing.T) {
+	route := Route{Event: EventTriggered, Severity: SeverityHigh, AlertType: TypeDowntime, ServiceTags: []string{"payments", "prod"}}
+
+	assert.True(t, RouteFilter{}.Matches(route))
+	assert.True(t, RouteFilter{MinSeverity: SeverityMedium, AlertTypes: []string{TypeDowntime}, ServiceTags: []string{"prod"}}.Matches(route))
+	assert.False(t, RouteFilter{MinSeverity: SeverityCritical}.Matches(route))
+	assert.False(t, RouteFilter{AlertTypes: []string{TypeLatency}}.Matches(route))
+	assert.False(t, RouteFilter{ServiceTags: []string{"staging"}}.Matches(route))
+	assert.False(t, RouteFilter{Events: []string{EventResolved}}.Matches(route))
+}
+
+func TestRuleFilterMatches(t *testing.T) {
+	owned := Route{Event: EventTriggered, Severity: SeverityCritical, ServiceID: "svc-1", OwnerID: "alice"}
+	unowned := Route{Event: EventTriggered, Severity: SeverityCritical, ServiceID: "svc-2"}
+
+	mine := RuleFilter{UserID: "alice", OwnedOnly: true}
+	assert.True(t, mine.Matches(owned))
+	assert.False(t, mine.Matches(unowned), "services nobody owns are not the user's")
+	assert.False(t, RuleFilter{UserID: "bob", OwnedOnly: true}.Matches(owned))
+
+	assert.True(t, RuleFilter{UserID: "bob", ServiceID: "svc-2"}.Matches(unowned))
+	assert.False(t, RuleFilter{UserID: "bob", ServiceID: "svc-2"}.Matches(owned))
+
+	criticalOnly := RuleFilter{UserID: "alice", OwnedOnly: true, RouteFilter: RouteFilter{MinSeverity: SeverityCritical}}
+	assert.True(t, criticalOnly.Matches(owned))
+	owned.Severity = SeverityHigh
+	assert.False(t, criticalOnly.Matches(owned))
+}
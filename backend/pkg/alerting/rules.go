@@ -0,0 +1,266 @@
+package alerting
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypeThreshold is the alert type raised by user-defined rules
+const TypeThreshold = "threshold"
+
+// Rule kinds
+const (
+	// RuleUptimeBelow breaches when uptime over the window drops below
+	// Threshold percent
+	RuleUptimeBelow = "uptime_below"
+	// RuleLatencyP95Above breaches when the 95th percentile response time
+	// over the window exceeds Threshold milliseconds
+	RuleLatencyP95Above = "latency_p95_above"
+	// RuleConsecutiveFailures breaches after Threshold failed checks in a row
+	RuleConsecutiveFailures = "consecutive_failures"
+	// RuleStatusCodeCount breaches when more than Threshold checks in the
+	// window returned a status code matching StatusCodes
+	RuleStatusCodeCount = "status_code_count"
+)
+
+// Rule is a user-defined alert condition
+type Rule struct {
+	ID        string
+	Name      string
+	Kind      string
+	Threshold float64
+	// StatusCodes is an exact code ("503") or a class ("5xx"), used by
+	// RuleStatusCodeCount
+	StatusCodes     string
+	WindowSeconds   int
+	CooldownSeconds int
+	Severity        string
+	LastTriggeredAt *time.Time
+}
+
+// RuleResult is the outcome of evaluating a rule. Known is false when the
+// window holds no usable checks, in which case the rule neither opens nor
+// resolves anything.
+type RuleResult struct {
+	Known    bool
+	Breached bool
+	Message  string
+}
+
+// ValidateRule reports the first problem with a rule's configuration
+func ValidateRule(rule Rule) error {
+	switch rule.Kind {
+	case RuleUptimeBelow:
+		if rule.Threshold <= 0 || rule.Threshold > 100 {
+			return fmt.Errorf("threshold must be a percentage between 0 and 100")
+		}
+	case RuleLatencyP95Above:
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("threshold must be a positive number of milliseconds")
+		}
+	case RuleConsecutiveFailures:
+		if rule.Threshold < 1 || rule.Threshold != math.Trunc(rule.Threshold) {
+			return fmt.Errorf("threshold must be a whole number of checks, at least 1")
+		}
+	case RuleStatusCodeCount:
+		if rule.Threshold < 0 || rule.Threshold != math.Trunc(rule.Threshold) {
+			return fmt.Errorf("threshold must be a whole number of checks")
+		}
+		if !validStatusCodes(rule.StatusCodes) {
+			return fmt.Errorf("status_codes must be a status code such as 503 or a class such as 5xx")
+		}
+	default:
+		return fmt.Errorf("kind must be one of %s, %s, %s, %s",
+			RuleUptimeBelow, RuleLatencyP95Above, RuleConsecutiveFailures, RuleStatusCodeCount)
+	}
+
+	if rule.Kind != RuleConsecutiveFailures && rule.WindowSeconds <= 0 {
+		return fmt.Errorf("window_seconds must be positive")
+	}
+	if rule.CooldownSeconds < 0 {
+		return fmt.Errorf("cooldown_seconds cannot be negative")
+	}
+	switch rule.Severity {
+	case "low", SeverityMedium, SeverityHigh, SeverityCritical:
+	default:
+		return fmt.Errorf("severity must be one of low, medium, high, critical")
+	}
+
+	return nil
+}
+
+// EvaluateRule checks a rule against the checks in its window. checks may
+// cover more than the rule's window; only those taken within WindowSeconds
+// of now count. consecutiveFailures includes the current check.
+func EvaluateRule(serviceName string, rule Rule, checks []Check, consecutiveFailures int, now time.Time) RuleResult {
+	if rule.Kind == RuleConsecutiveFailures {
+		limit := int(rule.Threshold)
+		return RuleResult{
+			Known:    true,
+			Breached: consecutiveFailures >= limit,
+			Message:  fmt.Sprintf("%d consecutive failed checks (limit %d): %s", consecutiveFailures, limit, serviceName),
+		}
+	}
+
+	window := time.Duration(rule.WindowSeconds) * time.Second
+	since := now.Add(-window)
+	var inWindow []Check
+	for _, check := range checks {
+		if check.InMaintenance || check.CheckedAt.Before(since) {
+			continue
+		}
+		inWindow = append(inWindow, check)
+	}
+	over := FormatDuration(window)
+
+	switch rule.Kind {
+	case RuleUptimeBelow:
+		if len(inWindow) == 0 {
+			return RuleResult{}
+		}
+		up := 0
+		for _, check := range inWindow {
+			if check.Status == "up" {
+				up++
+			}
+		}
+		uptime := float64(up) / float64(len(inWindow)) * 100
+		return RuleResult{
+			Known:    true,
+			Breached: uptime < rule.Threshold,
+			Message:  fmt.Sprintf("Uptime %.2f%% below %s%% over %s: %s", uptime, formatThreshold(rule.Threshold), over, serviceName),
+		}
+
+	case RuleLatencyP95Above:
+		var times []int
+		for _, check := range inWindow {
+			if check.ResponseTimeMs != nil {
+				times = append(times, *check.ResponseTimeMs)
+			}
+		}
+		if len(times) == 0 {
+			return RuleResult{}
+		}
+		p95 := Percentile(times, 95)
+		return RuleResult{
+			Known:    true,
+			Breached: float64(p95) > rule.Threshold,
+			Message:  fmt.Sprintf("p95 latency %dms above %sms over %s: %s", p95, formatThreshold(rule.Threshold), over, serviceName),
+		}
+
+	case RuleStatusCodeCount:
+		count := 0
+		for _, check := range inWindow {
+			if check.StatusCode != nil && statusCodeMatches(rule.StatusCodes, *check.StatusCode) {
+				count++
+			}
+		}
+		limit := int(rule.Threshold)
+		return RuleResult{
+			Known:    true,
+			Breached: count > limit,
+			Message:  fmt.Sprintf("%d responses with status %s in %s (limit %d): %s", count, rule.StatusCodes, over, limit, serviceName),
+		}
+	}
+
+	return RuleResult{}
+}
+
+// EvaluateRules returns the actions for a service's rules. window holds the
+// recent checks, including the current one. Like Evaluate, it does nothing
+// for checks taken during maintenance. A breached rule opens an alert unless
+// one for the rule is already open or the rule is cooling down; a rule that
+// clears resolves its alert when the service auto-resolves.
+func EvaluateRules(service Service, rules []Rule, current Check, window []Check, state State, now time.Time) []Action {
+	if current.InMaintenance {
+		return nil
+	}
+
+	var actions []Action
+	for _, rule := range rules {
+		result := EvaluateRule(service.Name, rule, window, state.ConsecutiveFailures, now)
+		if !result.Known {
+			continue
+		}
+
+		var open []OpenAlert
+		for _, alert := range state.OpenAlerts {
+			if alert.RuleID != "" && alert.RuleID == rule.ID {
+				open = append(open, alert)
+			}
+		}
+
+		if !result.Breached {
+			actions = append(actions, resolveAll(service, open)...)
+			continue
+		}
+		if len(open) > 0 {
+			continue
+		}
+		cooldown := time.Duration(rule.CooldownSeconds) * time.Second
+		if rule.LastTriggeredAt != nil && now.Sub(*rule.LastTriggeredAt) < cooldown {
+			continue
+		}
+
+		message := result.Message
+		if rule.Name != "" {
+			message = rule.Name + ": " + message
+		}
+		actions = append(actions, Action{
+			Kind:      ActionOpen,
+			AlertType: TypeThreshold,
+			Severity:  rule.Severity,
+			Message:   message,
+			RuleID:    rule.ID,
+		})
+	}
+
+	return actions
+}
+
+// Percentile returns the p-th percentile of values by the nearest-rank method
+func Percentile(values []int, p float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func validStatusCodes(pattern string) bool {
+	if len(pattern) != 3 {
+		return false
+	}
+	if pattern[0] < '1' || pattern[0] > '5' {
+		return false
+	}
+	if strings.EqualFold(pattern[1:], "xx") {
+		return true
+	}
+	_, err := strconv.Atoi(pattern)
+	return err == nil
+}
+
+func statusCodeMatches(pattern string, code int) bool {
+	if !validStatusCodes(pattern) {
+		return false
+	}
+	if strings.EqualFold(pattern[1:], "xx") {
+		return code/100 == int(pattern[0]-'0')
+	}
+	return strconv.Itoa(code) == pattern
+}
+
+func formatThreshold(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,176 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func checkAt(ago time.Duration, status string, ms int, code int) Check {
+	return Check{
+		Status:         status,
+		ResponseTimeMs: intPtr(ms),
+		StatusCode:     intPtr(code),
+		CheckedAt:      now.Add(-ago),
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	valid := Rule{Kind: RuleUptimeBelow, Threshold: 99, WindowSeconds: 3600, Severity: "high"}
+	assert.NoError(t, ValidateRule(valid))
+
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"unknown kind", Rule{Kind: "bogus", Threshold: 1, WindowSeconds: 60, Severity: "high"}},
+		{"uptime over 100", Rule{Kind: RuleUptimeBelow, Threshold: 101, WindowSeconds: 60, Severity: "high"}},
+		{"zero latency", Rule{Kind: RuleLatencyP95Above, Threshold: 0, WindowSeconds: 60, Severity: "high"}},
+		{"fractional failures", Rule{Kind: RuleConsecutiveFailures, Threshold: 2.5, Severity: "high"}},
+		{"bad status pattern", Rule{Kind: RuleStatusCodeCount, Threshold: 5, StatusCodes: "9xx", WindowSeconds: 60, Severity: "high"}},
+		{"missing window", Rule{Kind: RuleUptimeBelow, Threshold: 99, Severity: "high"}},
+		{"negative cooldown", Rule{Kind: RuleUptimeBelow, Threshold: 99, WindowSeconds: 60, CooldownSeconds: -1, Severity: "high"}},
+		{"bad severity", Rule{Kind: RuleUptimeBelow, Threshold: 99, WindowSeconds: 60, Severity: "urgent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, ValidateRule(tt.rule))
+		})
+	}
+
+	// Consecutive failures ignore the window
+	assert.NoError(t, ValidateRule(Rule{Kind: RuleConsecutiveFailures, Threshold: 3, Severity: "critical"}))
+}
+
+func TestEvaluateRule(t *testing.T) {
+	checks := []Check{
+		checkAt(1*time.Minute, "down", 900, 503),
+		checkAt(5*time.Minute, "up", 850, 200),
+		checkAt(10*time.Minute, "up", 100, 200),
+		checkAt(20*time.Minute, "down", 1200, 500),
+		{Status: "down", StatusCode: intPtr(503), InMaintenance: true, CheckedAt: now.Add(-2 * time.Minute)},
+		checkAt(2*time.Hour, "down", 5000, 500),
+	}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		failures int
+		want     RuleResult
+	}{
+		{
+			"uptime below over window",
+			Rule{Kind: RuleUptimeBelow, Threshold: 99, WindowSeconds: 3600},
+			0,
+			RuleResult{Known: true, Breached: true, Message: "Uptime 50.00% below 99% over 1h: api"},
+		},
+		{
+			"uptime above threshold",
+			Rule{Kind: RuleUptimeBelow, Threshold: 60, WindowSeconds: 900},
+			0,
+			RuleResult{Known: true, Breached: false, Message: "Uptime 66.67% below 60% over 15m: api"},
+		},
+		{
+			"uptime with empty window is unknown",
+			Rule{Kind: RuleUptimeBelow, Threshold: 99, WindowSeconds: 30},
+			0,
+			RuleResult{},
+		},
+		{
+			"p95 latency above",
+			Rule{Kind: RuleLatencyP95Above, Threshold: 800, WindowSeconds: 600},
+			0,
+			RuleResult{Known: true, Breached: true, Message: "p95 latency 900ms above 800ms over 10m: api"},
+		},
+		{
+			"p95 latency under",
+			Rule{Kind: RuleLatencyP95Above, Threshold: 1000, WindowSeconds: 600},
+			0,
+			RuleResult{Known: true, Breached: false, Message: "p95 latency 900ms above 1000ms over 10m: api"},
+		},
+		{
+			"consecutive failures reached",
+			Rule{Kind: RuleConsecutiveFailures, Threshold: 3},
+			3,
+			RuleResult{Known: true, Breached: true, Message: "3 consecutive failed checks (limit 3): api"},
+		},
+		{
+			"consecutive failures below",
+			Rule{Kind: RuleConsecutiveFailures, Threshold: 3},
+			2,
+			RuleResult{Known: true, Breached: false, Message: "2 consecutive failed checks (limit 3): api"},
+		},
+		{
+			"5xx count above limit excludes maintenance",
+			Rule{Kind: RuleStatusCodeCount, Threshold: 1, StatusCodes: "5xx", WindowSeconds: 1800},
+			0,
+			RuleResult{Known: true, Breached: true, Message: "2 responses with status 5xx in 30m (limit 1): api"},
+		},
+		{
+			"exact code at limit",
+			Rule{Kind: RuleStatusCodeCount, Threshold: 1, StatusCodes: "503", WindowSeconds: 1800},
+			0,
+			RuleResult{Known: true, Breached: false, Message: "1 responses with status 503 in 30m (limit 1): api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EvaluateRule("api", tt.rule, checks, tt.failures, now))
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	service := Service{Name: "api", AutoResolve: true}
+	rule := Rule{ID: "r1", Name: "Too many failures", Kind: RuleConsecutiveFailures, Threshold: 3, CooldownSeconds: 600, Severity: SeverityCritical}
+	openRuleAlert := []OpenAlert{{ID: "a1", Type: TypeThreshold, Severity: SeverityCritical, RuleID: "r1"}}
+	recently := now.Add(-5 * time.Minute)
+	longAgo := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		service Service
+		current Check
+		state   State
+		want    []string
+	}{
+		{"breach opens", rule, service, down(), State{ConsecutiveFailures: 3}, []string{"open:threshold"}},
+		{"breach with open alert is quiet", rule, service, down(), State{ConsecutiveFailures: 4, OpenAlerts: openRuleAlert}, nil},
+		{"breach during cooldown is quiet", Rule{ID: "r1", Kind: RuleConsecutiveFailures, Threshold: 3, CooldownSeconds: 600, Severity: SeverityCritical, LastTriggeredAt: &recently}, service, down(), State{ConsecutiveFailures: 3}, nil},
+		{"breach after cooldown opens", Rule{ID: "r1", Kind: RuleConsecutiveFailures, Threshold: 3, CooldownSeconds: 600, Severity: SeverityCritical, LastTriggeredAt: &longAgo}, service, down(), State{ConsecutiveFailures: 3}, []string{"open:threshold"}},
+		{"clearing resolves", rule, service, up(100), State{OpenAlerts: openRuleAlert}, []string{"resolve:threshold"}},
+		{"clearing without auto resolve is quiet", rule, Service{Name: "api"}, up(100), State{OpenAlerts: openRuleAlert}, nil},
+		{"other rule's alert is untouched", Rule{ID: "r2", Kind: RuleConsecutiveFailures, Threshold: 3, Severity: SeverityHigh}, service, up(100), State{OpenAlerts: openRuleAlert}, nil},
+		{"maintenance is ignored", rule, service, Check{Status: "down", InMaintenance: true}, State{ConsecutiveFailures: 3}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions := EvaluateRules(tt.service, []Rule{tt.rule}, tt.current, nil, tt.state, now)
+			assert.Equal(t, tt.want, kinds(actions))
+		})
+	}
+
+	actions := EvaluateRules(service, []Rule{rule}, down(), nil, State{ConsecutiveFailures: 3}, now)
+	assert.Equal(t, "r1", actions[0].RuleID)
+	assert.Equal(t, SeverityCritical, actions[0].Severity)
+	assert.Equal(t, "Too many failures: 3 consecutive failed checks (limit 3): api", actions[0].Message)
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, 0, Percentile(nil, 95))
+	assert.Equal(t, 7, Percentile([]int{7}, 95))
+	assert.Equal(t, 95, Percentile(func() []int {
+		var v []int
+		for i := 100; i >= 1; i-- {
+			v = append(v, i)
+		}
+		return v
+	}(), 95))
+	assert.Equal(t, 50, Percentile([]int{10, 50, 20}, 95))
+}
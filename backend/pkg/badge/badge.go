@@ -0,0 +1,189 @@
+// Package badge renders the small SVG shields embedded in READMEs and wikis
+// to show a service's status, uptime and response time.
+package badge
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Badge colors, from good to bad
+const (
+	ColorBrightGreen = "#4c1"
+	ColorGreen       = "#97ca00"
+	ColorYellowGreen = "#a4a61d"
+	ColorYellow      = "#dfb317"
+	ColorOrange      = "#fe7d37"
+	ColorRed         = "#e05d44"
+	ColorGrey        = "#9f9f9f"
+)
+
+// MaxLabel caps the length of a custom label
+const MaxLabel = 40
+
+// Periods uptime and response time badges can cover
+var Periods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// DefaultPeriod is used when a badge asks for none
+const DefaultPeriod = "24h"
+
+// ParsePeriod returns the length of a badge period, one of 24h, 7d and 30d.
+// Empty means DefaultPeriod.
+func ParsePeriod(period string) (string, time.Duration, error) {
+	if period == "" {
+		period = DefaultPeriod
+	}
+	d, ok := Periods[period]
+	if !ok {
+		return "", 0, fmt.Errorf("period must be 24h, 7d or 30d")
+	}
+	return period, d, nil
+}
+
+// Status is the message and color for a service's last check result (up,
+// degraded or down). Paused services and those never checked show as such.
+func Status(lastStatus string, active bool) (message, color string) {
+	switch {
+	case !active:
+		return "paused", ColorGrey
+	case lastStatus == "up":
+		return "up", ColorBrightGreen
+	case lastStatus == "degraded":
+		return "degraded", ColorYellow
+	case lastStatus == "down":
+		return "down", ColorRed
+	default:
+		return "unknown", ColorGrey
+	}
+}
+
+// Uptime is the message and color for an uptime percentage, or "no data"
+// when there were no checks in the period
+func Uptime(percent float64, checks int) (message, color string) {
+	if checks == 0 {
+		return "no data", ColorGrey
+	}
+
+	message = strconv.FormatFloat(percent, 'f', 2, 64)
+	message = strings.TrimSuffix(strings.TrimRight(message, "0"), ".") + "%"
+	switch {
+	case percent >= 99.9:
+		color = ColorBrightGreen
+	case percent >= 99:
+		color = ColorGreen
+	case percent >= 97:
+		color = ColorYellowGreen
+	case percent >= 95:
+		color = ColorYellow
+	case percent >= 90:
+		color = ColorOrange
+	default:
+		color = ColorRed
+	}
+	return message, color
+}
+
+// ResponseTime is the message and color for an average response time in
+// milliseconds, or "no data" when there were no checks in the period
+func ResponseTime(ms float64, checks int) (message, color string) {
+	if checks == 0 {
+		return "no data", ColorGrey
+	}
+
+	message = fmt.Sprintf("%d ms", int(ms+0.5))
+	switch {
+	case ms < 200:
+		color = ColorBrightGreen
+	case ms < 500:
+		color = ColorGreen
+	case ms < 1000:
+		color = ColorYellow
+	case ms < 2000:
+		color = ColorOrange
+	default:
+		color = ColorRed
+	}
+	return message, color
+}
+
+// Label trims a custom label to MaxLabel characters, falling back to def
+// when it is empty
+func Label(custom, def string) string {
+	custom = strings.TrimSpace(custom)
+	if custom == "" {
+		return def
+	}
+	if utf8.RuneCountInString(custom) > MaxLabel {
+		custom = string([]rune(custom)[:MaxLabel])
+	}
+	return custom
+}
+
+// Render draws a flat two-part badge: label on grey, message on color
+func Render(label, message, color string) string {
+	labelWidth := textWidth(label) + 10
+	messageWidth := textWidth(message) + 10
+	width := labelWidth + messageWidth
+	title := html.EscapeString(label + ": " + message)
+	label, message = html.EscapeString(label), html.EscapeString(message)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">`, width, title)
+	fmt.Fprintf(&b, `<title>%s</title>`, title)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&b, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, messageWidth, html.EscapeString(color), width)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, part := range []struct {
+		x    float64
+		text string
+	}{
+		{float64(labelWidth) / 2, label},
+		{float64(labelWidth) + float64(messageWidth)/2, message},
+	} {
+		fmt.Fprintf(&b, `<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`, part.x, part.text, part.x, part.text)
+	}
+	b.WriteString(`</g></svg>`)
+	return b.String()
+}
+
+// textWidth estimates the width in pixels of s in 11px Verdana, close
+// enough to size the badge around it
+func textWidth(s string) int {
+	var width float64
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("il.,:;|!'", r):
+			width += 3.5
+		case strings.ContainsRune("fjrt() ", r):
+			width += 4.5
+		case strings.ContainsRune("mwMW%", r):
+			width += 10
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 7
+		}
+	}
+	return int(width + 0.5)
+}
+
+// NewToken returns a random token for a badge's address
+func NewToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
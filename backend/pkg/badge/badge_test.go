@@ -0,0 +1,87 @@
+package badge
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	period, d, err := ParsePeriod("")
+	require.NoError(t, err)
+	assert.Equal(t, "24h", period)
+	assert.Equal(t, 24*time.Hour, d)
+
+	_, d, err = ParsePeriod("30d")
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, d)
+
+	_, _, err = ParsePeriod("1y")
+	assert.Error(t, err)
+}
+
+func TestMessages(t *testing.T) {
+	message, color := Status("up", true)
+	assert.Equal(t, "up", message)
+	assert.Equal(t, ColorBrightGreen, color)
+	message, _ = Status("down", false)
+	assert.Equal(t, "paused", message)
+	message, _ = Status("", true)
+	assert.Equal(t, "unknown", message)
+
+	message, color = Uptime(99.95, 100)
+	assert.Equal(t, "99.95%", message)
+	assert.Equal(t, ColorBrightGreen, color)
+	message, color = Uptime(100, 100)
+	assert.Equal(t, "100%", message)
+	assert.Equal(t, ColorBrightGreen, color)
+	message, color = Uptime(98.5, 100)
+	assert.Equal(t, "98.5%", message)
+	assert.Equal(t, ColorYellowGreen, color)
+	message, color = Uptime(0, 0)
+	assert.Equal(t, "no data", message)
+	assert.Equal(t, ColorGrey, color)
+
+	message, color = ResponseTime(349.6, 10)
+	assert.Equal(t, "350 ms", message)
+	assert.Equal(t, ColorGreen, color)
+	message, _ = ResponseTime(0, 0)
+	assert.Equal(t, "no data", message)
+}
+
+func TestLabel(t *testing.T) {
+	assert.Equal(t, "uptime 7d", Label("  ", "uptime 7d"))
+	assert.Equal(t, "api", Label("api", "status"))
+	assert.Len(t, []rune(Label(strings.Repeat("é", 60), "status")), MaxLabel)
+}
+
+func TestRender(t *testing.T) {
+	svg := Render(`a<b>&"c"`, "up", ColorBrightGreen)
+
+	// Well-formed, with the label escaped
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err != nil {
+			assert.Equal(t, "EOF", err.Error())
+			break
+		}
+	}
+	assert.Contains(t, svg, "a&lt;b&gt;&amp;&#34;c&#34;")
+	assert.NotContains(t, svg, "<b>")
+
+	// Longer messages make wider badges
+	assert.Greater(t, width(t, Render("uptime", "99.95%", ColorGreen)), width(t, Render("uptime", "up", ColorGreen)))
+}
+
+func width(t *testing.T, svg string) int {
+	var doc struct {
+		Width int `xml:"width,attr"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(svg), &doc))
+	return doc.Width
+}
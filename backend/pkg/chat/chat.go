@@ -0,0 +1,298 @@
+// Package chat renders alert notifications as rich messages for chat tools
+// (Microsoft Teams, Discord, Telegram and Mattermost) and posts them.
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"pulsegrid/backend/pkg/webhook"
+)
+
+// Channels
+const (
+	Teams      = "teams"
+	Discord    = "discord"
+	Telegram   = "telegram"
+	Mattermost = "mattermost"
+)
+
+// DefaultTelegramAPI is the Telegram Bot API base URL
+const DefaultTelegramAPI = "https://api.telegram.org"
+
+// IsChannel reports whether channel is one of the chat channels
+func IsChannel(channel string) bool {
+	switch channel {
+	case Teams, Discord, Telegram, Mattermost:
+		return true
+	}
+	return false
+}
+
+// Message is an alert notification. Severity is the alert's severity;
+// Resolved messages are shown in green whatever it is.
+type Message struct {
+	Title    string
+	Service  string
+	Severity string
+	Resolved bool
+	Error    string
+	Link     string
+}
+
+// ServiceLink returns the dashboard page of a service, or "" when no
+// dashboard URL is configured
+func ServiceLink(dashboardURL, serviceID string) string {
+	if dashboardURL == "" {
+		return ""
+	}
+	return strings.TrimRight(dashboardURL, "/") + "/services/" + serviceID
+}
+
+// telegramChatID is a numeric chat ID or a public @channel name
+var telegramChatID = regexp.MustCompile(`^(-?\d+|@[A-Za-z][A-Za-z0-9_]{4,})$`)
+
+// ValidateDestination checks a chat channel's destination: a Telegram chat
+// ID, or an incoming webhook URL for the others
+func ValidateDestination(channel, destination string) error {
+	switch channel {
+	case Telegram:
+		if !telegramChatID.MatchString(destination) {
+			return fmt.Errorf("destination must be a Telegram chat ID or @channel name")
+		}
+		return nil
+	case Teams, Discord, Mattermost:
+		if err := webhook.ValidateURL(destination); err != nil {
+			return fmt.Errorf("destination must be the channel's incoming webhook URL")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown chat channel: %s", channel)
+}
+
+// Render builds the request body that posts msg to destination on channel
+func Render(channel, destination string, msg Message) ([]byte, error) {
+	switch channel {
+	case Teams:
+		return json.Marshal(teamsCard(msg))
+	case Discord:
+		return json.Marshal(discordEmbed(msg))
+	case Telegram:
+		return json.Marshal(telegramMessage(destination, msg))
+	case Mattermost:
+		return json.Marshal(mattermostAttachment(msg))
+	}
+	return nil, fmt.Errorf("unknown chat channel: %s", channel)
+}
+
+// Sender posts rendered messages
+type Sender struct {
+	Client *http.Client
+	// TelegramToken is the bot token Telegram messages are sent with
+	TelegramToken string
+	// TelegramAPI overrides DefaultTelegramAPI
+	TelegramAPI string
+}
+
+// Post sends a body built by Render. It returns the response status code,
+// or 0 if no response arrived; any status outside 2xx is an error.
+func (s *Sender) Post(channel, destination string, body []byte) (int, error) {
+	target := destination
+	if channel == Telegram {
+		if s.TelegramToken == "" {
+			return 0, fmt.Errorf("TELEGRAM_BOT_TOKEN is not configured")
+		}
+		api := s.TelegramAPI
+		if api == "" {
+			api = DefaultTelegramAPI
+		}
+		target = api + "/bot" + s.TelegramToken + "/sendMessage"
+	}
+
+	resp, err := s.Client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		if channel == Telegram {
+			// The URL holds the bot token; keep it out of logs
+			return 0, fmt.Errorf("telegram request failed")
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s returned status %d", channel, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Color returns the hex color for a message's severity
+func Color(msg Message) string {
+	if msg.Resolved {
+		return "#2EB67D"
+	}
+	switch msg.Severity {
+	case "critical":
+		return "#D32F2F"
+	case "high":
+		return "#F57C00"
+	case "medium":
+		return "#FBC02D"
+	case "low":
+		return "#1976D2"
+	}
+	return "#757575"
+}
+
+func severityLabel(msg Message) string {
+	if msg.Resolved {
+		return "Resolved"
+	}
+	if msg.Severity == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(msg.Severity[:1]) + msg.Severity[1:]
+}
+
+// teamsCard is an Adaptive Card for a Teams incoming webhook. Cards only
+// take named colors, so severities map onto the nearest one.
+func teamsCard(msg Message) map[string]interface{} {
+	color := "Default"
+	switch {
+	case msg.Resolved:
+		color = "Good"
+	case msg.Severity == "critical" || msg.Severity == "high":
+		color = "Attention"
+	case msg.Severity == "medium":
+		color = "Warning"
+	case msg.Severity == "low":
+		color = "Accent"
+	}
+
+	body := []interface{}{
+		map[string]interface{}{"type": "TextBlock", "text": msg.Title, "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+		map[string]interface{}{"type": "FactSet", "facts": []interface{}{
+			map[string]string{"title": "Service", "value": msg.Service},
+			map[string]string{"title": "Severity", "value": severityLabel(msg)},
+		}},
+	}
+	if msg.Error != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": msg.Error, "wrap": true})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if msg.Link != "" {
+		card["actions"] = []interface{}{
+			map[string]string{"type": "Action.OpenUrl", "title": "View in PulseGrid", "url": msg.Link},
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
+
+func discordEmbed(msg Message) map[string]interface{} {
+	var color int
+	fmt.Sscanf(Color(msg), "#%x", &color)
+
+	embed := map[string]interface{}{
+		"title": msg.Title,
+		"color": color,
+		"fields": []interface{}{
+			map[string]interface{}{"name": "Service", "value": msg.Service, "inline": true},
+			map[string]interface{}{"name": "Severity", "value": severityLabel(msg), "inline": true},
+		},
+	}
+	if msg.Error != "" {
+		embed["description"] = msg.Error
+	}
+	if msg.Link != "" {
+		embed["url"] = msg.Link
+	}
+
+	return map[string]interface{}{
+		"username": "PulseGrid",
+		"embeds":   []interface{}{embed},
+	}
+}
+
+// telegramEscaper escapes the characters MarkdownV2 reserves
+var telegramEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// telegramMessage is a Bot API sendMessage request. Telegram has no message
+// colors, so the severity is shown as a colored circle.
+func telegramMessage(chatID string, msg Message) map[string]interface{} {
+	icon := "⚪"
+	switch {
+	case msg.Resolved:
+		icon = "✅"
+	case msg.Severity == "critical":
+		icon = "🔴"
+	case msg.Severity == "high":
+		icon = "🟠"
+	case msg.Severity == "medium":
+		icon = "🟡"
+	case msg.Severity == "low":
+		icon = "🔵"
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s *%s*\n\n", icon, telegramEscaper.Replace(msg.Title))
+	fmt.Fprintf(&text, "*Service:* %s\n", telegramEscaper.Replace(msg.Service))
+	fmt.Fprintf(&text, "*Severity:* %s\n", telegramEscaper.Replace(severityLabel(msg)))
+	if msg.Error != "" {
+		fmt.Fprintf(&text, "\n%s\n", telegramEscaper.Replace(msg.Error))
+	}
+	if msg.Link != "" {
+		// Inside a link target only ) and \ need escaping
+		link := strings.NewReplacer(`\`, `\\`, ")", `\)`).Replace(msg.Link)
+		fmt.Fprintf(&text, "\n[View in PulseGrid](%s)", link)
+	}
+
+	return map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text.String(),
+		"parse_mode":               "MarkdownV2",
+		"disable_web_page_preview": true,
+	}
+}
+
+func mattermostAttachment(msg Message) map[string]interface{} {
+	attachment := map[string]interface{}{
+		"fallback": msg.Title,
+		"color":    Color(msg),
+		"title":    msg.Title,
+		"fields": []interface{}{
+			map[string]interface{}{"short": true, "title": "Service", "value": msg.Service},
+			map[string]interface{}{"short": true, "title": "Severity", "value": severityLabel(msg)},
+		},
+	}
+	if msg.Error != "" {
+		attachment["text"] = msg.Error
+	}
+	if msg.Link != "" {
+		attachment["title_link"] = msg.Link
+	}
+
+	return map[string]interface{}{
+		"username":    "PulseGrid",
+		"attachments": []interface{}{attachment},
+	}
+}
@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var down = Message{
+	Title:    "Service Down Alert",
+	Service:  "api",
+	Severity: "critical",
+	Error:    "connection refused (port 443)",
+	Link:     "https://app.example.com/services/42",
+}
+
+func render(t *testing.T, channel, destination string, msg Message) map[string]interface{} {
+	body, err := Render(channel, destination, msg)
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &out))
+	return out
+}
+
+func TestColor(t *testing.T) {
+	assert.Equal(t, "#D32F2F", Color(down))
+	assert.Equal(t, "#1976D2", Color(Message{Severity: "low"}))
+	assert.Equal(t, "#2EB67D", Color(Message{Severity: "critical", Resolved: true}))
+	assert.Equal(t, "#757575", Color(Message{}))
+}
+
+func TestRender(t *testing.T) {
+	teams := render(t, Teams, "", down)
+	card := teams["attachments"].([]interface{})[0].(map[string]interface{})["content"].(map[string]interface{})
+	assert.Equal(t, "AdaptiveCard", card["type"])
+	assert.Equal(t, "Attention", card["body"].([]interface{})[0].(map[string]interface{})["color"])
+	assert.Equal(t, down.Link, card["actions"].([]interface{})[0].(map[string]interface{})["url"])
+
+	discord := render(t, Discord, "", down)
+	embed := discord["embeds"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(0xD32F2F), embed["color"])
+	assert.Equal(t, down.Error, embed["description"])
+	assert.Equal(t, down.Link, embed["url"])
+
+	mattermost := render(t, Mattermost, "", down)
+	attachment := mattermost["attachments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "#D32F2F", attachment["color"])
+	assert.Equal(t, down.Link, attachment["title_link"])
+
+	telegram := render(t, Telegram, "-1001234", down)
+	assert.Equal(t, "-1001234", telegram["chat_id"])
+	assert.Equal(t, "MarkdownV2", telegram["parse_mode"])
+	assert.Equal(t, "🔴 *Service Down Alert*\n\n*Service:* api\n*Severity:* Critical\n\n"+
+		`connection refused \(port 443\)`+"\n\n[View in PulseGrid](https://app.example.com/services/42)", telegram["text"])
+
+	_, err := Render("pager", "", down)
+	assert.Error(t, err)
+}
+
+func TestValidateDestination(t *testing.T) {
+	assert.NoError(t, ValidateDestination(Telegram, "-1001234"))
+	assert.NoError(t, ValidateDestination(Telegram, "@pulsegrid_alerts"))
+	assert.Error(t, ValidateDestination(Telegram, "https://t.me/x"))
+	assert.NoError(t, ValidateDestination(Discord, "https://discord.com/api/webhooks/1/abc"))
+	assert.Error(t, ValidateDestination(Teams, "not a url"))
+	assert.Error(t, ValidateDestination("pager", "x"))
+}
+
+func TestPost(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := &Sender{Client: server.Client(), TelegramToken: "123:abc", TelegramAPI: server.URL}
+	code, err := sender.Post(Telegram, "-1001234", []byte(`{"chat_id":"-1001234"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "/bot123:abc/sendMessage", path)
+	assert.Equal(t, `{"chat_id":"-1001234"}`, body)
+
+	_, err = sender.Post(Discord, server.URL+"/webhook", []byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "/webhook", path)
+
+	_, err = (&Sender{Client: server.Client()}).Post(Telegram, "-1", []byte(`{}`))
+	assert.Error(t, err, "no bot token")
+}
@@ -0,0 +1,151 @@
+// Package correlation decides which open incident a new alert belongs to,
+// so that one failure behind many services is handled and notified as a
+// single incident. It is pure; IncidentRepository.AttachAlert applies its
+// decision.
+package correlation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pulsegrid/backend/pkg/alerting"
+)
+
+// Incident timeline event kinds
+const (
+	EventOpened        = "opened"
+	EventAlertAdded    = "alert_added"
+	EventAlertResolved = "alert_resolved"
+	EventAcknowledged  = "acknowledged"
+	EventResolved      = "resolved"
+	EventNote          = "note"
+)
+
+// Bounds on the correlation window
+const (
+	DefaultWindow = 5 * time.Minute
+	MinWindow     = time.Minute
+	MaxWindow     = 24 * time.Hour
+)
+
+// Rules are an organization's correlation settings. An alert joins an open
+// incident whose latest alert was raised within Window of it and, when
+// MatchTags or MatchIPs is set, whose services share a tag or resolved IP
+// with the alert's service. With neither set, time proximity alone groups
+// alerts. Disabled rules give every alert an incident of its own.
+type Rules struct {
+	Enabled   bool
+	Window    time.Duration
+	MatchTags bool
+	MatchIPs  bool
+}
+
+// DefaultRules apply to organizations that have not configured correlation
+func DefaultRules() Rules {
+	return Rules{Enabled: true, Window: DefaultWindow, MatchTags: true, MatchIPs: true}
+}
+
+// ValidateRules checks the window is within bounds
+func ValidateRules(rules Rules) error {
+	if rules.Window < MinWindow || rules.Window > MaxWindow {
+		return fmt.Errorf("window must be between %s and %s", alerting.FormatDuration(MinWindow), alerting.FormatDuration(MaxWindow))
+	}
+	return nil
+}
+
+// Incident is an open incident as correlation sees it: when it last gained
+// an alert and the tags and resolved IPs of the services it affects
+type Incident struct {
+	ID          string
+	LastAlertAt time.Time
+	Tags        []string
+	IPs         []string
+}
+
+// Alert is a newly raised alert with its service's tags and last resolved IP
+type Alert struct {
+	RaisedAt time.Time
+	Tags     []string
+	IP       string
+}
+
+// Match returns the open incident alert should join and a short reason for
+// the incident timeline. When several incidents match, the one with the most
+// recent alert wins.
+func Match(rules Rules, alert Alert, incidents []Incident) (id, reason string, ok bool) {
+	if !rules.Enabled {
+		return "", "", false
+	}
+
+	var best *Incident
+	for i := range incidents {
+		incident := &incidents[i]
+		gap := alert.RaisedAt.Sub(incident.LastAlertAt)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap > rules.Window {
+			continue
+		}
+
+		why, matched := related(rules, alert, incident)
+		if !matched {
+			continue
+		}
+		if best == nil || incident.LastAlertAt.After(best.LastAlertAt) {
+			best = incident
+			reason = why
+		}
+	}
+
+	if best == nil {
+		return "", "", false
+	}
+	return best.ID, reason, true
+}
+
+func related(rules Rules, alert Alert, incident *Incident) (string, bool) {
+	if !rules.MatchTags && !rules.MatchIPs {
+		return fmt.Sprintf("raised within %s of the incident's last alert", alerting.FormatDuration(rules.Window)), true
+	}
+	if rules.MatchIPs && alert.IP != "" && contains(incident.IPs, alert.IP) {
+		return "shares resolved IP " + alert.IP, true
+	}
+	if rules.MatchTags {
+		for _, tag := range alert.Tags {
+			if contains(incident.Tags, tag) {
+				return "shares tag " + tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecoveryMessage describes the end of an incident for recovery
+// notifications. services are the names of the affected services.
+func RecoveryMessage(title string, services []string, duration time.Duration) string {
+	return fmt.Sprintf("Incident resolved: %s (%s; resolved after %s)", title, describeServices(services), alerting.FormatDuration(duration))
+}
+
+func describeServices(services []string) string {
+	switch len(services) {
+	case 0:
+		return "no services affected"
+	case 1:
+		return services[0] + " affected"
+	case 2, 3:
+		return strings.Join(services, ", ") + " affected"
+	default:
+		return fmt.Sprintf("%s and %d other services affected", strings.Join(services[:2], ", "), len(services)-2)
+	}
+}
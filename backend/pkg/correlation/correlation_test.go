@@ -0,0 +1,58 @@
+package correlation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestValidateRules(t *testing.T) {
+	assert.NoError(t, ValidateRules(DefaultRules()))
+	assert.Error(t, ValidateRules(Rules{Window: 30 * time.Second}))
+	assert.Error(t, ValidateRules(Rules{Window: 25 * time.Hour}))
+}
+
+func TestMatch(t *testing.T) {
+	incidents := []Incident{
+		{ID: "db", LastAlertAt: now.Add(-2 * time.Minute), Tags: []string{"database"}, IPs: []string{"10.0.0.5"}},
+		{ID: "edge", LastAlertAt: now.Add(-time.Minute), Tags: []string{"cdn", "public"}, IPs: []string{"192.0.2.1"}},
+		{ID: "stale", LastAlertAt: now.Add(-time.Hour), Tags: []string{"payments"}},
+	}
+
+	tests := []struct {
+		name       string
+		rules      Rules
+		alert      Alert
+		wantID     string
+		wantReason string
+		wantOK     bool
+	}{
+		{"shared ip", DefaultRules(), Alert{RaisedAt: now, IP: "10.0.0.5"}, "db", "shares resolved IP 10.0.0.5", true},
+		{"shared tag ignores case", DefaultRules(), Alert{RaisedAt: now, Tags: []string{"Public"}}, "edge", "shares tag Public", true},
+		{"nothing shared", DefaultRules(), Alert{RaisedAt: now, Tags: []string{"other"}, IP: "10.9.9.9"}, "", "", false},
+		{"outside window", DefaultRules(), Alert{RaisedAt: now, Tags: []string{"payments"}}, "", "", false},
+		{"ips only", Rules{Enabled: true, Window: DefaultWindow, MatchIPs: true}, Alert{RaisedAt: now, Tags: []string{"cdn"}}, "", "", false},
+		{"disabled", Rules{Window: DefaultWindow, MatchTags: true}, Alert{RaisedAt: now, Tags: []string{"cdn"}}, "", "", false},
+		{"time only picks latest", Rules{Enabled: true, Window: DefaultWindow}, Alert{RaisedAt: now}, "edge", "raised within 5m of the incident's last alert", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, reason, ok := Match(tt.rules, tt.alert, incidents)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantID, id)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestRecoveryMessage(t *testing.T) {
+	assert.Equal(t,
+		"Incident resolved: Service is down: API (API affected; resolved after 12m)",
+		RecoveryMessage("Service is down: API", []string{"API"}, 12*time.Minute))
+	assert.Equal(t,
+		"Incident resolved: DB down (API, Web and 2 other services affected; resolved after 1h 5m)",
+		RecoveryMessage("DB down", []string{"API", "Web", "Jobs", "Admin"}, 65*time.Minute))
+}
@@ -0,0 +1,243 @@
+// Package digest decides when a subscription's notifications are held back
+// and renders the held ones as a single digest.
+//
+// A subscription can set quiet hours, a daily window in its own time zone
+// during which everything but critical alerts is held until the window
+// ends, and a digest interval, which batches low-severity alerts into one
+// message every so many minutes.
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"time"
+
+	"pulsegrid/backend/pkg/message"
+)
+
+// MaxIntervalMinutes caps the digest interval at a day
+const MaxIntervalMinutes = 24 * 60
+
+// Schedule is a subscription's quiet hours and digest settings. Quiet
+// hours are off when Start and End are empty; digests are off when
+// IntervalMinutes is 0.
+type Schedule struct {
+	// Start and End are "HH:MM" clock times in TimeZone. A window whose end
+	// is before its start runs overnight.
+	Start    string
+	End      string
+	TimeZone string
+	// IntervalMinutes batches low-severity alerts into one message sent
+	// at most this often
+	IntervalMinutes int
+}
+
+// Item is one held notification
+type Item struct {
+	Event    string // triggered or resolved
+	Severity string
+	Service  string
+	Message  string
+	At       time.Time
+}
+
+// Validate checks the clock times, time zone and interval
+func (s Schedule) Validate() error {
+	if (s.Start == "") != (s.End == "") {
+		return fmt.Errorf("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if s.Start != "" {
+		start, err := parseClock(s.Start)
+		if err != nil {
+			return fmt.Errorf("quiet_hours_start: %v", err)
+		}
+		end, err := parseClock(s.End)
+		if err != nil {
+			return fmt.Errorf("quiet_hours_end: %v", err)
+		}
+		if start == end {
+			return fmt.Errorf("quiet hours cannot start and end at the same time")
+		}
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return fmt.Errorf("time_zone must be an IANA time zone such as Europe/Berlin")
+	}
+	if s.IntervalMinutes < 0 || s.IntervalMinutes > MaxIntervalMinutes {
+		return fmt.Errorf("digest_minutes must be between 0 and %d", MaxIntervalMinutes)
+	}
+	return nil
+}
+
+// QuietUntil returns when the quiet window now falls in ends, or the zero
+// time when now is outside quiet hours
+func (s Schedule) QuietUntil(now time.Time) time.Time {
+	if s.Start == "" {
+		return time.Time{}
+	}
+	start, err := parseClock(s.Start)
+	if err != nil {
+		return time.Time{}
+	}
+	end, err := parseClock(s.End)
+	if err != nil {
+		return time.Time{}
+	}
+
+	local := now.In(s.location())
+	minute := local.Hour()*60 + local.Minute()
+	// The window ends today unless it runs overnight and started today
+	day := 0
+	switch {
+	case start < end:
+		if minute < start || minute >= end {
+			return time.Time{}
+		}
+	case minute >= start:
+		day = 1
+	case minute >= end:
+		return time.Time{}
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day()+day, end/60, end%60, 0, 0, local.Location())
+	return until.UTC()
+}
+
+// HoldUntil returns when a notification of severity, sent at now, should be
+// delivered, or the zero time to deliver it straight away. Critical alerts
+// are never held. pending is when the subscription's held notifications
+// are already due out, if it has any; a batched alert joins them.
+func (s Schedule) HoldUntil(severity string, now time.Time, pending *time.Time) time.Time {
+	if severity == "critical" {
+		return time.Time{}
+	}
+	if until := s.QuietUntil(now); !until.IsZero() {
+		return until
+	}
+	if s.IntervalMinutes > 0 && severity == "low" {
+		if pending != nil {
+			return *pending
+		}
+		return now.Add(time.Duration(s.IntervalMinutes) * time.Minute).UTC()
+	}
+	return time.Time{}
+}
+
+func (s Schedule) location() *time.Location {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// parseClock turns "HH:MM" into minutes after midnight
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("must be a 24-hour time such as 22:00")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+const digestHTML = `<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f5f7;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#172b4d">
+  <table role="presentation" width="100%" style="max-width:560px;margin:0 auto;background:#ffffff;border-radius:6px;border-top:4px solid #0052cc">
+    <tr><td style="padding:24px">
+      <h2 style="margin:0 0 16px;font-size:18px">{{.Heading}}</h2>
+      <table role="presentation" style="font-size:14px;border-collapse:collapse">
+        {{- range .Lines}}
+        <tr>
+          <td style="padding:6px 12px 6px 0;color:#5e6c84;white-space:nowrap;vertical-align:top">{{.Time}}</td>
+          <td style="padding:6px 12px 6px 0;white-space:nowrap;vertical-align:top">{{.Label}}</td>
+          <td style="padding:6px 0;vertical-align:top"><strong>{{.Service}}</strong>: {{.Message}}</td>
+        </tr>
+        {{- end}}
+      </table>
+      {{- if .Link}}
+      <p style="margin:24px 0 0"><a href="{{.Link}}" style="background:#0052cc;color:#ffffff;padding:10px 16px;border-radius:4px;text-decoration:none">View in PulseGrid</a></p>
+      {{- end}}
+    </td></tr>
+  </table>
+</body>
+</html>`
+
+var digestTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(digestHTML))
+
+type line struct {
+	Time    string
+	Label   string
+	Service string
+	Message string
+}
+
+// Render renders held notifications, oldest first, as one email. Times are
+// shown in the schedule's time zone, and link is the dashboard.
+func (s Schedule) Render(items []Item, link string) (message.Rendered, error) {
+	loc := s.location()
+	alerts, recoveries := 0, 0
+	lines := make([]line, 0, len(items))
+	for _, item := range items {
+		l := line{
+			Time:    item.At.In(loc).Format("Jan 2 15:04"),
+			Label:   message.Severity(item.Severity),
+			Service: item.Service,
+			Message: item.Message,
+		}
+		if item.Event == message.EventResolved {
+			l.Label = "✅ RESOLVED"
+			recoveries++
+		} else {
+			alerts++
+		}
+		lines = append(lines, l)
+	}
+
+	heading := fmt.Sprintf("%s while notifications were held", countOf(alerts, recoveries))
+	var body strings.Builder
+	body.WriteString(heading + ":\n")
+	for _, l := range lines {
+		fmt.Fprintf(&body, "\n[%s] %s %s: %s", l.Time, l.Label, l.Service, l.Message)
+	}
+	if link != "" {
+		body.WriteString("\n\nView in PulseGrid: " + link)
+	}
+
+	var html bytes.Buffer
+	err := digestTemplate.Execute(&html, struct {
+		Heading string
+		Lines   []line
+		Link    string
+	}{heading, lines, link})
+	if err != nil {
+		return message.Rendered{}, err
+	}
+
+	return message.Rendered{
+		Subject: "PulseGrid Digest: " + countOf(alerts, recoveries),
+		Body:    body.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// countOf describes the alerts and recoveries in a digest, e.g.
+// "3 alerts, 1 recovery"
+func countOf(alerts, recoveries int) string {
+	var parts []string
+	if alerts > 0 || recoveries == 0 {
+		parts = append(parts, plural(alerts, "alert", "alerts"))
+	}
+	if recoveries > 0 {
+		parts = append(parts, plural(recoveries, "recovery", "recoveries"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
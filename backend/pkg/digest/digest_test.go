@@ -0,0 +1,95 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Schedule{}.Validate())
+	assert.NoError(t, Schedule{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin", IntervalMinutes: 30}.Validate())
+
+	assert.Error(t, Schedule{Start: "22:00"}.Validate())
+	assert.Error(t, Schedule{Start: "25:00", End: "07:00"}.Validate())
+	assert.Error(t, Schedule{Start: "07:00", End: "07:00"}.Validate())
+	assert.Error(t, Schedule{TimeZone: "Mars/Olympus"}.Validate())
+	assert.Error(t, Schedule{IntervalMinutes: -5}.Validate())
+	assert.Error(t, Schedule{IntervalMinutes: MaxIntervalMinutes + 1}.Validate())
+}
+
+func TestQuietUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	overnight := Schedule{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"}
+	daytime := Schedule{Start: "09:00", End: "17:30", TimeZone: "Europe/Berlin"}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		now      time.Time
+		want     time.Time
+	}{
+		{"before an overnight window", overnight, time.Date(2026, 3, 10, 21, 59, 0, 0, berlin), time.Time{}},
+		{"evening of an overnight window", overnight, time.Date(2026, 3, 10, 23, 0, 0, 0, berlin), time.Date(2026, 3, 11, 7, 0, 0, 0, berlin)},
+		{"morning of an overnight window", overnight, time.Date(2026, 3, 11, 6, 30, 0, 0, berlin), time.Date(2026, 3, 11, 7, 0, 0, 0, berlin)},
+		{"end of an overnight window", overnight, time.Date(2026, 3, 11, 7, 0, 0, 0, berlin), time.Time{}},
+		{"inside a daytime window", daytime, time.Date(2026, 3, 10, 12, 0, 0, 0, berlin), time.Date(2026, 3, 10, 17, 30, 0, 0, berlin)},
+		{"after a daytime window", daytime, time.Date(2026, 3, 10, 18, 0, 0, 0, berlin), time.Time{}},
+		{"across a DST change", overnight, time.Date(2026, 3, 28, 23, 0, 0, 0, berlin), time.Date(2026, 3, 29, 7, 0, 0, 0, berlin)},
+		{"no quiet hours", Schedule{}, time.Date(2026, 3, 10, 23, 0, 0, 0, berlin), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.QuietUntil(tt.now.UTC())
+			assert.True(t, tt.want.Equal(got), "want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestHoldUntil(t *testing.T) {
+	schedule := Schedule{Start: "22:00", End: "07:00", TimeZone: "UTC", IntervalMinutes: 30}
+	night := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	morning := time.Date(2026, 3, 11, 7, 0, 0, 0, time.UTC)
+	day := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, schedule.HoldUntil("critical", night, nil).IsZero(), "critical alerts are never held")
+	assert.Equal(t, morning, schedule.HoldUntil("high", night, nil))
+	assert.Equal(t, morning, schedule.HoldUntil("low", night, nil))
+
+	assert.True(t, schedule.HoldUntil("medium", day, nil).IsZero(), "only low alerts are batched")
+	assert.Equal(t, day.Add(30*time.Minute), schedule.HoldUntil("low", day, nil))
+	pending := day.Add(10 * time.Minute)
+	assert.Equal(t, pending, schedule.HoldUntil("low", day, &pending), "batched alerts join the pending digest")
+
+	assert.True(t, Schedule{}.HoldUntil("low", night, nil).IsZero())
+}
+
+func TestRender(t *testing.T) {
+	schedule := Schedule{TimeZone: "Europe/Berlin"}
+	at := time.Date(2026, 3, 10, 22, 15, 0, 0, time.UTC)
+	items := []Item{
+		{Event: "triggered", Severity: "low", Service: "Checkout <API>", Message: "Latency above 2s", At: at},
+		{Event: "triggered", Severity: "high", Service: "Search", Message: "Service is down", At: at.Add(time.Hour)},
+		{Event: "resolved", Severity: "high", Service: "Search", Message: "Service recovered", At: at.Add(2 * time.Hour)},
+	}
+
+	r, err := schedule.Render(items, "https://app.example.com/dashboard")
+	require.NoError(t, err)
+	assert.Equal(t, "PulseGrid Digest: 2 alerts, 1 recovery", r.Subject)
+	assert.True(t, strings.HasPrefix(r.Body, "2 alerts, 1 recovery while notifications were held:\n"))
+	assert.Contains(t, r.Body, "[Mar 10 23:15] 🔵 LOW Checkout <API>: Latency above 2s")
+	assert.Contains(t, r.Body, "[Mar 11 01:15] ✅ RESOLVED Search: Service recovered")
+	assert.True(t, strings.HasSuffix(r.Body, "View in PulseGrid: https://app.example.com/dashboard"))
+	assert.Contains(t, r.HTML, "Checkout &lt;API&gt;")
+	assert.Contains(t, r.HTML, `href="https://app.example.com/dashboard"`)
+
+	r, err = schedule.Render(items[:1], "")
+	require.NoError(t, err)
+	assert.Equal(t, "PulseGrid Digest: 1 alert", r.Subject)
+	assert.NotContains(t, r.HTML, "View in PulseGrid")
+}
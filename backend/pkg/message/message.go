@@ -0,0 +1,308 @@
+// Package message renders alert notifications from Go templates.
+// Organizations can override the text sent on each channel, per alert type
+// and event; channels without an override use the defaults here.
+//
+// Templates are executed against Data:
+//
+//	.Event               "triggered" or "resolved"
+//	.Message             the alert message, or the recovery message once resolved
+//	.Alert.ID            alert ID
+//	.Alert.Type          downtime, latency, threshold or flapping
+//	.Alert.Severity      low, medium, high or critical
+//	.Alert.Message       the alert message
+//	.Alert.Status        triggered, acknowledged or resolved
+//	.Alert.CreatedAt     when the alert opened (time.Time)
+//	.Service.ID          service ID
+//	.Service.Name        service name
+//	.Service.URL         the URL the service is checked at
+//	.Link                the service's page on the dashboard
+//	.Duration            how long the alert was open, e.g. "1h 5m"; set on resolved
+//	.EscalationStep      the escalation step being notified, 0 outside escalations
+//
+// and the functions severity ("🔴 CRITICAL"), upper and lower. HTML
+// templates also have color, the severity's accent color: {{color .}}.
+package message
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Events
+const (
+	EventTriggered = "triggered"
+	EventResolved  = "resolved"
+)
+
+// Channels lists the channels whose text can be templated. Webhooks carry
+// their own template, and PagerDuty and Opsgenie take structured events.
+// Web Push notifications show the subject as their title.
+var Channels = []string{"email", "sms", "slack", "teams", "discord", "telegram", "mattermost", "webpush"}
+
+// IsChannel reports whether channel's text can be templated
+func IsChannel(channel string) bool {
+	for _, c := range Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Data is what templates are executed against
+type Data struct {
+	Event          string
+	Message        string
+	Alert          AlertData
+	Service        ServiceData
+	Link           string
+	Duration       string
+	EscalationStep int
+}
+
+type AlertData struct {
+	ID        string
+	Type      string
+	Severity  string
+	Message   string
+	Status    string
+	CreatedAt time.Time
+}
+
+type ServiceData struct {
+	ID   string
+	Name string
+	URL  string
+}
+
+// Template is the text sent on a channel. HTML is only used for email.
+type Template struct {
+	Subject string
+	Body    string
+	HTML    string
+}
+
+// Rendered is a template executed against Data
+type Rendered struct {
+	Subject string
+	Body    string
+	HTML    string
+}
+
+var funcs = map[string]interface{}{
+	"severity": Severity,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+// Severity labels a severity the way the default templates do
+func Severity(severity string) string {
+	switch severity {
+	case "critical":
+		return "🔴 CRITICAL"
+	case "high":
+		return "🟠 HIGH"
+	case "medium":
+		return "🟡 MEDIUM"
+	case "low":
+		return "🔵 LOW"
+	}
+	return "⚠️"
+}
+
+const (
+	defaultTriggeredSubject = `{{if gt .EscalationStep 1}}PulseGrid Escalation (step {{.EscalationStep}}): {{else}}PulseGrid Alert: {{end}}{{.Alert.Message}}`
+	defaultTriggeredBody    = `{{severity .Alert.Severity}} Alert: {{.Alert.Message}}` +
+		`{{if gt .EscalationStep 1}}
+
+Escalated to step {{.EscalationStep}}: the alert has not been acknowledged.{{end}}`
+	defaultResolvedSubject = `PulseGrid Recovery: {{.Message}}`
+	defaultResolvedBody    = `✅ {{.Message}}`
+	// Chat messages show the service and severity beside the text
+	defaultChatBody = `{{.Message}}`
+	// Desktop notifications already have the message as their title
+	defaultPushTriggeredBody = `{{severity .Alert.Severity}} · {{.Service.Name}}`
+	defaultPushResolvedBody  = `{{.Service.Name}} recovered{{if .Duration}} after {{.Duration}}{{end}}`
+)
+
+const defaultHTML = `<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f5f7;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#172b4d">
+  <table role="presentation" width="100%" style="max-width:560px;margin:0 auto;background:#ffffff;border-radius:6px;border-top:4px solid {{color .}}">
+    <tr><td style="padding:24px">
+      <h2 style="margin:0 0 16px;font-size:18px">{{if eq .Event "resolved"}}✅ Recovered{{else}}{{severity .Alert.Severity}} Alert{{end}}: {{.Service.Name}}</h2>
+      <p style="margin:0 0 16px;font-size:15px">{{.Message}}</p>
+      <table role="presentation" style="font-size:14px;color:#5e6c84">
+        <tr><td style="padding-right:16px">Service</td><td>{{.Service.Name}}</td></tr>
+        <tr><td style="padding-right:16px">Severity</td><td>{{.Alert.Severity}}</td></tr>
+        {{- if .Duration}}
+        <tr><td style="padding-right:16px">Duration</td><td>{{.Duration}}</td></tr>
+        {{- end}}
+        {{- if gt .EscalationStep 1}}
+        <tr><td style="padding-right:16px">Escalation</td><td>step {{.EscalationStep}}, not yet acknowledged</td></tr>
+        {{- end}}
+      </table>
+      {{- if .Link}}
+      <p style="margin:24px 0 0"><a href="{{.Link}}" style="background:#0052cc;color:#ffffff;padding:10px 16px;border-radius:4px;text-decoration:none">View in PulseGrid</a></p>
+      {{- end}}
+    </td></tr>
+  </table>
+</body>
+</html>`
+
+// Default returns the built-in template for a channel and event
+func Default(channel, event string) Template {
+	t := Template{Subject: defaultTriggeredSubject, Body: defaultTriggeredBody}
+	if event == EventResolved {
+		t = Template{Subject: defaultResolvedSubject, Body: defaultResolvedBody}
+	}
+	switch channel {
+	case "email":
+		t.HTML = defaultHTML
+	case "teams", "discord", "telegram", "mattermost":
+		t.Body = defaultChatBody
+	case "webpush":
+		t.Body = defaultPushTriggeredBody
+		if event == EventResolved {
+			t.Body = defaultPushResolvedBody
+		}
+	}
+	return t
+}
+
+// Override returns the default template for a channel and event with the
+// fields t sets replaced. An email whose text is overridden but not its HTML
+// loses the default HTML, so that both parts say the same thing.
+func Override(channel, event string, t Template) Template {
+	d := Default(channel, event)
+	if t.Subject != "" {
+		d.Subject = t.Subject
+	}
+	if t.Body != "" {
+		d.Body = t.Body
+		d.HTML = ""
+	}
+	if t.HTML != "" {
+		d.HTML = t.HTML
+	}
+	return d
+}
+
+// Render executes t against data. HTML is left empty when t has none; see
+// TextToHTML for emails that need one.
+func Render(t Template, data Data) (Rendered, error) {
+	var r Rendered
+	var err error
+	if r.Subject, err = renderText("subject", t.Subject, data); err != nil {
+		return Rendered{}, err
+	}
+	// Subjects are a single header line
+	r.Subject = strings.Join(strings.Fields(r.Subject), " ")
+	if r.Body, err = renderText("body", t.Body, data); err != nil {
+		return Rendered{}, err
+	}
+	if t.HTML != "" {
+		if r.HTML, err = renderHTML(t.HTML, data); err != nil {
+			return Rendered{}, err
+		}
+	}
+	return r, nil
+}
+
+// TextToHTML wraps plain text in a minimal HTML document, escaped and with
+// its line breaks kept
+func TextToHTML(text string) string {
+	escaped := htmltemplate.HTMLEscapeString(text)
+	return "<!DOCTYPE html>\n<html>\n<body style=\"font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif\">\n" +
+		strings.ReplaceAll(escaped, "\n", "<br>\n") + "\n</body>\n</html>"
+}
+
+// Validate renders t against a sample alert for each event, so a template
+// that cannot render is rejected when it is saved
+func Validate(t Template) error {
+	for _, event := range []string{EventTriggered, EventResolved} {
+		if _, err := Render(t, Sample(event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sample is example data for previews and validation
+func Sample(event string) Data {
+	data := Data{
+		Event:   EventTriggered,
+		Message: "Service is down: Checkout API (HTTP 503)",
+		Alert: AlertData{
+			ID:        "8c7f3d2e-5b1a-4c9e-9f6d-2a4b6c8d0e1f",
+			Type:      "downtime",
+			Severity:  "high",
+			Message:   "Service is down: Checkout API (HTTP 503)",
+			Status:    "triggered",
+			CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Service: ServiceData{
+			ID:   "3f2a1b0c-9d8e-4f7a-8b6c-5d4e3f2a1b0c",
+			Name: "Checkout API",
+			URL:  "https://api.example.com/health",
+		},
+		Link: "https://app.example.com/services/3f2a1b0c-9d8e-4f7a-8b6c-5d4e3f2a1b0c",
+	}
+	if event == EventResolved {
+		data.Event = EventResolved
+		data.Message = "Service recovered: Checkout API (recovered after 12m)"
+		data.Alert.Status = "resolved"
+		data.Duration = "12m"
+	}
+	return data
+}
+
+func renderText(name, tmpl string, data Data) (string, error) {
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	var out bytes.Buffer
+	if err := t.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	return out.String(), nil
+}
+
+func renderHTML(tmpl string, data Data) (string, error) {
+	htmlFuncs := htmltemplate.FuncMap{"color": color}
+	for name, fn := range funcs {
+		htmlFuncs[name] = fn
+	}
+	t, err := htmltemplate.New("html").Funcs(htmlFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid html template: %v", err)
+	}
+	var out bytes.Buffer
+	if err := t.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("invalid html template: %v", err)
+	}
+	return out.String(), nil
+}
+
+// color is the accent color of the default HTML email
+func color(data Data) htmltemplate.CSS {
+	if data.Event == EventResolved {
+		return "#2eb67d"
+	}
+	switch data.Alert.Severity {
+	case "critical":
+		return "#d32f2f"
+	case "high":
+		return "#f57c00"
+	case "medium":
+		return "#fbc02d"
+	case "low":
+		return "#1976d2"
+	}
+	return "#757575"
+}
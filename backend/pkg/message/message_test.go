@@ -0,0 +1,82 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderDefaults(t *testing.T) {
+	r, err := Render(Default("email", EventTriggered), Sample(EventTriggered))
+	require.NoError(t, err)
+	assert.Equal(t, "PulseGrid Alert: Service is down: Checkout API (HTTP 503)", r.Subject)
+	assert.Equal(t, "🟠 HIGH Alert: Service is down: Checkout API (HTTP 503)", r.Body)
+	assert.Contains(t, r.HTML, "border-top:4px solid #f57c00")
+	assert.Contains(t, r.HTML, `href="https://app.example.com/services/`)
+
+	escalated := Sample(EventTriggered)
+	escalated.EscalationStep = 2
+	r, err = Render(Default("sms", EventTriggered), escalated)
+	require.NoError(t, err)
+	assert.Equal(t, "PulseGrid Escalation (step 2): Service is down: Checkout API (HTTP 503)", r.Subject)
+	assert.True(t, strings.HasSuffix(r.Body, "Escalated to step 2: the alert has not been acknowledged."))
+	assert.Empty(t, r.HTML, "HTML is email only")
+
+	r, err = Render(Default("teams", EventResolved), Sample(EventResolved))
+	require.NoError(t, err)
+	assert.Equal(t, "PulseGrid Recovery: Service recovered: Checkout API (recovered after 12m)", r.Subject)
+	assert.Equal(t, "Service recovered: Checkout API (recovered after 12m)", r.Body)
+
+	r, err = Render(Default("webpush", EventTriggered), Sample(EventTriggered))
+	require.NoError(t, err)
+	assert.Equal(t, "🟠 HIGH · Checkout API", r.Body)
+	r, err = Render(Default("webpush", EventResolved), Sample(EventResolved))
+	require.NoError(t, err)
+	assert.Equal(t, "Checkout API recovered after 12m", r.Body)
+}
+
+func TestRenderCustom(t *testing.T) {
+	data := Sample(EventTriggered)
+	data.Service.Name = `<b>"shop"</b>`
+
+	r, err := Render(Template{
+		Subject: "[{{upper .Alert.Severity}}]\n{{.Service.Name}}",
+		Body:    "{{.Service.Name}} is {{.Alert.Type}}",
+		HTML:    "<p>{{.Service.Name}}</p>",
+	}, data)
+	require.NoError(t, err)
+	assert.Equal(t, `[HIGH] <b>"shop"</b>`, r.Subject, "subjects are folded onto one line")
+	assert.Equal(t, `<b>"shop"</b> is downtime`, r.Body)
+	assert.Equal(t, "<p>&lt;b&gt;&#34;shop&#34;&lt;/b&gt;</p>", r.HTML, "HTML is escaped")
+}
+
+func TestOverride(t *testing.T) {
+	defaults := Default("email", EventTriggered)
+	assert.Equal(t, defaults, Override("email", EventTriggered, Template{}))
+
+	tmpl := Override("email", EventTriggered, Template{Subject: "{{.Service.Name}} down"})
+	assert.Equal(t, "{{.Service.Name}} down", tmpl.Subject)
+	assert.Equal(t, defaults.Body, tmpl.Body)
+	assert.Equal(t, defaults.HTML, tmpl.HTML)
+
+	tmpl = Override("email", EventTriggered, Template{Body: "{{.Message}}"})
+	assert.Equal(t, defaults.Subject, tmpl.Subject)
+	assert.Empty(t, tmpl.HTML, "HTML follows an overridden body")
+}
+
+func TestValidate(t *testing.T) {
+	for _, channel := range Channels {
+		for _, event := range []string{EventTriggered, EventResolved} {
+			assert.NoError(t, Validate(Default(channel, event)), channel+" "+event)
+		}
+	}
+	assert.Error(t, Validate(Template{Body: "{{.Alert.Nope}}"}))
+	assert.Error(t, Validate(Template{Subject: "{{if}}"}))
+	assert.Error(t, Validate(Template{HTML: "{{.Missing}}"}))
+}
+
+func TestTextToHTML(t *testing.T) {
+	assert.Contains(t, TextToHTML("a < b\nc"), "a &lt; b<br>\nc")
+}
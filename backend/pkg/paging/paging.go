@@ -0,0 +1,272 @@
+// Package paging opens and resolves incidents in PagerDuty (Events API v2)
+// and Opsgenie, and reads the acknowledgements they send back. The PulseGrid
+// alert ID is the dedup key in PagerDuty and the alias in Opsgenie, so every
+// event about an alert lands on the same remote incident.
+package paging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Channels
+const (
+	PagerDuty = "pagerduty"
+	Opsgenie  = "opsgenie"
+)
+
+// Event actions
+const (
+	ActionTrigger = "trigger"
+	ActionResolve = "resolve"
+)
+
+// Default API endpoints
+const (
+	DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	DefaultOpsgenieURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// IsChannel reports whether channel is one of the paging channels
+func IsChannel(channel string) bool {
+	return channel == PagerDuty || channel == Opsgenie
+}
+
+// Event is a provider-neutral incident event. It is what the outbox stores,
+// and is translated into the provider's request when it is sent.
+type Event struct {
+	Action   string `json:"action"`
+	DedupKey string `json:"dedup_key"`
+	Summary  string `json:"summary"`
+	Details  string `json:"details,omitempty"`
+	Service  string `json:"service,omitempty"`
+	Severity string `json:"severity,omitempty"` // low, medium, high, critical
+	Link     string `json:"link,omitempty"`
+}
+
+// integrationKey matches PagerDuty routing keys and Opsgenie API keys
+var integrationKey = regexp.MustCompile(`^[A-Za-z0-9-]{20,64}$`)
+
+// ValidateKey checks a subscription's destination looks like an integration
+// key for channel
+func ValidateKey(channel, key string) error {
+	if !IsChannel(channel) {
+		return fmt.Errorf("unknown paging channel: %s", channel)
+	}
+	if !integrationKey.MatchString(key) {
+		if channel == PagerDuty {
+			return fmt.Errorf("destination must be a PagerDuty Events API v2 integration key")
+		}
+		return fmt.Errorf("destination must be an Opsgenie API integration key")
+	}
+	return nil
+}
+
+// Sender posts events
+type Sender struct {
+	Client *http.Client
+	// PagerDutyURL and OpsgenieURL override the default endpoints
+	PagerDutyURL string
+	OpsgenieURL  string
+}
+
+// Post sends event to the integration key on channel. It returns the
+// response status code, or 0 if no response arrived; any status outside 2xx
+// is an error.
+func (s *Sender) Post(channel, key string, event Event) (int, error) {
+	var req *http.Request
+	var err error
+	switch channel {
+	case PagerDuty:
+		req, err = s.pagerDutyRequest(key, event)
+	case Opsgenie:
+		req, err = s.opsgenieRequest(key, event)
+	default:
+		return 0, fmt.Errorf("unknown paging channel: %s", channel)
+	}
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s returned status %d", channel, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *Sender) pagerDutyRequest(key string, event Event) (*http.Request, error) {
+	body := map[string]interface{}{
+		"routing_key":  key,
+		"event_action": event.Action,
+		"dedup_key":    event.DedupKey,
+	}
+	if event.Action == ActionTrigger {
+		payload := map[string]interface{}{
+			"summary":  truncate(event.Summary, 1024),
+			"source":   "PulseGrid",
+			"severity": pagerDutySeverity(event.Severity),
+		}
+		if event.Service != "" {
+			payload["component"] = event.Service
+		}
+		if event.Details != "" {
+			payload["custom_details"] = map[string]string{"message": event.Details}
+		}
+		body["payload"] = payload
+		if event.Link != "" {
+			body["links"] = []map[string]string{{"href": event.Link, "text": "View in PulseGrid"}}
+		}
+	}
+
+	target := s.PagerDutyURL
+	if target == "" {
+		target = DefaultPagerDutyURL
+	}
+	return jsonRequest(target, body)
+}
+
+func (s *Sender) opsgenieRequest(key string, event Event) (*http.Request, error) {
+	base := s.OpsgenieURL
+	if base == "" {
+		base = DefaultOpsgenieURL
+	}
+
+	var req *http.Request
+	var err error
+	if event.Action == ActionResolve {
+		target := base + "/" + url.PathEscape(event.DedupKey) + "/close?identifierType=alias"
+		req, err = jsonRequest(target, map[string]string{"source": "PulseGrid", "note": event.Summary})
+	} else {
+		body := map[string]interface{}{
+			"message":  truncate(event.Summary, 130),
+			"alias":    event.DedupKey,
+			"source":   "PulseGrid",
+			"priority": opsgeniePriority(event.Severity),
+		}
+		description := event.Details
+		if event.Link != "" {
+			description = strings.TrimSpace(description + "\n\n" + event.Link)
+		}
+		if description != "" {
+			body["description"] = description
+		}
+		if event.Service != "" {
+			body["entity"] = event.Service
+		}
+		req, err = jsonRequest(base, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "GenieKey "+key)
+	return req, nil
+}
+
+func jsonRequest(target string, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequest(http.MethodPost, target, bytes.NewReader(data))
+}
+
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical":
+		return "critical"
+	case "high":
+		return "error"
+	case "medium":
+		return "warning"
+	}
+	return "info"
+}
+
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case "critical":
+		return "P1"
+	case "high":
+		return "P2"
+	case "medium":
+		return "P3"
+	}
+	return "P4"
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// Acknowledgement is an acknowledgement made on the remote side
+type Acknowledgement struct {
+	DedupKey string
+	// By names who acknowledged, when the provider says
+	By string
+}
+
+// ParseAcknowledgement reads an inbound webhook from channel. It returns nil
+// for events other than an acknowledgement of a PulseGrid incident.
+func ParseAcknowledgement(channel string, body []byte) (*Acknowledgement, error) {
+	switch channel {
+	case PagerDuty:
+		// Webhooks v3
+		var hook struct {
+			Event struct {
+				EventType string `json:"event_type"`
+				Agent     *struct {
+					Summary string `json:"summary"`
+				} `json:"agent"`
+				Data struct {
+					IncidentKey string `json:"incident_key"`
+				} `json:"data"`
+			} `json:"event"`
+		}
+		if err := json.Unmarshal(body, &hook); err != nil {
+			return nil, err
+		}
+		if hook.Event.EventType != "incident.acknowledged" || hook.Event.Data.IncidentKey == "" {
+			return nil, nil
+		}
+		ack := &Acknowledgement{DedupKey: hook.Event.Data.IncidentKey}
+		if hook.Event.Agent != nil {
+			ack.By = hook.Event.Agent.Summary
+		}
+		return ack, nil
+
+	case Opsgenie:
+		var hook struct {
+			Action string `json:"action"`
+			Alert  struct {
+				Alias    string `json:"alias"`
+				Username string `json:"username"`
+			} `json:"alert"`
+		}
+		if err := json.Unmarshal(body, &hook); err != nil {
+			return nil, err
+		}
+		if hook.Action != "Acknowledge" || hook.Alert.Alias == "" {
+			return nil, nil
+		}
+		return &Acknowledgement{DedupKey: hook.Alert.Alias, By: hook.Alert.Username}, nil
+	}
+	return nil, fmt.Errorf("unknown paging channel: %s", channel)
+}
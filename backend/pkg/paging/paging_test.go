@@ -0,0 +1,118 @@
+package paging
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type captured struct {
+	path, query, auth string
+	body              map[string]interface{}
+}
+
+func capture(t *testing.T, status int) (*httptest.Server, *captured) {
+	got := &captured{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.Path
+		got.query = r.URL.RawQuery
+		got.auth = r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		got.body = nil
+		require.NoError(t, json.Unmarshal(b, &got.body))
+		w.WriteHeader(status)
+	}))
+	return server, got
+}
+
+var trigger = Event{
+	Action:   ActionTrigger,
+	DedupKey: "6f1c2a4e-0000-4000-8000-000000000001",
+	Summary:  "api is down",
+	Details:  "connection refused",
+	Service:  "api",
+	Severity: "high",
+	Link:     "https://app.example.com/services/42",
+}
+
+func TestPostPagerDuty(t *testing.T) {
+	server, got := capture(t, http.StatusAccepted)
+	defer server.Close()
+	sender := &Sender{Client: server.Client(), PagerDutyURL: server.URL + "/v2/enqueue"}
+
+	code, err := sender.Post(PagerDuty, "R0UT1NGKEY0000000000000000000000", trigger)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "trigger", got.body["event_action"])
+	assert.Equal(t, trigger.DedupKey, got.body["dedup_key"])
+	payload := got.body["payload"].(map[string]interface{})
+	assert.Equal(t, "error", payload["severity"])
+	assert.Equal(t, "api", payload["component"])
+
+	_, err = sender.Post(PagerDuty, "R0UT1NGKEY0000000000000000000000", Event{Action: ActionResolve, DedupKey: trigger.DedupKey})
+	require.NoError(t, err)
+	assert.Equal(t, "resolve", got.body["event_action"])
+	assert.NotContains(t, got.body, "payload")
+}
+
+func TestPostOpsgenie(t *testing.T) {
+	server, got := capture(t, http.StatusAccepted)
+	defer server.Close()
+	sender := &Sender{Client: server.Client(), OpsgenieURL: server.URL + "/v2/alerts"}
+
+	_, err := sender.Post(Opsgenie, "genie-key", trigger)
+	require.NoError(t, err)
+	assert.Equal(t, "/v2/alerts", got.path)
+	assert.Equal(t, "GenieKey genie-key", got.auth)
+	assert.Equal(t, trigger.DedupKey, got.body["alias"])
+	assert.Equal(t, "P2", got.body["priority"])
+
+	_, err = sender.Post(Opsgenie, "genie-key", Event{Action: ActionResolve, DedupKey: trigger.DedupKey, Summary: "recovered"})
+	require.NoError(t, err)
+	assert.Equal(t, "/v2/alerts/"+trigger.DedupKey+"/close", got.path)
+	assert.Equal(t, "identifierType=alias", got.query)
+	assert.Equal(t, "recovered", got.body["note"])
+}
+
+func TestPostFailure(t *testing.T) {
+	server, _ := capture(t, http.StatusBadRequest)
+	defer server.Close()
+
+	code, err := (&Sender{Client: server.Client(), PagerDutyURL: server.URL}).Post(PagerDuty, "key", trigger)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestParseAcknowledgement(t *testing.T) {
+	ack, err := ParseAcknowledgement(PagerDuty, []byte(`{"event":{"event_type":"incident.acknowledged",
+		"agent":{"summary":"Ada Lovelace"},"data":{"incident_key":"abc"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, &Acknowledgement{DedupKey: "abc", By: "Ada Lovelace"}, ack)
+
+	ack, err = ParseAcknowledgement(PagerDuty, []byte(`{"event":{"event_type":"incident.triggered","data":{"incident_key":"abc"}}}`))
+	require.NoError(t, err)
+	assert.Nil(t, ack)
+
+	ack, err = ParseAcknowledgement(Opsgenie, []byte(`{"action":"Acknowledge","alert":{"alias":"abc","username":"ada@example.com"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, &Acknowledgement{DedupKey: "abc", By: "ada@example.com"}, ack)
+
+	ack, err = ParseAcknowledgement(Opsgenie, []byte(`{"action":"Close","alert":{"alias":"abc"}}`))
+	require.NoError(t, err)
+	assert.Nil(t, ack)
+
+	_, err = ParseAcknowledgement(Opsgenie, []byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestValidateKey(t *testing.T) {
+	assert.NoError(t, ValidateKey(PagerDuty, "R0UT1NGKEY0000000000000000000000"))
+	assert.NoError(t, ValidateKey(Opsgenie, "0b7c6a8e-1d2f-4a3b-9c8d-7e6f5a4b3c2d"))
+	assert.Error(t, ValidateKey(PagerDuty, "short"))
+	assert.Error(t, ValidateKey("slack", "R0UT1NGKEY0000000000000000000000"))
+}
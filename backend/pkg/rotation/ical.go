@@ -0,0 +1,84 @@
+package rotation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const icalTimeFormat = "20060102T150405Z"
+
+// CalendarEvent is one entry of an iCalendar export
+type CalendarEvent struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// ICalendar renders events as an RFC 5545 calendar named name. stamp is the
+// DTSTAMP of every event, normally the time of the export.
+func ICalendar(name string, events []CalendarEvent, stamp time.Time) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldLine(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//PulseGrid//On-call//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeText(name))
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("DTSTAMP:" + stamp.UTC().Format(icalTimeFormat))
+		line("DTSTART:" + event.Start.UTC().Format(icalTimeFormat))
+		line("DTEND:" + event.End.UTC().Format(icalTimeFormat))
+		line("SUMMARY:" + escapeText(event.Summary))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	return b.String()
+}
+
+// ShiftUID is a stable event UID for a shift, so calendar apps update
+// existing events when a feed is refreshed
+func ShiftUID(scheduleID string, shift Shift) string {
+	return fmt.Sprintf("%s-%s-%d@pulsegrid", scheduleID, shift.UserID, shift.Start.Unix())
+}
+
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, ";", `\;`)
+	s = strings.ReplaceAll(s, ",", `\,`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
+
+// foldLine splits content lines longer than 75 octets, continuing each with
+// a leading space, without breaking a UTF-8 sequence
+func foldLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
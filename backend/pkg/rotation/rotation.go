@@ -0,0 +1,263 @@
+// Package rotation works out who is on call from a schedule's layered
+// rotations and overrides. Handoffs happen at a wall-clock time in the
+// schedule's time zone, so a shift spanning a DST change is an hour shorter
+// or longer than usual rather than drifting off the handoff time. Like
+// pkg/alerting it has no database or HTTP dependencies.
+package rotation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Schedules name IANA time zones; embed the database so lookups work
+	// in minimal containers
+	_ "time/tzdata"
+)
+
+// Rotation kinds
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+// SourceOverride is the Shift.Source of time covered by an override
+const SourceOverride = "override"
+
+// Layer hands off between Participants, in order, at HandoffTime each day or
+// each week on HandoffWeekday. The first participant is on call from
+// StartsAt until the first handoff after it. A layer applies from StartsAt
+// until EndsAt (if set).
+type Layer struct {
+	Name           string
+	Rotation       string
+	HandoffTime    string // "HH:MM" in the schedule's time zone
+	HandoffWeekday time.Weekday
+	StartsAt       time.Time
+	EndsAt         *time.Time
+	Participants   []string
+}
+
+// Override puts UserID on call between StartsAt and EndsAt, ahead of every layer
+type Override struct {
+	UserID   string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// Schedule stacks layers: where several apply, the last one wins. Overrides
+// beat every layer, and a later override beats an earlier one.
+type Schedule struct {
+	Location  *time.Location
+	Layers    []Layer
+	Overrides []Override
+}
+
+// Shift is a stretch of time with one person on call. Source is the layer
+// name or SourceOverride.
+type Shift struct {
+	UserID string
+	Source string
+	Start  time.Time
+	End    time.Time
+}
+
+// ParseClock parses an "HH:MM" handoff time
+func ParseClock(clock string) (hour, minute int, err error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 {
+		return 0, 0, fmt.Errorf("handoff_time must be HH:MM")
+	}
+	hour, err = strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("handoff_time must be HH:MM")
+	}
+	minute, err = strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("handoff_time must be HH:MM")
+	}
+	return hour, minute, nil
+}
+
+// ValidateLayer reports the first problem with a layer's configuration
+func ValidateLayer(layer Layer) error {
+	switch layer.Rotation {
+	case Daily, Weekly:
+	default:
+		return fmt.Errorf("rotation must be %s or %s", Daily, Weekly)
+	}
+	if _, _, err := ParseClock(layer.HandoffTime); err != nil {
+		return err
+	}
+	if layer.HandoffWeekday < time.Sunday || layer.HandoffWeekday > time.Saturday {
+		return fmt.Errorf("handoff_weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	if len(layer.Participants) == 0 {
+		return fmt.Errorf("a layer needs at least one participant")
+	}
+	if layer.StartsAt.IsZero() {
+		return fmt.Errorf("starts_at is required")
+	}
+	if layer.EndsAt != nil && !layer.EndsAt.After(layer.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// At returns who is on call at t. ok is false when nobody is.
+func (s Schedule) At(t time.Time) (userID, source string, ok bool) {
+	// Later overrides win
+	for i := len(s.Overrides) - 1; i >= 0; i-- {
+		o := s.Overrides[i]
+		if !t.Before(o.StartsAt) && t.Before(o.EndsAt) {
+			return o.UserID, SourceOverride, true
+		}
+	}
+
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+		if user, ok := s.Layers[i].at(t, s.location()); ok {
+			return user, s.Layers[i].Name, true
+		}
+	}
+
+	return "", "", false
+}
+
+// Shifts returns the on-call timeline between from and to, merging adjacent
+// stretches with the same person. Gaps with nobody on call are left out, and
+// the first and last shifts are clipped to the range.
+func (s Schedule) Shifts(from, to time.Time) []Shift {
+	if !to.After(from) {
+		return nil
+	}
+
+	// Who is on call can only change at these instants
+	bounds := []time.Time{from, to}
+	add := func(t time.Time) {
+		if t.After(from) && t.Before(to) {
+			bounds = append(bounds, t)
+		}
+	}
+	for _, o := range s.Overrides {
+		add(o.StartsAt)
+		add(o.EndsAt)
+	}
+	for _, layer := range s.Layers {
+		add(layer.StartsAt)
+		if layer.EndsAt != nil {
+			add(*layer.EndsAt)
+		}
+		for _, h := range layer.handoffs(from, to, s.location()) {
+			add(h)
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	var shifts []Shift
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		if !end.After(start) {
+			continue
+		}
+		user, source, ok := s.At(start)
+		if !ok {
+			continue
+		}
+		if n := len(shifts); n > 0 && shifts[n-1].UserID == user && shifts[n-1].Source == source && shifts[n-1].End.Equal(start) {
+			shifts[n-1].End = end
+			continue
+		}
+		shifts = append(shifts, Shift{UserID: user, Source: source, Start: start, End: end})
+	}
+
+	return shifts
+}
+
+func (s Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+// at returns the layer's participant on call at t
+func (l Layer) at(t time.Time, loc *time.Location) (string, bool) {
+	if len(l.Participants) == 0 || t.Before(l.StartsAt) {
+		return "", false
+	}
+	if l.EndsAt != nil && !t.Before(*l.EndsAt) {
+		return "", false
+	}
+
+	handoffs := l.period(t, loc) - l.period(l.StartsAt, loc)
+	n := int64(len(l.Participants))
+	return l.Participants[((handoffs%n)+n)%n], true
+}
+
+// period numbers the rotation periods in the layer's local calendar. Each
+// period starts at a handoff, so the number of handoffs between two instants
+// is the difference of their periods.
+func (l Layer) period(t time.Time, loc *time.Location) int64 {
+	local := t.In(loc)
+	day := civilDay(local.Year(), local.Month(), local.Day())
+	if t.Before(l.handoffOn(day, loc)) {
+		day--
+	}
+	if l.Rotation == Weekly {
+		return floorDiv(day-l.weekdayOffset(), 7)
+	}
+	return day
+}
+
+// handoffs returns the layer's handoff instants strictly between from and to
+func (l Layer) handoffs(from, to time.Time, loc *time.Location) []time.Time {
+	var out []time.Time
+	for p := l.period(from, loc) + 1; ; p++ {
+		h := l.periodStart(p, loc)
+		if !h.Before(to) {
+			return out
+		}
+		if h.After(from) {
+			out = append(out, h)
+		}
+	}
+}
+
+// periodStart is the handoff that begins period p
+func (l Layer) periodStart(p int64, loc *time.Location) time.Time {
+	if l.Rotation == Weekly {
+		return l.handoffOn(p*7+l.weekdayOffset(), loc)
+	}
+	return l.handoffOn(p, loc)
+}
+
+// handoffOn is the handoff instant on a local calendar day. A handoff time
+// skipped by a DST change falls at the equivalent instant Go picks for it,
+// consistently for both period and periodStart.
+func (l Layer) handoffOn(day int64, loc *time.Location) time.Time {
+	hour, minute, _ := ParseClock(l.HandoffTime)
+	date := time.Unix(day*86400, 0).UTC()
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
+}
+
+// weekdayOffset is the first civil day falling on the handoff weekday
+func (l Layer) weekdayOffset() int64 {
+	// Civil day 0, 1 January 1970, was a Thursday
+	return int64((int(l.HandoffWeekday) - int(time.Thursday) + 7) % 7)
+}
+
+// civilDay counts days since 1 January 1970 for a calendar date, ignoring
+// time zones, so DST never makes a day longer or shorter
+func civilDay(year int, month time.Month, day int) int64 {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,231 @@
+package rotation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func local(loc *time.Location, year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, loc)
+}
+
+func TestParseClock(t *testing.T) {
+	hour, minute, err := ParseClock("09:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 9, hour)
+	assert.Equal(t, 30, minute)
+
+	for _, bad := range []string{"9:30", "24:00", "12:60", "noon", "12-00", ""} {
+		_, _, err := ParseClock(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestValidateLayer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	valid := Layer{Rotation: Daily, HandoffTime: "09:00", StartsAt: start, Participants: []string{"a"}}
+	assert.NoError(t, ValidateLayer(valid))
+
+	tests := []struct {
+		name  string
+		layer Layer
+	}{
+		{"bad rotation", Layer{Rotation: "monthly", HandoffTime: "09:00", StartsAt: start, Participants: []string{"a"}}},
+		{"bad handoff", Layer{Rotation: Daily, HandoffTime: "9am", StartsAt: start, Participants: []string{"a"}}},
+		{"bad weekday", Layer{Rotation: Weekly, HandoffTime: "09:00", HandoffWeekday: 7, StartsAt: start, Participants: []string{"a"}}},
+		{"no participants", Layer{Rotation: Daily, HandoffTime: "09:00", StartsAt: start}},
+		{"no start", Layer{Rotation: Daily, HandoffTime: "09:00", Participants: []string{"a"}}},
+		{"ends before start", Layer{Rotation: Daily, HandoffTime: "09:00", StartsAt: start, EndsAt: &before, Participants: []string{"a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, ValidateLayer(tt.layer))
+		})
+	}
+}
+
+func TestDailyRotationAcrossSpringForward(t *testing.T) {
+	// New York springs forward at 02:00 on 10 March 2024
+	ny := mustLoad(t, "America/New_York")
+	s := Schedule{Location: ny, Layers: []Layer{{
+		Name: "primary", Rotation: Daily, HandoffTime: "09:00",
+		StartsAt:     local(ny, 2024, 3, 8, 9, 0),
+		Participants: []string{"a", "b", "c"},
+	}}}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{local(ny, 2024, 3, 8, 9, 0), "a"},
+		{local(ny, 2024, 3, 9, 8, 59), "a"},
+		{local(ny, 2024, 3, 9, 9, 0), "b"},
+		{local(ny, 2024, 3, 10, 8, 59), "b"},
+		{local(ny, 2024, 3, 10, 9, 0), "c"},
+		{local(ny, 2024, 3, 11, 9, 0), "a"},
+	}
+	for _, tt := range tests {
+		user, source, ok := s.At(tt.at)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, user, tt.at.String())
+		assert.Equal(t, "primary", source)
+	}
+
+	// Handoffs stay at 09:00 local, so the shift spanning the change is 23h
+	shifts := s.Shifts(local(ny, 2024, 3, 9, 9, 0), local(ny, 2024, 3, 11, 9, 0))
+	require.Len(t, shifts, 2)
+	assert.Equal(t, "b", shifts[0].UserID)
+	assert.Equal(t, 23*time.Hour, shifts[0].End.Sub(shifts[0].Start))
+	assert.Equal(t, time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC), shifts[0].End.UTC())
+	assert.Equal(t, 24*time.Hour, shifts[1].End.Sub(shifts[1].Start))
+}
+
+func TestDailyRotationAcrossFallBack(t *testing.T) {
+	// New York falls back at 02:00 on 3 November 2024
+	ny := mustLoad(t, "America/New_York")
+	s := Schedule{Location: ny, Layers: []Layer{{
+		Name: "primary", Rotation: Daily, HandoffTime: "09:00",
+		StartsAt:     local(ny, 2024, 11, 1, 9, 0),
+		Participants: []string{"a", "b"},
+	}}}
+
+	shifts := s.Shifts(local(ny, 2024, 11, 2, 9, 0), local(ny, 2024, 11, 4, 9, 0))
+	require.Len(t, shifts, 2)
+	assert.Equal(t, "b", shifts[0].UserID)
+	assert.Equal(t, 25*time.Hour, shifts[0].End.Sub(shifts[0].Start))
+	assert.Equal(t, "a", shifts[1].UserID)
+	assert.Equal(t, 24*time.Hour, shifts[1].End.Sub(shifts[1].Start))
+}
+
+func TestHandoffInsideSkippedHour(t *testing.T) {
+	// 02:30 does not exist in New York on 10 March 2024; there must still be
+	// exactly one handoff that day and the rotation must not skip anyone
+	ny := mustLoad(t, "America/New_York")
+	s := Schedule{Location: ny, Layers: []Layer{{
+		Name: "night", Rotation: Daily, HandoffTime: "02:30",
+		StartsAt:     local(ny, 2024, 3, 8, 2, 30),
+		Participants: []string{"a", "b", "c"},
+	}}}
+
+	shifts := s.Shifts(local(ny, 2024, 3, 8, 2, 30), local(ny, 2024, 3, 12, 2, 30))
+	require.Len(t, shifts, 4)
+	var users []string
+	for i, shift := range shifts {
+		users = append(users, shift.UserID)
+		if i > 0 {
+			assert.Equal(t, shifts[i-1].End, shift.Start)
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a"}, users)
+}
+
+func TestWeeklyRotationAcrossDST(t *testing.T) {
+	// London springs forward on 31 March 2024; handoff is Mondays at 10:00
+	london := mustLoad(t, "Europe/London")
+	s := Schedule{Location: london, Layers: []Layer{{
+		Name: "weekly", Rotation: Weekly, HandoffTime: "10:00", HandoffWeekday: time.Monday,
+		// Starting mid-week: the first participant covers until the next Monday
+		StartsAt:     local(london, 2024, 3, 20, 12, 0),
+		Participants: []string{"a", "b"},
+	}}}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{local(london, 2024, 3, 20, 12, 0), "a"},
+		{local(london, 2024, 3, 25, 9, 59), "a"},
+		{local(london, 2024, 3, 25, 10, 0), "b"},
+		{local(london, 2024, 3, 31, 23, 0), "b"},
+		{local(london, 2024, 4, 1, 9, 59), "b"},
+		{local(london, 2024, 4, 1, 10, 0), "a"},
+	}
+	for _, tt := range tests {
+		user, _, ok := s.At(tt.at)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, user, tt.at.String())
+	}
+
+	shifts := s.Shifts(local(london, 2024, 3, 25, 10, 0), local(london, 2024, 4, 1, 10, 0))
+	require.Len(t, shifts, 1)
+	assert.Equal(t, 7*24*time.Hour-time.Hour, shifts[0].End.Sub(shifts[0].Start))
+}
+
+func TestLayersAndOverrides(t *testing.T) {
+	start := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	layerEnd := start.Add(48 * time.Hour)
+	s := Schedule{
+		Layers: []Layer{
+			{Name: "base", Rotation: Weekly, HandoffTime: "09:00", HandoffWeekday: time.Monday, StartsAt: start, Participants: []string{"base"}},
+			// A later layer wins while it applies, then base takes over again
+			{Name: "cover", Rotation: Daily, HandoffTime: "09:00", StartsAt: start.Add(24 * time.Hour), EndsAt: &layerEnd, Participants: []string{"cover"}},
+		},
+		Overrides: []Override{
+			{UserID: "first", StartsAt: start.Add(2 * time.Hour), EndsAt: start.Add(6 * time.Hour)},
+			{UserID: "second", StartsAt: start.Add(4 * time.Hour), EndsAt: start.Add(5 * time.Hour)},
+		},
+	}
+
+	tests := []struct {
+		offset     time.Duration
+		wantUser   string
+		wantSource string
+	}{
+		{time.Hour, "base", "base"},
+		{3 * time.Hour, "first", SourceOverride},
+		{4*time.Hour + 30*time.Minute, "second", SourceOverride},
+		{5*time.Hour + 30*time.Minute, "first", SourceOverride},
+		{30 * time.Hour, "cover", "cover"},
+		{50 * time.Hour, "base", "base"},
+	}
+	for _, tt := range tests {
+		user, source, ok := s.At(start.Add(tt.offset))
+		assert.True(t, ok)
+		assert.Equal(t, tt.wantUser, user, tt.offset.String())
+		assert.Equal(t, tt.wantSource, source, tt.offset.String())
+	}
+
+	_, _, ok := s.At(start.Add(-time.Minute))
+	assert.False(t, ok)
+
+	var timeline []string
+	for _, shift := range s.Shifts(start, start.Add(72*time.Hour)) {
+		timeline = append(timeline, shift.UserID)
+	}
+	assert.Equal(t, []string{"base", "first", "second", "first", "base", "cover", "base"}, timeline)
+}
+
+func TestICalendar(t *testing.T) {
+	stamp := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	shift := Shift{UserID: "u1", Start: time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC), End: time.Date(2024, 6, 4, 9, 0, 0, 0, time.UTC)}
+	out := ICalendar("Ops, primary", []CalendarEvent{{
+		UID:     ShiftUID("s1", shift),
+		Summary: "On call: Ops; primary",
+		Start:   shift.Start,
+		End:     shift.End,
+	}}, stamp)
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "X-WR-CALNAME:Ops\\, primary\r\n")
+	assert.Contains(t, out, "UID:s1-u1-1717405200@pulsegrid\r\n")
+	assert.Contains(t, out, "DTSTART:20240603T090000Z\r\n")
+	assert.Contains(t, out, "DTEND:20240604T090000Z\r\n")
+	assert.Contains(t, out, "SUMMARY:On call: Ops\\; primary\r\n")
+
+	long := foldLine("SUMMARY:" + strings.Repeat("x", 100))
+	for _, l := range strings.Split(long, "\r\n") {
+		assert.LessOrEqual(t, len(l), 75)
+	}
+}
@@ -0,0 +1,137 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// DefaultHTTPTemplate is the body sent by the HTTP provider when it has no
+// template of its own
+const DefaultHTTPTemplate = `{"to": {{json .To}}, "message": {{json .Body}}}`
+
+// HTTP sends through any HTTP API, with a request body rendered from a
+// text/template. Templates are executed against .To and .Body, and have
+// json, which quotes a value as a JSON string, and urlquery.
+type HTTP struct {
+	Client *http.Client
+	URL    string
+	// Method defaults to POST
+	Method  string
+	Headers map[string]string
+	// Template defaults to DefaultHTTPTemplate
+	Template string
+	// IDField names the field of the JSON response holding the message
+	// ID, with dots between nested fields, e.g. "messages.0.id"
+	IDField string
+}
+
+var httpFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func (h *HTTP) validate() error {
+	if h.URL == "" {
+		return fmt.Errorf("the http SMS provider needs SMS_HTTP_URL")
+	}
+	_, err := h.template()
+	return err
+}
+
+func (h *HTTP) template() (*template.Template, error) {
+	text := h.Template
+	if text == "" {
+		text = DefaultHTTPTemplate
+	}
+	tmpl, err := template.New("sms").Funcs(httpFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("SMS_HTTP_TEMPLATE: %v", err)
+	}
+	return tmpl, nil
+}
+
+func (h *HTTP) Send(to, body string) (Result, error) {
+	tmpl, err := h.template()
+	if err != nil {
+		return Result{}, err
+	}
+	var payload bytes.Buffer
+	if err := tmpl.Execute(&payload, struct{ To, Body string }{to, body}); err != nil {
+		return Result{}, err
+	}
+
+	method := h.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, h.URL, &payload)
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range h.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	result := Result{StatusCode: resp.StatusCode, Status: StatusAccepted}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("SMS provider returned status %d", resp.StatusCode)
+	}
+	if h.IDField != "" {
+		result.MessageID = lookup(data, h.IDField)
+	}
+	return result, nil
+}
+
+// lookup returns the value at a dotted path in a JSON document, or "" if
+// there is none
+func lookup(data []byte, path string) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			var i int
+			if _, err := fmt.Sscan(key, &i); err != nil || i < 0 || i >= len(node) {
+				return ""
+			}
+			v = node[i]
+		default:
+			return ""
+		}
+	}
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return fmt.Sprintf("%.0f", id)
+	}
+	return ""
+}
+
+// parseHeaders reads headers from a JSON object of names to values
+func parseHeaders(value string) (map[string]string, error) {
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(value), &headers); err != nil {
+		return nil, fmt.Errorf("must be a JSON object of header names to values")
+	}
+	return headers, nil
+}
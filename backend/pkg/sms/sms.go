@@ -0,0 +1,283 @@
+// Package sms sends text messages to individual phone numbers through a
+// pluggable provider: Amazon SNS, a Twilio-compatible REST API, or any HTTP
+// API described by a request template.
+//
+// Messages are capped at a number of segments before they are sent, so a
+// long alert costs a known amount. A segment holds 160 GSM-7 characters, or
+// 70 UTF-16 code units once the text needs anything outside the GSM
+// alphabet; a message of several segments loses a few characters from each
+// to the header that joins them back together.
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Providers
+const (
+	ProviderSNS    = "sns"
+	ProviderTwilio = "twilio"
+	ProviderHTTP   = "http"
+)
+
+// Provider statuses reported for a message the provider took, before any
+// delivery receipt
+const (
+	StatusAccepted = "accepted"
+	StatusQueued   = "queued"
+)
+
+// DefaultMaxSegments caps messages at three segments, 459 GSM-7 characters
+const DefaultMaxSegments = 3
+
+// ErrInvalidNumber is returned for a destination that is not an E.164
+// phone number. Sending to it again fails the same way.
+var ErrInvalidNumber = errors.New("destination must be a phone number in E.164 format, e.g. +14155550123")
+
+// phoneNumber matches E.164 numbers, e.g. +14155550123
+var phoneNumber = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// ValidateNumber checks a destination is an E.164 phone number
+func ValidateNumber(number string) error {
+	if !phoneNumber.MatchString(number) {
+		return ErrInvalidNumber
+	}
+	return nil
+}
+
+// Result is what a provider said about a message it took
+type Result struct {
+	// MessageID is the provider's ID for the message, which its delivery
+	// receipts refer to
+	MessageID string
+	// Status is the provider's status for the message, e.g. queued
+	Status string
+	// StatusCode is the HTTP status of the provider's answer, or 0 if
+	// none arrived
+	StatusCode int
+	// Segments is how many segments the message was sent as
+	Segments int
+}
+
+// Provider sends one message to one phone number. It returns the HTTP
+// status code of the provider's answer in Result, also on error.
+type Provider interface {
+	Send(to, body string) (Result, error)
+}
+
+// Sender validates the number and fits the message into MaxSegments
+// before handing it to Provider
+type Sender struct {
+	Provider    Provider
+	MaxSegments int
+}
+
+// Send sends body to the phone number to
+func (s *Sender) Send(to, body string) (Result, error) {
+	if err := ValidateNumber(to); err != nil {
+		return Result{}, err
+	}
+	max := s.MaxSegments
+	if max <= 0 {
+		max = DefaultMaxSegments
+	}
+	body = Fit(body, max)
+
+	result, err := s.Provider.Send(to, body)
+	result.Segments = Segments(body)
+	return result, err
+}
+
+// Config selects and configures a provider
+type Config struct {
+	// Provider is sns, twilio or http; empty logs messages instead of
+	// sending them
+	Provider    string
+	MaxSegments int
+	Twilio      Twilio
+	HTTP        HTTP
+	SNS         SNS
+}
+
+// LoadConfig reads the provider configuration from the environment:
+//
+//	SMS_PROVIDER                  sns, twilio or http
+//	SMS_MAX_SEGMENTS              segments a message is capped at (3)
+//	TWILIO_API_URL                base URL of a Twilio-compatible API
+//	TWILIO_ACCOUNT_SID            account SID, also the basic auth user
+//	TWILIO_AUTH_TOKEN             auth token, also signs status callbacks
+//	TWILIO_FROM_NUMBER            sending number, or
+//	TWILIO_MESSAGING_SERVICE_SID  messaging service to send from
+//	SMS_STATUS_CALLBACK_URL       where Twilio posts delivery receipts
+//	SMS_HTTP_URL                  generic provider endpoint
+//	SMS_HTTP_METHOD               request method (POST)
+//	SMS_HTTP_HEADERS              request headers as a JSON object
+//	SMS_HTTP_TEMPLATE             request body template
+//	SMS_HTTP_ID_FIELD             response JSON field holding the message ID
+//	SNS_SMS_SENDER_ID             alphanumeric sender ID for SNS
+func LoadConfig(getenv func(string) string) (Config, error) {
+	cfg := Config{
+		Provider: strings.ToLower(getenv("SMS_PROVIDER")),
+		Twilio: Twilio{
+			BaseURL:             getenv("TWILIO_API_URL"),
+			AccountSID:          getenv("TWILIO_ACCOUNT_SID"),
+			AuthToken:           getenv("TWILIO_AUTH_TOKEN"),
+			From:                getenv("TWILIO_FROM_NUMBER"),
+			MessagingServiceSID: getenv("TWILIO_MESSAGING_SERVICE_SID"),
+			StatusCallback:      getenv("SMS_STATUS_CALLBACK_URL"),
+		},
+		HTTP: HTTP{
+			URL:      getenv("SMS_HTTP_URL"),
+			Method:   getenv("SMS_HTTP_METHOD"),
+			Template: getenv("SMS_HTTP_TEMPLATE"),
+			IDField:  getenv("SMS_HTTP_ID_FIELD"),
+		},
+		SNS: SNS{SenderID: getenv("SNS_SMS_SENDER_ID")},
+	}
+	if v := getenv("SMS_MAX_SEGMENTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("SMS_MAX_SEGMENTS must be a positive number")
+		}
+		cfg.MaxSegments = n
+	}
+	if v := getenv("SMS_HTTP_HEADERS"); v != "" {
+		headers, err := parseHeaders(v)
+		if err != nil {
+			return cfg, fmt.Errorf("SMS_HTTP_HEADERS: %v", err)
+		}
+		cfg.HTTP.Headers = headers
+	}
+	return cfg, nil
+}
+
+// New returns a Sender for the configured provider, or nil when no
+// provider is configured. client makes the HTTP providers' requests.
+func New(cfg Config, client *http.Client) (*Sender, error) {
+	var provider Provider
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderSNS:
+		sns := cfg.SNS
+		if sns.Client == nil {
+			if err := sns.connect(); err != nil {
+				return nil, err
+			}
+		}
+		provider = &sns
+	case ProviderTwilio:
+		twilio := cfg.Twilio
+		twilio.Client = client
+		if err := twilio.validate(); err != nil {
+			return nil, err
+		}
+		provider = &twilio
+	case ProviderHTTP:
+		h := cfg.HTTP
+		h.Client = client
+		if err := h.validate(); err != nil {
+			return nil, err
+		}
+		provider = &h
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q; use sns, twilio or http", cfg.Provider)
+	}
+	return &Sender{Provider: provider, MaxSegments: cfg.MaxSegments}, nil
+}
+
+// Segment sizes
+const (
+	gsmSingle  = 160
+	gsmPart    = 153
+	ucs2Single = 70
+	ucs2Part   = 67
+)
+
+// gsmBasic and gsmExtended are the GSM 03.38 alphabet. Extended characters
+// take two septets, an escape and the character.
+const (
+	gsmBasic    = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExtended = "\f^{}\\[~]|€"
+)
+
+// IsGSM reports whether body can be sent in the GSM-7 alphabet
+func IsGSM(body string) bool {
+	for _, r := range body {
+		if !strings.ContainsRune(gsmBasic, r) && !strings.ContainsRune(gsmExtended, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Segments returns how many segments body is sent as
+func Segments(body string) int {
+	gsm := IsGSM(body)
+	units := 0
+	for _, r := range body {
+		units += width(r, gsm)
+	}
+	if units == 0 {
+		return 1
+	}
+	single, part := gsmSingle, gsmPart
+	if !gsm {
+		single, part = ucs2Single, ucs2Part
+	}
+	if units <= single {
+		return 1
+	}
+	return (units + part - 1) / part
+}
+
+// Fit cuts body down to maxSegments, marking the cut with "...". It never
+// splits an extended GSM character or a surrogate pair.
+func Fit(body string, maxSegments int) string {
+	if Segments(body) <= maxSegments {
+		return body
+	}
+
+	gsm := IsGSM(body)
+	capacity := maxSegments * gsmPart
+	switch {
+	case !gsm && maxSegments == 1:
+		capacity = ucs2Single
+	case !gsm:
+		capacity = maxSegments * ucs2Part
+	case maxSegments == 1:
+		capacity = gsmSingle
+	}
+	capacity -= len("...")
+
+	var b strings.Builder
+	used := 0
+	for _, r := range body {
+		w := width(r, gsm)
+		if used+w > capacity {
+			break
+		}
+		used += w
+		b.WriteRune(r)
+	}
+	return strings.TrimRight(b.String(), " \n") + "..."
+}
+
+// width is how many septets (GSM-7) or UTF-16 code units (UCS-2) r takes
+func width(r rune, gsm bool) int {
+	if gsm {
+		if strings.ContainsRune(gsmExtended, r) {
+			return 2
+		}
+		return 1
+	}
+	if r > 0xFFFF {
+		return 2
+	}
+	return 1
+}
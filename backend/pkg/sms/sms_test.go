@@ -0,0 +1,253 @@
+package sms
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type captured struct {
+	method, path, auth, contentType string
+	body                            string
+}
+
+// provider stands in for an SMS provider, answering every request with
+// status and response
+func provider(t *testing.T, status int, response string) (*httptest.Server, *captured) {
+	got := &captured{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method = r.Method
+		got.path = r.URL.Path
+		got.auth = r.Header.Get("Authorization")
+		got.contentType = r.Header.Get("Content-Type")
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		got.body = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	return server, got
+}
+
+func TestValidateNumber(t *testing.T) {
+	assert.NoError(t, ValidateNumber("+14155550123"))
+	assert.NoError(t, ValidateNumber("+4915112345678"))
+	for _, number := range []string{"", "4155550123", "+0155550123", "+1 415 555 0123", "+12345", "+1234567890123456"} {
+		assert.ErrorIs(t, ValidateNumber(number), ErrInvalidNumber, number)
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty", "", 1},
+		{"one GSM segment", strings.Repeat("a", 160), 1},
+		{"two GSM segments", strings.Repeat("a", 161), 2},
+		{"three GSM segments", strings.Repeat("a", 307), 3},
+		{"extended characters take two septets", strings.Repeat("€", 80), 1},
+		{"extended characters past one segment", strings.Repeat("€", 81), 2},
+		{"one UCS-2 segment", strings.Repeat("ж", 70), 1},
+		{"two UCS-2 segments", strings.Repeat("ж", 71), 2},
+		{"one emoji makes the message UCS-2", "🔴 " + strings.Repeat("a", 67), 1},
+		{"surrogate pairs take two units", strings.Repeat("🔴", 36), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Segments(tt.body))
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	short := "Service is down"
+	assert.Equal(t, short, Fit(short, 1))
+
+	long := strings.Repeat("word ", 200)
+	fitted := Fit(long, 2)
+	assert.True(t, strings.HasSuffix(fitted, "..."))
+	assert.Equal(t, 2, Segments(fitted))
+	assert.LessOrEqual(t, len(fitted), 2*gsmPart)
+
+	fitted = Fit(strings.Repeat("€", 200), 1)
+	assert.Equal(t, 1, Segments(fitted))
+	assert.Equal(t, strings.Repeat("€", 78)+"...", fitted, "extended characters are not split")
+
+	fitted = Fit(strings.Repeat("🔴", 100), 1)
+	assert.Equal(t, 1, Segments(fitted))
+	assert.Equal(t, strings.Repeat("🔴", 33)+"...", fitted, "surrogate pairs are not split")
+}
+
+func TestSenderFitsAndValidates(t *testing.T) {
+	server, got := provider(t, http.StatusOK, `{}`)
+	defer server.Close()
+	sender := &Sender{Provider: &HTTP{Client: server.Client(), URL: server.URL}, MaxSegments: 1}
+
+	result, err := sender.Send("+14155550123", strings.Repeat("a", 400))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Segments)
+	assert.Contains(t, got.body, strings.Repeat("a", 157)+`..."`)
+
+	_, err = sender.Send("555-0123", "hi")
+	assert.ErrorIs(t, err, ErrInvalidNumber)
+}
+
+func TestTwilioSend(t *testing.T) {
+	server, got := provider(t, http.StatusCreated, `{"sid": "SM123", "status": "queued"}`)
+	defer server.Close()
+	twilio := &Twilio{
+		Client:         server.Client(),
+		BaseURL:        server.URL,
+		AccountSID:     "AC123",
+		AuthToken:      "secret",
+		From:           "+15005550006",
+		StatusCallback: "https://pulsegrid.example.com/api/v1/sms/twilio/status",
+	}
+
+	result, err := twilio.Send("+14155550123", "api is down")
+	require.NoError(t, err)
+	assert.Equal(t, Result{MessageID: "SM123", Status: "queued", StatusCode: http.StatusCreated}, result)
+	assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", got.path)
+	assert.Equal(t, "application/x-www-form-urlencoded", got.contentType)
+	assert.True(t, strings.HasPrefix(got.auth, "Basic "))
+	form, err := url.ParseQuery(got.body)
+	require.NoError(t, err)
+	assert.Equal(t, "+14155550123", form.Get("To"))
+	assert.Equal(t, "+15005550006", form.Get("From"))
+	assert.Equal(t, "api is down", form.Get("Body"))
+	assert.Equal(t, twilio.StatusCallback, form.Get("StatusCallback"))
+}
+
+func TestTwilioSendRejected(t *testing.T) {
+	server, _ := provider(t, http.StatusBadRequest, `{"code": 21211, "message": "The 'To' number is not a valid phone number."}`)
+	defer server.Close()
+	twilio := &Twilio{Client: server.Client(), BaseURL: server.URL, AccountSID: "AC123", AuthToken: "secret", MessagingServiceSID: "MG123"}
+
+	result, err := twilio.Send("+14155550123", "api is down")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+	assert.Contains(t, err.Error(), "21211")
+}
+
+func TestValidateSignature(t *testing.T) {
+	// Signed independently: HMAC-SHA1 of the URL and sorted parameters
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	callback := "https://example.com/myapp.php?foo=1&bar=2"
+	assert.True(t, ValidateSignature("12345", callback, params, "vNe7KK2kJwCsxc9K3OLkkKB3qqI="))
+	assert.False(t, ValidateSignature("12345", callback, params, "bm90IHRoZSBzaWduYXR1cmU="))
+	assert.False(t, ValidateSignature("wrong", callback, params, "vNe7KK2kJwCsxc9K3OLkkKB3qqI="))
+}
+
+func TestHTTPSend(t *testing.T) {
+	server, got := provider(t, http.StatusAccepted, `{"messages": [{"id": "msg-42"}]}`)
+	defer server.Close()
+	h := &HTTP{
+		Client:  server.Client(),
+		URL:     server.URL + "/send",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		IDField: "messages.0.id",
+	}
+
+	result, err := h.Send("+14155550123", `api is "down"`)
+	require.NoError(t, err)
+	assert.Equal(t, "msg-42", result.MessageID)
+	assert.Equal(t, StatusAccepted, result.Status)
+	assert.Equal(t, http.MethodPost, got.method)
+	assert.Equal(t, "/send", got.path)
+	assert.Equal(t, "Bearer token", got.auth)
+	assert.JSONEq(t, `{"to": "+14155550123", "message": "api is \"down\""}`, got.body)
+
+	h.Method = http.MethodPut
+	h.Template = `to={{urlquery .To}}&text={{urlquery .Body}}`
+	h.Headers = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	_, err = h.Send("+14155550123", "api down")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, got.method)
+	assert.Equal(t, "application/x-www-form-urlencoded", got.contentType)
+	assert.Equal(t, "to=%2B14155550123&text=api+down", got.body)
+
+	failing, _ := provider(t, http.StatusServiceUnavailable, ``)
+	defer failing.Close()
+	result, err = (&HTTP{Client: failing.Client(), URL: failing.URL}).Send("+14155550123", "api down")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+}
+
+func TestSNSSend(t *testing.T) {
+	server, got := provider(t, http.StatusOK, `<PublishResponse xmlns="http://sns.amazonaws.com/doc/2010-03-31/">
+  <PublishResult><MessageId>sns-7</MessageId></PublishResult>
+  <ResponseMetadata><RequestId>r-1</RequestId></ResponseMetadata>
+</PublishResponse>`)
+	defer server.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		HTTPClient:  server.Client(),
+	}))
+
+	result, err := (&SNS{Client: sns.New(sess), SenderID: "PulseGrid"}).Send("+14155550123", "api is down")
+	require.NoError(t, err)
+	assert.Equal(t, "sns-7", result.MessageID)
+	form, err := url.ParseQuery(got.body)
+	require.NoError(t, err)
+	assert.Equal(t, "Publish", form.Get("Action"))
+	assert.Equal(t, "+14155550123", form.Get("PhoneNumber"))
+	assert.Empty(t, form.Get("TopicArn"), "SMS goes to the number, not a topic")
+	assert.Equal(t, "api is down", form.Get("Message"))
+}
+
+func TestLoadConfig(t *testing.T) {
+	env := map[string]string{
+		"SMS_PROVIDER":       "Twilio",
+		"SMS_MAX_SEGMENTS":   "2",
+		"TWILIO_ACCOUNT_SID": "AC123",
+		"TWILIO_AUTH_TOKEN":  "secret",
+		"TWILIO_FROM_NUMBER": "+15005550006",
+		"SMS_HTTP_HEADERS":   `{"X-Key": "k"}`,
+	}
+	cfg, err := LoadConfig(func(key string) string { return env[key] })
+	require.NoError(t, err)
+	assert.Equal(t, ProviderTwilio, cfg.Provider)
+	assert.Equal(t, 2, cfg.MaxSegments)
+	assert.Equal(t, map[string]string{"X-Key": "k"}, cfg.HTTP.Headers)
+
+	sender, err := New(cfg, http.DefaultClient)
+	require.NoError(t, err)
+	assert.IsType(t, &Twilio{}, sender.Provider)
+
+	sender, err = New(Config{}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Nil(t, sender, "no provider logs messages instead")
+
+	_, err = New(Config{Provider: ProviderTwilio}, http.DefaultClient)
+	assert.Error(t, err)
+	_, err = New(Config{Provider: ProviderHTTP, HTTP: HTTP{URL: "https://sms.example.com", Template: "{{"}}, http.DefaultClient)
+	assert.Error(t, err)
+	_, err = New(Config{Provider: "carrier-pigeon"}, http.DefaultClient)
+	assert.Error(t, err)
+
+	env["SMS_MAX_SEGMENTS"] = "0"
+	_, err = LoadConfig(func(key string) string { return env[key] })
+	assert.Error(t, err)
+}
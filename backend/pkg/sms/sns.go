@@ -0,0 +1,54 @@
+package sms
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// SNS sends through Amazon SNS, publishing straight to the phone number
+// rather than to a topic
+type SNS struct {
+	Client snsiface.SNSAPI
+	// SenderID is the alphanumeric sender ID shown in countries that
+	// support one
+	SenderID string
+}
+
+// connect makes a client from the default AWS configuration
+func (s *SNS) connect() error {
+	sess, err := session.NewSession()
+	if err != nil {
+		return err
+	}
+	s.Client = sns.New(sess)
+	return nil
+}
+
+func (s *SNS) Send(to, body string) (Result, error) {
+	attributes := map[string]*sns.MessageAttributeValue{
+		// Alerts are sent at the transactional rate, which is delivered
+		// ahead of marketing messages
+		"AWS.SNS.SMS.SMSType": {DataType: aws.String("String"), StringValue: aws.String("Transactional")},
+	}
+	if s.SenderID != "" {
+		attributes["AWS.SNS.SMS.SenderID"] = &sns.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(s.SenderID)}
+	}
+
+	out, err := s.Client.Publish(&sns.PublishInput{
+		PhoneNumber:       aws.String(to),
+		Message:           aws.String(body),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		var result Result
+		if reqErr, ok := err.(awserr.RequestFailure); ok {
+			result.StatusCode = reqErr.StatusCode()
+		}
+		return result, err
+	}
+
+	return Result{MessageID: aws.StringValue(out.MessageId), Status: StatusAccepted, StatusCode: 200}, nil
+}
@@ -0,0 +1,128 @@
+package sms
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultTwilioURL is Twilio's REST API
+const DefaultTwilioURL = "https://api.twilio.com"
+
+// Twilio sends through the Twilio Messages API, or any API that copies it
+type Twilio struct {
+	Client *http.Client
+	// BaseURL overrides DefaultTwilioURL for compatible APIs
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	// From is the sending number; MessagingServiceSID sends from a
+	// messaging service's pool instead
+	From                string
+	MessagingServiceSID string
+	// StatusCallback is where the API posts delivery receipts
+	StatusCallback string
+}
+
+// twilioMessage is the API's answer to a send, and its error body
+type twilioMessage struct {
+	SID          string `json:"sid"`
+	Status       string `json:"status"`
+	Code         int    `json:"code"`
+	Message      string `json:"message"`
+	ErrorCode    *int   `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+func (t *Twilio) validate() error {
+	if t.AccountSID == "" || t.AuthToken == "" {
+		return fmt.Errorf("the twilio SMS provider needs TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN")
+	}
+	if t.From == "" && t.MessagingServiceSID == "" {
+		return fmt.Errorf("the twilio SMS provider needs TWILIO_FROM_NUMBER or TWILIO_MESSAGING_SERVICE_SID")
+	}
+	return nil
+}
+
+func (t *Twilio) Send(to, body string) (Result, error) {
+	form := url.Values{"To": {to}, "Body": {body}}
+	if t.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", t.MessagingServiceSID)
+	} else {
+		form.Set("From", t.From)
+	}
+	if t.StatusCallback != "" {
+		form.Set("StatusCallback", t.StatusCallback)
+	}
+
+	base := t.BaseURL
+	if base == "" {
+		base = DefaultTwilioURL
+	}
+	target := strings.TrimRight(base, "/") + "/2010-04-01/Accounts/" + url.PathEscape(t.AccountSID) + "/Messages.json"
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	result := Result{StatusCode: resp.StatusCode}
+	var msg twilioMessage
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&msg)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if msg.Message != "" {
+			return result, fmt.Errorf("twilio returned status %d: %s (error %d)", resp.StatusCode, msg.Message, msg.Code)
+		}
+		return result, fmt.Errorf("twilio returned status %d", resp.StatusCode)
+	}
+
+	result.MessageID = msg.SID
+	result.Status = msg.Status
+	if result.Status == "" {
+		result.Status = StatusQueued
+	}
+	if msg.Status == "failed" || msg.Status == "undelivered" {
+		return result, fmt.Errorf("twilio reported the message %s: %s", msg.Status, msg.ErrorMessage)
+	}
+	return result, nil
+}
+
+// ValidateSignature checks the X-Twilio-Signature of a status callback:
+// the base64 HMAC-SHA1, keyed by the auth token, of the callback URL
+// followed by each form parameter's name and value in name order
+func ValidateSignature(authToken, callbackURL string, params url.Values, signature string) bool {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(callbackURL)
+	for _, name := range names {
+		for _, value := range params[name] {
+			b.WriteString(name)
+			b.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
@@ -0,0 +1,159 @@
+package statuspage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of post an organization writes on its status page
+const (
+	KindIncident    = "incident"
+	KindMaintenance = "maintenance"
+)
+
+// Incident post statuses, in the order an incident usually moves through
+// them
+const (
+	PostInvestigating = "investigating"
+	PostIdentified    = "identified"
+	PostMonitoring    = "monitoring"
+	PostResolved      = "resolved"
+)
+
+// Scheduled maintenance statuses
+const (
+	PostScheduled  = "scheduled"
+	PostInProgress = "in_progress"
+	PostVerifying  = "verifying"
+	PostCompleted  = "completed"
+)
+
+// Impacts a post can have on the components it names
+const (
+	ImpactNone     = "none"
+	ImpactMinor    = "minor"
+	ImpactMajor    = "major"
+	ImpactCritical = "critical"
+)
+
+// Subscriber channels
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+const (
+	// PostHistory is how long a closed post stays on the page
+	PostHistory = 7 * 24 * time.Hour
+	// ConfirmTTL is how long a subscriber has to confirm before the link
+	// stops working
+	ConfirmTTL = 7 * 24 * time.Hour
+	// ConfirmResendInterval is how often a pending subscriber can be sent a
+	// new confirmation, so the form can't be used to flood an address
+	ConfirmResendInterval = 10 * time.Minute
+)
+
+var postStatuses = map[string][]string{
+	KindIncident:    {PostInvestigating, PostIdentified, PostMonitoring, PostResolved},
+	KindMaintenance: {PostScheduled, PostInProgress, PostVerifying, PostCompleted},
+}
+
+// ValidateKind checks a post's kind
+func ValidateKind(kind string) error {
+	if _, ok := postStatuses[kind]; !ok {
+		return fmt.Errorf("kind must be incident or maintenance")
+	}
+	return nil
+}
+
+// ValidatePostStatus checks status is one a post of the given kind can have
+func ValidatePostStatus(kind, status string) error {
+	for _, s := range postStatuses[kind] {
+		if s == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("status of %s must be one of %s", kind, strings.Join(postStatuses[kind], ", "))
+}
+
+// ValidateImpact checks a post's impact
+func ValidateImpact(impact string) error {
+	switch impact {
+	case ImpactNone, ImpactMinor, ImpactMajor, ImpactCritical:
+		return nil
+	default:
+		return fmt.Errorf("impact must be none, minor, major or critical")
+	}
+}
+
+// InitialStatus is the status a new post of the given kind starts in
+func InitialStatus(kind string) string {
+	if kind == KindMaintenance {
+		return PostScheduled
+	}
+	return PostInvestigating
+}
+
+// Closed reports whether status ends a post: a resolved incident or
+// completed maintenance
+func Closed(status string) bool {
+	return status == PostResolved || status == PostCompleted
+}
+
+// StatusLabel is how a post status reads in notifications, e.g. "In
+// progress" for in_progress
+func StatusLabel(status string) string {
+	if status == "" {
+		return ""
+	}
+	label := strings.ReplaceAll(status, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// PostComponentStatus is the status an open post puts the components it
+// names in: maintenance under way puts them under maintenance, and an
+// incident degrades them according to its impact. It returns
+// StatusOperational for posts that leave the components as they are, which
+// never hides a worse status their checks show.
+func PostComponentStatus(kind, status, impact string) string {
+	if Closed(status) {
+		return StatusOperational
+	}
+	if kind == KindMaintenance {
+		if status == PostInProgress || status == PostVerifying {
+			return StatusUnderMaintenance
+		}
+		return StatusOperational
+	}
+	switch impact {
+	case ImpactMinor:
+		return StatusDegraded
+	case ImpactMajor:
+		return StatusPartialOutage
+	case ImpactCritical:
+		return StatusMajorOutage
+	default:
+		return StatusOperational
+	}
+}
+
+// Worse returns the worse of two statuses
+func Worse(a, b string) string {
+	if rank(b) > rank(a) {
+		return b
+	}
+	return a
+}
+
+// NewToken returns a random token for a subscriber's confirmation and
+// unsubscribe links
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
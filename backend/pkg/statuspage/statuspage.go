@@ -0,0 +1,222 @@
+// Package statuspage works out what an organization's public status page
+// shows: each component's current status, built from the services grouped
+// into it, and the daily uptime bars drawn from their health checks.
+package statuspage
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Component and page statuses, from best to worst
+const (
+	StatusOperational      = "operational"
+	StatusUnderMaintenance = "under_maintenance"
+	StatusDegraded         = "degraded_performance"
+	StatusPartialOutage    = "partial_outage"
+	StatusMajorOutage      = "major_outage"
+	// StatusNoData marks a day without any checks
+	StatusNoData = "no_data"
+)
+
+// DefaultDays is the length of the uptime bars
+const DefaultDays = 90
+
+// MaxComponents caps the components on one page
+const MaxComponents = 50
+
+// rank orders statuses by how bad they are
+func rank(status string) int {
+	switch status {
+	case StatusUnderMaintenance:
+		return 1
+	case StatusDegraded:
+		return 2
+	case StatusPartialOutage:
+		return 3
+	case StatusMajorOutage:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ServiceStatus maps a service's last check (up, degraded or down) to the
+// status shown for it. A service in a maintenance window is under
+// maintenance whatever its checks say, and one never checked counts as
+// operational.
+func ServiceStatus(lastStatus string, inMaintenance bool) string {
+	switch {
+	case inMaintenance:
+		return StatusUnderMaintenance
+	case lastStatus == "down":
+		return StatusMajorOutage
+	case lastStatus == "degraded":
+		return StatusDegraded
+	default:
+		return StatusOperational
+	}
+}
+
+// ComponentStatus combines the statuses of a component's services. Some of
+// them down is a partial outage, all of them a major one. Services under
+// maintenance are left out, unless every service is.
+func ComponentStatus(services []string) string {
+	var counted, down int
+	status := StatusOperational
+	for _, s := range services {
+		if s == StatusUnderMaintenance {
+			continue
+		}
+		counted++
+		if s == StatusMajorOutage {
+			down++
+		} else if rank(s) > rank(status) {
+			status = s
+		}
+	}
+
+	switch {
+	case counted == 0 && len(services) > 0:
+		return StatusUnderMaintenance
+	case down > 0 && down == counted:
+		return StatusMajorOutage
+	case down > 0:
+		return StatusPartialOutage
+	default:
+		return status
+	}
+}
+
+// Overall is the worst of the components' statuses, shown at the top of the
+// page
+func Overall(components []string) string {
+	status := StatusOperational
+	for _, s := range components {
+		if rank(s) > rank(status) {
+			status = s
+		}
+	}
+	return status
+}
+
+// Day counts a service's checks on one UTC day. Checks during maintenance
+// windows count towards neither uptime nor downtime.
+type Day struct {
+	Date        time.Time
+	Total       int
+	Up          int
+	Degraded    int
+	Down        int
+	Maintenance int
+}
+
+// Bar is one day of a component's uptime bar. UptimePercent is nil for a
+// day without checks.
+type Bar struct {
+	Date          string   `json:"date"`
+	UptimePercent *float64 `json:"uptime_percent"`
+	Status        string   `json:"status"`
+}
+
+// Bars lays the checks of a component's services out as one bar per day
+// for the days days up to and including now's, oldest first, and returns
+// the uptime over all of them. Counts for the same day are added together.
+// uptime is nil when there were no checks.
+func Bars(checks []Day, now time.Time, days int) (bars []Bar, uptime *float64) {
+	byDate := make(map[string]Day, len(checks))
+	for _, d := range checks {
+		key := d.Date.UTC().Format("2006-01-02")
+		sum := byDate[key]
+		sum.Total += d.Total
+		sum.Up += d.Up
+		sum.Degraded += d.Degraded
+		sum.Down += d.Down
+		sum.Maintenance += d.Maintenance
+		byDate[key] = sum
+	}
+
+	today := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	var all Day
+	bars = make([]Bar, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		key := today.AddDate(0, 0, -i).Format("2006-01-02")
+		d := byDate[key]
+		bar := Bar{Date: key, UptimePercent: d.uptime(), Status: d.status()}
+		bars = append(bars, bar)
+
+		all.Total += d.Total
+		all.Up += d.Up
+		all.Maintenance += d.Maintenance
+	}
+	return bars, all.uptime()
+}
+
+// uptime is the share of counted checks that were up, 100 for a day spent
+// entirely in maintenance, and nil for a day without checks
+func (d Day) uptime() *float64 {
+	if d.Total == 0 {
+		return nil
+	}
+	percent := 100.0
+	if counted := d.Total - d.Maintenance; counted > 0 {
+		percent = float64(d.Up) / float64(counted) * 100
+	}
+	return &percent
+}
+
+// status summarizes a day: any downtime is an outage, a major one once
+// uptime drops below 95%
+func (d Day) status() string {
+	uptime := d.uptime()
+	switch {
+	case uptime == nil:
+		return StatusNoData
+	case d.Down > 0 && *uptime < 95:
+		return StatusMajorOutage
+	case d.Down > 0:
+		return StatusPartialOutage
+	case d.Degraded > 0:
+		return StatusDegraded
+	default:
+		return StatusOperational
+	}
+}
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// ValidateSlug checks a page's slug, the last part of its /status/:slug
+// address: lowercase letters, digits and inner hyphens, at most 63 long
+func ValidateSlug(slug string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("slug must be 1 to 63 lowercase letters, digits or hyphens, not starting or ending with a hyphen")
+	}
+	return nil
+}
+
+// ValidateColor checks a branding color, which must be #RRGGBB. Empty keeps
+// the default.
+func ValidateColor(color string) error {
+	if color != "" && !colorPattern.MatchString(color) {
+		return fmt.Errorf("brand_color must be a hex color like #1f6feb")
+	}
+	return nil
+}
+
+// ValidateURL checks a branding link, such as the logo or support page,
+// which must be http or https. Empty leaves it out.
+func ValidateURL(field, link string) error {
+	if link == "" {
+		return nil
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an http or https URL", field)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package statuspage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceStatus(t *testing.T) {
+	assert.Equal(t, StatusOperational, ServiceStatus("up", false))
+	assert.Equal(t, StatusOperational, ServiceStatus("", false), "never checked")
+	assert.Equal(t, StatusDegraded, ServiceStatus("degraded", false))
+	assert.Equal(t, StatusMajorOutage, ServiceStatus("down", false))
+	assert.Equal(t, StatusUnderMaintenance, ServiceStatus("down", true))
+}
+
+func TestComponentStatus(t *testing.T) {
+	tests := []struct {
+		services []string
+		want     string
+	}{
+		{nil, StatusOperational},
+		{[]string{StatusOperational, StatusOperational}, StatusOperational},
+		{[]string{StatusOperational, StatusDegraded}, StatusDegraded},
+		{[]string{StatusOperational, StatusMajorOutage}, StatusPartialOutage},
+		{[]string{StatusMajorOutage, StatusMajorOutage}, StatusMajorOutage},
+		{[]string{StatusMajorOutage, StatusUnderMaintenance}, StatusMajorOutage},
+		{[]string{StatusUnderMaintenance, StatusOperational}, StatusOperational},
+		{[]string{StatusUnderMaintenance, StatusUnderMaintenance}, StatusUnderMaintenance},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ComponentStatus(tt.services), "%v", tt.services)
+	}
+}
+
+func TestOverall(t *testing.T) {
+	assert.Equal(t, StatusOperational, Overall(nil))
+	assert.Equal(t, StatusPartialOutage, Overall([]string{StatusDegraded, StatusPartialOutage, StatusUnderMaintenance}))
+}
+
+func TestBars(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time { return time.Date(2024, 3, 10+offset, 0, 0, 0, 0, time.UTC) }
+
+	checks := []Day{
+		// Two services on the same day are added together
+		{Date: day(0), Total: 10, Up: 10},
+		{Date: day(0), Total: 10, Up: 9, Down: 1},
+		{Date: day(-1), Total: 10, Up: 9, Degraded: 1},
+		{Date: day(-2), Total: 10, Up: 5, Down: 5},
+		{Date: day(-3), Total: 4, Maintenance: 4},
+		// Outside the bars
+		{Date: day(-10), Total: 10, Down: 10},
+	}
+
+	bars, uptime := Bars(checks, now, 5)
+	require.Len(t, bars, 5)
+	assert.Equal(t, "2024-03-06", bars[0].Date)
+	assert.Equal(t, "2024-03-10", bars[4].Date)
+
+	assert.Nil(t, bars[0].UptimePercent)
+	assert.Equal(t, StatusNoData, bars[0].Status)
+	assert.Equal(t, 100.0, *bars[1].UptimePercent, "maintenance is not downtime")
+	assert.Equal(t, StatusOperational, bars[1].Status)
+	assert.Equal(t, StatusMajorOutage, bars[2].Status)
+	assert.Equal(t, StatusDegraded, bars[3].Status)
+	assert.Equal(t, 95.0, *bars[4].UptimePercent)
+	assert.Equal(t, StatusPartialOutage, bars[4].Status)
+
+	require.NotNil(t, uptime)
+	assert.InDelta(t, 33.0/40*100, *uptime, 0.001)
+
+	_, uptime = Bars(nil, now, 5)
+	assert.Nil(t, uptime)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, ValidateSlug("acme"))
+	assert.NoError(t, ValidateSlug("acme-cloud-2"))
+	assert.Error(t, ValidateSlug(""))
+	assert.Error(t, ValidateSlug("-acme"))
+	assert.Error(t, ValidateSlug("Acme"))
+	assert.Error(t, ValidateSlug("acme/status"))
+
+	assert.NoError(t, ValidateColor(""))
+	assert.NoError(t, ValidateColor("#1F6feb"))
+	assert.Error(t, ValidateColor("blue"))
+
+	assert.NoError(t, ValidateURL("logo_url", "https://cdn.example.com/logo.svg"))
+	assert.NoError(t, ValidateURL("logo_url", ""))
+	assert.Error(t, ValidateURL("logo_url", "javascript:alert(1)"))
+}
+
+func TestPostStatuses(t *testing.T) {
+	assert.NoError(t, ValidatePostStatus(KindIncident, PostIdentified))
+	assert.Error(t, ValidatePostStatus(KindIncident, PostInProgress))
+	assert.NoError(t, ValidatePostStatus(KindMaintenance, PostCompleted))
+	assert.Error(t, ValidatePostStatus(KindMaintenance, PostResolved))
+	assert.Error(t, ValidateKind("outage"))
+	assert.Error(t, ValidateImpact("severe"))
+
+	assert.Equal(t, PostInvestigating, InitialStatus(KindIncident))
+	assert.Equal(t, PostScheduled, InitialStatus(KindMaintenance))
+	assert.True(t, Closed(PostResolved))
+	assert.False(t, Closed(PostMonitoring))
+	assert.Equal(t, "In progress", StatusLabel(PostInProgress))
+}
+
+func TestPostComponentStatus(t *testing.T) {
+	assert.Equal(t, StatusPartialOutage, PostComponentStatus(KindIncident, PostIdentified, ImpactMajor))
+	assert.Equal(t, StatusOperational, PostComponentStatus(KindIncident, PostResolved, ImpactCritical))
+	assert.Equal(t, StatusOperational, PostComponentStatus(KindMaintenance, PostScheduled, ImpactMajor))
+	assert.Equal(t, StatusUnderMaintenance, PostComponentStatus(KindMaintenance, PostInProgress, ImpactNone))
+
+	// A post never hides a worse status the checks show
+	assert.Equal(t, StatusMajorOutage, Worse(StatusMajorOutage, StatusDegraded))
+	assert.Equal(t, StatusUnderMaintenance, Worse(StatusOperational, StatusUnderMaintenance))
+}
@@ -0,0 +1,79 @@
+// Package verification confirms that a subscription's destination reaches
+// someone before alerts are sent to it. Email and SMS destinations are
+// confirmed with a code sent to them; HTTP destinations (webhooks, chat and
+// paging integrations) with a test message that must be accepted.
+package verification
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"pulsegrid/backend/pkg/sms"
+)
+
+// Subscription verification statuses. Only verified subscriptions are
+// notified.
+const (
+	StatusPending  = "pending"
+	StatusVerified = "verified"
+)
+
+const (
+	// CodeTTL is how long a confirmation code can be used
+	CodeTTL = 24 * time.Hour
+	// MaxAttempts is how many wrong codes are accepted before a new code
+	// has to be sent
+	MaxAttempts = 5
+	// FailureLimit is how many notifications in a row can be dead-lettered
+	// before their subscription is disabled
+	FailureLimit = 3
+)
+
+var (
+	ErrNoCode          = errors.New("no confirmation code has been sent; send a test message first")
+	ErrExpired         = errors.New("confirmation code has expired; send a test message for a new one")
+	ErrTooManyAttempts = errors.New("too many wrong codes; send a test message for a new one")
+	ErrMismatch        = errors.New("confirmation code is incorrect")
+)
+
+// ByCode reports whether a channel's destinations are confirmed with a code
+// rather than a test message
+func ByCode(channel string) bool {
+	return channel == "email" || channel == "sms"
+}
+
+// ValidatePhoneNumber checks an SMS destination is an E.164 phone number
+func ValidatePhoneNumber(number string) error {
+	return sms.ValidateNumber(number)
+}
+
+// NewCode returns a random six-digit confirmation code
+func NewCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// Check compares a submitted code with the one sent, which expires at
+// expiresAt and has had attempts wrong guesses so far
+func Check(sent string, expiresAt *time.Time, attempts int, submitted string, now time.Time) error {
+	if sent == "" || expiresAt == nil {
+		return ErrNoCode
+	}
+	if attempts >= MaxAttempts {
+		return ErrTooManyAttempts
+	}
+	if now.After(*expiresAt) {
+		return ErrExpired
+	}
+	if subtle.ConstantTimeCompare([]byte(sent), []byte(submitted)) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
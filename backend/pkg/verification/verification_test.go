@@ -0,0 +1,42 @@
+package verification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCode(t *testing.T) {
+	code, err := NewCode()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9]{6}$`, code)
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(CodeTTL)
+
+	assert.NoError(t, Check("042137", &expires, 0, "042137", now))
+	assert.Equal(t, ErrMismatch, Check("042137", &expires, 0, "042138", now))
+	assert.Equal(t, ErrNoCode, Check("", nil, 0, "042137", now))
+	assert.Equal(t, ErrExpired, Check("042137", &expires, 0, "042137", expires.Add(time.Second)))
+	assert.Equal(t, ErrTooManyAttempts, Check("042137", &expires, MaxAttempts, "042137", now),
+		"the right code is refused once the attempts run out")
+}
+
+func TestByCode(t *testing.T) {
+	assert.True(t, ByCode("email"))
+	assert.True(t, ByCode("sms"))
+	assert.False(t, ByCode("webhook"))
+	assert.False(t, ByCode("slack"))
+}
+
+func TestValidatePhoneNumber(t *testing.T) {
+	assert.NoError(t, ValidatePhoneNumber("+14155550123"))
+	assert.NoError(t, ValidatePhoneNumber("+447700900123"))
+	assert.Error(t, ValidatePhoneNumber("4155550123"), "needs the country code")
+	assert.Error(t, ValidatePhoneNumber("+1 415 555 0123"))
+	assert.Error(t, ValidatePhoneNumber("+0123456789"))
+}
@@ -0,0 +1,167 @@
+// Package webhook renders, signs and posts webhook notifications, signing
+// every one the same way so receivers verify them alike.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Headers set on every signed request. The signature is the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the subscription's secret; receivers
+// should recompute it and reject timestamps more than a few minutes old so a
+// captured request cannot be replayed.
+const (
+	SignatureHeader = "X-PulseGrid-Signature"
+	TimestampHeader = "X-PulseGrid-Timestamp"
+)
+
+// Events
+const (
+	EventAlertTriggered = "alert.triggered"
+	EventAlertResolved  = "alert.resolved"
+	EventTest           = "test"
+)
+
+// MaxHeaders caps the custom headers on a subscription
+const MaxHeaders = 20
+
+// reservedHeaders are set by the sender and cannot be overridden, keyed in
+// lower case
+var reservedHeaders = map[string]bool{
+	"content-length":                 true,
+	"host":                           true,
+	"transfer-encoding":              true,
+	strings.ToLower(SignatureHeader): true,
+	strings.ToLower(TimestampHeader): true,
+}
+
+// Payload is the default request body, and the data a custom template is
+// executed against. Alert is the alert the notification is about, if any.
+type Payload struct {
+	Event   string      `json:"event"`
+	Subject string      `json:"subject"`
+	Message string      `json:"message"`
+	Alert   interface{} `json:"alert,omitempty"`
+	SentAt  time.Time   `json:"sent_at"`
+}
+
+// Render builds a request body: the payload as JSON, or the output of tmpl
+// executed against it
+func Render(tmpl string, payload Payload) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		body, err := json.Marshal(payload)
+		return string(body), err
+	}
+
+	t, err := template.New("webhook").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var body bytes.Buffer
+	if err := t.Execute(&body, payload); err != nil {
+		return "", err
+	}
+	return body.String(), nil
+}
+
+// ValidateURL checks a webhook destination is an absolute http(s) URL
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("destination must be an http or https URL")
+	}
+	return nil
+}
+
+// ValidateTemplate parses tmpl and executes it against a sample payload, so
+// a template that cannot render is rejected when it is saved rather than
+// when an alert fires
+func ValidateTemplate(tmpl string, sample Payload) error {
+	if _, err := Render(tmpl, sample); err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	return nil
+}
+
+// ValidateHeaders checks custom headers are well formed and do not replace
+// the ones the sender sets
+func ValidateHeaders(headers map[string]string) error {
+	if len(headers) > MaxHeaders {
+		return fmt.Errorf("at most %d headers are allowed", MaxHeaders)
+	}
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " :\t\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("header %s must not contain line breaks", name)
+		}
+		if reservedHeaders[strings.ToLower(name)] {
+			return fmt.Errorf("header %s is set by PulseGrid and cannot be overridden", name)
+		}
+	}
+	return nil
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature of body sent at timestamp (Unix seconds)
+func Sign(secret string, timestamp int64, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Post sends body to target with the custom headers, signed with secret when
+// one is set. It returns the response status code, or 0 if no response
+// arrived; any status outside 2xx is an error.
+func Post(client *http.Client, target, body string, headers map[string]string, secret string, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PulseGrid-Webhook/1.0")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if secret != "" {
+		timestamp := now.Unix()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type alert struct {
+	Severity string `json:"severity"`
+}
+
+func TestRender(t *testing.T) {
+	payload := Payload{Event: EventAlertTriggered, Subject: "Down", Message: "api is down", Alert: &alert{Severity: "high"}, SentAt: now}
+
+	body, err := Render("", payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event":"alert.triggered","subject":"Down","message":"api is down","alert":{"severity":"high"},"sent_at":"2024-03-01T12:00:00Z"}`, body)
+
+	body, err = Render(`{"summary":"{{.Message}}","level":"{{.Alert.Severity}}"}`, payload)
+	require.NoError(t, err)
+	assert.Equal(t, `{"summary":"api is down","level":"high"}`, body)
+
+	_, err = Render("{{.Nope}}", payload)
+	assert.Error(t, err)
+	assert.Error(t, ValidateTemplate("{{if}}", payload))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://hooks.example.com/pulsegrid"))
+	assert.Error(t, ValidateURL("ftp://example.com"))
+	assert.Error(t, ValidateURL("/relative"))
+
+	assert.NoError(t, ValidateHeaders(map[string]string{"Authorization": "Bearer token"}))
+	assert.Error(t, ValidateHeaders(map[string]string{"x-pulsegrid-signature": "forged"}))
+	assert.Error(t, ValidateHeaders(map[string]string{"Bad Name": "x"}))
+	assert.Error(t, ValidateHeaders(map[string]string{"X-Ok": "a\r\nInjected: yes"}))
+}
+
+func TestPost(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	code, err := Post(server.Client(), server.URL, `{"ok":true}`, map[string]string{"X-Team": "sre"}, "secret", now)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, `{"ok":true}`, gotBody)
+	assert.Equal(t, "sre", got.Header.Get("X-Team"))
+	assert.Equal(t, "1709294400", got.Header.Get(TimestampHeader))
+	assert.Equal(t, "sha256="+Sign("secret", now.Unix(), `{"ok":true}`), got.Header.Get(SignatureHeader))
+
+	status = http.StatusBadGateway
+	code, err = Post(server.Client(), server.URL, "{}", nil, "", now)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, code)
+	assert.Empty(t, got.Header.Get(SignatureHeader), "unsigned without a secret")
+}
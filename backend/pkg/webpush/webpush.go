@@ -0,0 +1,239 @@
+// Package webpush sends Web Push messages to browsers: payloads encrypted
+// for the subscription (RFC 8291) and requests signed with the application
+// server's VAPID key (RFC 8292).
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Urgency tells the push service how soon to wake the device
+const (
+	UrgencyLow    = "low"
+	UrgencyNormal = "normal"
+	UrgencyHigh   = "high"
+)
+
+// DefaultTTL is how long a push service keeps a message for a browser that
+// is offline
+const DefaultTTL = 24 * time.Hour
+
+// ErrGone is returned when the push service says the subscription no longer
+// exists (404 or 410). It should be deleted.
+var ErrGone = errors.New("push subscription has expired or been unsubscribed")
+
+// Subscription is a browser's PushSubscription, as its toJSON() returns it
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		// P256dh is the browser's public key, Auth its auth secret, both
+		// base64url encoded
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// Validate checks a subscription has an HTTPS endpoint and keys that
+// payloads can be encrypted with. Plain HTTP is allowed on the loopback
+// interface, for a push service stand-in run locally.
+func (s Subscription) Validate() error {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname()))) {
+		return fmt.Errorf("endpoint must be an https URL")
+	}
+	key, err := decodeKey(s.Keys.P256dh)
+	if err == nil {
+		_, err = ecdh.P256().NewPublicKey(key)
+	}
+	if err != nil {
+		return fmt.Errorf("keys.p256dh must be a base64url P-256 public key")
+	}
+	if auth, err := decodeKey(s.Keys.Auth); err != nil || len(auth) != 16 {
+		return fmt.Errorf("keys.auth must be a base64url 16-byte secret")
+	}
+	return nil
+}
+
+// Keys is an application server's VAPID key pair, base64url encoded: the
+// uncompressed P-256 public key browsers subscribe with, and the private
+// scalar
+type Keys struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+// GenerateKeys makes a new VAPID key pair
+func GenerateKeys() (Keys, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return Keys{}, err
+	}
+	return Keys{
+		PublicKey:  encodeKey(key.PublicKey().Bytes()),
+		PrivateKey: encodeKey(key.Bytes()),
+	}, nil
+}
+
+// signingKey returns the private key as an ECDSA key for signing VAPID
+// tokens, checking it matches the public key
+func (k Keys) signingKey() (*ecdsa.PrivateKey, error) {
+	scalar, err := decodeKey(k.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key")
+	}
+	private, err := ecdh.P256().NewPrivateKey(scalar)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key")
+	}
+	public := private.PublicKey().Bytes()
+	if k.PublicKey != encodeKey(public) {
+		return nil, fmt.Errorf("VAPID public key does not match the private key")
+	}
+
+	// The uncompressed point is 0x04 || X || Y
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(scalar),
+	}, nil
+}
+
+// Validate checks the key pair is usable
+func (k Keys) Validate() error {
+	_, err := k.signingKey()
+	return err
+}
+
+// Message is one push to one subscription
+type Message struct {
+	Payload []byte
+	// TTL defaults to DefaultTTL
+	TTL time.Duration
+	// Urgency defaults to normal
+	Urgency string
+	// Topic replaces an undelivered message with the same topic; at most
+	// 32 base64url characters
+	Topic string
+}
+
+// Sender sends push messages signed with its VAPID keys
+type Sender struct {
+	Client *http.Client
+	Keys   Keys
+	// Subject is a mailto: or https: contact for the push service
+	Subject string
+}
+
+// Send encrypts and posts a message to a subscription's push service. It
+// returns the response status code, or 0 if no response arrived, and
+// ErrGone when the subscription should be deleted.
+func (s *Sender) Send(sub Subscription, msg Message) (int, error) {
+	body, err := Encrypt(sub, msg.Payload)
+	if err != nil {
+		return 0, err
+	}
+	authorization, err := s.authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ttl := msg.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	urgency := msg.Urgency
+	if urgency == "" {
+		urgency = UrgencyNormal
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", urgency)
+	if msg.Topic != "" {
+		req.Header.Set("Topic", msg.Topic)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return resp.StatusCode, ErrGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return resp.StatusCode, fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// authorization returns the VAPID Authorization header for an endpoint: a
+// JWT for the push service's origin, signed with ES256, and the public key
+func (s *Sender) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	key, err := s.Keys.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		// Push services reject tokens valid for more than a day
+		"exp": now.Add(12 * time.Hour).Unix(),
+	}
+	if s.Subject != "" {
+		claims["sub"] = s.Subject
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(key)
+	if err != nil {
+		return "", err
+	}
+	return "vapid t=" + token + ", k=" + s.Keys.PublicKey, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// decodeKey reads base64url, with or without padding
+func decodeKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func encodeKey(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,214 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The example from RFC 8291 Appendix A
+const (
+	rfcPlaintext = "When I grow up, I want to be a watermelon"
+	rfcASPrivate = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfcUAPublic  = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfcUAPrivate = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	rfcSalt      = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfcAuth      = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfcMessage   = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func rfcSubscription(endpoint string) Subscription {
+	sub := Subscription{Endpoint: endpoint}
+	sub.Keys.P256dh = rfcUAPublic
+	sub.Keys.Auth = rfcAuth
+	return sub
+}
+
+func mustDecode(t *testing.T, s string) []byte {
+	b, err := decodeKey(s)
+	require.NoError(t, err)
+	return b
+}
+
+// decrypt does what a browser does with a push message
+func decrypt(t *testing.T, uaPrivate, auth string, message []byte) []byte {
+	require.Greater(t, len(message), headerSize)
+	salt := message[:16]
+	assert.Equal(t, uint32(recordSize), binary.BigEndian.Uint32(message[16:20]))
+	require.Equal(t, byte(65), message[20])
+	asPublicBytes := message[21:headerSize]
+
+	private, err := ecdh.P256().NewPrivateKey(mustDecode(t, uaPrivate))
+	require.NoError(t, err)
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	require.NoError(t, err)
+	secret, err := private.ECDH(asPublic)
+	require.NoError(t, err)
+
+	info := append([]byte("WebPush: info\x00"), private.PublicKey().Bytes()...)
+	info = append(info, asPublicBytes...)
+	ikm, err := expand(mustDecode(t, auth), secret, info, 32)
+	require.NoError(t, err)
+	cek, err := expand(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	require.NoError(t, err)
+	nonce, err := expand(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(cek)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	record, err := gcm.Open(nil, nonce, message[headerSize:], nil)
+	require.NoError(t, err)
+
+	record = bytes.TrimRight(record, "\x00")
+	require.Equal(t, byte(0x02), record[len(record)-1], "single final record")
+	return record[:len(record)-1]
+}
+
+func TestEncryptRFC8291Example(t *testing.T) {
+	random := io.MultiReader(bytes.NewReader(mustDecode(t, rfcSalt)), bytes.NewReader(mustDecode(t, rfcASPrivate)))
+
+	message, err := encrypt(rfcSubscription("https://push.example.net/push/JzLQ3raZJfFBR0aqvOMsLrt54w4rJUsV"), []byte(rfcPlaintext), random)
+	require.NoError(t, err)
+	assert.Equal(t, rfcMessage, encodeKey(message))
+	assert.Equal(t, rfcPlaintext, string(decrypt(t, rfcUAPrivate, rfcAuth, message)))
+}
+
+func TestEncryptLimits(t *testing.T) {
+	sub := rfcSubscription("https://push.example.net/push/1")
+
+	message, err := Encrypt(sub, bytes.Repeat([]byte("a"), MaxPayload))
+	require.NoError(t, err)
+	assert.Equal(t, recordSize, len(message))
+
+	_, err = Encrypt(sub, bytes.Repeat([]byte("a"), MaxPayload+1))
+	assert.Error(t, err)
+
+	sub.Keys.Auth = "c2hvcnQ"
+	_, err = Encrypt(sub, []byte("hi"))
+	assert.Error(t, err)
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	assert.NoError(t, rfcSubscription("https://fcm.googleapis.com/fcm/send/abc").Validate())
+	assert.Error(t, rfcSubscription("http://push.example.net/push/1").Validate())
+	assert.NoError(t, rfcSubscription("http://127.0.0.1:8090/push/1").Validate(), "local stand-ins may use plain HTTP")
+	assert.Error(t, rfcSubscription("").Validate())
+
+	sub := rfcSubscription("https://push.example.net/push/1")
+	sub.Keys.P256dh = "BAAA"
+	assert.Error(t, sub.Validate())
+
+	// Padded base64url, as some browsers send it, is accepted
+	sub = rfcSubscription("https://push.example.net/push/1")
+	sub.Keys.Auth += "=="
+	assert.NoError(t, sub.Validate())
+}
+
+func TestKeys(t *testing.T) {
+	keys, err := GenerateKeys()
+	require.NoError(t, err)
+	assert.NoError(t, keys.Validate())
+	assert.Len(t, mustDecode(t, keys.PublicKey), 65)
+
+	other, err := GenerateKeys()
+	require.NoError(t, err)
+	assert.Error(t, Keys{PublicKey: keys.PublicKey, PrivateKey: other.PrivateKey}.Validate())
+	assert.Error(t, Keys{PublicKey: keys.PublicKey, PrivateKey: "not a key"}.Validate())
+}
+
+type pushRequest struct {
+	path    string
+	header  http.Header
+	payload []byte
+}
+
+// pushService stands in for a browser vendor's push service, decrypting
+// each message as the browser would and answering with status
+func pushService(t *testing.T, status int) (*httptest.Server, *pushRequest) {
+	got := &pushRequest{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.Path
+		got.header = r.Header.Clone()
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		got.payload = decrypt(t, rfcUAPrivate, rfcAuth, body)
+		w.WriteHeader(status)
+	}))
+	return server, got
+}
+
+func TestSend(t *testing.T) {
+	server, got := pushService(t, http.StatusCreated)
+	defer server.Close()
+	keys, err := GenerateKeys()
+	require.NoError(t, err)
+	sender := &Sender{Client: server.Client(), Keys: keys, Subject: "mailto:ops@example.com"}
+
+	status, err := sender.Send(rfcSubscription(server.URL+"/push/abc"), Message{
+		Payload: []byte(`{"title": "api is down"}`),
+		Urgency: UrgencyHigh,
+		Topic:   "alert1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "/push/abc", got.path)
+	assert.Equal(t, `{"title": "api is down"}`, string(got.payload))
+	assert.Equal(t, "aes128gcm", got.header.Get("Content-Encoding"))
+	assert.Equal(t, "86400", got.header.Get("TTL"))
+	assert.Equal(t, "high", got.header.Get("Urgency"))
+	assert.Equal(t, "alert1", got.header.Get("Topic"))
+
+	// The VAPID token is signed with the key browsers subscribed with, for
+	// the push service's origin
+	auth := got.header.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "vapid t="))
+	parts := strings.SplitN(strings.TrimPrefix(auth, "vapid t="), ", k=", 2)
+	require.Len(t, parts, 2)
+	assert.Equal(t, keys.PublicKey, parts[1])
+	signing, err := keys.signingKey()
+	require.NoError(t, err)
+	token, err := jwt.Parse(parts[0], func(*jwt.Token) (interface{}, error) { return &signing.PublicKey, nil },
+		jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(server.URL))
+	require.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, "mailto:ops@example.com", claims["sub"])
+	exp, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(12*time.Hour), exp.Time, time.Minute)
+}
+
+func TestSendGone(t *testing.T) {
+	keys, err := GenerateKeys()
+	require.NoError(t, err)
+
+	for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+		server, _ := pushService(t, status)
+		sender := &Sender{Client: server.Client(), Keys: keys}
+		got, err := sender.Send(rfcSubscription(server.URL+"/push/abc"), Message{Payload: []byte("hi")})
+		assert.ErrorIs(t, err, ErrGone)
+		assert.Equal(t, status, got)
+		server.Close()
+	}
+
+	server, _ := pushService(t, http.StatusTooManyRequests)
+	defer server.Close()
+	sender := &Sender{Client: server.Client(), Keys: keys}
+	status, err := sender.Send(rfcSubscription(server.URL+"/push/abc"), Message{Payload: []byte("hi")})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrGone)
+	assert.Equal(t, http.StatusTooManyRequests, status)
+}
@@ -4,16 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"net"
-	"net/http"
-	"os"
 	"time"
 
 	"pulsegrid/workers/internal/checker"
 	"pulsegrid/workers/internal/database"
 	"pulsegrid/workers/internal/models"
-	"pulsegrid/workers/internal/notifier"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	_ "github.com/lib/pq"
@@ -40,28 +35,10 @@ func handler(ctx context.Context, event Event) error {
 	// Perform health check
 	result := performHealthCheck(service)
 
-	// Save health check result
+	// Queue the result; the backend records it exactly like its own checks,
+	// flagging maintenance, tracking the adaptive interval and alerting
 	if err := saveHealthCheck(db, service.ID, result); err != nil {
-		log.Printf("Failed to save health check: %v", err)
-	}
-
-	// Check if alert should be triggered
-	if result.Status == "down" {
-		// Get previous check
-		prevCheck, _ := getLastHealthCheck(db, service.ID)
-		
-		// Only alert if previous check was up (new downtime)
-		if prevCheck == nil || prevCheck.Status == "up" {
-			if err := triggerAlert(db, service, result); err != nil {
-				log.Printf("Failed to trigger alert: %v", err)
-			}
-		}
-	} else if result.Status == "up" {
-		// Check if we should resolve a previous alert
-		prevCheck, _ := getLastHealthCheck(db, service.ID)
-		if prevCheck != nil && prevCheck.Status == "down" {
-			// Service recovered, could resolve alerts here
-		}
+		return fmt.Errorf("failed to save health check: %w", err)
 	}
 
 	return nil
@@ -112,10 +89,12 @@ func performHealthCheck(service *models.Service) *checker.HealthCheckResult {
 	}
 }
 
+// saveHealthCheck queues result in pending_health_checks for the backend to
+// record
 func saveHealthCheck(db *sql.DB, serviceID string, result *checker.HealthCheckResult) error {
 	query := `
-		INSERT INTO health_checks (id, service_id, status, response_time_ms, status_code, error_message, checked_at)
-		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6)
+		INSERT INTO pending_health_checks (id, service_id, status, response_time_ms, status_code, error_message, resolved_ip, checked_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7)
 	`
 
 	var statusCode *int
@@ -127,102 +106,12 @@ func saveHealthCheck(db *sql.DB, serviceID string, result *checker.HealthCheckRe
 	_, err := db.Exec(
 		query,
 		serviceID, result.Status, result.ResponseTimeMs, statusCode,
-		result.ErrorMessage, time.Now(),
+		result.ErrorMessage, result.ResolvedIP, time.Now().UTC(),
 	)
 
 	return err
 }
 
-func getLastHealthCheck(db *sql.DB, serviceID string) (*checker.HealthCheckResult, error) {
-	query := `
-		SELECT status, response_time_ms, status_code, error_message
-		FROM health_checks
-		WHERE service_id = $1
-		ORDER BY checked_at DESC
-		LIMIT 1
-	`
-
-	var status string
-	var responseTime, statusCode sql.NullInt64
-	var errorMsg sql.NullString
-
-	err := db.QueryRow(query, serviceID).Scan(&status, &responseTime, &statusCode, &errorMsg)
-	if err != nil {
-		return nil, err
-	}
-
-	result := &checker.HealthCheckResult{Status: status}
-	if responseTime.Valid {
-		rt := int(responseTime.Int64)
-		result.ResponseTimeMs = &rt
-	}
-	if statusCode.Valid {
-		sc := int(statusCode.Int64)
-		result.StatusCode = &sc
-	}
-	if errorMsg.Valid {
-		result.ErrorMessage = &errorMsg.String
-	}
-
-	return result, nil
-}
-
-func triggerAlert(db *sql.DB, service *models.Service, result *checker.HealthCheckResult) error {
-	// Create alert record
-	alertQuery := `
-		INSERT INTO alerts (id, service_id, type, message, severity, is_resolved, created_at)
-		VALUES (gen_random_uuid(), $1, $2, $3, $4, FALSE, $5)
-		RETURNING id
-	`
-
-	var alertID string
-	message := fmt.Sprintf("Service %s is down: %s", service.Name, service.URL)
-	if result.ErrorMessage != nil {
-		message += fmt.Sprintf(" - %s", *result.ErrorMessage)
-	}
-
-	err := db.QueryRow(
-		alertQuery, service.ID, "downtime", message, "high", time.Now(),
-	).Scan(&alertID)
-
-	if err != nil {
-		return err
-	}
-
-	// Get alert subscriptions
-	subsQuery := `
-		SELECT channel, destination
-		FROM alert_subscriptions
-		WHERE organization_id = $1 AND (service_id = $2 OR service_id IS NULL) AND is_active = TRUE
-	`
-
-	rows, err := db.Query(subsQuery, service.OrganizationID, service.ID)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-
-	// Send notifications
-	notifier := notifier.NewNotifier()
-	for rows.Next() {
-		var channel, destination string
-		if err := rows.Scan(&channel, &destination); err != nil {
-			continue
-		}
-
-		switch channel {
-		case "email":
-			notifier.SendEmail(destination, "Service Down Alert", message)
-		case "sms":
-			notifier.SendSMS(destination, message)
-		case "slack":
-			notifier.SendSlack(destination, message)
-		}
-	}
-
-	return nil
-}
-
 func main() {
 	lambda.Start(handler)
 }
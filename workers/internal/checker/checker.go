@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -12,12 +15,63 @@ type HealthCheckResult struct {
 	ResponseTimeMs *int
 	StatusCode    *int
 	ErrorMessage  *string
+	ResolvedIP    *string // address dialed, also set when the connection failed; unset behind a proxy
+}
+
+// ipRecorder remembers the last address a dialer connected to, so results
+// carry the IP even when the connection or request fails. Requests sent
+// through a proxy dial the proxy rather than the target, so they record none.
+type ipRecorder struct {
+	mu      sync.Mutex
+	ip      string
+	proxied bool
+}
+
+// proxy picks the proxy from the environment, noting whether one is used
+func (r *ipRecorder) proxy(req *http.Request) (*url.URL, error) {
+	proxyURL, err := http.ProxyFromEnvironment(req)
+	if proxyURL != nil {
+		r.mu.Lock()
+		r.proxied = true
+		r.mu.Unlock()
+	}
+	return proxyURL, err
+}
+
+func (r *ipRecorder) dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if host, _, err := net.SplitHostPort(address); err == nil {
+				r.mu.Lock()
+				r.ip = host
+				r.mu.Unlock()
+			}
+			return nil
+		},
+	}
+}
+
+func (r *ipRecorder) get() *string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ip == "" || r.proxied {
+		return nil
+	}
+	ip := r.ip
+	return &ip
 }
 
 func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *HealthCheckResult {
 	start := time.Now()
+	var recorder ipRecorder
 	client := &http.Client{
 		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:             recorder.proxy,
+			DialContext:       recorder.dialer(timeout).DialContext,
+			DisableKeepAlives: true,
+		},
 	}
 
 	resp, err := client.Get(url)
@@ -29,6 +83,7 @@ func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *Heal
 			Status:        "down",
 			ResponseTimeMs: &elapsed,
 			ErrorMessage:  &msg,
+			ResolvedIP:    recorder.get(),
 		}
 	}
 	defer resp.Body.Close()
@@ -42,6 +97,7 @@ func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *Heal
 			ResponseTimeMs: &elapsed,
 			StatusCode:    &resp.StatusCode,
 			ErrorMessage:  &msg,
+			ResolvedIP:    recorder.get(),
 		}
 	}
 
@@ -49,12 +105,14 @@ func CheckHTTP(url string, timeout time.Duration, expectedStatusCode *int) *Heal
 		Status:        status,
 		ResponseTimeMs: &elapsed,
 		StatusCode:    &resp.StatusCode,
+		ResolvedIP:    recorder.get(),
 	}
 }
 
 func CheckTCP(url string, timeout time.Duration) *HealthCheckResult {
 	start := time.Now()
-	conn, err := net.DialTimeout("tcp", url, timeout)
+	var recorder ipRecorder
+	conn, err := recorder.dialer(timeout).Dial("tcp", url)
 	elapsed := int(time.Since(start).Milliseconds())
 
 	if err != nil {
@@ -63,6 +121,7 @@ func CheckTCP(url string, timeout time.Duration) *HealthCheckResult {
 			Status:        "down",
 			ResponseTimeMs: &elapsed,
 			ErrorMessage:  &msg,
+			ResolvedIP:    recorder.get(),
 		}
 	}
 	defer conn.Close()
@@ -70,6 +129,7 @@ func CheckTCP(url string, timeout time.Duration) *HealthCheckResult {
 	return &HealthCheckResult{
 		Status:        "up",
 		ResponseTimeMs: &elapsed,
+		ResolvedIP:    recorder.get(),
 	}
 }
 